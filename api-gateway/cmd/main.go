@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/api-service/internal/middleware"
 	"github.com/OvsyannikovAlexandr/marketplace/api-service/internal/proxy"
+	"github.com/OvsyannikovAlexandr/marketplace/api-service/pkg/kafka"
 )
 
 func main() {
@@ -14,7 +18,27 @@ func main() {
 		port = "8080"
 	}
 
-	handler := proxy.NewRouter()
+	// Подтверждённые сессии кешируются: отзыв вступает в силу не позже чем через SESSION_CHECK_TTL
+	sessionCheckTTL := 30 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("SESSION_CHECK_TTL")); err == nil && v >= 0 {
+		sessionCheckTTL = v
+	}
+	sessions := middleware.NewSessionClient("http://user-service:8080", sessionCheckTTL)
+
+	// Блокировка и удаление аккаунта сбрасывают кеш сессий сразу, без ожидания SESSION_CHECK_TTL
+	if kafkaBroker := os.Getenv("KAFKA_BROKER"); kafkaBroker != "" {
+		topic := os.Getenv("USER_EVENTS_TOPIC")
+		if topic == "" {
+			topic = "user-events"
+		}
+		hostname, _ := os.Hostname()
+		userEvents := kafka.NewUserEventsConsumer(kafkaBroker, topic, "api-gateway-"+hostname, sessions)
+		defer userEvents.Close()
+		go userEvents.Run(context.Background())
+		log.Println("User events consumer started")
+	}
+
+	handler := proxy.NewRouter(sessions)
 	log.Printf("API gateway running on port %s\n", port)
 	log.Fatal(http.ListenAndServe(":"+port, handler))
 }
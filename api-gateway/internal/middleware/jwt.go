@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -9,7 +10,14 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func JWTMiddleware(next http.Handler) http.Handler {
+// JWTMiddleware проверяет подпись токена и то, что его сессия (sid) не отозвана
+func JWTMiddleware(sessions SessionChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return jwtHandler(next, sessions)
+	}
+}
+
+func jwtHandler(next http.Handler, sessions SessionChecker) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		auth := r.Header.Get("Authorization")
 		if !strings.HasPrefix(auth, "Bearer ") {
@@ -35,7 +43,28 @@ func JWTMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Токены без sid выданы до учёта сессий и не могут быть отозваны — требуем войти заново
+		sessionID, _ := claims["sid"].(string)
+		if sessionID == "" {
+			http.Error(w, "token has no session, please log in again", http.StatusUnauthorized)
+			return
+		}
+		status, err := sessions.CheckSession(r.Context(), int64(userID), sessionID)
+		if err != nil {
+			log.Printf("Не удалось проверить сессию %s: %v", sessionID, err)
+			http.Error(w, "session check unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if !status.Active {
+			http.Error(w, "session revoked", http.StatusUnauthorized)
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), "user_id", int64(userID))
+		ctx = context.WithValue(ctx, "session_id", sessionID)
+		// email_verified из токена — снимок на момент входа, поэтому берётся текущее значение
+		// из проверки сессии
+		ctx = context.WithValue(ctx, "email_verified", status.EmailVerified)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionStatus — результат проверки сессии. EmailVerified берётся из user-service,
+// а не из токена, поэтому смена и подтверждение email действуют без нового входа.
+type SessionStatus struct {
+	Active        bool `json:"-"`
+	EmailVerified bool `json:"email_verified"`
+}
+
+// SessionChecker сообщает, действует ли сессия, к которой привязан токен
+type SessionChecker interface {
+	CheckSession(ctx context.Context, userID int64, sessionID string) (SessionStatus, error)
+}
+
+// SessionClient проверяет сессии в user-service. Действующие сессии запоминаются
+// на cacheTTL, поэтому отзыв и смена email вступают в силу с задержкой не больше cacheTTL;
+// блокировка и удаление аккаунта сбрасывают кеш сразу через ForgetUser.
+type SessionClient struct {
+	baseURL    string
+	httpClient *http.Client
+	cacheTTL   time.Duration
+
+	mu     sync.Mutex
+	active map[string]cachedSession
+}
+
+type cachedSession struct {
+	status SessionStatus
+	until  time.Time
+}
+
+func NewSessionClient(baseURL string, cacheTTL time.Duration) *SessionClient {
+	return &SessionClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 2 * time.Second},
+		cacheTTL:   cacheTTL,
+		active:     make(map[string]cachedSession),
+	}
+}
+
+func (c *SessionClient) CheckSession(ctx context.Context, userID int64, sessionID string) (SessionStatus, error) {
+	key := fmt.Sprintf("%d:%s", userID, sessionID)
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.active[key]
+	c.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		return cached.status, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/internal/sessions/%s?user_id=%d", c.baseURL, url.PathEscape(sessionID), userID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return SessionStatus{}, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return SessionStatus{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		status := SessionStatus{Active: true}
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			return SessionStatus{}, err
+		}
+		c.mu.Lock()
+		c.active[key] = cachedSession{status: status, until: now.Add(c.cacheTTL)}
+		// Просроченные записи вычищаются при записи, чтобы кеш не рос бесконечно
+		for k, entry := range c.active {
+			if now.After(entry.until) {
+				delete(c.active, k)
+			}
+		}
+		c.mu.Unlock()
+		return status, nil
+	case http.StatusNotFound:
+		c.mu.Lock()
+		delete(c.active, key)
+		c.mu.Unlock()
+		return SessionStatus{}, nil
+	default:
+		return SessionStatus{}, fmt.Errorf("user-service returned %s", resp.Status)
+	}
+}
+
+// ForgetUser удаляет из кеша все сессии пользователя
+func (c *SessionClient) ForgetUser(userID int64) {
+	prefix := fmt.Sprintf("%d:", userID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.active {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.active, k)
+		}
+	}
+}
@@ -5,23 +5,44 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 
 	"github.com/OvsyannikovAlexandr/marketplace/api-service/internal/middleware"
 	"github.com/gorilla/mux"
 )
 
-func NewRouter() http.Handler {
+func NewRouter(sessions middleware.SessionChecker) http.Handler {
 	r := mux.NewRouter()
 
 	r.PathPrefix("/users/login").Handler(proxyTo("http://user-service:8080"))
 	r.PathPrefix("/users/register").Handler(proxyTo("http://user-service:8080"))
+	// Ссылка подтверждения email открывается без входа; повторная отправка требует JWT
+	r.Path("/users/verify").Handler(proxyTo("http://user-service:8080"))
+	// Сброс пароля нужен как раз тем, кто не может войти
+	r.PathPrefix("/users/password/reset").Handler(proxyTo("http://user-service:8080"))
+	// OIDC-провайдер: приложения партнёров ходят сюда без токена marketplace,
+	// /oauth2/userinfo проверяет собственный access-токен
+	r.Path("/.well-known/openid-configuration").Handler(proxyTo("http://user-service:8080"))
+	r.Path("/oauth2/jwks").Handler(proxyTo("http://user-service:8080"))
+	r.Path("/oauth2/authorize").Handler(proxyTo("http://user-service:8080"))
+	r.Path("/oauth2/token").Handler(proxyTo("http://user-service:8080"))
+	r.Path("/oauth2/userinfo").Handler(proxyTo("http://user-service:8080"))
+	// Гостевая корзина доступна без авторизации, доступ к ней даёт X-Cart-Token
+	r.PathPrefix("/cart/guest").Handler(proxyTo("http://cart-service:8080"))
+	// Опубликованные списки желаний открываются по ссылке без авторизации
+	r.PathPrefix("/wishlists/shared/").Handler(proxyTo("http://cart-service:8080"))
 
 	protected := r.PathPrefix("/").Subrouter()
-	protected.Use(middleware.JWTMiddleware)
+	protected.Use(middleware.JWTMiddleware(sessions))
 
 	protected.PathPrefix("/users").Handler(proxyTo("http://user-service:8080"))
+	// Регистрация приложений партнёров — только администраторам
+	protected.PathPrefix("/oauth2/clients").Handler(proxyTo("http://user-service:8080"))
 	protected.PathPrefix("/products").Handler(proxyTo("http://product-service:8080"))
+	// Курсы валют только читаются; PUT /rates доступен лишь внутри сети сервисов
+	protected.Path("/rates").Methods("GET").Handler(proxyTo("http://product-service:8080"))
 	protected.PathPrefix("/cart").Handler(proxyTo("http://cart-service:8080"))
+	protected.PathPrefix("/wishlists").Handler(proxyTo("http://cart-service:8080"))
 	protected.PathPrefix("/orders").Handler(proxyTo("http://order-service:8080"))
 
 	return r
@@ -34,8 +55,18 @@ func proxyTo(target string) http.Handler {
 	originalDirector := proxy.Director
 	proxy.Director = func(req *http.Request) {
 		originalDirector(req)
+		// X-User-ID, X-Session-ID и X-Email-Verified выставляет только gateway,
+		// значения от клиента отбрасываются
+		req.Header.Del("X-User-ID")
+		req.Header.Del("X-Session-ID")
+		req.Header.Del("X-Email-Verified")
 		if userID, ok := req.Context().Value("user_id").(int64); ok {
 			req.Header.Set("X-User-ID", fmt.Sprintf("%d", userID))
+			emailVerified, _ := req.Context().Value("email_verified").(bool)
+			req.Header.Set("X-Email-Verified", strconv.FormatBool(emailVerified))
+			if sessionID, ok := req.Context().Value("session_id").(string); ok {
+				req.Header.Set("X-Session-ID", sessionID)
+			}
 		}
 	}
 
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// События user-service, после которых токены пользователя не должны приниматься
+const (
+	UserBannedType  = "UserBanned"
+	UserDeletedType = "UserDeleted"
+)
+
+// SessionForgetter забывает подтверждённые сессии пользователя, чтобы следующий
+// запрос с его токеном снова проверялся в user-service
+type SessionForgetter interface {
+	ForgetUser(userID int64)
+}
+
+type userEvent struct {
+	Type   string `json:"type"`
+	UserID int64  `json:"user_id"`
+}
+
+// UserEventsConsumer читает события user-service. У каждого экземпляра gateway
+// свой groupID: кеш сессий у каждого свой, и событие нужно всем.
+type UserEventsConsumer struct {
+	reader   *kafka.Reader
+	sessions SessionForgetter
+}
+
+func NewUserEventsConsumer(brokerAddress, topic, groupID string, sessions SessionForgetter) *UserEventsConsumer {
+	return &UserEventsConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{brokerAddress},
+			Topic:   topic,
+			GroupID: groupID,
+			// Старые события не нужны: кеш сессий живёт недолго
+			StartOffset: kafka.LastOffset,
+		}),
+		sessions: sessions,
+	}
+}
+
+// Run обрабатывает события до отмены ctx
+func (c *UserEventsConsumer) Run(ctx context.Context) {
+	for {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Ошибка чтения событий пользователей: %v", err)
+			}
+			return
+		}
+		handleUserEvent(msg.Value, c.sessions)
+	}
+}
+
+func (c *UserEventsConsumer) Close() error {
+	return c.reader.Close()
+}
+
+func handleUserEvent(value []byte, sessions SessionForgetter) {
+	var event userEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("Пропущено нечитаемое событие пользователя: %v", err)
+		return
+	}
+	if (event.Type != UserBannedType && event.Type != UserDeletedType) || event.UserID == 0 {
+		return
+	}
+	sessions.ForgetUser(event.UserID)
+	log.Printf("Сессии пользователя %d сброшены после события %s", event.UserID, event.Type)
+}
@@ -6,12 +6,21 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/cache"
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/db"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/jobs"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/productclient"
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/userclient"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/migrations"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/pkg/kafka"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/migrator"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
@@ -31,23 +40,169 @@ func main() {
 
 	fmt.Println("Connected to PostgreSQL")
 
+	// По умолчанию миграции накатывает migration-service; флаг позволяет сервису
+	// применить свои миграции самому с теми же проверками и ограничениями
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		svc := migrator.Service{Name: "cart-service", Schema: migrations.Schema, FS: migrations.FS}
+		if err := migrator.Apply(ctx, dbpool, svc, migrator.TimeoutsFromEnv()); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		log.Println("Migrations applied")
+	}
+
 	productServiceURL := os.Getenv("PRODUCT_SERVICE_URL")
 
 	redisAddr := os.Getenv("REDIS_ADDR")
 	redisCache := cache.NewRedisCache(redisAddr)
 
 	cartRepository := repository.NewCartRepository(dbpool)
-	cartService := service.NewCartService(cartRepository, productServiceURL, redisCache)
+	promotionRepository := repository.NewPromotionRepository(dbpool)
+	productClient := productclient.NewClient(productServiceURL, productclient.DefaultConfig())
+	cartConfig := service.DefaultConfig()
+	if v, err := strconv.Atoi(os.Getenv("CART_MAX_ITEM_QUANTITY")); err == nil {
+		cartConfig.MaxItemQuantity = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("CART_GUEST_TTL")); err == nil {
+		cartConfig.GuestCartTTL = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("CART_TAX_RATE"), 64); err == nil {
+		cartConfig.Pricing.TaxRate = v
+	}
+	if v := domain.MergeStrategy(os.Getenv("CART_MERGE_STRATEGY")); v != "" {
+		if !v.Valid() {
+			log.Fatalf("Invalid CART_MERGE_STRATEGY: %s", v)
+		}
+		cartConfig.MergeStrategy = v
+	}
+
+	guestCarts := cache.NewGuestCartStore(redisCache, cartConfig.GuestCartTTL)
+	ratesTTL := time.Minute
+	if v, err := time.ParseDuration(os.Getenv("CART_RATES_TTL")); err == nil {
+		ratesTTL = v
+	}
+	ratesClient := productclient.NewRatesClient(productServiceURL, ratesTTL, productclient.DefaultConfig().Timeout)
+	cartService := service.NewCartService(cartRepository, promotionRepository, productClient, redisCache, guestCarts, cartConfig).
+		WithRates(ratesClient)
+	// Без USER_SERVICE_URL заказы оформляются без адреса доставки
+	if userServiceURL := os.Getenv("USER_SERVICE_URL"); userServiceURL != "" {
+		cartService.WithAddresses(userclient.NewAddressClient(userServiceURL, productclient.DefaultConfig().Timeout))
+	}
 	cartHandler := handler.NewCartHandler(cartService)
+	if os.Getenv("CHECKOUT_REQUIRE_VERIFIED_EMAIL") == "true" {
+		cartHandler.WithVerifiedEmailRequired()
+	}
+	promotionHandler := handler.NewPromotionHandler(service.NewPromotionService(promotionRepository))
+
+	wishlistRepository := repository.NewWishlistRepository(dbpool)
+	wishlistHandler := handler.NewWishlistHandler(
+		service.NewWishlistService(wishlistRepository, productClient, redisCache, cartConfig),
+	)
+	privacyService := service.NewPrivacyService(repository.NewPrivacyRepository(dbpool), cartRepository,
+		wishlistRepository, promotionRepository, redisCache)
+	privacyHandler := handler.NewPrivacyHandler(privacyService)
+
+	// Фоновые задачи публикуют события в Kafka; на нескольких репликах их
+	// выполнение разделяется через advisory-блокировки Postgres
+	if kafkaBroker := os.Getenv("KAFKA_BROKER"); kafkaBroker != "" {
+		topic := os.Getenv("CART_EVENTS_TOPIC")
+		if topic == "" {
+			topic = "cart-events"
+		}
+		producer := kafka.NewCartProducer(kafkaBroker, topic)
+		defer producer.Close()
+
+		// После удаления аккаунта в user-service корзина и списки желаний пользователя удаляются
+		userEventsTopic := os.Getenv("USER_EVENTS_TOPIC")
+		if userEventsTopic == "" {
+			userEventsTopic = "user-events"
+		}
+		userEvents := kafka.NewUserEventsConsumer(kafkaBroker, userEventsTopic, "cart-service", privacyService)
+		defer userEvents.Close()
+		go userEvents.Run(ctx)
+		log.Println("User events consumer started")
+
+		if os.Getenv("CART_ABANDONED_JOB") != "false" {
+			jobConfig := jobs.DefaultAbandonedCartsConfig()
+			if v, err := time.ParseDuration(os.Getenv("CART_ABANDONED_INTERVAL")); err == nil {
+				jobConfig.Interval = v
+			}
+			if v, err := time.ParseDuration(os.Getenv("CART_ABANDONED_AFTER")); err == nil {
+				jobConfig.AbandonAfter = v
+			}
+			if v, err := time.ParseDuration(os.Getenv("CART_RETENTION")); err == nil {
+				jobConfig.Retention = v
+			}
+			if v, err := strconv.Atoi(os.Getenv("CART_ABANDONED_BATCH_SIZE")); err == nil {
+				jobConfig.BatchSize = v
+			}
+
+			abandonedCarts := jobs.NewAbandonedCarts(
+				repository.NewAbandonedCartRepository(dbpool),
+				producer,
+				db.NewAdvisoryLock(dbpool, jobs.AbandonedCartsLockKey),
+				jobConfig,
+			)
+			go abandonedCarts.Run(ctx)
+			log.Println("Abandoned carts job started")
+		}
+
+		if os.Getenv("WISHLIST_PRICE_DROP_JOB") != "false" {
+			jobConfig := jobs.DefaultPriceDropsConfig()
+			if v, err := time.ParseDuration(os.Getenv("WISHLIST_PRICE_DROP_INTERVAL")); err == nil {
+				jobConfig.Interval = v
+			}
+
+			priceDrops := jobs.NewPriceDrops(
+				wishlistRepository,
+				productClient,
+				producer,
+				db.NewAdvisoryLock(dbpool, jobs.PriceDropsLockKey),
+				jobConfig,
+			)
+			go priceDrops.Run(ctx)
+			log.Println("Wishlist price drops job started")
+		}
+	}
 
 	router := mux.NewRouter()
 
+	// Гостевые маршруты регистрируются раньше /cart/{user_id}
+	router.HandleFunc("/cart/guest", cartHandler.CreateGuestCart).Methods("POST")
+	router.HandleFunc("/cart/guest", cartHandler.GetGuestCart).Methods("GET")
+	router.HandleFunc("/cart/guest", cartHandler.UpdateGuestCart).Methods("PATCH")
+	router.HandleFunc("/cart/guest", cartHandler.DeleteGuestCart).Methods("DELETE")
+	router.HandleFunc("/cart/guest/items/{product_id:[0-9]+}", cartHandler.SetGuestItemQuantity).Methods("PUT")
+	router.HandleFunc("/cart/merge", cartHandler.MergeGuestCart).Methods("POST")
+
 	router.HandleFunc("/cart", cartHandler.AddItem).Methods("POST")
+	router.HandleFunc("/cart", cartHandler.UpdateCart).Methods("PATCH")
+	router.HandleFunc("/cart/items/{product_id:[0-9]+}", cartHandler.SetItemQuantity).Methods("PUT")
+	router.HandleFunc("/cart/confirm-prices", cartHandler.ConfirmPrices).Methods("POST")
+	router.HandleFunc("/cart/coupon", cartHandler.ApplyCoupon).Methods("POST")
+	router.HandleFunc("/cart/coupon", cartHandler.RemoveCoupon).Methods("DELETE")
 	router.HandleFunc("/cart/{user_id}", cartHandler.GetCartDetailsHandler).Methods("GET")
 	router.HandleFunc("/cart/{user_id}/clear", cartHandler.ClearCart).Methods("DELETE")
 	router.HandleFunc("/cart/{user_id}/checkout", cartHandler.Checkout).Methods("POST")
 	router.HandleFunc("/cart/{user_id}/{product_id:[0-9]+}", cartHandler.DeleteItem).Methods("DELETE")
 
+	router.HandleFunc("/wishlists", wishlistHandler.List).Methods("GET")
+	router.HandleFunc("/wishlists", wishlistHandler.Create).Methods("POST")
+	router.HandleFunc("/wishlists/shared/{token}", wishlistHandler.GetShared).Methods("GET")
+	router.HandleFunc("/wishlists/{id:[0-9]+}", wishlistHandler.Get).Methods("GET")
+	router.HandleFunc("/wishlists/{id:[0-9]+}", wishlistHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/wishlists/{id:[0-9]+}/share", wishlistHandler.Share).Methods("POST")
+	router.HandleFunc("/wishlists/{id:[0-9]+}/share", wishlistHandler.Unshare).Methods("DELETE")
+	router.HandleFunc("/wishlists/{id:[0-9]+}/items", wishlistHandler.AddItem).Methods("POST")
+	router.HandleFunc("/wishlists/{id:[0-9]+}/items/{product_id:[0-9]+}", wishlistHandler.RemoveItem).Methods("DELETE")
+	router.HandleFunc("/wishlists/{id:[0-9]+}/items/{product_id:[0-9]+}/move-to-cart", wishlistHandler.MoveToCart).Methods("POST")
+	router.HandleFunc("/wishlists/{id:[0-9]+}/move-from-cart", wishlistHandler.MoveFromCart).Methods("POST")
+
+	// Управление акциями — внутренний API, api-gateway его не проксирует
+	router.HandleFunc("/promotions", promotionHandler.Create).Methods("POST")
+	router.HandleFunc("/promotions", promotionHandler.List).Methods("GET")
+
+	router.HandleFunc("/internal/users/{user_id:[0-9]+}/export", privacyHandler.Export).Methods("GET")
+
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Cart service OK"))
 	})
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidCartToken = errors.New("invalid cart token")
+
+// GuestCartStore хранит корзины анонимных покупателей в Redis:
+// hash cart:guest:<token> с полями product_id (или product_id:variant_id для варианта) -> quantity.
+// TTL продлевается при каждом изменении.
+type GuestCartStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewGuestCartStore(cache *RedisCache, ttl time.Duration) *GuestCartStore {
+	return &GuestCartStore{client: cache.client, ttl: ttl}
+}
+
+// NewToken генерирует непрозрачный токен гостевой корзины.
+func (s *GuestCartStore) NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Items возвращает товары гостевой корзины; несуществующая корзина пуста.
+func (s *GuestCartStore) Items(ctx context.Context, token string) ([]domain.CartItem, error) {
+	key, err := guestCartKey(token)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := s.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseGuestItems(values)
+}
+
+// ApplyOperations применяет операции атомарно (WATCH/MULTI): при конкурентном
+// изменении той же корзины транзакция повторяется.
+func (s *GuestCartStore) ApplyOperations(ctx context.Context, token string, ops []domain.CartOperation, maxQuantity int) error {
+	key, err := guestCartKey(token)
+	if err != nil {
+		return err
+	}
+
+	txf := func(tx *redis.Tx) error {
+		values, err := tx.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+
+		quantities := make(map[domain.ItemKey]int, len(values))
+		items, err := parseGuestItems(values)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			quantities[item.Key()] = item.Quantity
+		}
+
+		changed := make(map[domain.ItemKey]bool, len(ops))
+		for _, op := range ops {
+			item := op.Key()
+			switch op.Op {
+			case domain.OperationAdd:
+				quantities[item] += op.Quantity
+			case domain.OperationSet:
+				quantities[item] = op.Quantity
+			case domain.OperationRemove:
+				quantities[item] = 0
+			}
+			if maxQuantity > 0 && quantities[item] > maxQuantity {
+				return fmt.Errorf("%w: product %s would have %d items, max is %d",
+					domain.ErrQuantityExceeded, item, quantities[item], maxQuantity)
+			}
+			changed[item] = true
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for item := range changed {
+				field := item.String()
+				if q := quantities[item]; q > 0 {
+					pipe.HSet(ctx, key, field, q)
+				} else {
+					pipe.HDel(ctx, key, field)
+				}
+			}
+			pipe.Expire(ctx, key, s.ttl)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		err = s.client.Watch(ctx, txf, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *GuestCartStore) Delete(ctx context.Context, token string) error {
+	key, err := guestCartKey(token)
+	if err != nil {
+		return err
+	}
+	return s.client.Del(ctx, key).Err()
+}
+
+func guestCartKey(token string) (string, error) {
+	if b, err := hex.DecodeString(token); err != nil || len(b) != 16 {
+		return "", ErrInvalidCartToken
+	}
+	return "cart:guest:" + token, nil
+}
+
+func parseGuestItems(values map[string]string) ([]domain.CartItem, error) {
+	items := make([]domain.CartItem, 0, len(values))
+	for field, value := range values {
+		key, err := domain.ParseItemKey(field)
+		if err != nil {
+			return nil, err
+		}
+		quantity, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, domain.CartItem{ProductID: key.ProductID, VariantID: key.VariantID, Quantity: quantity})
+	}
+	return items, nil
+}
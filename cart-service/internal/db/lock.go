@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLock — сессионная advisory-блокировка Postgres. Соединение держится, пока
+// блокировка захвачена; если процесс упадёт, Postgres снимет её вместе с сессией.
+type AdvisoryLock struct {
+	pool *pgxpool.Pool
+	key  int64
+}
+
+func NewAdvisoryLock(pool *pgxpool.Pool, key int64) *AdvisoryLock {
+	return &AdvisoryLock{pool: pool, key: key}
+}
+
+// TryLock не ждёт: если блокировку держит другая реплика, возвращает ok=false.
+func (l *AdvisoryLock) TryLock(ctx context.Context) (release func(), ok bool, err error) {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&ok); err != nil || !ok {
+		conn.Release()
+		return nil, false, err
+	}
+
+	release = func() {
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, l.key)
+		conn.Release()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrAddressRequired — у покупателя нет адреса доставки, заказ оформить нельзя
+	ErrAddressRequired = errors.New("shipping address is required")
+	ErrAddressNotFound = errors.New("address not found")
+	ErrInvalidAddress  = errors.New("invalid address")
+)
+
+// Address — адрес доставки из адресной книги user-service. При оформлении
+// копируется в заказ, чтобы последующие правки адреса не меняли историю заказов.
+type Address struct {
+	ID         int64  `json:"id"`
+	Recipient  string `json:"recipient"`
+	Phone      string `json:"phone,omitempty"`
+	Country    string `json:"country"`
+	Region     string `json:"region,omitempty"`
+	City       string `json:"city"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	PostalCode string `json:"postal_code"`
+}
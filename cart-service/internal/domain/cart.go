@@ -1,26 +1,213 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+var (
+	ErrInvalidOperation     = errors.New("invalid cart operation")
+	ErrQuantityExceeded     = errors.New("quantity exceeds maximum per item")
+	ErrInvalidMergeStrategy = errors.New("invalid merge strategy")
+	// ErrPriceChanged — цены изменились с момента добавления, перед оформлением их нужно подтвердить
+	ErrPriceChanged = errors.New("cart prices changed, confirmation required")
+)
 
 type CartItem struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	ProductID int64     `json:"product_id"`
+	ID        int64 `json:"id"`
+	UserID    int64 `json:"user_id"`
+	ProductID int64 `json:"product_id"`
+	// VariantID — выбранный вариант (SKU) товара; 0 — товар без вариантов
+	VariantID int64     `json:"variant_id,omitempty"`
 	Quantity  int       `json:"quantity"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// PriceAtAdd — цена товара, которую покупатель видел при добавлении; nil для старых позиций
+	PriceAtAdd *money.Money `json:"price_at_add,omitempty"`
+}
+
+func (i CartItem) Key() ItemKey {
+	return ItemKey{ProductID: i.ProductID, VariantID: i.VariantID}
+}
+
+// ItemKey — позиция корзины: товар и его вариант. Варианты одного товара — разные позиции.
+type ItemKey struct {
+	ProductID int64
+	VariantID int64
+}
+
+// String записывает позицию как "product" или "product:variant"
+func (k ItemKey) String() string {
+	if k.VariantID == 0 {
+		return strconv.FormatInt(k.ProductID, 10)
+	}
+	return strconv.FormatInt(k.ProductID, 10) + ":" + strconv.FormatInt(k.VariantID, 10)
+}
+
+// ParseItemKey разбирает запись ItemKey.String
+func ParseItemKey(s string) (ItemKey, error) {
+	product, variant, hasVariant := strings.Cut(s, ":")
+
+	var k ItemKey
+	var err error
+	if k.ProductID, err = strconv.ParseInt(product, 10, 64); err != nil {
+		return ItemKey{}, err
+	}
+	if hasVariant {
+		if k.VariantID, err = strconv.ParseInt(variant, 10, 64); err != nil {
+			return ItemKey{}, err
+		}
+	}
+	return k, nil
 }
 
 type Product struct {
-	ID          int64   `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	CreatedAt   string  `json:"created_at,omitempty"`
-	UpdatedAt   string  `json:"updated_at,omitempty"`
+	ID          int64       `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	Category    string      `json:"category,omitempty"`
+	Variants    []Variant   `json:"variants,omitempty"`
+	// OwnerSellerID — продавец товара; не задан у товаров самого маркетплейса
+	OwnerSellerID *int64 `json:"owner_seller_id,omitempty"`
+	CreatedAt     string `json:"created_at,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+}
+
+// Variant — вариант (SKU) товара из product-service
+type Variant struct {
+	ID      int64             `json:"id"`
+	SKU     string            `json:"sku"`
+	Options map[string]string `json:"options,omitempty"`
+	// Price — своя цена варианта; nil, если действует цена товара
+	Price *money.Money `json:"price,omitempty"`
+}
+
+// ResolveVariant возвращает выбранный вариант товара: у товара с вариантами он обязателен,
+// у товара без вариантов variantID должен быть 0 (тогда возвращается nil).
+func (p Product) ResolveVariant(variantID int64) (*Variant, error) {
+	if variantID == 0 {
+		if len(p.Variants) > 0 {
+			return nil, fmt.Errorf("%w: variant_id is required for product %d", ErrInvalidOperation, p.ID)
+		}
+		return nil, nil
+	}
+	for _, v := range p.Variants {
+		if v.ID == variantID {
+			return &v, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: variant %d of product %d not found", ErrInvalidOperation, variantID, p.ID)
+}
+
+// UnitPrice — цена варианта, если она задана, иначе цена товара
+func (p Product) UnitPrice(v *Variant) money.Money {
+	if v != nil && v.Price != nil {
+		return *v.Price
+	}
+	return p.Price
 }
 
 type CartItemDetail struct {
-	Product  Product `json:"product"`
-	Quantity int     `json:"quantity"`
+	// Product — данные товара; список его вариантов в позицию не копируется
+	Product Product `json:"product"`
+	// VariantID и Variant — выбранный вариант товара
+	VariantID int64    `json:"variant_id,omitempty"`
+	Variant   *Variant `json:"variant,omitempty"`
+	Quantity  int      `json:"quantity"`
+	// Available — false, если данные продукта получить не удалось (только в частичном ответе)
+	Available bool `json:"available"`
+	// Stale — данные продукта взяты из локального кэша, product-service не ответил
+	Stale bool `json:"stale,omitempty"`
+
+	UnitPrice  money.Money  `json:"unit_price"`
+	PriceAtAdd *money.Money `json:"price_at_add,omitempty"`
+	LineTotal  money.Money  `json:"line_total"`
+	// PriceChanged — текущая цена отличается от цены на момент добавления
+	PriceChanged bool `json:"price_changed,omitempty"`
+}
+
+type AppliedDiscount struct {
+	Code        string      `json:"code,omitempty"`
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
+}
+
+// Cart — корзина с рассчитанными на сервере суммами. Недоступные позиции
+// (Available=false) в суммы не входят. Все суммы в одной валюте — валюте товаров корзины
+// или, если запрошена валюта отображения, в ней.
+type Cart struct {
+	Items         []CartItemDetail  `json:"items"`
+	Subtotal      money.Money       `json:"subtotal"`
+	Discounts     []AppliedDiscount `json:"discounts,omitempty"`
+	DiscountTotal money.Money       `json:"discount_total"`
+	Tax           money.Money       `json:"tax"`
+	Total         money.Money       `json:"total"`
+	// BaseTotal — итог в валюте товаров; заполняется, если суммы пересчитаны в валюту отображения
+	BaseTotal money.Money `json:"base_total,omitzero"`
+	// ExchangeRate — курс пересчёта: сколько единиц валюты отображения за единицу валюты товаров
+	ExchangeRate string `json:"exchange_rate,omitempty"`
+	// PriceChanged — цена хотя бы одного товара изменилась, Checkout потребует подтверждения
+	PriceChanged bool `json:"price_changed"`
+	// Coupon — применённый к корзине купон; CouponError объясняет, почему он сейчас не действует
+	Coupon      string `json:"coupon,omitempty"`
+	CouponError string `json:"coupon_error,omitempty"`
+}
+
+type CartOperationType string
+
+const (
+	// OperationAdd увеличивает количество товара в корзине
+	OperationAdd CartOperationType = "add"
+	// OperationSet задаёт абсолютное количество; 0 удаляет товар
+	OperationSet CartOperationType = "set"
+	// OperationRemove удаляет товар из корзины
+	OperationRemove CartOperationType = "remove"
+)
+
+type CartOperation struct {
+	Op        CartOperationType `json:"op"`
+	ProductID int64             `json:"product_id"`
+	VariantID int64             `json:"variant_id,omitempty"`
+	Quantity  int               `json:"quantity"`
+	// Price — текущая цена товара, её проставляет сервис перед сохранением
+	Price money.Money `json:"-"`
+}
+
+func (op CartOperation) Key() ItemKey {
+	return ItemKey{ProductID: op.ProductID, VariantID: op.VariantID}
+}
+
+// MergeStrategy определяет, как объединять количество товара, который есть
+// и в гостевой корзине, и в корзине пользователя
+type MergeStrategy string
+
+const (
+	// MergeSum складывает количества (с ограничением максимума на позицию)
+	MergeSum MergeStrategy = "sum"
+	// MergeMax оставляет большее из двух количеств
+	MergeMax MergeStrategy = "max"
+	// MergeKeepUser оставляет количество из корзины пользователя
+	MergeKeepUser MergeStrategy = "keep-user"
+)
+
+func (s MergeStrategy) Valid() bool {
+	switch s {
+	case MergeSum, MergeMax, MergeKeepUser:
+		return true
+	}
+	return false
+}
+
+// AbandonedCart — корзина пользователя без изменений дольше заданного порога
+type AbandonedCart struct {
+	UserID         int64
+	ProductIDs     []int64
+	TotalQuantity  int
+	LastActivityAt time.Time
 }
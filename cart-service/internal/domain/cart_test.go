@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestItemKey_RoundTrip(t *testing.T) {
+	for _, key := range []ItemKey{{ProductID: 5}, {ProductID: 5, VariantID: 12}} {
+		parsed, err := ParseItemKey(key.String())
+		if err != nil || parsed != key {
+			t.Fatalf("ParseItemKey(%q) = %v, %v; want %v", key.String(), parsed, err, key)
+		}
+	}
+	if _, err := ParseItemKey("5:x"); err == nil {
+		t.Fatal("expected error for invalid variant")
+	}
+}
+
+func TestResolveVariant(t *testing.T) {
+	product := Product{ID: 1, Variants: []Variant{{ID: 7, SKU: "TS-M"}}}
+
+	if v, err := product.ResolveVariant(7); err != nil || v.SKU != "TS-M" {
+		t.Fatalf("expected variant TS-M, got %v, %v", v, err)
+	}
+	if _, err := product.ResolveVariant(0); !errors.Is(err, ErrInvalidOperation) {
+		t.Fatalf("expected variant to be required, got %v", err)
+	}
+	if _, err := product.ResolveVariant(8); !errors.Is(err, ErrInvalidOperation) {
+		t.Fatalf("expected unknown variant to be rejected, got %v", err)
+	}
+	if v, err := (Product{ID: 2}).ResolveVariant(0); err != nil || v != nil {
+		t.Fatalf("expected no variant for simple product, got %v, %v", v, err)
+	}
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// UserExport — данные пользователя в cart-service для выгрузки по запросу субъекта данных
+type UserExport struct {
+	CartItems   []CartItem       `json:"cart_items"`
+	Coupon      string           `json:"coupon,omitempty"`
+	Wishlists   []WishlistExport `json:"wishlists"`
+	Redemptions []Redemption     `json:"promotion_redemptions"`
+}
+
+type WishlistExport struct {
+	Wishlist
+	Items []WishlistItem `json:"items"`
+}
+
+// Redemption — использование акции покупателем
+type Redemption struct {
+	PromotionID int64     `json:"promotion_id"`
+	RedeemedAt  time.Time `json:"redeemed_at"`
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponNotApplicable = errors.New("coupon is not applicable")
+	ErrCouponUsageLimit    = errors.New("coupon usage limit reached")
+	ErrInvalidPromotion    = errors.New("invalid promotion")
+)
+
+type PromotionType string
+
+const (
+	// PromotionPercent — скидка Value процентов от стоимости подходящих товаров
+	PromotionPercent PromotionType = "percent"
+	// PromotionFixed — фиксированная скидка Amount, не больше стоимости подходящих товаров
+	PromotionFixed PromotionType = "fixed"
+	// PromotionBuyXGetY — из каждых BuyQuantity+GetQuantity единиц товара GetQuantity бесплатно
+	PromotionBuyXGetY PromotionType = "buy_x_get_y"
+)
+
+// Promotion — акция. С Code это купон, который покупатель применяет сам,
+// без Code акция применяется ко всем корзинам автоматически.
+// Нулевые лимиты и пустые списки таргетинга означают «без ограничений».
+// Amount и MinOrderAmount задаются в одной валюте и применяются только к корзинам в ней.
+type Promotion struct {
+	ID             int64         `json:"id"`
+	Code           string        `json:"code,omitempty"`
+	Name           string        `json:"name"`
+	Type           PromotionType `json:"type"`
+	Value          float64       `json:"value,omitempty"`
+	Amount         money.Money   `json:"amount,omitzero"`
+	BuyQuantity    int           `json:"buy_quantity,omitempty"`
+	GetQuantity    int           `json:"get_quantity,omitempty"`
+	MinOrderAmount money.Money   `json:"min_order_amount,omitzero"`
+	MaxUses        int           `json:"max_uses,omitempty"`
+	MaxUsesPerUser int           `json:"max_uses_per_user,omitempty"`
+	StartsAt       *time.Time    `json:"starts_at,omitempty"`
+	EndsAt         *time.Time    `json:"ends_at,omitempty"`
+	ProductIDs     []int64       `json:"product_ids,omitempty"`
+	Categories     []string      `json:"categories,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// Currency — валюта сумм акции; для акций без сумм — валюта по умолчанию.
+func (p Promotion) Currency() money.Currency {
+	for _, m := range []money.Money{p.Amount, p.MinOrderAmount} {
+		if m.Currency() != "" {
+			return m.Currency()
+		}
+	}
+	return money.DefaultCurrency
+}
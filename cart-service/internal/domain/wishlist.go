@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+var (
+	ErrWishlistNotFound = errors.New("wishlist not found")
+	ErrWishlistExists   = errors.New("wishlist with this name already exists")
+	ErrItemNotFound     = errors.New("item not found")
+)
+
+type Wishlist struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	// ShareToken — токен публичной ссылки; пустой, если список не опубликован
+	ShareToken string    `json:"share_token,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type WishlistItem struct {
+	ID          int64        `json:"id"`
+	WishlistID  int64        `json:"wishlist_id"`
+	ProductID   int64        `json:"product_id"`
+	PriceAtSave *money.Money `json:"price_at_save,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type WishlistItemDetail struct {
+	Product     Product      `json:"product"`
+	Available   bool         `json:"available"`
+	Stale       bool         `json:"stale,omitempty"`
+	PriceAtSave *money.Money `json:"price_at_save,omitempty"`
+	PriceDrop   bool         `json:"price_drop,omitempty"`
+	SavedAt     time.Time    `json:"saved_at"`
+}
+
+type WishlistDetails struct {
+	Wishlist
+	Items []WishlistItemDetail `json:"items"`
+}
+
+// WatchedWishlistItem — позиция списка, за ценой которой следит задача уведомлений.
+// ReferencePrice — цена, ниже которой снижение считается новым.
+type WatchedWishlistItem struct {
+	ItemID         int64
+	WishlistID     int64
+	UserID         int64
+	ProductID      int64
+	ReferencePrice money.Money
+}
@@ -2,22 +2,34 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/cache"
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/gorilla/mux"
 )
 
 type CartHandler struct {
-	svc service.CartServiceInterface
+	svc                  service.CartServiceInterface
+	requireVerifiedEmail bool
 }
 
 func NewCartHandler(svc service.CartServiceInterface) *CartHandler {
 	return &CartHandler{svc: svc}
 }
 
+// WithVerifiedEmailRequired запрещает оформление заказа пользователям
+// с неподтверждённым email (X-Email-Verified от api-gateway)
+func (h *CartHandler) WithVerifiedEmailRequired() *CartHandler {
+	h.requireVerifiedEmail = true
+	return h
+}
+
 func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 	var item domain.CartItem
 	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
@@ -26,12 +38,119 @@ func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 	}
 	err := h.svc.AddItem(r.Context(), item)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeCartError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
 }
 
+type setQuantityRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+type updateCartRequest struct {
+	Operations []domain.CartOperation `json:"operations"`
+}
+
+// userIDFromHeader читает ID пользователя, который api-gateway берёт из JWT
+func userIDFromHeader(r *http.Request) (int64, error) {
+	return strconv.ParseInt(r.Header.Get("X-User-ID"), 10, 64)
+}
+
+// itemKeyFromRequest читает позицию корзины: product_id из пути и необязательный ?variant_id=
+func itemKeyFromRequest(r *http.Request) (domain.ItemKey, error) {
+	var key domain.ItemKey
+	var err error
+	if key.ProductID, err = strconv.ParseInt(mux.Vars(r)["product_id"], 10, 64); err != nil {
+		return domain.ItemKey{}, err
+	}
+	if v := r.URL.Query().Get("variant_id"); v != "" {
+		if key.VariantID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return domain.ItemKey{}, err
+		}
+	}
+	return key, nil
+}
+
+// displayCurrency читает валюту отображения из параметра currency или заголовка X-Currency
+func displayCurrency(r *http.Request) (money.Currency, error) {
+	code := r.URL.Query().Get("currency")
+	if code == "" {
+		code = r.Header.Get("X-Currency")
+	}
+	if code == "" {
+		return "", nil
+	}
+	return money.ParseCurrency(code)
+}
+
+// writeCartError отвечает 400 на ошибки валидации, неизвестную валюту и отсутствие адреса доставки,
+// 404 на ненайденные купон, список, товар или адрес, 409 на конфликт с текущим состоянием корзины
+// (в том числе товары в разных валютах) и 500 на остальные
+func writeCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidOperation), errors.Is(err, domain.ErrQuantityExceeded),
+		errors.Is(err, domain.ErrInvalidMergeStrategy), errors.Is(err, cache.ErrInvalidCartToken),
+		errors.Is(err, domain.ErrCouponNotApplicable), errors.Is(err, domain.ErrInvalidPromotion),
+		errors.Is(err, money.ErrInvalidCurrency), errors.Is(err, money.ErrUnknownRate),
+		errors.Is(err, domain.ErrAddressRequired), errors.Is(err, domain.ErrInvalidAddress):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrCouponNotFound), errors.Is(err, domain.ErrWishlistNotFound),
+		errors.Is(err, domain.ErrItemNotFound), errors.Is(err, domain.ErrAddressNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrPriceChanged), errors.Is(err, domain.ErrCouponUsageLimit),
+		errors.Is(err, domain.ErrWishlistExists), errors.Is(err, money.ErrCurrencyMismatch):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *CartHandler) SetItemQuantity(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return
+	}
+	key, err := itemKeyFromRequest(r)
+	if err != nil {
+		http.Error(w, "invalid product_id or variant_id", http.StatusBadRequest)
+		return
+	}
+
+	var req setQuantityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.SetItemQuantity(r.Context(), userID, key, req.Quantity); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHandler) UpdateCart(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return
+	}
+
+	var req updateCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.UpdateCart(r.Context(), userID, req.Operations); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *CartHandler) GetItems(w http.ResponseWriter, r *http.Request) {
 	userIDStr := mux.Vars(r)["user_id"]
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
@@ -49,17 +168,16 @@ func (h *CartHandler) GetItems(w http.ResponseWriter, r *http.Request) {
 
 func (h *CartHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 	userIDStr := mux.Vars(r)["user_id"]
-	productIDStr := mux.Vars(r)["product_id"]
 
 	userID, err1 := strconv.ParseInt(userIDStr, 10, 64)
-	productID, err2 := strconv.ParseInt(productIDStr, 10, 64)
+	key, err2 := itemKeyFromRequest(r)
 
 	if err1 != nil || err2 != nil {
 		http.Error(w, "invalid parameters", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.svc.DeleteItem(r.Context(), userID, productID); err != nil {
+	if err := h.svc.DeleteItem(r.Context(), userID, key); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -91,16 +209,31 @@ func (h *CartHandler) GetCartDetailsHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	items, err := h.svc.GetCartWithDetails(r.Context(), userID)
+	partial := r.URL.Query().Get("partial") == "true"
+	currency, err := displayCurrency(r)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	items, err := h.svc.GetCartWithDetails(r.Context(), userID, partial)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if items, err = h.svc.ConvertCart(r.Context(), items, currency); err != nil {
+		writeCartError(w, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(items)
 }
 
+type checkoutRequest struct {
+	AddressID int64 `json:"address_id"`
+}
+
 func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	userIDStr := mux.Vars(r)["user_id"]
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
@@ -109,9 +242,206 @@ func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Checkout(r.Context(), userID); err != nil {
+	if h.requireVerifiedEmail && r.Header.Get("X-Email-Verified") != "true" {
+		http.Error(w, "email is not verified", http.StatusForbidden)
+		return
+	}
+
+	currency, err := displayCurrency(r)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	// Адрес доставки можно выбрать в теле {"address_id": N}; без него берётся адрес по умолчанию
+	var req checkoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.Checkout(r.Context(), userID, currency, req.AddressID); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// ConfirmPrices подтверждает текущие цены товаров после их изменения
+func (h *CartHandler) ConfirmPrices(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.svc.ConfirmPrices(r.Context(), userID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type couponRequest struct {
+	Code string `json:"code"`
+}
+
+// ApplyCoupon применяет купон к корзине и возвращает пересчитанную корзину
+func (h *CartHandler) ApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return
+	}
+
+	var req couponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	currency, err := displayCurrency(r)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	cart, err := h.svc.ApplyCoupon(r.Context(), userID, req.Code)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	if cart, err = h.svc.ConvertCart(r.Context(), cart, currency); err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) RemoveCoupon(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.svc.RemoveCoupon(r.Context(), userID); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type guestCartResponse struct {
+	CartToken string `json:"cart_token"`
+}
+
+type mergeCartRequest struct {
+	CartToken string               `json:"cart_token"`
+	Strategy  domain.MergeStrategy `json:"strategy,omitempty"`
+}
+
+// cartTokenFromHeader читает токен гостевой корзины
+func cartTokenFromHeader(r *http.Request) string {
+	return r.Header.Get("X-Cart-Token")
+}
+
+func (h *CartHandler) CreateGuestCart(w http.ResponseWriter, r *http.Request) {
+	token, err := h.svc.CreateGuestCart(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(guestCartResponse{CartToken: token})
+}
+
+func (h *CartHandler) GetGuestCart(w http.ResponseWriter, r *http.Request) {
+	partial := r.URL.Query().Get("partial") == "true"
+	currency, err := displayCurrency(r)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	items, err := h.svc.GetGuestCartWithDetails(r.Context(), cartTokenFromHeader(r), partial)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	if items, err = h.svc.ConvertCart(r.Context(), items, currency); err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *CartHandler) UpdateGuestCart(w http.ResponseWriter, r *http.Request) {
+	var req updateCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.UpdateGuestCart(r.Context(), cartTokenFromHeader(r), req.Operations); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHandler) SetGuestItemQuantity(w http.ResponseWriter, r *http.Request) {
+	key, err := itemKeyFromRequest(r)
+	if err != nil {
+		http.Error(w, "invalid product_id or variant_id", http.StatusBadRequest)
+		return
+	}
+
+	var req setQuantityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	ops := []domain.CartOperation{{Op: domain.OperationSet, ProductID: key.ProductID, VariantID: key.VariantID, Quantity: req.Quantity}}
+	if err := h.svc.UpdateGuestCart(r.Context(), cartTokenFromHeader(r), ops); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHandler) DeleteGuestCart(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteGuestCart(r.Context(), cartTokenFromHeader(r)); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MergeGuestCart переносит гостевую корзину в корзину пользователя после входа
+func (h *CartHandler) MergeGuestCart(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return
+	}
+
+	var req mergeCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.MergeGuestCart(r.Context(), userID, req.CartToken, req.Strategy); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+type PrivacyHandler struct {
+	svc service.PrivacyServiceInterface
+}
+
+func NewPrivacyHandler(svc service.PrivacyServiceInterface) *PrivacyHandler {
+	return &PrivacyHandler{svc: svc}
+}
+
+// Export — GET /internal/users/{user_id}/export: данные пользователя для архива, который
+// собирает user-service. Маршрут не публикуется через gateway.
+func (h *PrivacyHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusBadRequest)
+		return
+	}
+
+	export, err := h.svc.ExportUser(r.Context(), userID)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(export)
+}
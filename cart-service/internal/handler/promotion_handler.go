@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/service"
+)
+
+type PromotionHandler struct {
+	svc service.PromotionServiceInterface
+}
+
+func NewPromotionHandler(svc service.PromotionServiceInterface) *PromotionHandler {
+	return &PromotionHandler{svc: svc}
+}
+
+func (h *PromotionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var p domain.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.svc.CreatePromotion(r.Context(), p)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *PromotionHandler) List(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.svc.ListPromotions(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotions)
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+type WishlistHandler struct {
+	svc service.WishlistServiceInterface
+}
+
+func NewWishlistHandler(svc service.WishlistServiceInterface) *WishlistHandler {
+	return &WishlistHandler{svc: svc}
+}
+
+type createWishlistRequest struct {
+	Name string `json:"name"`
+}
+
+type wishlistItemRequest struct {
+	ProductID int64 `json:"product_id"`
+	// VariantID — вариант позиции корзины при переносе из корзины в список
+	VariantID int64 `json:"variant_id,omitempty"`
+}
+
+type moveToCartRequest struct {
+	Quantity int `json:"quantity"`
+	// VariantID — вариант, который кладётся в корзину; обязателен для товара с вариантами
+	VariantID int64 `json:"variant_id,omitempty"`
+}
+
+type shareResponse struct {
+	ShareToken string `json:"share_token"`
+}
+
+// wishlistParams читает пользователя из X-User-ID и ID списка из пути
+func wishlistParams(w http.ResponseWriter, r *http.Request) (userID, wishlistID int64, ok bool) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	wishlistID, err = strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid wishlist id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, wishlistID, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *WishlistHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return
+	}
+
+	var req createWishlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	wishlist, err := h.svc.CreateWishlist(r.Context(), userID, req.Name)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, wishlist)
+}
+
+func (h *WishlistHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusUnauthorized)
+		return
+	}
+
+	lists, err := h.svc.ListWishlists(r.Context(), userID)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lists)
+}
+
+func (h *WishlistHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, wishlistID, ok := wishlistParams(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := h.svc.GetWishlist(r.Context(), userID, wishlistID)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, details)
+}
+
+// GetShared — публичный просмотр списка по ссылке, авторизация не нужна
+func (h *WishlistHandler) GetShared(w http.ResponseWriter, r *http.Request) {
+	details, err := h.svc.GetSharedWishlist(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, details)
+}
+
+func (h *WishlistHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, wishlistID, ok := wishlistParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteWishlist(r.Context(), userID, wishlistID); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WishlistHandler) Share(w http.ResponseWriter, r *http.Request) {
+	userID, wishlistID, ok := wishlistParams(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := h.svc.Share(r.Context(), userID, wishlistID)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, shareResponse{ShareToken: token})
+}
+
+func (h *WishlistHandler) Unshare(w http.ResponseWriter, r *http.Request) {
+	userID, wishlistID, ok := wishlistParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Unshare(r.Context(), userID, wishlistID); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WishlistHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	userID, wishlistID, ok := wishlistParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req wishlistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.AddItem(r.Context(), userID, wishlistID, req.ProductID); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *WishlistHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	userID, wishlistID, ok := wishlistParams(w, r)
+	if !ok {
+		return
+	}
+	productID, err := strconv.ParseInt(mux.Vars(r)["product_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product_id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.RemoveItem(r.Context(), userID, wishlistID, productID); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WishlistHandler) MoveToCart(w http.ResponseWriter, r *http.Request) {
+	userID, wishlistID, ok := wishlistParams(w, r)
+	if !ok {
+		return
+	}
+	productID, err := strconv.ParseInt(mux.Vars(r)["product_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product_id", http.StatusBadRequest)
+		return
+	}
+
+	// тело необязательно: без него переносится одна единица товара
+	var req moveToCartRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	key := domain.ItemKey{ProductID: productID, VariantID: req.VariantID}
+	if err := h.svc.MoveToCart(r.Context(), userID, wishlistID, key, req.Quantity); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WishlistHandler) MoveFromCart(w http.ResponseWriter, r *http.Request) {
+	userID, wishlistID, ok := wishlistParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req wishlistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	key := domain.ItemKey{ProductID: req.ProductID, VariantID: req.VariantID}
+	if err := h.svc.MoveFromCart(r.Context(), userID, wishlistID, key); err != nil {
+		writeCartError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+// Package jobs — фоновые задачи cart-service.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/pkg/kafka"
+)
+
+type AbandonedCartsConfig struct {
+	// Interval — период запуска задачи
+	Interval time.Duration
+	// AbandonAfter — через сколько после последнего изменения корзина считается брошенной
+	AbandonAfter time.Duration
+	// Retention — через сколько после последнего изменения корзина удаляется; 0 отключает удаление
+	Retention time.Duration
+	// BatchSize — сколько корзин обрабатывается за один запрос к БД
+	BatchSize int
+}
+
+func DefaultAbandonedCartsConfig() AbandonedCartsConfig {
+	return AbandonedCartsConfig{
+		Interval:     10 * time.Minute,
+		AbandonAfter: 24 * time.Hour,
+		Retention:    30 * 24 * time.Hour,
+		BatchSize:    500,
+	}
+}
+
+// AbandonedCartsLockKey — ключ advisory-блокировки задачи в Postgres
+const AbandonedCartsLockKey int64 = 0x63617274
+
+// Locker гарантирует, что задача выполняется только на одной реплике
+type Locker interface {
+	TryLock(ctx context.Context) (release func(), ok bool, err error)
+}
+
+// AbandonedCarts находит брошенные корзины, отправляет по ним CartAbandoned
+// и удаляет корзины старше срока хранения.
+type AbandonedCarts struct {
+	repo     repository.AbandonedCartRepositoryInterface
+	producer kafka.Producer
+	lock     Locker
+	cfg      AbandonedCartsConfig
+	now      func() time.Time
+}
+
+func NewAbandonedCarts(repo repository.AbandonedCartRepositoryInterface, producer kafka.Producer, lock Locker, cfg AbandonedCartsConfig) *AbandonedCarts {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultAbandonedCartsConfig().BatchSize
+	}
+	return &AbandonedCarts{repo: repo, producer: producer, lock: lock, cfg: cfg, now: time.Now}
+}
+
+// Run запускает задачу по расписанию до отмены ctx.
+func (j *AbandonedCarts) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Printf("Abandoned carts job failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce выполняет один проход, если удалось захватить блокировку.
+func (j *AbandonedCarts) RunOnce(ctx context.Context) error {
+	release, ok, err := j.lock.TryLock(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	defer release()
+
+	now := j.now()
+
+	notified, err := j.notifyAbandoned(ctx, now)
+	if notified > 0 {
+		log.Printf("Abandoned carts: sent %d CartAbandoned events", notified)
+	}
+	if err != nil {
+		return err
+	}
+
+	if j.cfg.Retention > 0 {
+		purged, err := j.repo.PurgeInactive(ctx, now.Add(-j.cfg.Retention))
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("Abandoned carts: purged %d carts", purged)
+		}
+	}
+	return nil
+}
+
+// notifyAbandoned отправляет события пачками. Отметка ставится после отправки,
+// поэтому при сбое между ними событие может уйти повторно (at-least-once).
+func (j *AbandonedCarts) notifyAbandoned(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for {
+		carts, err := j.repo.FindAbandoned(ctx, now.Add(-j.cfg.AbandonAfter), j.cfg.BatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, cart := range carts {
+			event := kafka.CartAbandonedEvent{
+				UserID:         cart.UserID,
+				ProductIDs:     cart.ProductIDs,
+				TotalQuantity:  cart.TotalQuantity,
+				LastActivityAt: cart.LastActivityAt,
+				DetectedAt:     now,
+			}
+			if err := j.producer.SendCartAbandoned(ctx, event); err != nil {
+				return sent, err
+			}
+			if err := j.repo.MarkNotified(ctx, cart); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+
+		if len(carts) < j.cfg.BatchSize {
+			return sent, nil
+		}
+	}
+}
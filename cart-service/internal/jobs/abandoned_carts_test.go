@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/pkg/kafka"
+)
+
+type fakeRepo struct {
+	carts       []domain.AbandonedCart
+	notified    map[int64]bool
+	purgeBefore time.Time
+}
+
+func (r *fakeRepo) FindAbandoned(ctx context.Context, inactiveSince time.Time, limit int) ([]domain.AbandonedCart, error) {
+	var result []domain.AbandonedCart
+	for _, c := range r.carts {
+		if c.LastActivityAt.Before(inactiveSince) && !r.notified[c.UserID] && len(result) < limit {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeRepo) MarkNotified(ctx context.Context, cart domain.AbandonedCart) error {
+	r.notified[cart.UserID] = true
+	return nil
+}
+
+func (r *fakeRepo) PurgeInactive(ctx context.Context, inactiveSince time.Time) (int64, error) {
+	r.purgeBefore = inactiveSince
+	return 0, nil
+}
+
+type fakeProducer struct {
+	events     []kafka.CartAbandonedEvent
+	priceDrops []kafka.WishlistPriceDroppedEvent
+}
+
+func (p *fakeProducer) SendCartAbandoned(ctx context.Context, event kafka.CartAbandonedEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *fakeProducer) SendWishlistPriceDropped(ctx context.Context, event kafka.WishlistPriceDroppedEvent) error {
+	p.priceDrops = append(p.priceDrops, event)
+	return nil
+}
+
+type fakeLock struct {
+	held bool
+}
+
+func (l *fakeLock) TryLock(ctx context.Context) (func(), bool, error) {
+	if l.held {
+		return nil, false, nil
+	}
+	return func() {}, true, nil
+}
+
+func TestAbandonedCarts_NotifiesOncePerCart(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	repo := &fakeRepo{
+		notified: map[int64]bool{},
+		carts: []domain.AbandonedCart{
+			{UserID: 1, LastActivityAt: now.Add(-48 * time.Hour)},
+			{UserID: 2, LastActivityAt: now.Add(-30 * time.Hour)},
+			{UserID: 3, LastActivityAt: now.Add(-time.Hour)},
+		},
+	}
+	producer := &fakeProducer{}
+
+	cfg := DefaultAbandonedCartsConfig()
+	cfg.BatchSize = 1
+	job := NewAbandonedCarts(repo, producer, &fakeLock{}, cfg)
+	job.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := job.RunOnce(context.Background()); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if len(producer.events) != 2 || producer.events[0].UserID != 1 || producer.events[1].UserID != 2 {
+		t.Fatalf("expected one event for carts 1 and 2, got %+v", producer.events)
+	}
+	if want := now.Add(-cfg.Retention); !repo.purgeBefore.Equal(want) {
+		t.Fatalf("expected purge of carts inactive since %v, got %v", want, repo.purgeBefore)
+	}
+}
+
+func TestAbandonedCarts_SkipsWhenLockHeld(t *testing.T) {
+	repo := &fakeRepo{
+		notified: map[int64]bool{},
+		carts:    []domain.AbandonedCart{{UserID: 1, LastActivityAt: time.Now().Add(-48 * time.Hour)}},
+	}
+	producer := &fakeProducer{}
+
+	job := NewAbandonedCarts(repo, producer, &fakeLock{held: true}, DefaultAbandonedCartsConfig())
+	if err := job.RunOnce(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(producer.events) != 0 || !repo.purgeBefore.IsZero() {
+		t.Fatal("expected job to do nothing while another replica holds the lock")
+	}
+}
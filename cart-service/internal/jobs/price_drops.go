@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/pricing"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/productclient"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/pkg/kafka"
+)
+
+// PriceDropsLockKey — ключ advisory-блокировки задачи в Postgres
+const PriceDropsLockKey int64 = 0x77697368
+
+type PriceDropsConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+func DefaultPriceDropsConfig() PriceDropsConfig {
+	return PriceDropsConfig{Interval: time.Hour, BatchSize: 500}
+}
+
+// PriceDrops сравнивает цены товаров в списках желаний с ценой сохранения и
+// отправляет WishlistPriceDropped, когда цена опускается ниже уже известной.
+type PriceDrops struct {
+	repo     repository.WishlistRepositoryInterface
+	products productclient.ClientInterface
+	producer kafka.Producer
+	lock     Locker
+	cfg      PriceDropsConfig
+	now      func() time.Time
+}
+
+func NewPriceDrops(repo repository.WishlistRepositoryInterface, products productclient.ClientInterface, producer kafka.Producer, lock Locker, cfg PriceDropsConfig) *PriceDrops {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultPriceDropsConfig().BatchSize
+	}
+	return &PriceDrops{repo: repo, products: products, producer: producer, lock: lock, cfg: cfg, now: time.Now}
+}
+
+func (j *PriceDrops) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Printf("Wishlist price drops job failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce проходит по всем отслеживаемым позициям. Устаревшие данные из LRU
+// для уведомлений не используются: при ошибке product-service проход прерывается.
+func (j *PriceDrops) RunOnce(ctx context.Context) error {
+	release, ok, err := j.lock.TryLock(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	defer release()
+
+	sent := 0
+	defer func() {
+		if sent > 0 {
+			log.Printf("Wishlist price drops: sent %d events", sent)
+		}
+	}()
+
+	var afterID int64
+	for {
+		items, err := j.repo.ListWatchedItems(ctx, afterID, j.cfg.BatchSize)
+		if err != nil || len(items) == 0 {
+			return err
+		}
+
+		ids := make([]int64, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.ProductID)
+		}
+		lookup, err := j.products.GetProducts(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			afterID = item.ItemID
+
+			product, ok := lookup.Products[item.ProductID]
+			if !ok || !pricing.PriceDropped(product.Price, item.ReferencePrice) {
+				continue
+			}
+
+			event := kafka.WishlistPriceDroppedEvent{
+				UserID:     item.UserID,
+				WishlistID: item.WishlistID,
+				ProductID:  item.ProductID,
+				OldPrice:   item.ReferencePrice,
+				NewPrice:   product.Price,
+				DetectedAt: j.now(),
+			}
+			if err := j.producer.SendWishlistPriceDropped(ctx, event); err != nil {
+				return err
+			}
+			if err := j.repo.SetNotifiedPrice(ctx, item.ItemID, product.Price); err != nil {
+				return err
+			}
+			sent++
+		}
+
+		if len(items) < j.cfg.BatchSize {
+			return nil
+		}
+	}
+}
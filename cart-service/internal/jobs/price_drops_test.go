@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/productclient"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+func usd(amount string) money.Money { return money.MustParse(amount, money.USD) }
+
+type fakeWishlistRepo struct {
+	// остальные методы задаче не нужны
+	repository.WishlistRepositoryInterface
+	items []domain.WatchedWishlistItem
+}
+
+func (r *fakeWishlistRepo) ListWatchedItems(ctx context.Context, afterItemID int64, limit int) ([]domain.WatchedWishlistItem, error) {
+	var result []domain.WatchedWishlistItem
+	for _, item := range r.items {
+		if item.ItemID > afterItemID && len(result) < limit {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeWishlistRepo) SetNotifiedPrice(ctx context.Context, itemID int64, price money.Money) error {
+	for i := range r.items {
+		if r.items[i].ItemID == itemID {
+			r.items[i].ReferencePrice = price
+		}
+	}
+	return nil
+}
+
+type fakeProducts map[int64]money.Money
+
+func (f fakeProducts) GetProducts(ctx context.Context, ids []int64) (productclient.Lookup, error) {
+	lookup := productclient.Lookup{Products: map[int64]domain.Product{}, Stale: map[int64]bool{}}
+	for _, id := range ids {
+		if price, ok := f[id]; ok {
+			lookup.Products[id] = domain.Product{ID: id, Price: price}
+		}
+	}
+	return lookup, nil
+}
+
+func TestPriceDrops_NotifiesOnlyBelowReference(t *testing.T) {
+	repo := &fakeWishlistRepo{items: []domain.WatchedWishlistItem{
+		{ItemID: 1, UserID: 1, ProductID: 10, ReferencePrice: usd("100")},
+		{ItemID: 2, UserID: 1, ProductID: 11, ReferencePrice: usd("50")},
+		{ItemID: 3, UserID: 2, ProductID: 10, ReferencePrice: usd("80")},
+		{ItemID: 4, UserID: 2, ProductID: 12, ReferencePrice: money.MustParse("100", money.EUR)},
+	}}
+	producer := &fakeProducer{}
+	products := fakeProducts{10: usd("90"), 11: usd("60"), 12: usd("50")}
+
+	job := NewPriceDrops(repo, products, producer, &fakeLock{}, PriceDropsConfig{BatchSize: 2})
+	for i := 0; i < 2; i++ {
+		if err := job.RunOnce(context.Background()); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if len(producer.priceDrops) != 1 {
+		t.Fatalf("expected exactly one price drop event, got %+v", producer.priceDrops)
+	}
+	if e := producer.priceDrops[0]; e.UserID != 1 || e.ProductID != 10 || e.OldPrice != usd("100") || e.NewPrice != usd("90") {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
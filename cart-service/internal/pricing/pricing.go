@@ -0,0 +1,215 @@
+// Package pricing рассчитывает суммы корзины: стоимость позиций, подытог,
+// скидки, налог и итог.
+//
+// Правила округления: стоимость позиции и подытог считаются точно в минимальных
+// единицах валюты; процентные скидки округляются по каждой акции (money.HalfUp),
+// налог — один раз от суммы после скидок (money.HalfUp). Все позиции корзины
+// должны быть в одной валюте, иначе возвращается money.ErrCurrencyMismatch.
+package pricing
+
+import (
+	"fmt"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+type Config struct {
+	// TaxRate — ставка налога, начисляемого сверху на сумму после скидок (0.2 = 20%)
+	TaxRate float64
+}
+
+type Engine struct {
+	cfg Config
+}
+
+func NewEngine(cfg Config) *Engine {
+	return &Engine{cfg: cfg}
+}
+
+// PriceItems проставляет цену (цену варианта, если она задана), стоимость позиции и признак
+// изменения цены. Смена валюты товара тоже считается изменением цены.
+func PriceItems(items []domain.CartItemDetail) error {
+	for i := range items {
+		item := &items[i]
+		if !item.Available {
+			item.UnitPrice, item.LineTotal, item.PriceChanged = money.Money{}, money.Money{}, false
+			continue
+		}
+		item.UnitPrice = item.Product.UnitPrice(item.Variant)
+		var err error
+		if item.LineTotal, err = item.UnitPrice.Mul(int64(item.Quantity)); err != nil {
+			return fmt.Errorf("product %d: %w", item.Product.ID, err)
+		}
+		item.PriceChanged = item.PriceAtAdd != nil && *item.PriceAtAdd != item.UnitPrice
+	}
+	return nil
+}
+
+// Currency — валюта корзины: валюта первой доступной позиции, для пустой корзины — валюта по умолчанию.
+func Currency(items []domain.CartItemDetail) money.Currency {
+	for _, item := range items {
+		if item.Available && item.UnitPrice.Currency() != "" {
+			return item.UnitPrice.Currency()
+		}
+	}
+	return money.DefaultCurrency
+}
+
+// Subtotal — сумма доступных позиций до скидок.
+func Subtotal(items []domain.CartItemDetail) (money.Money, error) {
+	subtotal := money.Zero(Currency(items))
+	for _, item := range items {
+		if !item.Available {
+			continue
+		}
+		var err error
+		if subtotal, err = subtotal.Add(item.LineTotal); err != nil {
+			return money.Money{}, fmt.Errorf("product %d: %w", item.Product.ID, err)
+		}
+	}
+	return subtotal, nil
+}
+
+// Price собирает корзину с суммами. Скидки суммарно не превышают подытог.
+func (e *Engine) Price(items []domain.CartItemDetail, discounts []domain.AppliedDiscount) (domain.Cart, error) {
+	if err := PriceItems(items); err != nil {
+		return domain.Cart{}, err
+	}
+
+	subtotal, err := Subtotal(items)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	cart := domain.Cart{
+		Items:     items,
+		Subtotal:  subtotal,
+		Discounts: discounts,
+	}
+
+	for _, item := range items {
+		if item.PriceChanged {
+			cart.PriceChanged = true
+		}
+	}
+
+	if err := e.total(&cart); err != nil {
+		return domain.Cart{}, err
+	}
+	return cart, nil
+}
+
+// total считает скидку, налог и итог по подытогу и скидкам корзины.
+func (e *Engine) total(cart *domain.Cart) error {
+	discountTotal := money.Zero(cart.Subtotal.Currency())
+	for _, d := range cart.Discounts {
+		var err error
+		if discountTotal, err = discountTotal.Add(d.Amount); err != nil {
+			return fmt.Errorf("discount %q: %w", d.Description, err)
+		}
+	}
+
+	var err error
+	if cart.DiscountTotal, err = money.Min(discountTotal, cart.Subtotal); err != nil {
+		return err
+	}
+	taxable, err := cart.Subtotal.Sub(cart.DiscountTotal)
+	if err != nil {
+		return err
+	}
+	if cart.Tax, err = taxable.Scale(e.cfg.TaxRate, money.HalfUp); err != nil {
+		return err
+	}
+	cart.Total, err = taxable.Add(cart.Tax)
+	return err
+}
+
+// Convert пересчитывает рассчитанную корзину в валюту to. Цены позиций и скидки переводятся
+// по курсу с округлением money.HalfUp, а подытог, налог и итог считаются заново уже в валюте to —
+// так итог всегда равен сумме показанных покупателю цен. Исходный итог остаётся в BaseTotal.
+func (e *Engine) Convert(cart domain.Cart, rates *money.RateTable, to money.Currency) (domain.Cart, error) {
+	from := cart.Total.Currency()
+	if from == to {
+		return cart, nil
+	}
+	rate, err := rates.Rate(from, to)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	convert := func(m money.Money) (money.Money, error) {
+		if m.Currency() == "" {
+			return m, nil
+		}
+		return m.Convert(to, rate, money.HalfUp)
+	}
+
+	converted := cart
+	converted.Items = make([]domain.CartItemDetail, len(cart.Items))
+	for i, item := range cart.Items {
+		if item.Available && item.UnitPrice.Currency() != from {
+			return domain.Cart{}, fmt.Errorf("product %d: %w", item.Product.ID, money.ErrCurrencyMismatch)
+		}
+		if err := convertItem(&item, convert); err != nil {
+			return domain.Cart{}, fmt.Errorf("product %d: %w", item.Product.ID, err)
+		}
+		converted.Items[i] = item
+	}
+
+	converted.Discounts = make([]domain.AppliedDiscount, len(cart.Discounts))
+	for i, d := range cart.Discounts {
+		if d.Amount, err = convert(d.Amount); err != nil {
+			return domain.Cart{}, fmt.Errorf("discount %q: %w", d.Description, err)
+		}
+		converted.Discounts[i] = d
+	}
+
+	if converted.Subtotal, err = Subtotal(converted.Items); err != nil {
+		return domain.Cart{}, err
+	}
+	if converted.Subtotal.IsZero() {
+		converted.Subtotal = money.Zero(to)
+	}
+	if err := e.total(&converted); err != nil {
+		return domain.Cart{}, err
+	}
+	converted.BaseTotal = cart.Total
+	converted.ExchangeRate = money.FormatRate(rate)
+	return converted, nil
+}
+
+// convertItem переводит цены позиции и пересчитывает её стоимость.
+func convertItem(item *domain.CartItemDetail, convert func(money.Money) (money.Money, error)) error {
+	var err error
+	if item.Product.Price, err = convert(item.Product.Price); err != nil {
+		return err
+	}
+	if item.Variant != nil && item.Variant.Price != nil {
+		variant := *item.Variant
+		price, err := convert(*item.Variant.Price)
+		if err != nil {
+			return err
+		}
+		variant.Price = &price
+		item.Variant = &variant
+	}
+	if item.UnitPrice, err = convert(item.UnitPrice); err != nil {
+		return err
+	}
+	if item.LineTotal, err = item.UnitPrice.Mul(int64(item.Quantity)); err != nil {
+		return err
+	}
+	if item.PriceAtAdd != nil {
+		priceAtAdd, err := convert(*item.PriceAtAdd)
+		if err != nil {
+			return err
+		}
+		item.PriceAtAdd = &priceAtAdd
+	}
+	return nil
+}
+
+// PriceDropped — текущая цена ниже опорной. Цены в разных валютах не сравниваются.
+func PriceDropped(current, reference money.Money) bool {
+	c, err := current.Cmp(reference)
+	return err == nil && c < 0
+}
@@ -0,0 +1,143 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+func usd(amount string) money.Money { return money.MustParse(amount, money.USD) }
+
+func ptr(v money.Money) *money.Money { return &v }
+
+func TestPrice_Totals(t *testing.T) {
+	items := []domain.CartItemDetail{
+		{Product: domain.Product{ID: 1, Price: usd("10.50")}, Quantity: 2, Available: true, PriceAtAdd: ptr(usd("10.50"))},
+		{Product: domain.Product{ID: 2, Price: usd("3")}, Quantity: 3, Available: true},
+		{Product: domain.Product{ID: 3}, Quantity: 1, Available: false},
+	}
+
+	cart, err := NewEngine(Config{TaxRate: 0.2}).Price(items, []domain.AppliedDiscount{{Description: "sale", Amount: usd("5")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cart.Items[0].LineTotal != usd("21") || cart.Items[1].LineTotal != usd("9") || !cart.Items[2].LineTotal.IsZero() {
+		t.Fatalf("unexpected line totals: %+v", cart.Items)
+	}
+	if cart.Subtotal != usd("30") || cart.DiscountTotal != usd("5") || cart.Tax != usd("5") || cart.Total != usd("30") {
+		t.Fatalf("unexpected totals: %+v", cart)
+	}
+	if cart.PriceChanged {
+		t.Fatal("expected no price change")
+	}
+}
+
+func TestPrice_TaxRoundedOnce(t *testing.T) {
+	// 3 × 0.35 = 1.05; налог 7% = 0.0735 → 0.07. Округление по позициям дало бы 3 × 0.02 = 0.06
+	items := []domain.CartItemDetail{
+		{Product: domain.Product{ID: 1, Price: usd("0.35")}, Quantity: 3, Available: true},
+	}
+
+	cart, err := NewEngine(Config{TaxRate: 0.07}).Price(items, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.Tax != usd("0.07") || cart.Total != usd("1.12") {
+		t.Fatalf("unexpected tax %s and total %s", cart.Tax, cart.Total)
+	}
+}
+
+func TestPrice_DetectsPriceChange(t *testing.T) {
+	items := []domain.CartItemDetail{
+		{Product: domain.Product{ID: 1, Price: usd("12")}, Quantity: 1, Available: true, PriceAtAdd: ptr(usd("10"))},
+	}
+
+	cart, err := NewEngine(Config{}).Price(items, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cart.PriceChanged || !cart.Items[0].PriceChanged {
+		t.Fatalf("expected price change to be flagged, got %+v", cart)
+	}
+}
+
+func TestPrice_DiscountCappedAtSubtotal(t *testing.T) {
+	items := []domain.CartItemDetail{
+		{Product: domain.Product{ID: 1, Price: usd("4")}, Quantity: 1, Available: true},
+	}
+
+	cart, err := NewEngine(Config{TaxRate: 0.1}).Price(items, []domain.AppliedDiscount{{Amount: usd("10")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.DiscountTotal != usd("4") || !cart.Total.IsZero() {
+		t.Fatalf("expected discount capped at subtotal, got %+v", cart)
+	}
+}
+
+func TestPrice_RejectsMixedCurrencies(t *testing.T) {
+	items := []domain.CartItemDetail{
+		{Product: domain.Product{ID: 1, Price: usd("4")}, Quantity: 1, Available: true},
+		{Product: domain.Product{ID: 2, Price: money.MustParse("4", money.EUR)}, Quantity: 1, Available: true},
+	}
+
+	if _, err := NewEngine(Config{}).Price(items, nil); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Fatalf("expected ErrCurrencyMismatch, got %v", err)
+	}
+}
+
+func TestConvert_RecalculatesInDisplayCurrency(t *testing.T) {
+	items := []domain.CartItemDetail{
+		{Product: domain.Product{ID: 1, Price: usd("19.99")}, Quantity: 3, Available: true},
+	}
+	engine := NewEngine(Config{TaxRate: 0.1})
+
+	cart, err := engine.Price(items, []domain.AppliedDiscount{{Description: "sale", Amount: usd("5")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates, err := money.NewRateTable(money.USD, map[money.Currency]string{money.EUR: "0.92"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converted, err := engine.Convert(cart, rates, money.EUR)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eur := func(amount string) money.Money { return money.MustParse(amount, money.EUR) }
+	// 19.99 × 0.92 = 18.3908 → 18.39; 3 × 18.39 = 55.17; скидка 4.60; налог 10% от 50.57 = 5.057 → 5.06
+	if converted.Items[0].UnitPrice != eur("18.39") || converted.Subtotal != eur("55.17") ||
+		converted.DiscountTotal != eur("4.60") || converted.Tax != eur("5.06") || converted.Total != eur("55.63") {
+		t.Fatalf("unexpected converted cart: %+v", converted)
+	}
+	if converted.BaseTotal != cart.Total || converted.ExchangeRate != "0.92" {
+		t.Fatalf("expected base total %s at 0.92, got %s at %s", cart.Total, converted.BaseTotal, converted.ExchangeRate)
+	}
+	if cart.Items[0].UnitPrice != usd("19.99") {
+		t.Fatal("source cart must not be modified")
+	}
+}
+
+func TestPrice_UsesVariantPrice(t *testing.T) {
+	variant := domain.Variant{ID: 7, SKU: "TS-XL", Price: ptr(usd("12"))}
+	items := []domain.CartItemDetail{
+		{Product: domain.Product{ID: 1, Price: usd("10")}, Variant: &variant, VariantID: 7, Quantity: 2, Available: true, PriceAtAdd: ptr(usd("12"))},
+		{Product: domain.Product{ID: 1, Price: usd("10")}, Variant: &domain.Variant{ID: 8, SKU: "TS-S"}, VariantID: 8, Quantity: 1, Available: true},
+	}
+
+	cart, err := NewEngine(Config{}).Price(items, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.Items[0].UnitPrice != usd("12") || cart.Items[1].UnitPrice != usd("10") || cart.Total != usd("34") {
+		t.Fatalf("unexpected variant pricing: %+v", cart)
+	}
+	if cart.PriceChanged {
+		t.Fatal("expected no price change for unchanged variant price")
+	}
+}
@@ -0,0 +1,215 @@
+// Package productclient — клиент product-service для cart-service: пакетные
+// запросы, повторы с backoff, circuit breaker и локальный LRU последних известных данных.
+package productclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+)
+
+// batchSize соответствует лимиту GET /products?ids= в product-service
+const batchSize = 100
+
+type Config struct {
+	// Timeout — ограничение на одну попытку запроса
+	Timeout time.Duration
+	// MaxRetries — число повторов после первой неудачной попытки
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	// BreakerThreshold — сколько неудач подряд размыкают breaker
+	BreakerThreshold   int
+	BreakerOpenTimeout time.Duration
+	// FallbackSize — ёмкость локального LRU; 0 отключает запасной источник
+	FallbackSize int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:            2 * time.Second,
+		MaxRetries:         2,
+		RetryBaseDelay:     100 * time.Millisecond,
+		BreakerThreshold:   5,
+		BreakerOpenTimeout: 30 * time.Second,
+		FallbackSize:       10000,
+	}
+}
+
+// Lookup — результат пакетного запроса продуктов.
+type Lookup struct {
+	Products map[int64]domain.Product
+	// Stale — продукты, взятые из локального LRU, потому что product-service не ответил
+	Stale map[int64]bool
+}
+
+type ClientInterface interface {
+	GetProducts(ctx context.Context, ids []int64) (Lookup, error)
+}
+
+type Client struct {
+	baseURL    string
+	cfg        Config
+	httpClient *http.Client
+	breaker    *breaker
+	fallback   *lru
+}
+
+func NewClient(baseURL string, cfg Config) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		cfg:     cfg,
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		breaker:  newBreaker(cfg.BreakerThreshold, cfg.BreakerOpenTimeout),
+		fallback: newLRU(cfg.FallbackSize),
+	}
+}
+
+// GetProducts загружает продукты пакетами. Если product-service недоступен,
+// продукты по возможности берутся из LRU (и помечаются в Lookup.Stale), а ошибка
+// запроса всё равно возвращается, чтобы вызывающий код мог решить, допустимы ли устаревшие данные.
+// Продукты, которых нет в product-service, просто отсутствуют в Lookup.Products.
+func (c *Client) GetProducts(ctx context.Context, ids []int64) (Lookup, error) {
+	lookup := Lookup{
+		Products: make(map[int64]domain.Product, len(ids)),
+		Stale:    make(map[int64]bool),
+	}
+
+	var fetchErr error
+	for start := 0; start < len(ids); start += batchSize {
+		batch := ids[start:min(start+batchSize, len(ids))]
+
+		products, err := c.fetchWithRetry(ctx, batch)
+		if err != nil {
+			fetchErr = err
+			for _, id := range batch {
+				if p, ok := c.fallback.get(id); ok {
+					lookup.Products[id] = p
+					lookup.Stale[id] = true
+				}
+			}
+			continue
+		}
+
+		for _, p := range products {
+			lookup.Products[p.ID] = p
+			c.fallback.put(p)
+		}
+	}
+
+	return lookup, fetchErr
+}
+
+func (c *Client) fetchWithRetry(ctx context.Context, ids []int64) ([]domain.Product, error) {
+	var err error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := c.breaker.allow(); err != nil {
+			return nil, err
+		}
+
+		var products []domain.Product
+		products, err = c.fetch(ctx, ids)
+		switch {
+		case err == nil:
+			c.breaker.success()
+			return products, nil
+		case ctx.Err() != nil:
+			// запрос отменил вызывающий, product-service тут ни при чём
+			c.breaker.cancel()
+			return nil, err
+		case !retryable(err):
+			c.breaker.success()
+			return nil, err
+		}
+		c.breaker.failure()
+	}
+
+	return nil, err
+}
+
+func (c *Client) fetch(ctx context.Context, ids []int64) ([]domain.Product, error) {
+	strIDs := make([]string, len(ids))
+	for i, id := range ids {
+		strIDs[i] = strconv.FormatInt(id, 10)
+	}
+
+	url := fmt.Sprintf("%s/products?ids=%s", c.baseURL, strings.Join(strIDs, ","))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode}
+	}
+
+	var products []domain.Product
+	if err := json.NewDecoder(resp.Body).Decode(&products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// backoff — экспоненциальная задержка с джиттером: base*2^(attempt-1) + [0, base).
+func (c *Client) backoff(attempt int) time.Duration {
+	base := c.cfg.RetryBaseDelay
+	if base <= 0 {
+		return 0
+	}
+	return base<<(attempt-1) + rand.N(base)
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("product-service returned status %d", e.code)
+}
+
+// retryable: сетевые ошибки, 5xx и 429 повторяются, остальные 4xx — нет.
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError || se.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
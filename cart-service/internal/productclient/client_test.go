@@ -0,0 +1,191 @@
+package productclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.RetryBaseDelay = time.Millisecond
+	cfg.BreakerThreshold = 3
+	return cfg
+}
+
+func TestGetProducts_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode([]domain.Product{{ID: 1, Name: "A", Price: money.MustParse("10", money.USD)}})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, testConfig())
+
+	lookup, err := c.GetProducts(context.Background(), []int64{1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if lookup.Products[1].Name != "A" || lookup.Stale[1] {
+		t.Fatalf("expected fresh product A, got %+v", lookup)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls.Load())
+	}
+}
+
+func TestGetProducts_FallsBackToLastKnown(t *testing.T) {
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode([]domain.Product{{ID: 1, Name: "A", Price: money.MustParse("10", money.USD)}})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, testConfig())
+	if _, err := c.GetProducts(context.Background(), []int64{1}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	down.Store(true)
+	lookup, err := c.GetProducts(context.Background(), []int64{1, 2})
+	if err == nil {
+		t.Fatal("expected error when product-service is down, got nil")
+	}
+	if !lookup.Stale[1] || lookup.Products[1].Name != "A" {
+		t.Fatalf("expected stale product A from fallback, got %+v", lookup)
+	}
+	if _, ok := lookup.Products[2]; ok {
+		t.Fatalf("expected product 2 to be missing, got %+v", lookup.Products[2])
+	}
+}
+
+func TestGetProducts_BreakerOpensAfterFailures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, testConfig())
+
+	_, _ = c.GetProducts(context.Background(), []int64{1})
+	_, err := c.GetProducts(context.Background(), []int64{1})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected breaker to stop calls after 3 failures, got %d calls", calls.Load())
+	}
+}
+
+func TestGetProducts_BreakerRecoversAfterCanceledTrial(t *testing.T) {
+	var mode atomic.Int32 // 0 — ошибка, 1 — зависает до отмены, 2 — отвечает
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch mode.Load() {
+		case 0:
+			w.WriteHeader(http.StatusInternalServerError)
+		case 1:
+			cancel()
+			<-r.Context().Done()
+		default:
+			json.NewEncoder(w).Encode([]domain.Product{{ID: 1, Name: "A", Price: money.MustParse("10", money.USD)}})
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, testConfig())
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+
+	_, _ = c.GetProducts(context.Background(), []int64{1})
+	if _, err := c.GetProducts(context.Background(), []int64{1}); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	// Пробный запрос после openTimeout отменяет вызывающий
+	now = now.Add(time.Minute)
+	mode.Store(1)
+	if _, err := c.GetProducts(ctx, []int64{1}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	mode.Store(2)
+	lookup, err := c.GetProducts(context.Background(), []int64{1})
+	if err != nil {
+		t.Fatalf("expected breaker to let a new trial through, got %v", err)
+	}
+	if lookup.Products[1].Name != "A" {
+		t.Fatalf("expected product A, got %+v", lookup)
+	}
+}
+
+func TestGetProducts_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, testConfig())
+
+	if _, err := c.GetProducts(context.Background(), []int64{1}); err == nil {
+		t.Fatal("expected error for 400 response, got nil")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected 1 call, got %d", calls.Load())
+	}
+}
+
+func TestGetRates_CachesAndFallsBackToLastKnown(t *testing.T) {
+	var calls atomic.Int32
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"base":"USD","rates":{"EUR":"0.92"}}`))
+	}))
+	defer srv.Close()
+
+	c := NewRatesClient(srv.URL, time.Hour, time.Second)
+	for range 2 {
+		if _, err := c.GetRates(context.Background()); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected rates to be cached, got %d calls", calls.Load())
+	}
+
+	down.Store(true)
+	c.ttl = 0
+	table, err := c.GetRates(context.Background())
+	if err != nil {
+		t.Fatalf("expected last known rates, got %v", err)
+	}
+	if table.Rates()[money.EUR] != "0.92" {
+		t.Fatalf("unexpected rates %v", table.Rates())
+	}
+}
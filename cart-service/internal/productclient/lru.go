@@ -0,0 +1,59 @@
+package productclient
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+)
+
+// lru хранит последние известные данные продуктов. Используется как запасной
+// источник, когда product-service недоступен.
+type lru struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[int64]*list.Element
+}
+
+func newLRU(capacity int) *lru {
+	return &lru{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[int64]*list.Element),
+	}
+}
+
+func (c *lru) get(id int64) (domain.Product, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[id]
+	if !ok {
+		return domain.Product{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(domain.Product), true
+}
+
+func (c *lru) put(p domain.Product) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[p.ID]; ok {
+		el.Value = p
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[p.ID] = c.order.PushFront(p)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(domain.Product).ID)
+	}
+}
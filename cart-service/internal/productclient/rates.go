@@ -0,0 +1,80 @@
+package productclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+// RatesInterface — источник курсов валют для пересчёта корзины в валюту отображения.
+type RatesInterface interface {
+	GetRates(ctx context.Context) (*money.RateTable, error)
+}
+
+// RatesClient загружает курсы из GET /rates product-service и держит их в памяти ttl.
+// Если product-service недоступен, используется последняя загруженная таблица.
+type RatesClient struct {
+	baseURL    string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	table     *money.RateTable
+	fetchedAt time.Time
+}
+
+func NewRatesClient(baseURL string, ttl time.Duration, timeout time.Duration) *RatesClient {
+	return &RatesClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		ttl:        ttl,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *RatesClient) GetRates(ctx context.Context) (*money.RateTable, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.table != nil && time.Since(c.fetchedAt) < c.ttl {
+		return c.table, nil
+	}
+
+	table, err := c.fetch(ctx)
+	if err != nil {
+		if c.table != nil {
+			return c.table, nil
+		}
+		return nil, err
+	}
+	c.table, c.fetchedAt = table, time.Now()
+	return table, nil
+}
+
+func (c *RatesClient) fetch(ctx context.Context) (*money.RateTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/rates", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode}
+	}
+
+	var table money.RateTable
+	if err := json.NewDecoder(resp.Body).Decode(&table); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
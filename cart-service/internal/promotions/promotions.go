@@ -0,0 +1,121 @@
+// Package promotions содержит правила акций: проверку настроек, окно действия,
+// таргетинг и расчёт скидки по позициям корзины.
+package promotions
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/pricing"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+// Validate проверяет настройки акции перед сохранением.
+func Validate(p domain.Promotion) error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: name must be set", domain.ErrInvalidPromotion)
+	}
+
+	switch p.Type {
+	case domain.PromotionPercent:
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("%w: percent value must be in (0, 100]", domain.ErrInvalidPromotion)
+		}
+	case domain.PromotionFixed:
+		if !p.Amount.IsPositive() {
+			return fmt.Errorf("%w: fixed amount must be positive", domain.ErrInvalidPromotion)
+		}
+	case domain.PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return fmt.Errorf("%w: buy_quantity and get_quantity must be positive", domain.ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", domain.ErrInvalidPromotion, p.Type)
+	}
+
+	if p.MinOrderAmount.IsNegative() || p.MaxUses < 0 || p.MaxUsesPerUser < 0 {
+		return fmt.Errorf("%w: limits can't be negative", domain.ErrInvalidPromotion)
+	}
+	if _, err := p.Amount.Add(p.MinOrderAmount); err != nil {
+		return fmt.Errorf("%w: amount and min_order_amount: %v", domain.ErrInvalidPromotion, err)
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", domain.ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// Active — акция действует в момент now.
+func Active(p domain.Promotion, now time.Time) bool {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Targets — акция распространяется на продукт.
+func Targets(p domain.Promotion, product domain.Product) bool {
+	if len(p.ProductIDs) == 0 && len(p.Categories) == 0 {
+		return true
+	}
+	return slices.Contains(p.ProductIDs, product.ID) ||
+		(product.Category != "" && slices.Contains(p.Categories, product.Category))
+}
+
+// Discount рассчитывает скидку по позициям с уже посчитанными LineTotal.
+// Возвращает ошибку domain.ErrCouponNotApplicable, если корзина не подходит под условия
+// или её валюта отличается от валюты сумм акции. Процентная скидка округляется money.HalfUp.
+func Discount(p domain.Promotion, items []domain.CartItemDetail) (money.Money, error) {
+	subtotal, err := pricing.Subtotal(items)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if c, err := subtotal.Cmp(p.MinOrderAmount); err != nil {
+		return money.Money{}, fmt.Errorf("%w: %v", domain.ErrCouponNotApplicable, err)
+	} else if c < 0 {
+		return money.Money{}, fmt.Errorf("%w: minimum order amount is %s", domain.ErrCouponNotApplicable, p.MinOrderAmount)
+	}
+
+	eligible := money.Zero(subtotal.Currency())
+	discount := money.Zero(subtotal.Currency())
+	for _, item := range items {
+		if !item.Available || !Targets(p, item.Product) {
+			continue
+		}
+		if eligible, err = eligible.Add(item.LineTotal); err != nil {
+			return money.Money{}, err
+		}
+
+		if p.Type == domain.PromotionBuyXGetY {
+			free := item.Quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+			freeTotal, err := item.UnitPrice.Mul(int64(free))
+			if err != nil {
+				return money.Money{}, err
+			}
+			if discount, err = discount.Add(freeTotal); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+
+	switch p.Type {
+	case domain.PromotionPercent:
+		if discount, err = eligible.Percent(p.Value, money.HalfUp); err != nil {
+			return money.Money{}, err
+		}
+	case domain.PromotionFixed:
+		if discount, err = money.Min(p.Amount, eligible); err != nil {
+			return money.Money{}, fmt.Errorf("%w: %v", domain.ErrCouponNotApplicable, err)
+		}
+	}
+
+	if !discount.IsPositive() {
+		return money.Money{}, fmt.Errorf("%w: no eligible items in cart", domain.ErrCouponNotApplicable)
+	}
+	return discount, nil
+}
@@ -0,0 +1,76 @@
+package promotions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/pricing"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+func usd(amount string) money.Money { return money.MustParse(amount, money.USD) }
+
+func cartItems() []domain.CartItemDetail {
+	items := []domain.CartItemDetail{
+		{Product: domain.Product{ID: 1, Price: usd("100"), Category: "laptops"}, Quantity: 1, Available: true},
+		{Product: domain.Product{ID: 2, Price: usd("10"), Category: "accessories"}, Quantity: 5, Available: true},
+	}
+	if err := pricing.PriceItems(items); err != nil {
+		panic(err)
+	}
+	return items
+}
+
+func TestDiscount(t *testing.T) {
+	tests := []struct {
+		name  string
+		promo domain.Promotion
+		want  money.Money
+	}{
+		{"percent", domain.Promotion{Type: domain.PromotionPercent, Value: 10}, usd("15")},
+		{"percent rounded half up", domain.Promotion{Type: domain.PromotionPercent, Value: 0.33}, usd("0.50")},
+		{"fixed", domain.Promotion{Type: domain.PromotionFixed, Amount: usd("20")}, usd("20")},
+		{"fixed capped by eligible", domain.Promotion{Type: domain.PromotionFixed, Amount: usd("80"), Categories: []string{"accessories"}}, usd("50")},
+		{"buy 2 get 1", domain.Promotion{Type: domain.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}, usd("10")},
+		{"product target", domain.Promotion{Type: domain.PromotionPercent, Value: 50, ProductIDs: []int64{1}}, usd("50")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Discount(tt.promo, cartItems())
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDiscount_NotApplicable(t *testing.T) {
+	promos := []domain.Promotion{
+		{Type: domain.PromotionPercent, Value: 10, MinOrderAmount: usd("500")},
+		{Type: domain.PromotionPercent, Value: 10, Categories: []string{"smartphones"}},
+		{Type: domain.PromotionFixed, Amount: money.MustParse("5", money.EUR)},
+	}
+	for _, p := range promos {
+		if _, err := Discount(p, cartItems()); !errors.Is(err, domain.ErrCouponNotApplicable) {
+			t.Fatalf("expected ErrCouponNotApplicable for %+v, got %v", p, err)
+		}
+	}
+}
+
+func TestActive(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	if !Active(domain.Promotion{StartsAt: &past, EndsAt: &future}, now) {
+		t.Fatal("expected promotion to be active")
+	}
+	if Active(domain.Promotion{StartsAt: &future}, now) || Active(domain.Promotion{EndsAt: &past}, now) {
+		t.Fatal("expected promotion outside its window to be inactive")
+	}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AbandonedCartRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAbandonedCartRepository(db *pgxpool.Pool) *AbandonedCartRepository {
+	return &AbandonedCartRepository{db: db}
+}
+
+type AbandonedCartRepositoryInterface interface {
+	FindAbandoned(ctx context.Context, inactiveSince time.Time, limit int) ([]domain.AbandonedCart, error)
+	MarkNotified(ctx context.Context, cart domain.AbandonedCart) error
+	PurgeInactive(ctx context.Context, inactiveSince time.Time) (int64, error)
+}
+
+// FindAbandoned возвращает корзины без изменений с inactiveSince, о которых ещё не
+// сообщали после последней активности. Самые старые корзины идут первыми.
+func (r *AbandonedCartRepository) FindAbandoned(ctx context.Context, inactiveSince time.Time, limit int) ([]domain.AbandonedCart, error) {
+	query := `
+		SELECT c.user_id, c.product_ids, c.total_quantity, c.last_activity_at
+		FROM (
+			SELECT user_id,
+				array_agg(DISTINCT product_id ORDER BY product_id) AS product_ids,
+				SUM(quantity) AS total_quantity,
+				MAX(updated_at) AS last_activity_at
+			FROM cart_service.cart_items
+			GROUP BY user_id
+		) c
+		LEFT JOIN cart_service.abandoned_carts a ON a.user_id = c.user_id
+		WHERE c.last_activity_at < $1
+			AND (a.user_id IS NULL OR a.last_activity_at < c.last_activity_at)
+		ORDER BY c.last_activity_at
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, inactiveSince, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var carts []domain.AbandonedCart
+	for rows.Next() {
+		var c domain.AbandonedCart
+		if err := rows.Scan(&c.UserID, &c.ProductIDs, &c.TotalQuantity, &c.LastActivityAt); err != nil {
+			return nil, err
+		}
+		carts = append(carts, c)
+	}
+	return carts, rows.Err()
+}
+
+func (r *AbandonedCartRepository) MarkNotified(ctx context.Context, cart domain.AbandonedCart) error {
+	query := `
+		INSERT INTO cart_service.abandoned_carts (user_id, last_activity_at, notified_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET last_activity_at = EXCLUDED.last_activity_at, notified_at = EXCLUDED.notified_at
+	`
+	_, err := r.db.Exec(ctx, query, cart.UserID, cart.LastActivityAt, time.Now())
+	return err
+}
+
+// PurgeInactive удаляет корзины (вместе с купоном и отметкой об уведомлении) без
+// изменений с inactiveSince и возвращает число удалённых корзин.
+func (r *AbandonedCartRepository) PurgeInactive(ctx context.Context, inactiveSince time.Time) (int64, error) {
+	query := `
+		WITH expired AS (
+			SELECT user_id FROM cart_service.cart_items
+			GROUP BY user_id
+			HAVING MAX(updated_at) < $1
+		),
+		deleted_coupons AS (
+			DELETE FROM cart_service.cart_coupons WHERE user_id IN (SELECT user_id FROM expired)
+		),
+		deleted_marks AS (
+			DELETE FROM cart_service.abandoned_carts WHERE user_id IN (SELECT user_id FROM expired)
+		),
+		deleted_items AS (
+			DELETE FROM cart_service.cart_items WHERE user_id IN (SELECT user_id FROM expired)
+			RETURNING user_id
+		)
+		SELECT COUNT(DISTINCT user_id) FROM deleted_items
+	`
+	var purged int64
+	err := r.db.QueryRow(ctx, query, inactiveSince).Scan(&purged)
+	return purged, err
+}
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,27 +20,71 @@ func NewCartRepository(db *pgxpool.Pool) *CartRepository {
 }
 
 type CartRepositoryInterface interface {
-	AddItem(ctx context.Context, item domain.CartItem) error
+	ApplyOperations(ctx context.Context, userID int64, ops []domain.CartOperation, maxQuantity int) error
 	GetItemsByUserID(ctx context.Context, userID int64) ([]domain.CartItem, error)
-	DeleteItem(ctx context.Context, userID, productID int64) error
+	DeleteItem(ctx context.Context, userID int64, key domain.ItemKey) error
 	ClearCart(ctx context.Context, userID int64) error
+	UpdatePrices(ctx context.Context, userID int64, prices map[domain.ItemKey]money.Money) error
 }
 
-func (r *CartRepository) AddItem(ctx context.Context, item domain.CartItem) error {
+// ApplyOperations применяет операции над корзиной в одной транзакции: либо все, либо ни одной.
+// Если после операции количество товара превышает maxQuantity, возвращается domain.ErrQuantityExceeded.
+func (r *CartRepository) ApplyOperations(ctx context.Context, userID int64, ops []domain.CartOperation, maxQuantity int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, op := range ops {
+		if err := applyOperation(ctx, tx, userID, op, maxQuantity); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func applyOperation(ctx context.Context, tx pgx.Tx, userID int64, op domain.CartOperation, maxQuantity int) error {
+	if op.Op == domain.OperationRemove || (op.Op == domain.OperationSet && op.Quantity == 0) {
+		_, err := tx.Exec(ctx, `DELETE FROM cart_service.cart_items WHERE user_id=$1 AND product_id=$2 AND variant_id=$3`,
+			userID, op.ProductID, op.VariantID)
+		return err
+	}
+
+	quantityExpr := "EXCLUDED.quantity"
+	if op.Op == domain.OperationAdd {
+		quantityExpr = "cart_items.quantity + EXCLUDED.quantity"
+	}
+
+	// price_at_add и его валюта фиксируются при первом добавлении и меняются только через UpdatePrices
 	query := `
-		INSERT INTO cart_service.cart_items (user_id, product_id, quantity, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT (user_id, product_id) DO UPDATE
-		SET quantity = cart_items.quantity + EXCLUDED.quantity,
+		INSERT INTO cart_service.cart_items (user_id, product_id, variant_id, quantity, price_at_add, currency, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, product_id, variant_id) DO UPDATE
+		SET quantity = ` + quantityExpr + `,
+			price_at_add = COALESCE(cart_items.price_at_add, EXCLUDED.price_at_add),
+			currency = CASE WHEN cart_items.price_at_add IS NULL THEN EXCLUDED.currency ELSE cart_items.currency END,
 			updated_at = EXCLUDED.updated_at
+		RETURNING quantity
 	`
-	_, err := r.db.Exec(ctx, query, item.UserID, item.ProductID, item.Quantity, time.Now(), time.Now())
-	return err
+	var quantity int
+	err := tx.QueryRow(ctx, query,
+		userID, op.ProductID, op.VariantID, op.Quantity, nullableAmount(op.Price), currencyOf(op.Price), time.Now(), time.Now(),
+	).Scan(&quantity)
+	if err != nil {
+		return err
+	}
+	if maxQuantity > 0 && quantity > maxQuantity {
+		return fmt.Errorf("%w: product %s would have %d items, max is %d", domain.ErrQuantityExceeded, op.Key(), quantity, maxQuantity)
+	}
+
+	return nil
 }
 
 func (r *CartRepository) GetItemsByUserID(ctx context.Context, userID int64) ([]domain.CartItem, error) {
 	query := `
-		SELECT id, user_id, product_id, quantity, created_at, updated_at
+		SELECT id, user_id, product_id, variant_id, quantity, price_at_add, currency, created_at, updated_at
 		FROM cart_service.cart_items
 		WHERE user_id = $1
 	`
@@ -50,24 +97,32 @@ func (r *CartRepository) GetItemsByUserID(ctx context.Context, userID int64) ([]
 	var items []domain.CartItem
 	for rows.Next() {
 		var item domain.CartItem
+		var priceAtAdd *string
+		var currency string
 		if err := rows.Scan(
 			&item.ID,
 			&item.UserID,
 			&item.ProductID,
+			&item.VariantID,
 			&item.Quantity,
+			&priceAtAdd,
+			&currency,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
+		if item.PriceAtAdd, err = parseNullableAmount(priceAtAdd, currency); err != nil {
+			return nil, err
+		}
 		items = append(items, item)
 	}
 	return items, nil
 }
 
-func (r *CartRepository) DeleteItem(ctx context.Context, userID, productID int64) error {
-	query := `DELETE FROM cart_service.cart_items WHERE user_id=$1 AND product_id=$2`
-	_, err := r.db.Exec(ctx, query, userID, productID)
+func (r *CartRepository) DeleteItem(ctx context.Context, userID int64, key domain.ItemKey) error {
+	query := `DELETE FROM cart_service.cart_items WHERE user_id=$1 AND product_id=$2 AND variant_id=$3`
+	_, err := r.db.Exec(ctx, query, userID, key.ProductID, key.VariantID)
 	return err
 }
 
@@ -76,3 +131,25 @@ func (r *CartRepository) ClearCart(ctx context.Context, userID int64) error {
 	_, err := r.db.Exec(ctx, query, userID)
 	return err
 }
+
+// UpdatePrices запоминает подтверждённые покупателем цены
+func (r *CartRepository) UpdatePrices(ctx context.Context, userID int64, prices map[domain.ItemKey]money.Money) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE cart_service.cart_items
+		SET price_at_add = $4, currency = $5, updated_at = $6
+		WHERE user_id = $1 AND product_id = $2 AND variant_id = $3
+	`
+	for key, price := range prices {
+		if _, err := tx.Exec(ctx, query, userID, key.ProductID, key.VariantID, nullableAmount(price), currencyOf(price), time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
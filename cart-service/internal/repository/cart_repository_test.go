@@ -0,0 +1,124 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/migrations"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Тесты общие для локального запуска (testcontainers, main_test.go) и CI (main_ci_test.go):
+// отличается только TestMain, который поднимает базу
+
+var dbpool *pgxpool.Pool
+
+// applyMigrations создаёт схему cart_service теми же up-миграциями, что применяет
+// migration-service, чтобы тесты не расходились с настоящей схемой
+func applyMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+	names, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sql, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			return err
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func clearCartItems(t *testing.T) {
+	if _, err := dbpool.Exec(context.Background(), "DELETE FROM cart_service.cart_items"); err != nil {
+		t.Fatalf("failed to clear cart items: %v", err)
+	}
+}
+
+func cartQuantities(t *testing.T, repo *repository.CartRepository, userID int64) map[domain.ItemKey]int {
+	t.Helper()
+	items, err := repo.GetItemsByUserID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("GetItemsByUserID failed: %v", err)
+	}
+	quantities := make(map[domain.ItemKey]int, len(items))
+	for _, item := range items {
+		quantities[item.Key()] = item.Quantity
+	}
+	return quantities
+}
+
+func TestApplyOperations_RollsBackOnMaxQuantity(t *testing.T) {
+	clearCartItems(t)
+	ctx := context.Background()
+	repo := repository.NewCartRepository(dbpool)
+	price := money.MustParse("10", money.USD)
+
+	if err := repo.ApplyOperations(ctx, 1, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 1, Quantity: 98, Price: price},
+	}, 99); err != nil {
+		t.Fatalf("ApplyOperations failed: %v", err)
+	}
+
+	// Первая операция проходит, вторая превышает максимум — откатываются обе
+	err := repo.ApplyOperations(ctx, 1, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 2, Quantity: 1, Price: price},
+		{Op: domain.OperationRemove, ProductID: 3},
+		{Op: domain.OperationAdd, ProductID: 1, Quantity: 5, Price: price},
+	}, 99)
+	if !errors.Is(err, domain.ErrQuantityExceeded) {
+		t.Fatalf("expected ErrQuantityExceeded, got %v", err)
+	}
+	got := cartQuantities(t, repo, 1)
+	if len(got) != 1 || got[domain.ItemKey{ProductID: 1}] != 98 {
+		t.Fatalf("expected the cart to stay unchanged, got %v", got)
+	}
+}
+
+func TestApplyOperations_SetAndVariants(t *testing.T) {
+	clearCartItems(t)
+	ctx := context.Background()
+	repo := repository.NewCartRepository(dbpool)
+	price := money.MustParse("10", money.USD)
+
+	if err := repo.ApplyOperations(ctx, 1, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 1, VariantID: 10, Quantity: 2, Price: price},
+		{Op: domain.OperationAdd, ProductID: 1, VariantID: 11, Quantity: 1, Price: price},
+		{Op: domain.OperationAdd, ProductID: 2, Quantity: 4, Price: price},
+		{Op: domain.OperationSet, ProductID: 2, Quantity: 3, Price: price},
+	}, 99); err != nil {
+		t.Fatalf("ApplyOperations failed: %v", err)
+	}
+	got := cartQuantities(t, repo, 1)
+	want := map[domain.ItemKey]int{{ProductID: 1, VariantID: 10}: 2, {ProductID: 1, VariantID: 11}: 1, {ProductID: 2}: 3}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for key, quantity := range want {
+		if got[key] != quantity {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	if err := repo.ApplyOperations(ctx, 1, []domain.CartOperation{
+		{Op: domain.OperationSet, ProductID: 1, VariantID: 11, Quantity: 0},
+		{Op: domain.OperationRemove, ProductID: 2},
+	}, 99); err != nil {
+		t.Fatalf("ApplyOperations failed: %v", err)
+	}
+	got = cartQuantities(t, repo, 1)
+	if len(got) != 1 || got[domain.ItemKey{ProductID: 1, VariantID: 10}] != 2 {
+		t.Fatalf("expected only variant 10 to remain, got %v", got)
+	}
+}
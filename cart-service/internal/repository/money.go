@@ -0,0 +1,34 @@
+package repository
+
+import "github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+
+// Суммы хранятся в NUMERIC-колонках, валюта строки — в колонке currency.
+// pgx передаёт и читает NUMERIC как десятичную строку без потери точности.
+
+// nullableAmount — значение для nullable NUMERIC: сумма без валюты (неизвестная цена) сохраняется как NULL.
+func nullableAmount(m money.Money) *string {
+	if m.Currency() == "" {
+		return nil
+	}
+	amount := m.Decimal()
+	return &amount
+}
+
+func parseNullableAmount(amount *string, currency string) (*money.Money, error) {
+	if amount == nil {
+		return nil, nil
+	}
+	m, err := money.Parse(*amount, money.Currency(currency))
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// currencyOf — валюта для колонки currency; у неизвестной цены — валюта по умолчанию.
+func currencyOf(m money.Money) money.Currency {
+	if m.Currency() == "" {
+		return money.DefaultCurrency
+	}
+	return m.Currency()
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PrivacyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPrivacyRepository(db *pgxpool.Pool) *PrivacyRepository {
+	return &PrivacyRepository{db: db}
+}
+
+type PrivacyRepositoryInterface interface {
+	ListRedemptions(ctx context.Context, userID int64) ([]domain.Redemption, error)
+	PurgeUser(ctx context.Context, userID int64) error
+}
+
+func (r *PrivacyRepository) ListRedemptions(ctx context.Context, userID int64) ([]domain.Redemption, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT promotion_id, created_at FROM cart_service.promotion_redemptions
+		WHERE user_id = $1 ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []domain.Redemption{}
+	for rows.Next() {
+		var red domain.Redemption
+		if err := rows.Scan(&red.PromotionID, &red.RedeemedAt); err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, red)
+	}
+	return redemptions, rows.Err()
+}
+
+// PurgeUser удаляет корзину, купон, списки желаний и отметки о брошенной корзине.
+// Использования акций остаются: по ним считаются лимиты, а user_id без профиля
+// в user-service уже не указывает на человека.
+func (r *PrivacyRepository) PurgeUser(ctx context.Context, userID int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, query := range []string{
+		`DELETE FROM cart_service.cart_items WHERE user_id = $1`,
+		`DELETE FROM cart_service.cart_coupons WHERE user_id = $1`,
+		`DELETE FROM cart_service.abandoned_carts WHERE user_id = $1`,
+		`DELETE FROM cart_service.wishlists WHERE user_id = $1`,
+	} {
+		if _, err := tx.Exec(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
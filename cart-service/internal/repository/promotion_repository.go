@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PromotionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPromotionRepository(db *pgxpool.Pool) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+type PromotionRepositoryInterface interface {
+	CreatePromotion(ctx context.Context, p domain.Promotion) (int64, error)
+	ListPromotions(ctx context.Context) ([]domain.Promotion, error)
+	GetPromotionByCode(ctx context.Context, code string) (domain.Promotion, error)
+	GetAutomaticPromotions(ctx context.Context, now time.Time) ([]domain.Promotion, error)
+	CountRedemptions(ctx context.Context, promotionID, userID int64) (total, byUser int, err error)
+	Redeem(ctx context.Context, userID int64, promotions []domain.Promotion) ([]int64, error)
+	ReleaseRedemptions(ctx context.Context, ids []int64) error
+
+	SetCartCoupon(ctx context.Context, userID int64, code string) error
+	GetCartCoupon(ctx context.Context, userID int64) (string, error)
+	DeleteCartCoupon(ctx context.Context, userID int64) error
+}
+
+const promotionColumns = `
+	id, COALESCE(code, ''), name, type, value, buy_quantity, get_quantity, min_order_amount, currency,
+	max_uses, max_uses_per_user, starts_at, ends_at, product_ids, categories, created_at
+`
+
+// В колонке value хранится процент для percent-акций и сумма скидки для fixed;
+// суммы акции (value для fixed и min_order_amount) — в валюте из колонки currency.
+func scanPromotion(row pgx.Row) (domain.Promotion, error) {
+	var p domain.Promotion
+	var value, minOrderAmount, currency string
+	err := row.Scan(
+		&p.ID, &p.Code, &p.Name, &p.Type, &value, &p.BuyQuantity, &p.GetQuantity, &minOrderAmount, &currency,
+		&p.MaxUses, &p.MaxUsesPerUser, &p.StartsAt, &p.EndsAt, &p.ProductIDs, &p.Categories, &p.CreatedAt,
+	)
+	if err != nil {
+		return p, err
+	}
+
+	if p.Type == domain.PromotionFixed {
+		if p.Amount, err = money.Parse(value, money.Currency(currency)); err != nil {
+			return p, err
+		}
+	} else if p.Value, err = strconv.ParseFloat(value, 64); err != nil {
+		return p, err
+	}
+
+	if p.MinOrderAmount, err = money.Parse(minOrderAmount, money.Currency(currency)); err != nil {
+		return p, err
+	}
+	// нулевой минимум не привязывает акцию к валюте
+	if p.MinOrderAmount.IsZero() {
+		p.MinOrderAmount = money.Money{}
+	}
+	return p, nil
+}
+
+func promotionValue(p domain.Promotion) string {
+	if p.Type == domain.PromotionFixed {
+		return p.Amount.Decimal()
+	}
+	return strconv.FormatFloat(p.Value, 'f', -1, 64)
+}
+
+func (r *PromotionRepository) CreatePromotion(ctx context.Context, p domain.Promotion) (int64, error) {
+	if p.ProductIDs == nil {
+		p.ProductIDs = []int64{}
+	}
+	if p.Categories == nil {
+		p.Categories = []string{}
+	}
+
+	query := `
+		INSERT INTO cart_service.promotions (
+			code, name, type, value, buy_quantity, get_quantity, min_order_amount, currency,
+			max_uses, max_uses_per_user, starts_at, ends_at, product_ids, categories, created_at
+		)
+		VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id
+	`
+	var id int64
+	err := r.db.QueryRow(ctx, query,
+		p.Code, p.Name, p.Type, promotionValue(p), p.BuyQuantity, p.GetQuantity, p.MinOrderAmount.Decimal(), p.Currency(),
+		p.MaxUses, p.MaxUsesPerUser, p.StartsAt, p.EndsAt, p.ProductIDs, p.Categories, time.Now(),
+	).Scan(&id)
+	return id, err
+}
+
+func (r *PromotionRepository) ListPromotions(ctx context.Context) ([]domain.Promotion, error) {
+	return r.queryPromotions(ctx, `SELECT `+promotionColumns+` FROM cart_service.promotions ORDER BY id`)
+}
+
+func (r *PromotionRepository) GetPromotionByCode(ctx context.Context, code string) (domain.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM cart_service.promotions WHERE code = $1`
+	p, err := scanPromotion(r.db.QueryRow(ctx, query, code))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return p, domain.ErrCouponNotFound
+	}
+	return p, err
+}
+
+// GetAutomaticPromotions возвращает действующие акции без кода
+func (r *PromotionRepository) GetAutomaticPromotions(ctx context.Context, now time.Time) ([]domain.Promotion, error) {
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM cart_service.promotions
+		WHERE code IS NULL
+			AND (starts_at IS NULL OR starts_at <= $1)
+			AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY id
+	`
+	return r.queryPromotions(ctx, query, now)
+}
+
+func (r *PromotionRepository) queryPromotions(ctx context.Context, query string, args ...any) ([]domain.Promotion, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []domain.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+func (r *PromotionRepository) CountRedemptions(ctx context.Context, promotionID, userID int64) (int, int, error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+		FROM cart_service.promotion_redemptions
+		WHERE promotion_id = $1
+	`
+	var total, byUser int
+	err := r.db.QueryRow(ctx, query, promotionID, userID).Scan(&total, &byUser)
+	return total, byUser, err
+}
+
+// Redeem фиксирует использование акций в одной транзакции. Строки акций блокируются
+// (FOR UPDATE), чтобы параллельные оформления не превысили лимиты.
+func (r *PromotionRepository) Redeem(ctx context.Context, userID int64, promotions []domain.Promotion) ([]int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]int64, 0, len(promotions))
+	for _, p := range promotions {
+		var maxUses, maxUsesPerUser int
+		err := tx.QueryRow(ctx,
+			`SELECT max_uses, max_uses_per_user FROM cart_service.promotions WHERE id = $1 FOR UPDATE`, p.ID,
+		).Scan(&maxUses, &maxUsesPerUser)
+		if err != nil {
+			return nil, err
+		}
+
+		var total, byUser int
+		err = tx.QueryRow(ctx, `
+			SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+			FROM cart_service.promotion_redemptions
+			WHERE promotion_id = $1
+		`, p.ID, userID).Scan(&total, &byUser)
+		if err != nil {
+			return nil, err
+		}
+		if (maxUses > 0 && total >= maxUses) || (maxUsesPerUser > 0 && byUser >= maxUsesPerUser) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrCouponUsageLimit, p.Name)
+		}
+
+		var id int64
+		err = tx.QueryRow(ctx, `
+			INSERT INTO cart_service.promotion_redemptions (promotion_id, user_id, created_at)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, p.ID, userID, time.Now()).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, tx.Commit(ctx)
+}
+
+// ReleaseRedemptions отменяет использования, если заказ так и не был создан
+func (r *PromotionRepository) ReleaseRedemptions(ctx context.Context, ids []int64) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM cart_service.promotion_redemptions WHERE id = ANY($1)`, ids)
+	return err
+}
+
+func (r *PromotionRepository) SetCartCoupon(ctx context.Context, userID int64, code string) error {
+	query := `
+		INSERT INTO cart_service.cart_coupons (user_id, code, applied_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET code = EXCLUDED.code, applied_at = EXCLUDED.applied_at
+	`
+	_, err := r.db.Exec(ctx, query, userID, code, time.Now())
+	return err
+}
+
+// GetCartCoupon возвращает код купона корзины или пустую строку
+func (r *PromotionRepository) GetCartCoupon(ctx context.Context, userID int64) (string, error) {
+	var code string
+	err := r.db.QueryRow(ctx, `SELECT code FROM cart_service.cart_coupons WHERE user_id = $1`, userID).Scan(&code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return code, err
+}
+
+func (r *PromotionRepository) DeleteCartCoupon(ctx context.Context, userID int64) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM cart_service.cart_coupons WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WishlistRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWishlistRepository(db *pgxpool.Pool) *WishlistRepository {
+	return &WishlistRepository{db: db}
+}
+
+type WishlistRepositoryInterface interface {
+	CreateWishlist(ctx context.Context, userID int64, name string) (domain.Wishlist, error)
+	ListWishlists(ctx context.Context, userID int64) ([]domain.Wishlist, error)
+	GetWishlist(ctx context.Context, userID, wishlistID int64) (domain.Wishlist, error)
+	GetWishlistByShareToken(ctx context.Context, token string) (domain.Wishlist, error)
+	SetShareToken(ctx context.Context, userID, wishlistID int64, token string) error
+	DeleteWishlist(ctx context.Context, userID, wishlistID int64) error
+
+	GetItems(ctx context.Context, wishlistID int64) ([]domain.WishlistItem, error)
+	AddItem(ctx context.Context, wishlistID, productID int64, price money.Money) error
+	RemoveItem(ctx context.Context, wishlistID, productID int64) error
+	MoveToCart(ctx context.Context, userID, wishlistID int64, op domain.CartOperation, maxQuantity int) error
+	MoveFromCart(ctx context.Context, userID, wishlistID int64, key domain.ItemKey, price money.Money) error
+
+	ListWatchedItems(ctx context.Context, afterItemID int64, limit int) ([]domain.WatchedWishlistItem, error)
+	SetNotifiedPrice(ctx context.Context, itemID int64, price money.Money) error
+}
+
+const wishlistColumns = `id, user_id, name, COALESCE(share_token, ''), created_at, updated_at`
+
+func scanWishlist(row pgx.Row) (domain.Wishlist, error) {
+	var w domain.Wishlist
+	err := row.Scan(&w.ID, &w.UserID, &w.Name, &w.ShareToken, &w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return w, domain.ErrWishlistNotFound
+	}
+	return w, err
+}
+
+func (r *WishlistRepository) CreateWishlist(ctx context.Context, userID int64, name string) (domain.Wishlist, error) {
+	query := `
+		INSERT INTO cart_service.wishlists (user_id, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		RETURNING ` + wishlistColumns
+	w, err := scanWishlist(r.db.QueryRow(ctx, query, userID, name, time.Now()))
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return w, domain.ErrWishlistExists
+	}
+	return w, err
+}
+
+func (r *WishlistRepository) ListWishlists(ctx context.Context, userID int64) ([]domain.Wishlist, error) {
+	query := `SELECT ` + wishlistColumns + ` FROM cart_service.wishlists WHERE user_id = $1 ORDER BY id`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []domain.Wishlist
+	for rows.Next() {
+		w, err := scanWishlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, w)
+	}
+	return lists, rows.Err()
+}
+
+func (r *WishlistRepository) GetWishlist(ctx context.Context, userID, wishlistID int64) (domain.Wishlist, error) {
+	query := `SELECT ` + wishlistColumns + ` FROM cart_service.wishlists WHERE id = $1 AND user_id = $2`
+	return scanWishlist(r.db.QueryRow(ctx, query, wishlistID, userID))
+}
+
+func (r *WishlistRepository) GetWishlistByShareToken(ctx context.Context, token string) (domain.Wishlist, error) {
+	query := `SELECT ` + wishlistColumns + ` FROM cart_service.wishlists WHERE share_token = $1`
+	return scanWishlist(r.db.QueryRow(ctx, query, token))
+}
+
+// SetShareToken публикует список по токену; пустой token закрывает доступ по ссылке
+func (r *WishlistRepository) SetShareToken(ctx context.Context, userID, wishlistID int64, token string) error {
+	query := `
+		UPDATE cart_service.wishlists
+		SET share_token = NULLIF($3, ''), updated_at = $4
+		WHERE id = $1 AND user_id = $2
+	`
+	tag, err := r.db.Exec(ctx, query, wishlistID, userID, token, time.Now())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrWishlistNotFound
+	}
+	return nil
+}
+
+func (r *WishlistRepository) DeleteWishlist(ctx context.Context, userID, wishlistID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM cart_service.wishlists WHERE id = $1 AND user_id = $2`, wishlistID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrWishlistNotFound
+	}
+	return nil
+}
+
+func (r *WishlistRepository) GetItems(ctx context.Context, wishlistID int64) ([]domain.WishlistItem, error) {
+	query := `
+		SELECT id, wishlist_id, product_id, price_at_save, currency, created_at
+		FROM cart_service.wishlist_items
+		WHERE wishlist_id = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.WishlistItem
+	for rows.Next() {
+		var item domain.WishlistItem
+		var priceAtSave *string
+		var currency string
+		if err := rows.Scan(&item.ID, &item.WishlistID, &item.ProductID, &priceAtSave, &currency, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		if item.PriceAtSave, err = parseNullableAmount(priceAtSave, currency); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// AddItem сохраняет товар в список; повторное добавление ничего не меняет
+func (r *WishlistRepository) AddItem(ctx context.Context, wishlistID, productID int64, price money.Money) error {
+	return addWishlistItem(ctx, r.db, wishlistID, productID, price)
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+func addWishlistItem(ctx context.Context, db execer, wishlistID, productID int64, price money.Money) error {
+	query := `
+		INSERT INTO cart_service.wishlist_items (wishlist_id, product_id, price_at_save, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (wishlist_id, product_id) DO NOTHING
+	`
+	_, err := db.Exec(ctx, query, wishlistID, productID, nullableAmount(price), currencyOf(price), time.Now())
+	return err
+}
+
+func (r *WishlistRepository) RemoveItem(ctx context.Context, wishlistID, productID int64) error {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM cart_service.wishlist_items WHERE wishlist_id = $1 AND product_id = $2`, wishlistID, productID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrItemNotFound
+	}
+	return nil
+}
+
+// MoveToCart в одной транзакции убирает товар из списка и добавляет его в корзину
+func (r *WishlistRepository) MoveToCart(ctx context.Context, userID, wishlistID int64, op domain.CartOperation, maxQuantity int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM cart_service.wishlist_items WHERE wishlist_id = $1 AND product_id = $2`, wishlistID, op.ProductID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrItemNotFound
+	}
+
+	if err := applyOperation(ctx, tx, userID, op, maxQuantity); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// MoveFromCart в одной транзакции убирает позицию из корзины и сохраняет её товар в список
+func (r *WishlistRepository) MoveFromCart(ctx context.Context, userID, wishlistID int64, key domain.ItemKey, price money.Money) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM cart_service.cart_items WHERE user_id = $1 AND product_id = $2 AND variant_id = $3`,
+		userID, key.ProductID, key.VariantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrItemNotFound
+	}
+
+	if err := addWishlistItem(ctx, tx, wishlistID, key.ProductID, price); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListWatchedItems постранично (по возрастанию id) возвращает позиции с известной ценой сохранения
+func (r *WishlistRepository) ListWatchedItems(ctx context.Context, afterItemID int64, limit int) ([]domain.WatchedWishlistItem, error) {
+	query := `
+		SELECT i.id, i.wishlist_id, w.user_id, i.product_id, COALESCE(i.notified_price, i.price_at_save), i.currency
+		FROM cart_service.wishlist_items i
+		JOIN cart_service.wishlists w ON w.id = i.wishlist_id
+		WHERE i.id > $1 AND i.price_at_save IS NOT NULL
+		ORDER BY i.id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, afterItemID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.WatchedWishlistItem
+	for rows.Next() {
+		var item domain.WatchedWishlistItem
+		var referencePrice, currency string
+		if err := rows.Scan(&item.ItemID, &item.WishlistID, &item.UserID, &item.ProductID, &referencePrice, &currency); err != nil {
+			return nil, err
+		}
+		if item.ReferencePrice, err = money.Parse(referencePrice, money.Currency(currency)); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// SetNotifiedPrice запоминает цену уведомления; она хранится в валюте позиции, цена в другой валюте не записывается
+func (r *WishlistRepository) SetNotifiedPrice(ctx context.Context, itemID int64, price money.Money) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE cart_service.wishlist_items SET notified_price = $2 WHERE id = $1 AND currency = $3`,
+		itemID, price.Decimal(), price.Currency())
+	return err
+}
@@ -13,80 +13,231 @@ import (
 
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/cache"
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/pricing"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/productclient"
 	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/userclient"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 )
 
-type CartService struct {
-	repo              repository.CartRepositoryInterface
-	productServiceURL string
-	cache             *cache.RedisCache
+type Config struct {
+	// MaxItemQuantity — максимальное количество одного товара в корзине
+	MaxItemQuantity int
+	// GuestCartTTL — время жизни гостевой корзины с момента последнего изменения
+	GuestCartTTL time.Duration
+	// MergeStrategy — правило слияния по умолчанию, если клиент не указал своё
+	MergeStrategy domain.MergeStrategy
+	Pricing       pricing.Config
 }
 
-func NewCartService(repo repository.CartRepositoryInterface, productServiceURL string, cache *cache.RedisCache) *CartService {
-	return &CartService{repo: repo, productServiceURL: productServiceURL, cache: cache}
+func DefaultConfig() Config {
+	return Config{
+		MaxItemQuantity: 99,
+		GuestCartTTL:    7 * 24 * time.Hour,
+		MergeStrategy:   domain.MergeSum,
+	}
 }
 
-func (s *CartService) getProductDetails(productID int64) (domain.Product, error) {
-	url := fmt.Sprintf("%s/products/%d", s.productServiceURL, productID)
+type CartService struct {
+	repo       repository.CartRepositoryInterface
+	promotions repository.PromotionRepositoryInterface
+	products   productclient.ClientInterface
+	cache      *cache.RedisCache
+	guests     *cache.GuestCartStore
+	pricing    *pricing.Engine
+	rates      productclient.RatesInterface
+	addresses  userclient.AddressesInterface
+	cfg        Config
+}
 
-	client := http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return domain.Product{}, err
+func NewCartService(repo repository.CartRepositoryInterface, promotions repository.PromotionRepositoryInterface, products productclient.ClientInterface, cache *cache.RedisCache, guests *cache.GuestCartStore, cfg Config) *CartService {
+	return &CartService{
+		repo:       repo,
+		promotions: promotions,
+		products:   products,
+		cache:      cache,
+		guests:     guests,
+		pricing:    pricing.NewEngine(cfg.Pricing),
+		cfg:        cfg,
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return domain.Product{}, fmt.Errorf("product-service returned status %d", resp.StatusCode)
-	}
+// WithAddresses требует адрес доставки при оформлении и сохраняет его копию в заказе.
+func (s *CartService) WithAddresses(addresses userclient.AddressesInterface) *CartService {
+	s.addresses = addresses
+	return s
+}
+
+// WithRates включает пересчёт корзины и заказа в валюту отображения.
+func (s *CartService) WithRates(rates productclient.RatesInterface) *CartService {
+	s.rates = rates
+	return s
+}
 
-	var product domain.Product
-	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
-		return domain.Product{}, err
+func productIDs(items []domain.CartItem) []int64 {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
 	}
+	return ids
+}
 
-	return product, nil
+func uniqueIDs(items []domain.CartItem) map[int64]bool {
+	ids := make(map[int64]bool, len(items))
+	for _, item := range items {
+		ids[item.ProductID] = true
+	}
+	return ids
 }
 
 type CartServiceInterface interface {
 	AddItem(ctx context.Context, item domain.CartItem) error
+	SetItemQuantity(ctx context.Context, userID int64, key domain.ItemKey, quantity int) error
+	UpdateCart(ctx context.Context, userID int64, ops []domain.CartOperation) error
 	GetItems(ctx context.Context, userID int64) ([]domain.CartItem, error)
-	DeleteItem(ctx context.Context, userID, productID int64) error
+	DeleteItem(ctx context.Context, userID int64, key domain.ItemKey) error
 	ClearCart(ctx context.Context, userID int64) error
-	GetCartWithDetails(ctx context.Context, userID int64) ([]domain.CartItemDetail, error)
-	Checkout(ctx context.Context, userID int64) error
+	GetCartWithDetails(ctx context.Context, userID int64, partial bool) (domain.Cart, error)
+	ConfirmPrices(ctx context.Context, userID int64) error
+	ApplyCoupon(ctx context.Context, userID int64, code string) (domain.Cart, error)
+	RemoveCoupon(ctx context.Context, userID int64) error
+	ConvertCart(ctx context.Context, cart domain.Cart, currency money.Currency) (domain.Cart, error)
+	Checkout(ctx context.Context, userID int64, currency money.Currency, addressID int64) error
+
+	CreateGuestCart(ctx context.Context) (string, error)
+	UpdateGuestCart(ctx context.Context, token string, ops []domain.CartOperation) error
+	GetGuestCartWithDetails(ctx context.Context, token string, partial bool) (domain.Cart, error)
+	DeleteGuestCart(ctx context.Context, token string) error
+	MergeGuestCart(ctx context.Context, userID int64, token string, strategy domain.MergeStrategy) error
 }
 
 func (s *CartService) AddItem(ctx context.Context, item domain.CartItem) error {
 	if item.UserID == 0 {
 		return errors.New("user id must be set")
 	}
-	if item.ProductID == 0 {
-		return errors.New("product id must be set")
+	return s.UpdateCart(ctx, item.UserID, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity},
+	})
+}
+
+// SetItemQuantity задаёт абсолютное количество позиции; 0 удаляет её из корзины.
+func (s *CartService) SetItemQuantity(ctx context.Context, userID int64, key domain.ItemKey, quantity int) error {
+	return s.UpdateCart(ctx, userID, []domain.CartOperation{
+		{Op: domain.OperationSet, ProductID: key.ProductID, VariantID: key.VariantID, Quantity: quantity},
+	})
+}
+
+// UpdateCart атомарно применяет набор операций: при ошибке в любой из них корзина не меняется.
+func (s *CartService) UpdateCart(ctx context.Context, userID int64, ops []domain.CartOperation) error {
+	if userID == 0 {
+		return errors.New("user id must be set")
 	}
-	if item.Quantity <= 0 {
-		return errors.New("quantity must be possible")
+	if err := s.validateOperations(ops); err != nil {
+		return err
 	}
-	err := s.repo.AddItem(ctx, item)
-	if err != nil {
+	if err := s.attachPrices(ctx, ops); err != nil {
 		return err
 	}
-	cacheKey := fmt.Sprintf("cart:user:%d", item.UserID)
-	_ = s.cache.Delete(ctx, cacheKey)
+
+	if err := s.repo.ApplyOperations(ctx, userID, ops, s.cfg.MaxItemQuantity); err != nil {
+		return err
+	}
+
+	s.invalidateCart(ctx, userID)
 	return nil
 }
 
+func (s *CartService) validateOperations(ops []domain.CartOperation) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("%w: no operations", domain.ErrInvalidOperation)
+	}
+	for _, op := range ops {
+		if err := s.validateOperation(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachPrices проставляет в операции текущие цены товаров (или их вариантов) — они сохраняются
+// как цена на момент добавления. Подойдут и последние известные данные из LRU.
+// Для товара с вариантами операция должна указывать существующий вариант.
+func (s *CartService) attachPrices(ctx context.Context, ops []domain.CartOperation) error {
+	var ids []int64
+	for _, op := range ops {
+		if op.Op != domain.OperationRemove {
+			ids = append(ids, op.ProductID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	lookup, fetchErr := s.products.GetProducts(ctx, ids)
+	for i := range ops {
+		if ops[i].Op == domain.OperationRemove {
+			continue
+		}
+		product, ok := lookup.Products[ops[i].ProductID]
+		if !ok {
+			if fetchErr != nil {
+				return fmt.Errorf("failed to get price for product %d: %w", ops[i].ProductID, fetchErr)
+			}
+			return fmt.Errorf("%w: product %d not found", domain.ErrInvalidOperation, ops[i].ProductID)
+		}
+		variant, err := product.ResolveVariant(ops[i].VariantID)
+		if err != nil {
+			return err
+		}
+		ops[i].Price = product.UnitPrice(variant)
+	}
+	return nil
+}
+
+func (s *CartService) validateOperation(op domain.CartOperation) error {
+	if op.ProductID <= 0 {
+		return fmt.Errorf("%w: product id must be set", domain.ErrInvalidOperation)
+	}
+	if op.VariantID < 0 {
+		return fmt.Errorf("%w: invalid variant id", domain.ErrInvalidOperation)
+	}
+
+	switch op.Op {
+	case domain.OperationAdd:
+		if op.Quantity <= 0 {
+			return fmt.Errorf("%w: quantity must be positive", domain.ErrInvalidOperation)
+		}
+	case domain.OperationSet:
+		if op.Quantity < 0 {
+			return fmt.Errorf("%w: quantity can't be negative", domain.ErrInvalidOperation)
+		}
+	case domain.OperationRemove:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown op %q", domain.ErrInvalidOperation, op.Op)
+	}
+
+	if s.cfg.MaxItemQuantity > 0 && op.Quantity > s.cfg.MaxItemQuantity {
+		return fmt.Errorf("%w: max is %d", domain.ErrQuantityExceeded, s.cfg.MaxItemQuantity)
+	}
+	return nil
+}
+
+func (s *CartService) invalidateCart(ctx context.Context, userID int64) {
+	cacheKey := fmt.Sprintf("cart:user:%d", userID)
+	_ = s.cache.Delete(ctx, cacheKey)
+}
+
 func (s *CartService) GetItems(ctx context.Context, userID int64) ([]domain.CartItem, error) {
 	return s.repo.GetItemsByUserID(ctx, userID)
 }
 
-func (s *CartService) DeleteItem(ctx context.Context, userID, productID int64) error {
-	err := s.repo.DeleteItem(ctx, userID, productID)
+func (s *CartService) DeleteItem(ctx context.Context, userID int64, key domain.ItemKey) error {
+	err := s.repo.DeleteItem(ctx, userID, key)
 	if err != nil {
 		return err
 	}
-	cacheKey := fmt.Sprintf("cart:user%d", userID)
-	_ = s.cache.Delete(ctx, cacheKey)
+	s.invalidateCart(ctx, userID)
 	return nil
 }
 
@@ -95,19 +246,21 @@ func (s *CartService) ClearCart(ctx context.Context, userID int64) error {
 	if err != nil {
 		return err
 	}
-	cacheKey := fmt.Sprintf("cart:user:%d", userID)
-	_ = s.cache.Delete(ctx, cacheKey)
+	s.invalidateCart(ctx, userID)
 	return nil
 }
 
-func (s *CartService) GetCartWithDetails(ctx context.Context, userID int64) ([]domain.CartItemDetail, error) {
+// GetCartWithDetails возвращает корзину с данными продуктов и суммами. Если product-service
+// недоступен, используются последние известные данные (Stale). При partial=true
+// позиции, для которых данных нет вовсе, возвращаются с Available=false вместо ошибки.
+func (s *CartService) GetCartWithDetails(ctx context.Context, userID int64, partial bool) (domain.Cart, error) {
 	cacheKey := fmt.Sprintf("cart:user:%d", userID)
 
 	if cached, err := s.cache.Get(ctx, cacheKey); err == nil {
 		log.Println("Cache cart HIT:", cacheKey)
-		var items []domain.CartItemDetail
-		if err := json.Unmarshal([]byte(cached), &items); err == nil {
-			return items, nil
+		var cart domain.Cart
+		if err := json.Unmarshal([]byte(cached), &cart); err == nil {
+			return cart, nil
 		}
 	}
 
@@ -115,28 +268,160 @@ func (s *CartService) GetCartWithDetails(ctx context.Context, userID int64) ([]d
 
 	items, err := s.repo.GetItemsByUserID(ctx, userID)
 	if err != nil {
-		return nil, err
+		return domain.Cart{}, err
+	}
+
+	detailedItems, complete, err := s.buildDetails(ctx, items, partial)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	cart, _, err := s.priceCart(ctx, userID, detailedItems)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	// В кэш попадает только полный ответ со свежими данными
+	if complete {
+		data, _ := json.Marshal(cart)
+		_ = s.cache.Set(ctx, cacheKey, string(data), time.Minute*30)
+	}
+
+	return cart, nil
+}
+
+// buildDetails дополняет позиции корзины данными продуктов. complete=true, если
+// данные всех продуктов получены свежими из product-service.
+func (s *CartService) buildDetails(ctx context.Context, items []domain.CartItem, partial bool) ([]domain.CartItemDetail, bool, error) {
+	lookup, fetchErr := s.products.GetProducts(ctx, productIDs(items))
+	if fetchErr != nil {
+		log.Printf("product-service lookup failed: %v", fetchErr)
 	}
 
 	var detailedItems []domain.CartItemDetail
+	variantsMissing := false
 	for _, item := range items {
-		product, err := s.getProductDetails(item.ProductID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get product details for product %d: %w", item.ProductID, err)
+		product, ok := lookup.Products[item.ProductID]
+		if !ok {
+			if !partial {
+				if fetchErr != nil {
+					return nil, false, fmt.Errorf("failed to get product details for product %d: %w", item.ProductID, fetchErr)
+				}
+				return nil, false, fmt.Errorf("product %d not found", item.ProductID)
+			}
+			product = domain.Product{ID: item.ProductID}
+		}
+
+		// Вариант мог быть удалён из каталога: такая позиция недоступна, как и ненайденный товар
+		var variant *domain.Variant
+		if ok && item.VariantID != 0 {
+			var err error
+			if variant, err = product.ResolveVariant(item.VariantID); err != nil {
+				if !partial {
+					return nil, false, err
+				}
+				ok, variantsMissing = false, true
+			}
 		}
+		product.Variants = nil
+
 		detailedItems = append(detailedItems, domain.CartItemDetail{
-			Product:  product,
-			Quantity: item.Quantity,
+			Product:    product,
+			VariantID:  item.VariantID,
+			Variant:    variant,
+			Quantity:   item.Quantity,
+			Available:  ok,
+			Stale:      lookup.Stale[item.ProductID],
+			PriceAtAdd: item.PriceAtAdd,
 		})
 	}
 
-	data, _ := json.Marshal(detailedItems)
-	_ = s.cache.Set(ctx, cacheKey, string(data), time.Minute*30)
+	complete := fetchErr == nil && !variantsMissing && len(lookup.Products) == len(uniqueIDs(items))
+	return detailedItems, complete, nil
+}
+
+// freshDetails — как buildDetails, но только по актуальным данным product-service:
+// устаревшие данные из LRU для оформления заказа и подтверждения цен не подходят.
+func (s *CartService) freshDetails(ctx context.Context, items []domain.CartItem) ([]domain.CartItemDetail, error) {
+	lookup, err := s.products.GetProducts(ctx, productIDs(items))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+
+	detailedItems := make([]domain.CartItemDetail, 0, len(items))
+	for _, item := range items {
+		product, ok := lookup.Products[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("product %d not found", item.ProductID)
+		}
+		var variant *domain.Variant
+		if item.VariantID != 0 {
+			if variant, err = product.ResolveVariant(item.VariantID); err != nil {
+				return nil, err
+			}
+		}
+		product.Variants = nil
 
+		detailedItems = append(detailedItems, domain.CartItemDetail{
+			Product:    product,
+			VariantID:  item.VariantID,
+			Variant:    variant,
+			Quantity:   item.Quantity,
+			Available:  true,
+			PriceAtAdd: item.PriceAtAdd,
+		})
+	}
 	return detailedItems, nil
 }
 
-func (s *CartService) Checkout(ctx context.Context, userID int64) error {
+// ConfirmPrices принимает текущие цены всех товаров корзины, после чего Checkout снова доступен.
+func (s *CartService) ConfirmPrices(ctx context.Context, userID int64) error {
+	items, err := s.repo.GetItemsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	detailedItems, err := s.freshDetails(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	prices := make(map[domain.ItemKey]money.Money, len(detailedItems))
+	for _, item := range detailedItems {
+		key := domain.ItemKey{ProductID: item.Product.ID, VariantID: item.VariantID}
+		prices[key] = item.Product.UnitPrice(item.Variant)
+	}
+	if err := s.repo.UpdatePrices(ctx, userID, prices); err != nil {
+		return err
+	}
+
+	s.invalidateCart(ctx, userID)
+	return nil
+}
+
+// ConvertCart пересчитывает корзину в валюту отображения; пустая currency оставляет валюту товаров.
+func (s *CartService) ConvertCart(ctx context.Context, cart domain.Cart, currency money.Currency) (domain.Cart, error) {
+	if currency == "" || currency == cart.Total.Currency() {
+		return cart, nil
+	}
+	if s.rates == nil {
+		return domain.Cart{}, fmt.Errorf("%w: %s", money.ErrUnknownRate, currency)
+	}
+
+	rates, err := s.rates.GetRates(ctx)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	return s.pricing.Convert(cart, rates, currency)
+}
+
+// Checkout оформляет заказ на итоговую сумму корзины с учётом скидок. Если цены изменились с момента
+// добавления, возвращается domain.ErrPriceChanged — покупатель должен подтвердить новые цены.
+// Если задана currency, заказ оформляется в ней, а итог в валюте товаров и курс сохраняются в заказе.
+// Адрес доставки addressID (0 — адрес по умолчанию) копируется в заказ.
+func (s *CartService) Checkout(ctx context.Context, userID int64, currency money.Currency, addressID int64) error {
 	items, err := s.repo.GetItemsByUserID(ctx, userID)
 	if err != nil {
 		return err
@@ -145,30 +430,98 @@ func (s *CartService) Checkout(ctx context.Context, userID int64) error {
 		return errors.New("cart is empty")
 	}
 
-	var productIDs []int64
-	totalPrice := 0.0
-	totalQuantity := 0
+	detailedItems, err := s.freshDetails(ctx, items)
+	if err != nil {
+		return err
+	}
 
-	for _, item := range items {
-		product, err := s.getProductDetails(item.ProductID)
+	cart, applied, err := s.priceCart(ctx, userID, detailedItems)
+	if err != nil {
+		return err
+	}
+	if cart.PriceChanged {
+		return domain.ErrPriceChanged
+	}
+	if cart, err = s.ConvertCart(ctx, cart, currency); err != nil {
+		return err
+	}
+
+	var address *domain.Address
+	if s.addresses != nil {
+		resolved, err := s.addresses.ShippingAddress(ctx, userID, addressID)
+		if errors.Is(err, domain.ErrAddressNotFound) && addressID == 0 {
+			return domain.ErrAddressRequired
+		}
 		if err != nil {
-			return fmt.Errorf("failed to fetch product %d: %w", item.ProductID, err)
+			return err
+		}
+		address = &resolved
+	}
+
+	// Использование акций резервируется до создания заказа и отменяется, если заказ не создан
+	var redemptions []int64
+	if len(applied) > 0 {
+		if redemptions, err = s.promotions.Redeem(ctx, userID, applied); err != nil {
+			return err
 		}
-		totalPrice += product.Price * float64(item.Quantity)
+	}
+
+	if err := createOrder(userID, items, cart, address); err != nil {
+		if len(redemptions) > 0 {
+			if releaseErr := s.promotions.ReleaseRedemptions(ctx, redemptions); releaseErr != nil {
+				log.Printf("failed to release promotion redemptions %v: %v", redemptions, releaseErr)
+			}
+		}
+		return err
+	}
+
+	if err := s.promotions.DeleteCartCoupon(ctx, userID); err != nil {
+		log.Printf("failed to delete coupon of cart %d: %v", userID, err)
+	}
+	_ = s.cache.Delete(ctx, fmt.Sprintf("cart:user:%d", userID))
+	// Очистить корзину
+	return s.repo.ClearCart(ctx, userID)
+}
+
+// createOrder передаёт заказ в order-service. Для каждой позиции указываются продавец и стоимость:
+// по ним order-service делит заказ на подзаказы продавцов и распределяет между ними итог со скидками.
+func createOrder(userID int64, items []domain.CartItem, cart domain.Cart, address *domain.Address) error {
+	totalQuantity := 0
+	orderItems := make([]map[string]interface{}, 0, len(cart.Items))
+	for _, item := range cart.Items {
 		totalQuantity += item.Quantity
-		productIDs = append(productIDs, item.ProductID)
+		orderItem := map[string]interface{}{
+			"product_id": item.Product.ID,
+			"quantity":   item.Quantity,
+			"line_total": item.LineTotal,
+		}
+		if item.VariantID != 0 {
+			orderItem["variant_id"] = item.VariantID
+		}
+		if item.Product.OwnerSellerID != nil {
+			orderItem["seller_id"] = *item.Product.OwnerSellerID
+		}
+		orderItems = append(orderItems, orderItem)
 	}
 
 	order := map[string]interface{}{
 		"user_id":     userID,
-		"product_ids": productIDs,
+		"product_ids": productIDs(items),
+		"items":       orderItems,
 		"quantity":    totalQuantity,
-		"total_price": totalPrice,
+		"total_price": cart.Total,
 		"status":      "new",
 	}
+	if cart.ExchangeRate != "" {
+		order["base_total"] = cart.BaseTotal
+		order["exchange_rate"] = cart.ExchangeRate
+	}
+	if address != nil {
+		order["shipping_address"] = address
+	}
 
 	orderServiceURL := os.Getenv("ORDER_SERVICE_URL")
-	resp, err := http.Post(orderServiceURL+"/orders", "application/json", encodeToJSON(order))
+	resp, err := http.Post(orderServiceURL+"/internal/orders", "application/json", encodeToJSON(order))
 	if err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
 	}
@@ -177,10 +530,7 @@ func (s *CartService) Checkout(ctx context.Context, userID int64) error {
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("order-service returned status %d", resp.StatusCode)
 	}
-
-	_ = s.cache.Delete(ctx, fmt.Sprintf("cart:user:%d", userID))
-	// Очистить корзину
-	return s.repo.ClearCart(ctx, userID)
+	return nil
 }
 
 func encodeToJSON(v interface{}) *bytes.Buffer {
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/cache"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/productclient"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+func usd(amount string) money.Money { return money.MustParse(amount, money.USD) }
+
+// fakeCartRepo хранит корзину одного пользователя и, как транзакция в Postgres,
+// применяет набор операций целиком или не применяет вовсе
+type fakeCartRepo struct {
+	// остальные методы тестам не нужны
+	repository.CartRepositoryInterface
+	items map[domain.ItemKey]domain.CartItem
+	calls int
+}
+
+func newFakeCartRepo(items ...domain.CartItem) *fakeCartRepo {
+	repo := &fakeCartRepo{items: map[domain.ItemKey]domain.CartItem{}}
+	for _, item := range items {
+		repo.items[item.Key()] = item
+	}
+	return repo
+}
+
+func (r *fakeCartRepo) ApplyOperations(ctx context.Context, userID int64, ops []domain.CartOperation, maxQuantity int) error {
+	r.calls++
+	next := make(map[domain.ItemKey]domain.CartItem, len(r.items))
+	for k, v := range r.items {
+		next[k] = v
+	}
+	for _, op := range ops {
+		key := op.Key()
+		if op.Op == domain.OperationRemove || (op.Op == domain.OperationSet && op.Quantity == 0) {
+			delete(next, key)
+			continue
+		}
+		item, ok := next[key]
+		if !ok {
+			price := op.Price
+			item = domain.CartItem{UserID: userID, ProductID: op.ProductID, VariantID: op.VariantID, PriceAtAdd: &price}
+		}
+		if op.Op == domain.OperationAdd {
+			item.Quantity += op.Quantity
+		} else {
+			item.Quantity = op.Quantity
+		}
+		if maxQuantity > 0 && item.Quantity > maxQuantity {
+			return fmt.Errorf("%w: product %s would have %d items, max is %d", domain.ErrQuantityExceeded, key, item.Quantity, maxQuantity)
+		}
+		next[key] = item
+	}
+	r.items = next
+	return nil
+}
+
+func (r *fakeCartRepo) quantity(productID, variantID int64) int {
+	return r.items[domain.ItemKey{ProductID: productID, VariantID: variantID}].Quantity
+}
+
+type fakeCatalog map[int64]domain.Product
+
+func (f fakeCatalog) GetProducts(ctx context.Context, ids []int64) (productclient.Lookup, error) {
+	lookup := productclient.Lookup{Products: map[int64]domain.Product{}, Stale: map[int64]bool{}}
+	for _, id := range ids {
+		if p, ok := f[id]; ok {
+			lookup.Products[id] = p
+		}
+	}
+	return lookup, nil
+}
+
+func newTestCartService(repo *fakeCartRepo) *CartService {
+	variantPrice := usd("25")
+	catalog := fakeCatalog{
+		1: {ID: 1, Price: usd("10")},
+		2: {ID: 2, Price: usd("5")},
+		3: {ID: 3, Price: usd("20"), Variants: []domain.Variant{
+			{ID: 30, SKU: "TEE-S"},
+			{ID: 31, SKU: "TEE-XL", Price: &variantPrice},
+		}},
+	}
+	// Redis недоступен: сброс кэша корзины не мешает изменениям
+	return NewCartService(repo, nil, catalog, cache.NewRedisCache("127.0.0.1:1"), nil, DefaultConfig())
+}
+
+func TestUpdateCart_AllOrNothing(t *testing.T) {
+	repo := newFakeCartRepo(domain.CartItem{UserID: 7, ProductID: 1, Quantity: 2})
+	s := newTestCartService(repo)
+	ctx := context.Background()
+
+	err := s.UpdateCart(ctx, 7, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 2, Quantity: 1},
+		{Op: domain.OperationAdd, ProductID: 404, Quantity: 1},
+	})
+	if !errors.Is(err, domain.ErrInvalidOperation) {
+		t.Fatalf("expected ErrInvalidOperation for unknown product, got %v", err)
+	}
+	if repo.calls != 0 || len(repo.items) != 1 || repo.quantity(1, 0) != 2 {
+		t.Fatalf("cart must not change, got %+v", repo.items)
+	}
+
+	err = s.UpdateCart(ctx, 7, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 2, Quantity: 1},
+		{Op: domain.OperationRemove, ProductID: 1},
+		{Op: domain.OperationAdd, ProductID: 1, Quantity: -1},
+	})
+	if !errors.Is(err, domain.ErrInvalidOperation) {
+		t.Fatalf("expected ErrInvalidOperation for negative quantity, got %v", err)
+	}
+	if repo.calls != 0 || len(repo.items) != 1 || repo.quantity(1, 0) != 2 {
+		t.Fatalf("cart must not change, got %+v", repo.items)
+	}
+
+	err = s.UpdateCart(ctx, 7, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 2, Quantity: 3},
+		{Op: domain.OperationRemove, ProductID: 1},
+	})
+	if err != nil {
+		t.Fatalf("UpdateCart: %v", err)
+	}
+	if len(repo.items) != 1 || repo.quantity(2, 0) != 3 {
+		t.Fatalf("expected only product 2 x3, got %+v", repo.items)
+	}
+	if price := repo.items[domain.ItemKey{ProductID: 2}].PriceAtAdd; price == nil || *price != usd("5") {
+		t.Fatalf("expected price at add 5 USD, got %v", price)
+	}
+}
+
+func TestUpdateCart_MaxQuantityRollsBack(t *testing.T) {
+	repo := newFakeCartRepo(domain.CartItem{UserID: 7, ProductID: 1, Quantity: 98})
+	s := newTestCartService(repo)
+
+	// Каждая операция в пределах максимума, но вместе с корзиной количество товара 1 его превышает
+	err := s.UpdateCart(context.Background(), 7, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 2, Quantity: 1},
+		{Op: domain.OperationAdd, ProductID: 1, Quantity: 5},
+	})
+	if !errors.Is(err, domain.ErrQuantityExceeded) {
+		t.Fatalf("expected ErrQuantityExceeded, got %v", err)
+	}
+	if len(repo.items) != 1 || repo.quantity(1, 0) != 98 || repo.quantity(2, 0) != 0 {
+		t.Fatalf("expected the whole update to be rolled back, got %+v", repo.items)
+	}
+}
+
+func TestSetItemQuantity(t *testing.T) {
+	repo := newFakeCartRepo(domain.CartItem{UserID: 7, ProductID: 1, Quantity: 2})
+	s := newTestCartService(repo)
+	ctx := context.Background()
+
+	if err := s.SetItemQuantity(ctx, 7, domain.ItemKey{ProductID: 1}, 5); err != nil {
+		t.Fatalf("SetItemQuantity: %v", err)
+	}
+	if repo.quantity(1, 0) != 5 {
+		t.Fatalf("expected quantity 5, got %d", repo.quantity(1, 0))
+	}
+
+	if err := s.SetItemQuantity(ctx, 7, domain.ItemKey{ProductID: 1}, 100); !errors.Is(err, domain.ErrQuantityExceeded) {
+		t.Fatalf("expected ErrQuantityExceeded, got %v", err)
+	}
+	if err := s.SetItemQuantity(ctx, 7, domain.ItemKey{ProductID: 1}, -1); !errors.Is(err, domain.ErrInvalidOperation) {
+		t.Fatalf("expected ErrInvalidOperation, got %v", err)
+	}
+	if repo.quantity(1, 0) != 5 {
+		t.Fatalf("rejected updates must not change quantity, got %d", repo.quantity(1, 0))
+	}
+
+	if err := s.SetItemQuantity(ctx, 7, domain.ItemKey{ProductID: 1}, 0); err != nil {
+		t.Fatalf("SetItemQuantity: %v", err)
+	}
+	if _, ok := repo.items[domain.ItemKey{ProductID: 1}]; ok {
+		t.Fatalf("expected set to 0 to delete the item, got %+v", repo.items)
+	}
+}
+
+func TestUpdateCart_InvalidOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []domain.CartOperation
+		want error
+	}{
+		{"no operations", nil, domain.ErrInvalidOperation},
+		{"missing product", []domain.CartOperation{{Op: domain.OperationAdd, Quantity: 1}}, domain.ErrInvalidOperation},
+		{"negative variant", []domain.CartOperation{{Op: domain.OperationAdd, ProductID: 1, VariantID: -1, Quantity: 1}}, domain.ErrInvalidOperation},
+		{"add zero", []domain.CartOperation{{Op: domain.OperationAdd, ProductID: 1}}, domain.ErrInvalidOperation},
+		{"set negative", []domain.CartOperation{{Op: domain.OperationSet, ProductID: 1, Quantity: -2}}, domain.ErrInvalidOperation},
+		{"unknown op", []domain.CartOperation{{Op: "replace", ProductID: 1, Quantity: 1}}, domain.ErrInvalidOperation},
+		{"above max", []domain.CartOperation{{Op: domain.OperationAdd, ProductID: 1, Quantity: 100}}, domain.ErrQuantityExceeded},
+		{"remove without product", []domain.CartOperation{{Op: domain.OperationRemove}}, domain.ErrInvalidOperation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeCartRepo()
+			err := newTestCartService(repo).UpdateCart(context.Background(), 7, tt.ops)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if repo.calls != 0 {
+				t.Fatal("invalid operations must not reach the repository")
+			}
+		})
+	}
+
+	if err := newTestCartService(newFakeCartRepo()).UpdateCart(context.Background(), 0, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 1, Quantity: 1},
+	}); err == nil {
+		t.Fatal("expected an error without user id")
+	}
+}
+
+func TestUpdateCart_VariantKeys(t *testing.T) {
+	repo := newFakeCartRepo()
+	s := newTestCartService(repo)
+	ctx := context.Background()
+
+	for _, op := range []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 3, Quantity: 1},
+		{Op: domain.OperationAdd, ProductID: 3, VariantID: 99, Quantity: 1},
+		{Op: domain.OperationAdd, ProductID: 1, VariantID: 30, Quantity: 1},
+	} {
+		if err := s.UpdateCart(ctx, 7, []domain.CartOperation{op}); !errors.Is(err, domain.ErrInvalidOperation) {
+			t.Fatalf("expected ErrInvalidOperation for %+v, got %v", op, err)
+		}
+	}
+
+	err := s.UpdateCart(ctx, 7, []domain.CartOperation{
+		{Op: domain.OperationAdd, ProductID: 3, VariantID: 30, Quantity: 2},
+		{Op: domain.OperationAdd, ProductID: 3, VariantID: 31, Quantity: 1},
+	})
+	if err != nil {
+		t.Fatalf("UpdateCart: %v", err)
+	}
+	if len(repo.items) != 2 || repo.quantity(3, 30) != 2 || repo.quantity(3, 31) != 1 {
+		t.Fatalf("expected variants as separate items, got %+v", repo.items)
+	}
+	if price := repo.items[domain.ItemKey{ProductID: 3, VariantID: 30}].PriceAtAdd; price == nil || *price != usd("20") {
+		t.Fatalf("expected product price for variant without own price, got %v", price)
+	}
+	if price := repo.items[domain.ItemKey{ProductID: 3, VariantID: 31}].PriceAtAdd; price == nil || *price != usd("25") {
+		t.Fatalf("expected variant price, got %v", price)
+	}
+
+	if err := s.SetItemQuantity(ctx, 7, domain.ItemKey{ProductID: 3, VariantID: 31}, 0); err != nil {
+		t.Fatalf("SetItemQuantity: %v", err)
+	}
+	if len(repo.items) != 1 || repo.quantity(3, 30) != 2 {
+		t.Fatalf("expected only variant 31 to be removed, got %+v", repo.items)
+	}
+}
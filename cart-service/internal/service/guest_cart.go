@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+)
+
+// CreateGuestCart выдаёт токен новой гостевой корзины. Сама корзина появляется
+// в Redis при первом изменении.
+func (s *CartService) CreateGuestCart(ctx context.Context) (string, error) {
+	return s.guests.NewToken()
+}
+
+func (s *CartService) UpdateGuestCart(ctx context.Context, token string, ops []domain.CartOperation) error {
+	if err := s.validateOperations(ops); err != nil {
+		return err
+	}
+	return s.guests.ApplyOperations(ctx, token, ops, s.cfg.MaxItemQuantity)
+}
+
+func (s *CartService) GetGuestCartWithDetails(ctx context.Context, token string, partial bool) (domain.Cart, error) {
+	items, err := s.guests.Items(ctx, token)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+
+	detailedItems, _, err := s.buildDetails(ctx, items, partial)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	cart, _, err := s.priceCart(ctx, 0, detailedItems)
+	return cart, err
+}
+
+func (s *CartService) DeleteGuestCart(ctx context.Context, token string) error {
+	return s.guests.Delete(ctx, token)
+}
+
+// MergeGuestCart переносит товары гостевой корзины в корзину пользователя
+// (вызывается при входе) и удаляет гостевую корзину. Пустая strategy означает правило из конфигурации.
+func (s *CartService) MergeGuestCart(ctx context.Context, userID int64, token string, strategy domain.MergeStrategy) error {
+	if userID == 0 {
+		return errors.New("user id must be set")
+	}
+	if strategy == "" {
+		strategy = s.cfg.MergeStrategy
+	}
+	if !strategy.Valid() {
+		return fmt.Errorf("%w: %q", domain.ErrInvalidMergeStrategy, strategy)
+	}
+
+	guestItems, err := s.guests.Items(ctx, token)
+	if err != nil {
+		return err
+	}
+	if len(guestItems) == 0 {
+		return nil
+	}
+
+	userItems, err := s.repo.GetItemsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ops := mergeOperations(userItems, guestItems, strategy, s.cfg.MaxItemQuantity)
+	if len(ops) > 0 {
+		if err := s.attachPrices(ctx, ops); err != nil {
+			return err
+		}
+		if err := s.repo.ApplyOperations(ctx, userID, ops, s.cfg.MaxItemQuantity); err != nil {
+			return err
+		}
+		s.invalidateCart(ctx, userID)
+	}
+
+	return s.guests.Delete(ctx, token)
+}
+
+// mergeOperations вычисляет итоговые количества по правилу strategy и возвращает
+// операции set только для позиций, которые нужно изменить.
+func mergeOperations(userItems, guestItems []domain.CartItem, strategy domain.MergeStrategy, maxQuantity int) []domain.CartOperation {
+	current := make(map[domain.ItemKey]int, len(userItems))
+	for _, item := range userItems {
+		current[item.Key()] = item.Quantity
+	}
+
+	var ops []domain.CartOperation
+	for _, guest := range guestItems {
+		userQuantity, inUserCart := current[guest.Key()]
+
+		quantity := guest.Quantity
+		if inUserCart {
+			switch strategy {
+			case domain.MergeSum:
+				quantity = userQuantity + guest.Quantity
+			case domain.MergeMax:
+				quantity = max(userQuantity, guest.Quantity)
+			case domain.MergeKeepUser:
+				quantity = userQuantity
+			}
+		}
+		if maxQuantity > 0 && quantity > maxQuantity {
+			quantity = maxQuantity
+		}
+
+		if inUserCart && quantity == userQuantity {
+			continue
+		}
+		ops = append(ops, domain.CartOperation{
+			Op:        domain.OperationSet,
+			ProductID: guest.ProductID,
+			VariantID: guest.VariantID,
+			Quantity:  quantity,
+		})
+	}
+	return ops
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+)
+
+func TestMergeOperations(t *testing.T) {
+	userItems := []domain.CartItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 5},
+	}
+	guestItems := []domain.CartItem{
+		{ProductID: 1, Quantity: 3},
+		{ProductID: 2, Quantity: 1},
+		{ProductID: 3, Quantity: 4},
+	}
+
+	tests := []struct {
+		strategy domain.MergeStrategy
+		want     map[int64]int
+	}{
+		{domain.MergeSum, map[int64]int{1: 5, 2: 6, 3: 4}},
+		{domain.MergeMax, map[int64]int{1: 3, 3: 4}},
+		{domain.MergeKeepUser, map[int64]int{3: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			ops := mergeOperations(userItems, guestItems, tt.strategy, 99)
+
+			got := make(map[int64]int, len(ops))
+			for _, op := range ops {
+				if op.Op != domain.OperationSet {
+					t.Fatalf("expected set operation, got %q", op.Op)
+				}
+				got[op.ProductID] = op.Quantity
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for id, q := range tt.want {
+				if got[id] != q {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeOperations_CapsAtMaxQuantity(t *testing.T) {
+	ops := mergeOperations(
+		[]domain.CartItem{{ProductID: 1, Quantity: 8}},
+		[]domain.CartItem{{ProductID: 1, Quantity: 5}},
+		domain.MergeSum, 10,
+	)
+	if len(ops) != 1 || ops[0].Quantity != 10 {
+		t.Fatalf("expected quantity capped at 10, got %+v", ops)
+	}
+}
+
+func TestMergeOperations_KeepsVariantsApart(t *testing.T) {
+	userItems := []domain.CartItem{{ProductID: 1, VariantID: 10, Quantity: 2}}
+	guestItems := []domain.CartItem{
+		{ProductID: 1, VariantID: 10, Quantity: 1},
+		{ProductID: 1, VariantID: 11, Quantity: 3},
+	}
+
+	ops := mergeOperations(userItems, guestItems, domain.MergeSum, 99)
+
+	got := make(map[domain.ItemKey]int, len(ops))
+	for _, op := range ops {
+		got[op.Key()] = op.Quantity
+	}
+	want := map[domain.ItemKey]int{{ProductID: 1, VariantID: 10}: 3, {ProductID: 1, VariantID: 11}: 3}
+	if len(got) != len(want) || got[domain.ItemKey{ProductID: 1, VariantID: 10}] != 3 || got[domain.ItemKey{ProductID: 1, VariantID: 11}] != 3 {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
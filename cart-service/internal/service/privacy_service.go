@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/cache"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+)
+
+// PrivacyService выгружает данные пользователя по запросу user-service и
+// удаляет их после события UserDeleted
+type PrivacyService struct {
+	privacy    repository.PrivacyRepositoryInterface
+	carts      repository.CartRepositoryInterface
+	wishlists  repository.WishlistRepositoryInterface
+	promotions repository.PromotionRepositoryInterface
+	cache      *cache.RedisCache
+}
+
+type PrivacyServiceInterface interface {
+	ExportUser(ctx context.Context, userID int64) (domain.UserExport, error)
+	HandleUserDeleted(ctx context.Context, userID int64) error
+}
+
+func NewPrivacyService(privacy repository.PrivacyRepositoryInterface, carts repository.CartRepositoryInterface,
+	wishlists repository.WishlistRepositoryInterface, promotions repository.PromotionRepositoryInterface, cache *cache.RedisCache) *PrivacyService {
+	return &PrivacyService{privacy: privacy, carts: carts, wishlists: wishlists, promotions: promotions, cache: cache}
+}
+
+func (s *PrivacyService) ExportUser(ctx context.Context, userID int64) (domain.UserExport, error) {
+	var export domain.UserExport
+	var err error
+
+	if export.CartItems, err = s.carts.GetItemsByUserID(ctx, userID); err != nil {
+		return domain.UserExport{}, err
+	}
+	if export.CartItems == nil {
+		export.CartItems = []domain.CartItem{}
+	}
+	if export.Coupon, err = s.promotions.GetCartCoupon(ctx, userID); err != nil {
+		return domain.UserExport{}, err
+	}
+	lists, err := s.wishlists.ListWishlists(ctx, userID)
+	if err != nil {
+		return domain.UserExport{}, err
+	}
+	export.Wishlists = make([]domain.WishlistExport, 0, len(lists))
+	for _, list := range lists {
+		items, err := s.wishlists.GetItems(ctx, list.ID)
+		if err != nil {
+			return domain.UserExport{}, err
+		}
+		export.Wishlists = append(export.Wishlists, domain.WishlistExport{Wishlist: list, Items: items})
+	}
+	if export.Redemptions, err = s.privacy.ListRedemptions(ctx, userID); err != nil {
+		return domain.UserExport{}, err
+	}
+	return export, nil
+}
+
+// HandleUserDeleted удаляет данные удалённого пользователя; повторный вызов безопасен
+func (s *PrivacyService) HandleUserDeleted(ctx context.Context, userID int64) error {
+	if err := s.privacy.PurgeUser(ctx, userID); err != nil {
+		return err
+	}
+	_ = s.cache.Delete(ctx, fmt.Sprintf("cart:user:%d", userID))
+	return nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/pricing"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/promotions"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+// PromotionService управляет акциями. Это внутренний API: api-gateway его не публикует.
+type PromotionService struct {
+	repo repository.PromotionRepositoryInterface
+}
+
+func NewPromotionService(repo repository.PromotionRepositoryInterface) *PromotionService {
+	return &PromotionService{repo: repo}
+}
+
+type PromotionServiceInterface interface {
+	CreatePromotion(ctx context.Context, p domain.Promotion) (domain.Promotion, error)
+	ListPromotions(ctx context.Context) ([]domain.Promotion, error)
+}
+
+func (s *PromotionService) CreatePromotion(ctx context.Context, p domain.Promotion) (domain.Promotion, error) {
+	p.Code = normalizeCode(p.Code)
+	if err := promotions.Validate(p); err != nil {
+		return p, err
+	}
+
+	id, err := s.repo.CreatePromotion(ctx, p)
+	if err != nil {
+		return p, err
+	}
+	p.ID = id
+	return p, nil
+}
+
+func (s *PromotionService) ListPromotions(ctx context.Context) ([]domain.Promotion, error) {
+	return s.repo.ListPromotions(ctx)
+}
+
+// normalizeCode — коды купонов нечувствительны к регистру и пробелам по краям
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ApplyCoupon проверяет купон на текущей корзине и привязывает его к ней.
+func (s *CartService) ApplyCoupon(ctx context.Context, userID int64, code string) (domain.Cart, error) {
+	code = normalizeCode(code)
+	if code == "" {
+		return domain.Cart{}, domain.ErrCouponNotFound
+	}
+
+	items, err := s.repo.GetItemsByUserID(ctx, userID)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	detailedItems, _, err := s.buildDetails(ctx, items, true)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	if err := pricing.PriceItems(detailedItems); err != nil {
+		return domain.Cart{}, err
+	}
+
+	if _, _, err := s.evaluateCoupon(ctx, userID, code, detailedItems, time.Now()); err != nil {
+		return domain.Cart{}, err
+	}
+	if err := s.promotions.SetCartCoupon(ctx, userID, code); err != nil {
+		return domain.Cart{}, err
+	}
+	s.invalidateCart(ctx, userID)
+
+	cart, _, err := s.priceCart(ctx, userID, detailedItems)
+	return cart, err
+}
+
+func (s *CartService) RemoveCoupon(ctx context.Context, userID int64) error {
+	if err := s.promotions.DeleteCartCoupon(ctx, userID); err != nil {
+		return err
+	}
+	s.invalidateCart(ctx, userID)
+	return nil
+}
+
+// priceCart считает суммы корзины с учётом автоматических акций и купона пользователя.
+// Возвращает также акции, давшие скидку: при оформлении заказа фиксируется их использование.
+// userID=0 — гостевая корзина, к ней применяются только автоматические акции.
+func (s *CartService) priceCart(ctx context.Context, userID int64, items []domain.CartItemDetail) (domain.Cart, []domain.Promotion, error) {
+	if err := pricing.PriceItems(items); err != nil {
+		return domain.Cart{}, nil, err
+	}
+	now := time.Now()
+
+	var discounts []domain.AppliedDiscount
+	var applied []domain.Promotion
+	add := func(p domain.Promotion, amount money.Money) {
+		discounts = append(discounts, domain.AppliedDiscount{Code: p.Code, Description: p.Name, Amount: amount})
+		applied = append(applied, p)
+	}
+
+	automatic, err := s.promotions.GetAutomaticPromotions(ctx, now)
+	if err != nil {
+		return domain.Cart{}, nil, err
+	}
+	for _, p := range automatic {
+		if s.checkUsage(ctx, p, userID) != nil {
+			continue
+		}
+		if amount, err := promotions.Discount(p, items); err == nil {
+			add(p, amount)
+		}
+	}
+
+	var code string
+	var couponErr error
+	if userID != 0 {
+		if code, err = s.promotions.GetCartCoupon(ctx, userID); err != nil {
+			return domain.Cart{}, nil, err
+		}
+	}
+	if code != "" {
+		p, amount, err := s.evaluateCoupon(ctx, userID, code, items, now)
+		switch {
+		case err == nil:
+			add(p, amount)
+		case isCouponError(err):
+			couponErr = err
+		default:
+			return domain.Cart{}, nil, err
+		}
+	}
+
+	cart, err := s.pricing.Price(items, discounts)
+	if err != nil {
+		return domain.Cart{}, nil, err
+	}
+	cart.Coupon = code
+	if couponErr != nil {
+		cart.CouponError = couponErr.Error()
+	}
+	return cart, applied, nil
+}
+
+// evaluateCoupon проверяет окно действия, лимиты и условия купона. items должны быть уже оценены.
+func (s *CartService) evaluateCoupon(ctx context.Context, userID int64, code string, items []domain.CartItemDetail, now time.Time) (domain.Promotion, money.Money, error) {
+	p, err := s.promotions.GetPromotionByCode(ctx, code)
+	if err != nil {
+		return p, money.Money{}, err
+	}
+	if !promotions.Active(p, now) {
+		return p, money.Money{}, fmt.Errorf("%w: coupon is not active", domain.ErrCouponNotApplicable)
+	}
+	if err := s.checkUsage(ctx, p, userID); err != nil {
+		return p, money.Money{}, err
+	}
+
+	amount, err := promotions.Discount(p, items)
+	return p, amount, err
+}
+
+// checkUsage — предварительная проверка лимитов; окончательно они проверяются в Redeem
+func (s *CartService) checkUsage(ctx context.Context, p domain.Promotion, userID int64) error {
+	if p.MaxUses == 0 && p.MaxUsesPerUser == 0 {
+		return nil
+	}
+
+	total, byUser, err := s.promotions.CountRedemptions(ctx, p.ID, userID)
+	if err != nil {
+		return err
+	}
+	if p.MaxUses > 0 && total >= p.MaxUses {
+		return domain.ErrCouponUsageLimit
+	}
+	if userID != 0 && p.MaxUsesPerUser > 0 && byUser >= p.MaxUsesPerUser {
+		return domain.ErrCouponUsageLimit
+	}
+	return nil
+}
+
+func isCouponError(err error) bool {
+	return errors.Is(err, domain.ErrCouponNotFound) ||
+		errors.Is(err, domain.ErrCouponNotApplicable) ||
+		errors.Is(err, domain.ErrCouponUsageLimit)
+}
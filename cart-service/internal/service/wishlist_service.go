@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/cache"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/pricing"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/productclient"
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+type WishlistService struct {
+	repo     repository.WishlistRepositoryInterface
+	products productclient.ClientInterface
+	cache    *cache.RedisCache
+	cfg      Config
+}
+
+func NewWishlistService(repo repository.WishlistRepositoryInterface, products productclient.ClientInterface, cache *cache.RedisCache, cfg Config) *WishlistService {
+	return &WishlistService{repo: repo, products: products, cache: cache, cfg: cfg}
+}
+
+type WishlistServiceInterface interface {
+	CreateWishlist(ctx context.Context, userID int64, name string) (domain.Wishlist, error)
+	ListWishlists(ctx context.Context, userID int64) ([]domain.Wishlist, error)
+	GetWishlist(ctx context.Context, userID, wishlistID int64) (domain.WishlistDetails, error)
+	GetSharedWishlist(ctx context.Context, token string) (domain.WishlistDetails, error)
+	DeleteWishlist(ctx context.Context, userID, wishlistID int64) error
+	Share(ctx context.Context, userID, wishlistID int64) (string, error)
+	Unshare(ctx context.Context, userID, wishlistID int64) error
+
+	AddItem(ctx context.Context, userID, wishlistID, productID int64) error
+	RemoveItem(ctx context.Context, userID, wishlistID, productID int64) error
+	MoveToCart(ctx context.Context, userID, wishlistID int64, key domain.ItemKey, quantity int) error
+	MoveFromCart(ctx context.Context, userID, wishlistID int64, key domain.ItemKey) error
+}
+
+func (s *WishlistService) CreateWishlist(ctx context.Context, userID int64, name string) (domain.Wishlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.Wishlist{}, fmt.Errorf("%w: name must be set", domain.ErrInvalidOperation)
+	}
+	return s.repo.CreateWishlist(ctx, userID, name)
+}
+
+func (s *WishlistService) ListWishlists(ctx context.Context, userID int64) ([]domain.Wishlist, error) {
+	return s.repo.ListWishlists(ctx, userID)
+}
+
+func (s *WishlistService) GetWishlist(ctx context.Context, userID, wishlistID int64) (domain.WishlistDetails, error) {
+	w, err := s.repo.GetWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return domain.WishlistDetails{}, err
+	}
+	return s.details(ctx, w)
+}
+
+// GetSharedWishlist отдаёт опубликованный список по токену публичной ссылки
+func (s *WishlistService) GetSharedWishlist(ctx context.Context, token string) (domain.WishlistDetails, error) {
+	if token == "" {
+		return domain.WishlistDetails{}, domain.ErrWishlistNotFound
+	}
+	w, err := s.repo.GetWishlistByShareToken(ctx, token)
+	if err != nil {
+		return domain.WishlistDetails{}, err
+	}
+	return s.details(ctx, w)
+}
+
+// details дополняет позиции данными продуктов. Недоступный product-service не
+// ломает ответ: позиции без данных возвращаются с Available=false.
+func (s *WishlistService) details(ctx context.Context, w domain.Wishlist) (domain.WishlistDetails, error) {
+	items, err := s.repo.GetItems(ctx, w.ID)
+	if err != nil {
+		return domain.WishlistDetails{}, err
+	}
+
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	lookup, fetchErr := s.products.GetProducts(ctx, ids)
+	if fetchErr != nil {
+		log.Printf("product-service lookup failed for wishlist %d: %v", w.ID, fetchErr)
+	}
+
+	result := domain.WishlistDetails{Wishlist: w, Items: make([]domain.WishlistItemDetail, 0, len(items))}
+	for _, item := range items {
+		product, ok := lookup.Products[item.ProductID]
+		if !ok {
+			product = domain.Product{ID: item.ProductID}
+		}
+		result.Items = append(result.Items, domain.WishlistItemDetail{
+			Product:     product,
+			Available:   ok,
+			Stale:       lookup.Stale[item.ProductID],
+			PriceAtSave: item.PriceAtSave,
+			PriceDrop:   ok && item.PriceAtSave != nil && pricing.PriceDropped(product.Price, *item.PriceAtSave),
+			SavedAt:     item.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (s *WishlistService) DeleteWishlist(ctx context.Context, userID, wishlistID int64) error {
+	return s.repo.DeleteWishlist(ctx, userID, wishlistID)
+}
+
+// Share публикует список и возвращает токен публичной ссылки; повторный вызов выдаёт новый токен
+func (s *WishlistService) Share(ctx context.Context, userID, wishlistID int64) (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	if err := s.repo.SetShareToken(ctx, userID, wishlistID, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *WishlistService) Unshare(ctx context.Context, userID, wishlistID int64) error {
+	return s.repo.SetShareToken(ctx, userID, wishlistID, "")
+}
+
+func (s *WishlistService) AddItem(ctx context.Context, userID, wishlistID, productID int64) error {
+	if _, err := s.repo.GetWishlist(ctx, userID, wishlistID); err != nil {
+		return err
+	}
+	price, err := s.currentPrice(ctx, productID)
+	if err != nil {
+		return err
+	}
+	return s.repo.AddItem(ctx, wishlistID, productID, price)
+}
+
+func (s *WishlistService) RemoveItem(ctx context.Context, userID, wishlistID, productID int64) error {
+	if _, err := s.repo.GetWishlist(ctx, userID, wishlistID); err != nil {
+		return err
+	}
+	return s.repo.RemoveItem(ctx, wishlistID, productID)
+}
+
+// MoveToCart переносит товар из списка в корзину; quantity 0 означает одну единицу.
+// Список хранит товары без вариантов, поэтому для товара с вариантами вариант выбирается при переносе.
+func (s *WishlistService) MoveToCart(ctx context.Context, userID, wishlistID int64, key domain.ItemKey, quantity int) error {
+	if quantity == 0 {
+		quantity = 1
+	}
+	if quantity < 0 || (s.cfg.MaxItemQuantity > 0 && quantity > s.cfg.MaxItemQuantity) {
+		return fmt.Errorf("%w: invalid quantity %d", domain.ErrInvalidOperation, quantity)
+	}
+
+	if _, err := s.repo.GetWishlist(ctx, userID, wishlistID); err != nil {
+		return err
+	}
+	product, err := s.currentProduct(ctx, key.ProductID)
+	if err != nil {
+		return err
+	}
+	variant, err := product.ResolveVariant(key.VariantID)
+	if err != nil {
+		return err
+	}
+	op := domain.CartOperation{
+		Op:        domain.OperationAdd,
+		ProductID: key.ProductID,
+		VariantID: key.VariantID,
+		Quantity:  quantity,
+		Price:     product.UnitPrice(variant),
+	}
+
+	if err := s.repo.MoveToCart(ctx, userID, wishlistID, op, s.cfg.MaxItemQuantity); err != nil {
+		return err
+	}
+	_ = s.cache.Delete(ctx, fmt.Sprintf("cart:user:%d", userID))
+	return nil
+}
+
+// MoveFromCart переносит позицию корзины в список; в списке сохраняется товар по его цене без учёта варианта
+func (s *WishlistService) MoveFromCart(ctx context.Context, userID, wishlistID int64, key domain.ItemKey) error {
+	if _, err := s.repo.GetWishlist(ctx, userID, wishlistID); err != nil {
+		return err
+	}
+	product, err := s.currentProduct(ctx, key.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.MoveFromCart(ctx, userID, wishlistID, key, product.Price); err != nil {
+		return err
+	}
+	_ = s.cache.Delete(ctx, fmt.Sprintf("cart:user:%d", userID))
+	return nil
+}
+
+// currentPrice — цена для сохранения вместе с позицией; подойдут и данные из LRU
+func (s *WishlistService) currentPrice(ctx context.Context, productID int64) (money.Money, error) {
+	product, err := s.currentProduct(ctx, productID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return product.Price, nil
+}
+
+// currentProduct — данные товара с ценой и вариантами; подойдут и данные из LRU
+func (s *WishlistService) currentProduct(ctx context.Context, productID int64) (domain.Product, error) {
+	if productID <= 0 {
+		return domain.Product{}, fmt.Errorf("%w: product id must be set", domain.ErrInvalidOperation)
+	}
+
+	lookup, err := s.products.GetProducts(ctx, []int64{productID})
+	product, ok := lookup.Products[productID]
+	if !ok {
+		if err != nil {
+			return domain.Product{}, fmt.Errorf("failed to get price for product %d: %w", productID, err)
+		}
+		return domain.Product{}, fmt.Errorf("%w: product %d not found", domain.ErrInvalidOperation, productID)
+	}
+	return product, nil
+}
@@ -0,0 +1,66 @@
+// Package userclient — клиент user-service для cart-service: адреса доставки при оформлении заказа.
+package userclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+)
+
+type AddressesInterface interface {
+	// ShippingAddress возвращает адрес доставки покупателя; addressID = 0 — адрес по умолчанию
+	ShippingAddress(ctx context.Context, userID, addressID int64) (domain.Address, error)
+}
+
+type AddressClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewAddressClient(baseURL string, timeout time.Duration) *AddressClient {
+	return &AddressClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *AddressClient) ShippingAddress(ctx context.Context, userID, addressID int64) (domain.Address, error) {
+	id := "default"
+	if addressID != 0 {
+		id = strconv.FormatInt(addressID, 10)
+	}
+	endpoint := fmt.Sprintf("%s/internal/users/%d/addresses/%s?type=shipping", c.baseURL, userID, id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return domain.Address{}, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return domain.Address{}, fmt.Errorf("failed to fetch shipping address: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var address domain.Address
+		if err := json.NewDecoder(resp.Body).Decode(&address); err != nil {
+			return domain.Address{}, err
+		}
+		return address, nil
+	case http.StatusNotFound:
+		return domain.Address{}, domain.ErrAddressNotFound
+	case http.StatusBadRequest:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return domain.Address{}, fmt.Errorf("%w: %s", domain.ErrInvalidAddress, strings.TrimSpace(string(body)))
+	default:
+		return domain.Address{}, fmt.Errorf("user-service returned status %d", resp.StatusCode)
+	}
+}
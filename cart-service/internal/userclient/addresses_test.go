@@ -0,0 +1,43 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/cart-service/internal/domain"
+)
+
+func TestShippingAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("type") != "shipping" {
+			t.Errorf("expected type=shipping, got %q", r.URL.RawQuery)
+		}
+		switch r.URL.Path {
+		case "/internal/users/1/addresses/default":
+			w.Write([]byte(`{"id":7,"type":"shipping","recipient":"Alex","country":"RU","city":"Москва","line1":"ул. Тверская, 1","postal_code":"125009"}`))
+		case "/internal/users/1/addresses/8":
+			http.Error(w, "invalid address: address 8 is not a shipping address", http.StatusBadRequest)
+		default:
+			http.Error(w, "address not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewAddressClient(srv.URL, time.Second)
+	ctx := context.Background()
+
+	address, err := c.ShippingAddress(ctx, 1, 0)
+	if err != nil || address.ID != 7 || address.City != "Москва" {
+		t.Fatalf("expected default address 7, got %+v %v", address, err)
+	}
+	if _, err := c.ShippingAddress(ctx, 1, 8); !errors.Is(err, domain.ErrInvalidAddress) {
+		t.Fatalf("expected ErrInvalidAddress, got %v", err)
+	}
+	if _, err := c.ShippingAddress(ctx, 2, 0); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("expected ErrAddressNotFound, got %v", err)
+	}
+}
@@ -0,0 +1 @@
+ALTER TABLE cart_service.cart_items DROP COLUMN IF EXISTS price_at_add;
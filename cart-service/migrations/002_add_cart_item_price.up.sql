@@ -0,0 +1 @@
+ALTER TABLE cart_service.cart_items ADD COLUMN IF NOT EXISTS price_at_add NUMERIC(10,2);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS cart_service.cart_coupons;
+DROP TABLE IF EXISTS cart_service.promotion_redemptions;
+DROP TABLE IF EXISTS cart_service.promotions;
@@ -0,0 +1,7 @@
+-- Корзины, о которых уже отправлено событие CartAbandoned: повторное событие
+-- отправляется только после новой активности в корзине
+CREATE TABLE IF NOT EXISTS cart_service.abandoned_carts (
+    user_id INTEGER PRIMARY KEY,
+    last_activity_at TIMESTAMP NOT NULL,
+    notified_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
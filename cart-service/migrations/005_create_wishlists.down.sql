@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cart_service.wishlist_items;
+DROP TABLE IF EXISTS cart_service.wishlists;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS cart_service.wishlists (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    share_token TEXT UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT wishlists_user_name_unique UNIQUE (user_id, name)
+);
+
+-- notified_price — цена, о снижении до которой уже сообщили; следующее
+-- уведомление уходит, только если цена опустится ниже неё
+CREATE TABLE IF NOT EXISTS cart_service.wishlist_items (
+    id SERIAL PRIMARY KEY,
+    wishlist_id INTEGER NOT NULL REFERENCES cart_service.wishlists (id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL,
+    price_at_save NUMERIC(10,2),
+    notified_price NUMERIC(10,2),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT wishlist_items_wishlist_product_unique UNIQUE (wishlist_id, product_id)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE cart_service.wishlist_items DROP COLUMN IF EXISTS currency;
+ALTER TABLE cart_service.promotions DROP COLUMN IF EXISTS currency;
+ALTER TABLE cart_service.cart_items DROP COLUMN IF EXISTS currency;
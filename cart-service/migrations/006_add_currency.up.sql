@@ -0,0 +1,4 @@
+-- Валюта сумм, хранящихся в NUMERIC-колонках строки
+ALTER TABLE cart_service.cart_items ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE cart_service.promotions ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE cart_service.wishlist_items ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD';
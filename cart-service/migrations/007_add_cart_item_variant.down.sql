@@ -0,0 +1,5 @@
+DELETE FROM cart_service.cart_items WHERE variant_id <> 0;
+ALTER TABLE cart_service.cart_items DROP CONSTRAINT IF EXISTS cart_items_user_product_variant_unique;
+ALTER TABLE cart_service.cart_items
+    ADD CONSTRAINT cart_items_user_product_unique UNIQUE (user_id, product_id);
+ALTER TABLE cart_service.cart_items DROP COLUMN IF EXISTS variant_id;
@@ -0,0 +1,6 @@
+-- Позиция корзины — товар и его вариант (SKU); 0 означает товар без вариантов.
+-- Разные варианты одного товара хранятся отдельными позициями.
+ALTER TABLE cart_service.cart_items ADD COLUMN IF NOT EXISTS variant_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE cart_service.cart_items DROP CONSTRAINT IF EXISTS cart_items_user_product_unique;
+ALTER TABLE cart_service.cart_items
+    ADD CONSTRAINT cart_items_user_product_variant_unique UNIQUE (user_id, product_id, variant_id);
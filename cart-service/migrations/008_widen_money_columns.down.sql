@@ -0,0 +1,8 @@
+-- Суммы с тремя знаками после запятой округляются до двух
+ALTER TABLE cart_service.promotions
+    ALTER COLUMN value TYPE NUMERIC(10,2),
+    ALTER COLUMN min_order_amount TYPE NUMERIC(10,2);
+ALTER TABLE cart_service.wishlist_items
+    ALTER COLUMN price_at_save TYPE NUMERIC(10,2),
+    ALTER COLUMN notified_price TYPE NUMERIC(10,2);
+ALTER TABLE cart_service.cart_items ALTER COLUMN price_at_add TYPE NUMERIC(10,2);
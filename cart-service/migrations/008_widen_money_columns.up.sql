@@ -0,0 +1,11 @@
+-- Суммы в валютах с тремя знаками после запятой (KWD, BHD) не помещались в NUMERIC(10,2)
+-- и округлялись при записи. Смена масштаба переписывает таблицы, поэтому ограничение
+-- на время выполнения для этой миграции больше обычного.
+-- migrate:statement_timeout=30min
+ALTER TABLE cart_service.cart_items ALTER COLUMN price_at_add TYPE NUMERIC(19,4);
+ALTER TABLE cart_service.wishlist_items
+    ALTER COLUMN price_at_save TYPE NUMERIC(19,4),
+    ALTER COLUMN notified_price TYPE NUMERIC(19,4);
+ALTER TABLE cart_service.promotions
+    ALTER COLUMN value TYPE NUMERIC(19,4),
+    ALTER COLUMN min_order_amount TYPE NUMERIC(19,4);
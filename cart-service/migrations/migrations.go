@@ -0,0 +1,10 @@
+// Package migrations содержит SQL-миграции схемы cart_service, которой владеет cart-service.
+package migrations
+
+import "embed"
+
+// Schema — схема PostgreSQL сервиса. Версия миграций хранится в cart_service.schema_migrations.
+const Schema = "cart_service"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type recordingHandler struct {
+	deleted []int64
+	err     error
+}
+
+func (h *recordingHandler) HandleUserDeleted(ctx context.Context, userID int64) error {
+	h.deleted = append(h.deleted, userID)
+	return h.err
+}
+
+func TestHandleUserEvent(t *testing.T) {
+	h := &recordingHandler{}
+	ctx := context.Background()
+
+	for _, value := range []string{
+		`{"type":"UserLockedOut","user_id":1}`,
+		`not json`,
+		`{"type":"UserDeleted"}`,
+		`{"type":"UserDeleted","user_id":7,"deleted_at":"2026-01-01T00:00:00Z"}`,
+	} {
+		if err := handleUserEvent(ctx, []byte(value), h); err != nil {
+			t.Fatalf("unexpected error for %s: %v", value, err)
+		}
+	}
+	if len(h.deleted) != 1 || h.deleted[0] != 7 {
+		t.Fatalf("expected only user 7 to be purged, got %v", h.deleted)
+	}
+
+	h.err = errors.New("db is down")
+	if err := handleUserEvent(ctx, []byte(`{"type":"UserDeleted","user_id":8}`), h); err == nil {
+		t.Fatal("expected handler error to be returned so the event is retried")
+	}
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/segmentio/kafka-go"
+)
+
+type CartProducer struct {
+	writer *kafka.Writer
+}
+
+type Producer interface {
+	SendCartAbandoned(ctx context.Context, event CartAbandonedEvent) error
+	SendWishlistPriceDropped(ctx context.Context, event WishlistPriceDroppedEvent) error
+}
+
+// CartAbandonedEvent — корзина давно не менялась; его читает сервис уведомлений
+type CartAbandonedEvent struct {
+	Type           string    `json:"type"`
+	UserID         int64     `json:"user_id"`
+	ProductIDs     []int64   `json:"product_ids"`
+	TotalQuantity  int       `json:"total_quantity"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+// WishlistPriceDroppedEvent — цена сохранённого в списке товара снизилась
+type WishlistPriceDroppedEvent struct {
+	Type       string      `json:"type"`
+	UserID     int64       `json:"user_id"`
+	WishlistID int64       `json:"wishlist_id"`
+	ProductID  int64       `json:"product_id"`
+	OldPrice   money.Money `json:"old_price"`
+	NewPrice   money.Money `json:"new_price"`
+	DetectedAt time.Time   `json:"detected_at"`
+}
+
+const (
+	CartAbandonedType        = "CartAbandoned"
+	WishlistPriceDroppedType = "WishlistPriceDropped"
+)
+
+func NewCartProducer(brokerAddress, topic string) *CartProducer {
+	return &CartProducer{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokerAddress),
+			Topic:    topic,
+			Balancer: &kafka.LeastBytes{},
+		},
+	}
+}
+
+func (p *CartProducer) SendCartAbandoned(ctx context.Context, event CartAbandonedEvent) error {
+	event.Type = CartAbandonedType
+	return p.send(ctx, event.UserID, event)
+}
+
+func (p *CartProducer) SendWishlistPriceDropped(ctx context.Context, event WishlistPriceDroppedEvent) error {
+	event.Type = WishlistPriceDroppedType
+	return p.send(ctx, event.UserID, event)
+}
+
+// send публикует событие с ключом user_id, чтобы события одного пользователя шли по порядку
+func (p *CartProducer) send(ctx context.Context, userID int64, event any) error {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(strconv.FormatInt(userID, 10)),
+		Value: msg,
+		Time:  time.Now(),
+	})
+}
+
+func (p *CartProducer) Close() error {
+	return p.writer.Close()
+}
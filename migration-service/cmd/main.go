@@ -1,10 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/migrate"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/migrator"
 	"github.com/joho/godotenv"
 )
 
@@ -13,9 +17,58 @@ func main() {
 		log.Println(".env not found, using defaults")
 	}
 
-	if err := migrate.Run(); err != nil {
+	services := flag.String("services", os.Getenv("MIGRATE_SERVICES"), "comma-separated list of services to migrate (default: all)")
+	dryRun := flag.Bool("dry-run", false, "print pending migrations without applying them")
+	lintOnly := flag.Bool("lint", false, "check migrations for dangerous operations and exit")
+	lockTimeout := flag.String("lock-timeout", envOrDefault("MIGRATE_LOCK_TIMEOUT", migrator.DefaultTimeouts.LockTimeout), "lock_timeout applied to every migration")
+	statementTimeout := flag.String("statement-timeout", envOrDefault("MIGRATE_STATEMENT_TIMEOUT", migrator.DefaultTimeouts.StatementTimeout), "statement_timeout applied to every migration")
+	flag.Parse()
+
+	if *lintOnly {
+		findings, err := migrate.LintServices(splitList(*services))
+		if err != nil {
+			log.Fatalf("Ошибка проверки миграций: %v", err)
+		}
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+		if migrator.HasErrors(findings) {
+			os.Exit(1)
+		}
+		fmt.Println("Миграции проверены")
+		return
+	}
+
+	err := migrate.Run(migrate.Options{
+		Services: splitList(*services),
+		DryRun:   *dryRun,
+		Timeouts: migrator.Timeouts{
+			LockTimeout:      *lockTimeout,
+			StatementTimeout: *statementTimeout,
+		},
+	})
+	if err != nil {
 		log.Fatalf("Ошибка миграции: %v", err)
 	}
 
-	fmt.Println("Миграции применены успешно")
+	if !*dryRun {
+		fmt.Println("Миграции применены успешно")
+	}
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/db"
+	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/seed"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "fixtures/dev.yaml", "path to YAML or JSON fixtures")
+	products := flag.Int("products", 0, "number of generated products to keep in the catalog (for load testing)")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println(".env not found, using defaults")
+	}
+
+	fixtures, err := seed.Load(*file)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки фикстур: %v", err)
+	}
+
+	sqlDB, err := db.NewDatabase()
+	if err != nil {
+		log.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer sqlDB.Close()
+
+	seeder := seed.NewSeeder(sqlDB)
+	if err := seeder.Run(context.Background(), fixtures, seed.Options{GeneratedProducts: *products}); err != nil {
+		log.Fatalf("Ошибка загрузки данных: %v", err)
+	}
+
+	fmt.Println("Данные загружены успешно")
+}
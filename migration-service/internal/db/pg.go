@@ -0,0 +1,34 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+
+	_ "github.com/lib/pq"
+)
+
+func NewDatabase() (*sql.DB, error) {
+	return Open(nil)
+}
+
+// Open подключается к БД; params передаются серверу как параметры сессии
+// (например, lock_timeout и statement_timeout).
+func Open(params map[string]string) (*sql.DB, error) {
+	query := url.Values{"sslmode": {"disable"}}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+
+	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?%s",
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_NAME"),
+		query.Encode(),
+	)
+
+	return sql.Open("postgres", dbURL)
+}
@@ -1,34 +1,187 @@
 package migrate
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"log"
+	"sort"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	cartmigrations "github.com/OvsyannikovAlexandr/marketplace/cart-service/migrations"
+	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/db"
+	ordermigrations "github.com/OvsyannikovAlexandr/marketplace/order-service/migrations"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/migrator"
+	productmigrations "github.com/OvsyannikovAlexandr/marketplace/product-service/migrations"
+	usermigrations "github.com/OvsyannikovAlexandr/marketplace/user-service/migrations"
+	"github.com/golang-migrate/migrate/v4/source"
 )
 
-func Run() error {
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	)
-
-	m, err := migrate.New(
-		"file://migrations",
-		dbURL,
-	)
+// Services — миграции всех сервисов. Сервис может применить свои миграции и сам
+// при старте (MIGRATE_ON_START=true) через тот же пакет migrator.
+var Services = []migrator.Service{
+	{Name: "user-service", Schema: usermigrations.Schema, FS: usermigrations.FS},
+	{Name: "product-service", Schema: productmigrations.Schema, FS: productmigrations.FS},
+	{Name: "order-service", Schema: ordermigrations.Schema, FS: ordermigrations.FS},
+	{Name: "cart-service", Schema: cartmigrations.Schema, FS: cartmigrations.FS},
+}
+
+// Options управляет запуском миграций.
+type Options struct {
+	// Services — имена или схемы сервисов; если пусто, берутся все сервисы.
+	Services []string
+	// DryRun только выводит ожидающие миграции, ничего не применяя.
+	DryRun   bool
+	Timeouts migrator.Timeouts
+}
+
+// Run проверяет миграции линтером и применяет их (или выводит план при DryRun).
+// Замечания уровня error блокируют запуск.
+func Run(opts Options) error {
+	selected, err := selectServices(opts.Services)
+	if err != nil {
+		return err
+	}
+
+	findings, err := lintServices(selected)
+	if err != nil {
+		return err
+	}
+	for _, f := range findings {
+		log.Println(f)
+	}
+	if migrator.HasErrors(findings) {
+		return errors.New("migration lint failed")
+	}
+
+	sqlDB, err := db.Open(opts.Timeouts.RuntimeParams())
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	for _, svc := range selected {
+		if opts.DryRun {
+			err = printPending(sqlDB, svc)
+		} else {
+			err = migrator.Up(context.Background(), sqlDB, svc, opts.Timeouts)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", svc.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// LintServices проверяет миграции без подключения к БД.
+func LintServices(names []string) ([]migrator.Finding, error) {
+	selected, err := selectServices(names)
+	if err != nil {
+		return nil, err
+	}
+	return lintServices(selected)
+}
+
+func lintServices(services []migrator.Service) ([]migrator.Finding, error) {
+	var findings []migrator.Finding
+	for _, svc := range services {
+		f, err := migrator.Lint(svc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", svc.Name, err)
+		}
+		findings = append(findings, f...)
+	}
+	return findings, nil
+}
+
+// printPending выводит текущую версию схемы и ещё не применённые up-миграции.
+// Только читает БД: ни схема, ни таблица версий не создаются.
+func printPending(db *sql.DB, svc migrator.Service) error {
+	current, dirty, err := currentVersion(db, svc)
 	if err != nil {
 		return err
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+	entries, err := fs.ReadDir(svc.FS, ".")
+	if err != nil {
 		return err
 	}
 
+	var pending []string
+	for _, e := range entries {
+		m, err := source.DefaultParse(e.Name())
+		if err != nil {
+			continue
+		}
+		if m.Direction == source.Up && int64(m.Version) > current {
+			pending = append(pending, e.Name())
+		}
+	}
+	sort.Strings(pending)
+
+	state := "not initialized"
+	if current >= 0 {
+		state = fmt.Sprintf("version %d", current)
+	}
+	if dirty {
+		state += " (dirty)"
+	}
+
+	fmt.Printf("%s [%s]: %s, %d pending\n", svc.Name, svc.Schema, state, len(pending))
+	for _, name := range pending {
+		fmt.Printf("  %s\n", name)
+	}
+
 	return nil
 }
+
+// currentVersion возвращает -1, если миграции сервиса ещё не применялись.
+func currentVersion(db *sql.DB, svc migrator.Service) (int64, bool, error) {
+	var exists bool
+	if err := db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, svc.VersionTable()).Scan(&exists); err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return -1, false, nil
+	}
+
+	var version int64
+	var dirty bool
+	err := db.QueryRow(`SELECT version, dirty FROM `+svc.VersionTable()+` LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+func selectServices(names []string) ([]migrator.Service, error) {
+	if len(names) == 0 {
+		return Services, nil
+	}
+
+	var selected []migrator.Service
+	for _, name := range names {
+		svc, ok := findService(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+		selected = append(selected, svc)
+	}
+
+	return selected, nil
+}
+
+func findService(name string) (migrator.Service, bool) {
+	for _, svc := range Services {
+		if svc.Name == name || svc.Schema == name {
+			return svc, true
+		}
+	}
+	return migrator.Service{}, false
+}
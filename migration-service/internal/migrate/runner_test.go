@@ -0,0 +1,19 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/migrator"
+)
+
+func TestLint_ServiceMigrationsAreClean(t *testing.T) {
+	for _, svc := range Services {
+		findings, err := migrator.Lint(svc)
+		if err != nil {
+			t.Fatalf("Lint %s failed: %v", svc.Name, err)
+		}
+		if migrator.HasErrors(findings) {
+			t.Errorf("expected no lint errors in %s, got %v", svc.Name, findings)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures — детерминированный набор данных для локального окружения.
+// Связи задаются естественными ключами: пользователи и продавцы по email, товары по имени,
+// у заказов естественного ключа нет, поэтому каждому задаётся свой key.
+type Fixtures struct {
+	Users    []UserFixture    `json:"users" yaml:"users"`
+	Sellers  []SellerFixture  `json:"sellers" yaml:"sellers"`
+	Products []ProductFixture `json:"products" yaml:"products"`
+	Carts    []CartFixture    `json:"carts" yaml:"carts"`
+	Orders   []OrderFixture   `json:"orders" yaml:"orders"`
+}
+
+type UserFixture struct {
+	Name     string `json:"name" yaml:"name"`
+	Email    string `json:"email" yaml:"email"`
+	Password string `json:"password" yaml:"password"`
+	// Role — роль пользователя; пустая означает обычного покупателя
+	Role string `json:"role" yaml:"role"`
+}
+
+// SellerFixture — профиль продавца пользователя User; пустой Status означает одобренного продавца.
+type SellerFixture struct {
+	User        string `json:"user" yaml:"user"`
+	DisplayName string `json:"display_name" yaml:"display_name"`
+	Description string `json:"description" yaml:"description"`
+	Status      string `json:"status" yaml:"status"`
+}
+
+// ProductFixture — товар. Price читается как десятичная запись без перевода в float,
+// пустая Currency означает валюту по умолчанию, пустой Seller (email продавца) — товар маркетплейса.
+type ProductFixture struct {
+	Name        string      `json:"name" yaml:"name"`
+	Description string      `json:"description" yaml:"description"`
+	Price       json.Number `json:"price" yaml:"price"`
+	Currency    string      `json:"currency" yaml:"currency"`
+	Category    string      `json:"category" yaml:"category"`
+	Seller      string      `json:"seller" yaml:"seller"`
+}
+
+// Money — цена товара с проверкой точности и валюты.
+func (p ProductFixture) Money() (money.Money, error) {
+	currency := money.DefaultCurrency
+	if p.Currency != "" {
+		c, err := money.ParseCurrency(p.Currency)
+		if err != nil {
+			return money.Money{}, err
+		}
+		currency = c
+	}
+	return money.Parse(p.Price.String(), currency)
+}
+
+type CartFixture struct {
+	User  string            `json:"user" yaml:"user"`
+	Items []CartItemFixture `json:"items" yaml:"items"`
+}
+
+type CartItemFixture struct {
+	Product  string `json:"product" yaml:"product"`
+	Quantity int    `json:"quantity" yaml:"quantity"`
+}
+
+// OrderFixture — заказ покупателя. Key однозначно определяет заказ: по нему повторная загрузка
+// обновляет заказ после правки фикстуры. Подзаказы продавцов seed строит сам, как при оформлении.
+type OrderFixture struct {
+	Key             string            `json:"key" yaml:"key"`
+	User            string            `json:"user" yaml:"user"`
+	Items           []CartItemFixture `json:"items" yaml:"items"`
+	Status          string            `json:"status" yaml:"status"`
+	ShippingAddress *AddressFixture   `json:"shipping_address" yaml:"shipping_address"`
+}
+
+// AddressFixture — адрес доставки. JSON-теги совпадают со снимком адреса в order-service,
+// поэтому адрес сохраняется в заказе как есть.
+type AddressFixture struct {
+	Recipient  string `json:"recipient" yaml:"recipient"`
+	Phone      string `json:"phone,omitempty" yaml:"phone"`
+	Country    string `json:"country" yaml:"country"`
+	Region     string `json:"region,omitempty" yaml:"region"`
+	City       string `json:"city" yaml:"city"`
+	Line1      string `json:"line1" yaml:"line1"`
+	Line2      string `json:"line2,omitempty" yaml:"line2"`
+	PostalCode string `json:"postal_code" yaml:"postal_code"`
+}
+
+// Load читает фикстуры из YAML- или JSON-файла (по расширению).
+func Load(path string) (Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixtures{}, err
+	}
+
+	var f Fixtures
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &f)
+	case ".json":
+		err = json.Unmarshal(data, &f)
+	default:
+		return Fixtures{}, fmt.Errorf("unsupported fixtures format: %s", path)
+	}
+	if err != nil {
+		return Fixtures{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,32 @@
+//go:build ci
+
+package seed_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/db"
+	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/migrate"
+)
+
+var sqlDB *sql.DB
+
+// TestMain в CI использует базу из DB_* и докатывает на неё миграции всех сервисов
+func TestMain(m *testing.M) {
+	if err := migrate.Run(migrate.Options{}); err != nil {
+		panic(fmt.Sprintf("failed to apply migrations: %v", err))
+	}
+
+	var err error
+	if sqlDB, err = db.NewDatabase(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	sqlDB.Close()
+	os.Exit(code)
+}
@@ -0,0 +1,68 @@
+//go:build !ci
+
+package seed_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/db"
+	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/migrate"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var sqlDB *sql.DB
+
+// TestMain поднимает PostgreSQL в контейнере и накатывает на него миграции всех сервисов,
+// чтобы фикстуры проверялись на той же схеме, что и в окружении.
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:15",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_DB":       "marketplace",
+			"POSTGRES_USER":     "postgres",
+			"POSTGRES_PASSWORD": "postgres",
+		},
+		WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(30 * time.Second),
+	}
+
+	pgContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	host, _ := pgContainer.Host(ctx)
+	port, _ := pgContainer.MappedPort(ctx, "5432")
+	for k, v := range map[string]string{
+		"DB_HOST":     host,
+		"DB_PORT":     port.Port(),
+		"DB_USER":     "postgres",
+		"DB_PASSWORD": "postgres",
+		"DB_NAME":     "marketplace",
+	} {
+		os.Setenv(k, v)
+	}
+
+	if err := migrate.Run(migrate.Options{}); err != nil {
+		panic(fmt.Sprintf("failed to apply migrations: %v", err))
+	}
+	if sqlDB, err = db.NewDatabase(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	sqlDB.Close()
+	_ = pgContainer.Terminate(ctx)
+	os.Exit(code)
+}
@@ -0,0 +1,429 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const generatedProductPrefix = "seed-product-"
+
+// Options — параметры объёма данных.
+type Options struct {
+	// GeneratedProducts — сколько синтетических товаров держать в каталоге
+	// (например, 100000 для нагрузочного тестирования).
+	GeneratedProducts int
+}
+
+// Seeder загружает фикстуры в базу. Повторный запуск не создаёт дубликатов:
+// записи сопоставляются по email пользователя и имени товара, заказы — по ключу фикстуры.
+type Seeder struct {
+	db *sql.DB
+}
+
+func NewSeeder(db *sql.DB) *Seeder {
+	return &Seeder{db: db}
+}
+
+func (s *Seeder) Run(ctx context.Context, f Fixtures, opts Options) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	users, err := seedUsers(ctx, tx, f.Users)
+	if err != nil {
+		return fmt.Errorf("users: %w", err)
+	}
+
+	sellers, err := seedSellers(ctx, tx, f.Sellers, users)
+	if err != nil {
+		return fmt.Errorf("sellers: %w", err)
+	}
+
+	products, err := seedProducts(ctx, tx, f.Products, sellers)
+	if err != nil {
+		return fmt.Errorf("products: %w", err)
+	}
+
+	if err := seedCarts(ctx, tx, f.Carts, users, products); err != nil {
+		return fmt.Errorf("carts: %w", err)
+	}
+
+	if err := seedOrders(ctx, tx, f.Orders, users, products); err != nil {
+		return fmt.Errorf("orders: %w", err)
+	}
+
+	if err := generateProducts(ctx, tx, opts.GeneratedProducts); err != nil {
+		return fmt.Errorf("generated products: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+type productRef struct {
+	id    int64
+	price money.Money
+	// seller — email продавца-владельца, sellerID — его профиль; у товаров маркетплейса пусто
+	seller   string
+	sellerID *int64
+}
+
+func seedUsers(ctx context.Context, tx *sql.Tx, users []UserFixture) (map[string]int64, error) {
+	query := `
+		INSERT INTO user_service.users (name, email, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (email) DO UPDATE
+		SET name = EXCLUDED.name,
+			password_hash = EXCLUDED.password_hash,
+			role = EXCLUDED.role,
+			updated_at = NOW()
+		RETURNING id
+	`
+
+	ids := make(map[string]int64, len(users))
+	for _, u := range users {
+		hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+
+		role := u.Role
+		if role == "" {
+			role = "user"
+		}
+
+		var id int64
+		if err := tx.QueryRowContext(ctx, query, u.Name, u.Email, string(hash), role).Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", u.Email, err)
+		}
+		ids[u.Email] = id
+	}
+
+	log.Printf("seed: %d users", len(users))
+	return ids, nil
+}
+
+// seedSellers создаёт профили продавцов; возвращает их ID по email пользователя
+func seedSellers(ctx context.Context, tx *sql.Tx, sellers []SellerFixture, users map[string]int64) (map[string]int64, error) {
+	query := `
+		INSERT INTO user_service.sellers (user_id, display_name, description, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET display_name = EXCLUDED.display_name,
+			description = EXCLUDED.description,
+			status = EXCLUDED.status,
+			updated_at = NOW()
+		RETURNING id
+	`
+
+	ids := make(map[string]int64, len(sellers))
+	for _, sf := range sellers {
+		userID, ok := users[sf.User]
+		if !ok {
+			return nil, fmt.Errorf("unknown user %q", sf.User)
+		}
+
+		status := sf.Status
+		if status == "" {
+			status = "active"
+		}
+
+		var id int64
+		if err := tx.QueryRowContext(ctx, query, userID, sf.DisplayName, sf.Description, status).Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", sf.User, err)
+		}
+		ids[sf.User] = id
+	}
+
+	log.Printf("seed: %d sellers", len(sellers))
+	return ids, nil
+}
+
+func seedProducts(ctx context.Context, tx *sql.Tx, products []ProductFixture, sellers map[string]int64) (map[string]productRef, error) {
+	refs := make(map[string]productRef, len(products))
+	for _, p := range products {
+		price, err := p.Money()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, err)
+		}
+
+		var sellerID *int64
+		if p.Seller != "" {
+			id, ok := sellers[p.Seller]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown seller %q", p.Name, p.Seller)
+			}
+			sellerID = &id
+		}
+
+		var id int64
+		err = tx.QueryRowContext(ctx,
+			`SELECT id FROM product_service.products WHERE name = $1 ORDER BY id LIMIT 1`, p.Name,
+		).Scan(&id)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = tx.QueryRowContext(ctx, `
+				INSERT INTO product_service.products (name, description, price, currency, category, owner_seller_id, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+				RETURNING id
+			`, p.Name, p.Description, price.Decimal(), price.Currency(), p.Category, sellerID).Scan(&id)
+		case err == nil:
+			_, err = tx.ExecContext(ctx, `
+				UPDATE product_service.products
+				SET description = $2, price = $3, currency = $4, category = $5, owner_seller_id = $6, updated_at = NOW()
+				WHERE id = $1
+			`, id, p.Description, price.Decimal(), price.Currency(), p.Category, sellerID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, err)
+		}
+
+		refs[p.Name] = productRef{id: id, price: price, seller: p.Seller, sellerID: sellerID}
+	}
+
+	log.Printf("seed: %d products", len(products))
+	return refs, nil
+}
+
+func seedCarts(ctx context.Context, tx *sql.Tx, carts []CartFixture, users map[string]int64, products map[string]productRef) error {
+	query := `
+		INSERT INTO cart_service.cart_items (user_id, product_id, quantity, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (user_id, product_id, variant_id) DO UPDATE
+		SET quantity = EXCLUDED.quantity,
+			updated_at = NOW()
+	`
+
+	for _, c := range carts {
+		userID, ok := users[c.User]
+		if !ok {
+			return fmt.Errorf("unknown user %q", c.User)
+		}
+		for _, item := range c.Items {
+			product, ok := products[item.Product]
+			if !ok {
+				return fmt.Errorf("unknown product %q", item.Product)
+			}
+			if _, err := tx.ExecContext(ctx, query, userID, product.id, item.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Printf("seed: %d carts", len(carts))
+	return nil
+}
+
+// orderItem — позиция заказа в том виде, в каком её пишет order-service при оформлении
+type orderItem struct {
+	ProductID int64       `json:"product_id"`
+	Quantity  int         `json:"quantity"`
+	SellerID  int64       `json:"seller_id,omitempty"`
+	LineTotal money.Money `json:"line_total,omitzero"`
+}
+
+// orderRow — строка order_service.orders: заказ покупателя или подзаказ продавца
+type orderRow struct {
+	key        string
+	userID     int64
+	productIDs []int64
+	items      []orderItem
+	quantity   int
+	total      money.Money
+	address    *AddressFixture
+	status     string
+	parentID   *int64
+	sellerID   *int64
+}
+
+func (o *orderRow) add(item orderItem) error {
+	total := o.total
+	if total.Currency() == "" {
+		total = money.Zero(item.LineTotal.Currency())
+	}
+	total, err := total.Add(item.LineTotal)
+	if err != nil {
+		return err
+	}
+	o.total = total
+	o.items = append(o.items, item)
+	o.productIDs = append(o.productIDs, item.ProductID)
+	o.quantity += item.Quantity
+	return nil
+}
+
+// seedOrders загружает заказы с той же структурой, что пишет оформление заказа: позиции,
+// снимок адреса и, если в заказе есть товары продавцов, подзаказы по одному на продавца
+// (позиции маркетплейса — отдельный подзаказ). Заказы сопоставляются по ключу фикстуры,
+// подзаказы — по ключу заказа и продавцу; подзаказы, которых больше нет в фикстуре, удаляются.
+func seedOrders(ctx context.Context, tx *sql.Tx, orders []OrderFixture, users map[string]int64, products map[string]productRef) error {
+	keys := make(map[string]bool, len(orders))
+	for _, o := range orders {
+		if o.Key == "" {
+			return fmt.Errorf("order of %s has no key", o.User)
+		}
+		if keys[o.Key] {
+			return fmt.Errorf("duplicate order key %q", o.Key)
+		}
+		keys[o.Key] = true
+
+		userID, ok := users[o.User]
+		if !ok {
+			return fmt.Errorf("unknown user %q", o.User)
+		}
+
+		status := o.Status
+		if status == "" {
+			status = "new"
+		}
+
+		order := orderRow{key: o.Key, userID: userID, address: o.ShippingAddress, status: status}
+		var sellers []string
+		subOrders := map[string]*orderRow{}
+		hasSellers := false
+		for _, item := range o.Items {
+			product, ok := products[item.Product]
+			if !ok {
+				return fmt.Errorf("unknown product %q", item.Product)
+			}
+			lineTotal, err := product.price.Mul(int64(item.Quantity))
+			if err != nil {
+				return fmt.Errorf("order %s: %w", o.Key, err)
+			}
+			line := orderItem{ProductID: product.id, Quantity: item.Quantity, LineTotal: lineTotal}
+			if product.sellerID != nil {
+				line.SellerID = *product.sellerID
+				hasSellers = true
+			}
+			if err := order.add(line); err != nil {
+				return fmt.Errorf("order %s: %w", o.Key, err)
+			}
+
+			sub, ok := subOrders[product.seller]
+			if !ok {
+				sub = &orderRow{userID: userID, address: o.ShippingAddress, status: status, sellerID: product.sellerID}
+				sub.key = o.Key + "/marketplace"
+				if product.seller != "" {
+					sub.key = o.Key + "/" + product.seller
+				}
+				sellers = append(sellers, product.seller)
+				subOrders[product.seller] = sub
+			}
+			// Скидок у фикстур нет, поэтому итог подзаказа равен сумме его позиций
+			if err := sub.add(line); err != nil {
+				return fmt.Errorf("order %s: %w", o.Key, err)
+			}
+		}
+		if order.total.Currency() == "" {
+			order.total = money.Zero(money.DefaultCurrency)
+		}
+
+		orderID, err := upsertOrder(ctx, tx, order)
+		if err != nil {
+			return fmt.Errorf("order %s: %w", o.Key, err)
+		}
+
+		// Как и при оформлении, заказ только с товарами маркетплейса не делится
+		subKeys := []string{}
+		if hasSellers {
+			for _, seller := range sellers {
+				sub := subOrders[seller]
+				sub.parentID = &orderID
+				if _, err := upsertOrder(ctx, tx, *sub); err != nil {
+					return fmt.Errorf("order %s: %w", sub.key, err)
+				}
+				subKeys = append(subKeys, sub.key)
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM order_service.orders
+			WHERE parent_id = $1 AND (fixture_key IS NULL OR fixture_key <> ALL($2))
+		`, orderID, pq.Array(subKeys))
+		if err != nil {
+			return fmt.Errorf("order %s: %w", o.Key, err)
+		}
+	}
+
+	log.Printf("seed: %d orders", len(orders))
+	return nil
+}
+
+func upsertOrder(ctx context.Context, tx *sql.Tx, o orderRow) (int64, error) {
+	items, err := json.Marshal(o.items)
+	if err != nil {
+		return 0, err
+	}
+	var address any
+	if o.address != nil {
+		data, err := json.Marshal(o.address)
+		if err != nil {
+			return 0, err
+		}
+		address = string(data)
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO order_service.orders (fixture_key, user_id, product_ids, items, quantity, total_price, currency, shipping_address, status, parent_id, seller_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
+		ON CONFLICT (fixture_key) WHERE fixture_key IS NOT NULL DO UPDATE
+		SET user_id = EXCLUDED.user_id,
+			product_ids = EXCLUDED.product_ids,
+			items = EXCLUDED.items,
+			quantity = EXCLUDED.quantity,
+			total_price = EXCLUDED.total_price,
+			currency = EXCLUDED.currency,
+			shipping_address = EXCLUDED.shipping_address,
+			status = EXCLUDED.status,
+			parent_id = EXCLUDED.parent_id,
+			seller_id = EXCLUDED.seller_id,
+			updated_at = NOW()
+		RETURNING id
+	`, o.key, o.userID, pq.Array(o.productIDs), string(items), o.quantity, o.total.Decimal(), o.total.Currency(),
+		address, o.status, o.parentID, o.sellerID).Scan(&id)
+	return id, err
+}
+
+// generateProducts досоздаёт синтетические товары seed-product-000001..N.
+// Уже существующие не пересоздаются, поэтому повторный запуск с тем же N ничего не делает.
+func generateProducts(ctx context.Context, tx *sql.Tx, n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	var existing int
+	err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM product_service.products WHERE name LIKE $1`, generatedProductPrefix+"%",
+	).Scan(&existing)
+	if err != nil {
+		return err
+	}
+	if existing >= n {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO product_service.products (name, description, price, created_at, updated_at)
+		SELECT $1 || lpad(g::text, 6, '0'),
+			'Generated for load testing',
+			round((1 + (g * 37) % 100000) / 100.0, 2),
+			NOW(), NOW()
+		FROM generate_series($2::int, $3::int) AS g
+	`, generatedProductPrefix, existing+1, n)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("seed: %d generated products", n-existing)
+	return nil
+}
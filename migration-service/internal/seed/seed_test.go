@@ -0,0 +1,146 @@
+package seed_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/migration-service/internal/seed"
+)
+
+// TestSeeder_DevFixtures загружает фикстуры на схему из миграций: изменение ключей или колонок,
+// на которые опирается seed, ломает тест, а не docker compose --profile seed.
+func TestSeeder_DevFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	fixtures, err := seed.Load("../../fixtures/dev.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seeder := seed.NewSeeder(sqlDB)
+	opts := seed.Options{GeneratedProducts: 20}
+	// Второй запуск проверяет, что повторная загрузка обновляет записи, а не дублирует их
+	for run := 1; run <= 2; run++ {
+		if err := seeder.Run(ctx, fixtures, opts); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if err := sqlDB.QueryRowContext(ctx, query).Scan(&n); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return n
+	}
+
+	if n := count(`SELECT COUNT(*) FROM user_service.users`); n != len(fixtures.Users) {
+		t.Errorf("expected %d users, got %d", len(fixtures.Users), n)
+	}
+	if n := count(`SELECT COUNT(*) FROM product_service.products`); n != len(fixtures.Products)+opts.GeneratedProducts {
+		t.Errorf("expected %d products, got %d", len(fixtures.Products)+opts.GeneratedProducts, n)
+	}
+
+	cartItems := 0
+	for _, c := range fixtures.Carts {
+		cartItems += len(c.Items)
+	}
+	if n := count(`SELECT COUNT(*) FROM cart_service.cart_items`); n != cartItems {
+		t.Errorf("expected %d cart items, got %d", cartItems, n)
+	}
+	if n := count(`SELECT COUNT(*) FROM user_service.sellers`); n != len(fixtures.Sellers) {
+		t.Errorf("expected %d sellers, got %d", len(fixtures.Sellers), n)
+	}
+	if n := count(`SELECT COUNT(*) FROM order_service.orders`); n != expectedOrders(fixtures) {
+		t.Errorf("expected %d orders with sub-orders, got %d", expectedOrders(fixtures), n)
+	}
+	if n := count(`SELECT COUNT(*) FROM order_service.orders WHERE parent_id IS NOT NULL`); n == 0 {
+		t.Error("expected sub-orders for orders with seller products")
+	}
+	if n := count(`
+		SELECT COUNT(*) FROM order_service.orders
+		WHERE jsonb_array_length(items) = 0 OR shipping_address IS NULL
+	`); n != 0 {
+		t.Errorf("%d orders without items or shipping address", n)
+	}
+	if n := count(`
+		SELECT COUNT(*) FROM order_service.orders p
+		WHERE p.parent_id IS NULL
+			AND EXISTS (SELECT 1 FROM order_service.orders s WHERE s.parent_id = p.id)
+			AND p.total_price <> (SELECT SUM(s.total_price) FROM order_service.orders s WHERE s.parent_id = p.id)
+	`); n != 0 {
+		t.Errorf("%d orders whose sub-orders don't add up to the total", n)
+	}
+
+	// Правка фикстуры обновляет заказ по ключу: меняется количество, а подзаказ продавца,
+	// чьего товара в заказе больше нет, удаляется
+	edited := fixtures
+	edited.Orders = append([]seed.OrderFixture(nil), fixtures.Orders...)
+	first := edited.Orders[0]
+	first.Items = []seed.CartItemFixture{{Product: "iPhone 15", Quantity: 5}}
+	edited.Orders[0] = first
+	if err := seeder.Run(ctx, edited, opts); err != nil {
+		t.Fatalf("edited run: %v", err)
+	}
+
+	if n := count(`SELECT COUNT(*) FROM order_service.orders`); n != expectedOrders(edited) {
+		t.Errorf("after edit: expected %d orders with sub-orders, got %d", expectedOrders(edited), n)
+	}
+	var quantity int
+	err = sqlDB.QueryRowContext(ctx,
+		`SELECT quantity FROM order_service.orders WHERE fixture_key = $1`, first.Key,
+	).Scan(&quantity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quantity != 5 {
+		t.Errorf("expected edited order quantity 5, got %d", quantity)
+	}
+}
+
+func TestSeeder_OrderKeys(t *testing.T) {
+	order := seed.OrderFixture{User: "alex@email.com", Items: []seed.CartItemFixture{{Product: "Cable", Quantity: 1}}}
+	fixtures := seed.Fixtures{
+		Users:    []seed.UserFixture{{Name: "Alex", Email: "alex@email.com", Password: "secret"}},
+		Products: []seed.ProductFixture{{Name: "Cable", Price: "1"}},
+	}
+
+	for name, orders := range map[string][]seed.OrderFixture{
+		"missing key":   {order},
+		"duplicate key": {withKey(order, "a"), withKey(order, "a")},
+	} {
+		f := fixtures
+		f.Orders = orders
+		if err := seed.NewSeeder(sqlDB).Run(context.Background(), f, seed.Options{}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func withKey(o seed.OrderFixture, key string) seed.OrderFixture {
+	o.Key = key
+	return o
+}
+
+// expectedOrders считает заказы вместе с подзаказами: заказ с товарами продавцов делится
+// по одному подзаказу на продавца, товары маркетплейса — отдельный подзаказ
+func expectedOrders(f seed.Fixtures) int {
+	sellers := make(map[string]string, len(f.Products))
+	for _, p := range f.Products {
+		sellers[p.Name] = p.Seller
+	}
+
+	n := 0
+	for _, o := range f.Orders {
+		groups := map[string]bool{}
+		for _, item := range o.Items {
+			groups[sellers[item.Product]] = true
+		}
+		n++
+		if len(groups) > 1 || !groups[""] {
+			n += len(groups)
+		}
+	}
+	return n
+}
@@ -1 +0,0 @@
-DROP SCHEMA IF EXISTS user_service CASCADE;
\ No newline at end of file
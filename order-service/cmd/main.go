@@ -6,13 +6,17 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/cache"
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/db"
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/handler"
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/repository"
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/userclient"
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/migrations"
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/pkg/kafka"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/migrator"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
@@ -32,6 +36,16 @@ func main() {
 
 	fmt.Println("Connected to PostgreSQl")
 
+	// По умолчанию миграции накатывает migration-service; флаг позволяет сервису
+	// применить свои миграции самому с теми же проверками и ограничениями
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		svc := migrator.Service{Name: "order-service", Schema: migrations.Schema, FS: migrations.FS}
+		if err := migrator.Apply(ctx, dbpool, svc, migrator.TimeoutsFromEnv()); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		log.Println("Migrations applied")
+	}
+
 	kafkaBroker := os.Getenv("KAFKA_BROKER")
 	kafkaTopic := "logs"
 
@@ -41,14 +55,36 @@ func main() {
 
 	orderRepo := repository.NewOrderRepository(dbpool)
 	orderService := service.NewOrderService(orderRepo, producer, redisCache)
+	// Через user-service продавец получает доступ к своим подзаказам
+	if userServiceURL := os.Getenv("USER_SERVICE_URL"); userServiceURL != "" {
+		orderService.WithSellers(userclient.NewSellerClient(userServiceURL, 2*time.Second))
+	} else {
+		log.Println("USER_SERVICE_URL не задан, подзаказы продавцов недоступны")
+	}
 	orederHandler := handler.NewOrderHandler(orderService)
 
+	// После удаления аккаунта в user-service адреса в заказах пользователя обезличиваются
+	if kafkaBroker != "" {
+		userEventsTopic := os.Getenv("USER_EVENTS_TOPIC")
+		if userEventsTopic == "" {
+			userEventsTopic = "user-events"
+		}
+		userEvents := kafka.NewUserEventsConsumer(kafkaBroker, userEventsTopic, "order-service", orderService)
+		defer userEvents.Close()
+		go userEvents.Run(ctx)
+		log.Println("User events consumer started")
+	}
+
 	router := mux.NewRouter()
 
-	router.HandleFunc("/orders", orederHandler.Create).Methods("POST")
 	router.HandleFunc("/orders", orederHandler.GetAll).Methods("GET")
+	router.HandleFunc("/orders/seller", orederHandler.SellerOrders).Methods("GET")
 	router.HandleFunc("/orders/{id}", orederHandler.GetByID).Methods("GET")
 	router.HandleFunc("/orders/{id}", orederHandler.Delete).Methods("DELETE")
+	// Заказ создаёт только cart-service: продавцы и цены позиций берутся из корзины, а не от клиента.
+	// api-gateway проксирует лишь /orders, поэтому /internal/ снаружи недоступен.
+	router.HandleFunc("/internal/orders", orederHandler.Create).Methods("POST")
+	router.HandleFunc("/internal/users/{user_id:[0-9]+}/export", orederHandler.ExportUser).Methods("GET")
 
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Order service OK"))
@@ -1,14 +1,72 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
 
 type Order struct {
-	ID         int64     `json:"id"`
-	UserID     int64     `json:"user_id"`
-	ProductIDs []int64   `json:"product_ids"`
-	Quantity   int       `json:"quantity"`
-	TotalPrice float64   `json:"total_price"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         int64   `json:"id"`
+	UserID     int64   `json:"user_id"`
+	ProductIDs []int64 `json:"product_ids"`
+	// Items — позиции заказа с вариантами товаров; у старых заказов пусто
+	Items      []OrderItem `json:"items,omitempty"`
+	Quantity   int         `json:"quantity"`
+	TotalPrice money.Money `json:"total_price"`
+	// BaseTotal — итог в валюте товаров, если заказ оформлен в другой валюте
+	BaseTotal money.Money `json:"base_total,omitzero"`
+	// ExchangeRate — курс на момент оформления: сколько единиц TotalPrice за единицу BaseTotal.
+	// Сохраняется вместе с заказом, поэтому обновление курсов не меняет суммы старых заказов
+	ExchangeRate string `json:"exchange_rate,omitempty"`
+	// ShippingAddress — копия адреса доставки на момент оформления; у старых заказов пусто
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	// ParentID и SellerID заполнены у подзаказов: заказ с товарами нескольких продавцов
+	// делится по продавцам, SellerID == nil — товары самого маркетплейса
+	ParentID *int64 `json:"parent_id,omitempty"`
+	SellerID *int64 `json:"seller_id,omitempty"`
+	// SubOrders — подзаказы продавцов; заполняется только у заказа покупателя
+	SubOrders []Order   `json:"sub_orders,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var (
+	ErrForbidden = errors.New("forbidden")
+	// ErrOrderNotFound возвращается и для чужих заказов, чтобы не раскрывать их существование
+	ErrOrderNotFound = errors.New("order not found")
+)
+
+// SellerAccess — права пользователя из user-service: SellerID заполнен у действующего продавца
+type SellerAccess struct {
+	UserID   int64 `json:"user_id"`
+	SellerID int64 `json:"seller_id,omitempty"`
+	Admin    bool  `json:"admin"`
+}
+
+// Address — адрес доставки, скопированный из адресной книги user-service.
+// ID указывает на исходный адрес, который мог с тех пор измениться или быть удалён.
+type Address struct {
+	ID         int64  `json:"id,omitempty"`
+	Recipient  string `json:"recipient"`
+	Phone      string `json:"phone,omitempty"`
+	Country    string `json:"country"`
+	Region     string `json:"region,omitempty"`
+	City       string `json:"city"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	PostalCode string `json:"postal_code"`
+}
+
+// OrderItem — позиция заказа: товар, его вариант (0 — товар без вариантов) и количество.
+// SellerID — продавец-владелец товара (0 — маркетплейс), LineTotal — стоимость позиции
+// в валюте заказа; по ним итог делится между подзаказами продавцов.
+type OrderItem struct {
+	ProductID int64       `json:"product_id"`
+	VariantID int64       `json:"variant_id,omitempty"`
+	Quantity  int         `json:"quantity"`
+	SellerID  int64       `json:"seller_id,omitempty"`
+	LineTotal money.Money `json:"line_total,omitzero"`
 }
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -18,6 +19,7 @@ func NewOrderHandler(svc service.OrderServiceInterface) *OrderHandler {
 	return &OrderHandler{svc: svc}
 }
 
+// Create — POST /internal/orders: заказ из корзины, его передаёт только cart-service
 func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var order domain.Order
 	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
@@ -33,8 +35,26 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+// GetAll — GET /orders: заказы пользователя из X-User-ID.
+// GET /orders?all=true — заказы всех покупателей, только для администратора.
 func (h *OrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.svc.GetAll(r.Context())
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var orders []domain.Order
+	var err error
+	if r.URL.Query().Get("all") == "true" {
+		orders, err = h.svc.GetAll(r.Context(), userID)
+	} else {
+		orders, err = h.svc.GetByUserID(r.Context(), userID)
+	}
+	if errors.Is(err, domain.ErrForbidden) {
+		http.Error(w, "only admins can list all orders", http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -43,7 +63,14 @@ func (h *OrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(orders)
 }
 
+// GetByID — GET /orders/{id}: заказ виден покупателю и администратору, подзаказ — ещё и его
+// продавцу; остальным — 404
 func (h *OrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
 	idStr := mux.Vars(r)["id"]
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -51,16 +78,25 @@ func (h *OrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.svc.GetByID(r.Context(), id)
-	if err != nil {
+	order, err := h.svc.GetByID(r.Context(), userID, id)
+	if errors.Is(err, domain.ErrOrderNotFound) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	json.NewEncoder(w).Encode(order)
 }
 
 func (h *OrderHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
 	idStr := mux.Vars(r)["id"]
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -68,9 +104,59 @@ func (h *OrderHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Delete(r.Context(), id); err != nil {
+	err = h.svc.Delete(r.Context(), userID, id)
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// SellerOrders — GET /orders/seller: подзаказы продавца, которым является пользователь из X-User-ID
+func (h *OrderHandler) SellerOrders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orders, err := h.svc.GetForSeller(r.Context(), userID)
+	if errors.Is(err, domain.ErrForbidden) {
+		http.Error(w, "only active sellers can view seller orders", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"orders": orders})
+}
+
+// ExportUser — GET /internal/users/{user_id}/export: заказы пользователя для архива,
+// который собирает user-service. Маршрут не публикуется через gateway.
+func (h *OrderHandler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusBadRequest)
+		return
+	}
+
+	orders, err := h.svc.GetByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"orders": orders})
+}
+
+// currentUserID — пользователь, которого api-gateway выставил в X-User-ID после проверки JWT
+func currentUserID(r *http.Request) (int64, bool) {
+	userID, err := strconv.ParseInt(r.Header.Get("X-User-ID"), 10, 64)
+	return userID, err == nil && userID > 0
+}
@@ -0,0 +1,210 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/cache"
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/pkg/kafka"
+	"github.com/gorilla/mux"
+)
+
+const (
+	userA  = 1
+	userB  = 2
+	seller = 5
+	admin  = 9
+
+	sellerID      = 100
+	otherSellerID = 200
+)
+
+// fakeRepo хранит заказы в памяти; подзаказы лежат отдельными строками, как в Postgres
+type fakeRepo struct {
+	repository.OrderRepositoryInterface
+	orders map[int64]domain.Order
+}
+
+func (r *fakeRepo) GetOrderByID(ctx context.Context, id int64) (domain.Order, error) {
+	order, ok := r.orders[id]
+	if !ok {
+		return domain.Order{}, domain.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (r *fakeRepo) GetAllOrders(ctx context.Context) ([]domain.Order, error) {
+	var orders []domain.Order
+	for _, o := range r.orders {
+		if o.ParentID == nil {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+func (r *fakeRepo) GetOrdersByUserID(ctx context.Context, userID int64) ([]domain.Order, error) {
+	orders := []domain.Order{}
+	for _, o := range r.orders {
+		if o.UserID == userID && o.ParentID == nil {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+func (r *fakeRepo) DeleteOrder(ctx context.Context, id int64) error {
+	delete(r.orders, id)
+	return nil
+}
+
+type fakeSellers map[int64]domain.SellerAccess
+
+func (f fakeSellers) Access(ctx context.Context, userID int64) (domain.SellerAccess, error) {
+	return f[userID], nil
+}
+
+type nopProducer struct{}
+
+func (nopProducer) SendOrderCreated(ctx context.Context, event kafka.OrderCreatedEvent) error {
+	return nil
+}
+
+func newRouter(repo *fakeRepo) *mux.Router {
+	// Redis недоступен: заказы всегда читаются из репозитория
+	svc := service.NewOrderService(repo, nopProducer{}, cache.NewRedisCache("127.0.0.1:1")).
+		WithSellers(fakeSellers{
+			admin:  {UserID: admin, Admin: true},
+			seller: {UserID: seller, SellerID: sellerID},
+		})
+	h := handler.NewOrderHandler(svc)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/orders", h.GetAll).Methods("GET")
+	r.HandleFunc("/orders/{id}", h.GetByID).Methods("GET")
+	r.HandleFunc("/orders/{id}", h.Delete).Methods("DELETE")
+	return r
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{orders: map[int64]domain.Order{
+		10: {ID: 10, UserID: userA, ShippingAddress: &domain.Address{Recipient: "Anna", Phone: "+79990000000", Country: "RU", City: "Moscow", Line1: "Tverskaya 1"}},
+		20: {ID: 20, UserID: userB},
+		// Заказ покупателя A с подзаказами двух продавцов
+		30: {ID: 30, UserID: userA},
+		31: {ID: 31, UserID: userA, ParentID: ptr(30), SellerID: ptr(sellerID)},
+		32: {ID: 32, UserID: userA, ParentID: ptr(30), SellerID: ptr(otherSellerID)},
+	}}
+}
+
+func ptr(v int64) *int64 {
+	return &v
+}
+
+func do(r http.Handler, method, path string, userID int64) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if userID != 0 {
+		req.Header.Set("X-User-ID", fmt.Sprint(userID))
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetByID_OnlyOwnerOrAdmin(t *testing.T) {
+	r := newRouter(newFakeRepo())
+
+	if rec := do(r, "GET", "/orders/10", userA); rec.Code != http.StatusOK {
+		t.Fatalf("owner: expected 200, got %d", rec.Code)
+	}
+	rec := do(r, "GET", "/orders/10", userB)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("other user: expected 404, got %d", rec.Code)
+	}
+	var leaked domain.Order
+	if json.Unmarshal(rec.Body.Bytes(), &leaked) == nil && leaked.ShippingAddress != nil {
+		t.Fatalf("shipping address leaked to another user: %+v", leaked.ShippingAddress)
+	}
+	if rec := do(r, "GET", "/orders/10", admin); rec.Code != http.StatusOK {
+		t.Fatalf("admin: expected 200, got %d", rec.Code)
+	}
+	if rec := do(r, "GET", "/orders/10", 0); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without X-User-ID: expected 401, got %d", rec.Code)
+	}
+	if rec := do(r, "GET", "/orders/404", userA); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing order: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestGetAll_FiltersByUser(t *testing.T) {
+	r := newRouter(newFakeRepo())
+
+	rec := do(r, "GET", "/orders", userB)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var orders []domain.Order
+	if err := json.Unmarshal(rec.Body.Bytes(), &orders); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].ID != 20 {
+		t.Fatalf("user B must see only order 20, got %+v", orders)
+	}
+
+	if rec := do(r, "GET", "/orders?all=true", userB); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-admin listing all orders: expected 403, got %d", rec.Code)
+	}
+	rec = do(r, "GET", "/orders?all=true", admin)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("admin: expected 200, got %d", rec.Code)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &orders); err != nil || len(orders) != 3 {
+		t.Fatalf("admin must see all orders, got %+v, %v", orders, err)
+	}
+}
+
+func TestDelete_OnlyOwnerOrAdmin(t *testing.T) {
+	repo := newFakeRepo()
+	r := newRouter(repo)
+
+	if rec := do(r, "DELETE", "/orders/10", userB); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user: expected 404, got %d", rec.Code)
+	}
+	if _, ok := repo.orders[10]; !ok {
+		t.Fatal("order of user A must not be deleted by user B")
+	}
+	if rec := do(r, "DELETE", "/orders/10", userA); rec.Code != http.StatusNoContent {
+		t.Fatalf("owner: expected 204, got %d", rec.Code)
+	}
+}
+
+func TestGetByID_SellerSeesOnlyOwnSubOrder(t *testing.T) {
+	r := newRouter(newFakeRepo())
+
+	if rec := do(r, "GET", "/orders/31", seller); rec.Code != http.StatusOK {
+		t.Fatalf("own sub-order: expected 200, got %d", rec.Code)
+	}
+	if rec := do(r, "GET", "/orders/32", seller); rec.Code != http.StatusNotFound {
+		t.Fatalf("sub-order of another seller: expected 404, got %d", rec.Code)
+	}
+	if rec := do(r, "GET", "/orders/30", seller); rec.Code != http.StatusNotFound {
+		t.Fatalf("buyer's order: expected 404, got %d", rec.Code)
+	}
+	if rec := do(r, "GET", "/orders?all=true", seller); rec.Code != http.StatusForbidden {
+		t.Fatalf("seller listing all orders: expected 403, got %d", rec.Code)
+	}
+	if rec := do(r, "GET", "/orders/30", userA); rec.Code != http.StatusOK {
+		t.Fatalf("buyer: expected 200, got %d", rec.Code)
+	}
+	if rec := do(r, "DELETE", "/orders/31", seller); rec.Code != http.StatusNotFound {
+		t.Fatalf("seller deleting sub-order: expected 404, got %d", rec.Code)
+	}
+}
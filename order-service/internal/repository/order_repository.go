@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,29 +21,107 @@ type OrderRepositoryInterface interface {
 	GetOrderByID(ctx context.Context, id int64) (domain.Order, error)
 	GetAllOrders(ctx context.Context) ([]domain.Order, error)
 	DeleteOrder(ctx context.Context, id int64) error
+	GetOrdersByUserID(ctx context.Context, userID int64) ([]domain.Order, error)
+	PseudonymizeUserOrders(ctx context.Context, userID int64) ([]int64, error)
+	GetOrdersBySellerID(ctx context.Context, sellerID int64) ([]domain.Order, error)
 }
 
 func NewOrderRepository(db *pgxpool.Pool) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
+// CreateOrder сохраняет заказ и его подзаказы продавцов в одной транзакции
 func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertOrder(ctx, tx, order); err != nil {
+		return err
+	}
+	for i := range order.SubOrders {
+		sub := &order.SubOrders[i]
+		sub.ParentID = &order.ID
+		if err := insertOrder(ctx, tx, sub); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func insertOrder(ctx context.Context, tx pgx.Tx, order *domain.Order) error {
 	query := `
-		INSERT INTO order_service.orders (user_id, product_ids, quantity, total_price, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		INSERT INTO order_service.orders (user_id, product_ids, items, quantity, total_price, currency, base_total, base_currency, exchange_rate, shipping_address, status, parent_id, seller_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW(), NOW())
 		RETURNING id
 	`
 	productIDs := fmt.Sprintf("{%s}", strings.Trim(strings.Join(strings.Fields(fmt.Sprint(order.ProductIDs)), ","), "[]"))
 
-	err := r.db.QueryRow(ctx, query, order.UserID, productIDs, order.Quantity, order.TotalPrice, order.Status).Scan(&order.ID)
+	// Курс и итог в валюте товаров хранятся только для заказов, оформленных в другой валюте
+	var baseTotal, baseCurrency, exchangeRate *string
+	if order.ExchangeRate != "" {
+		amount, currency := order.BaseTotal.Decimal(), string(order.BaseTotal.Currency())
+		baseTotal, baseCurrency, exchangeRate = &amount, &currency, &order.ExchangeRate
+	}
 
-	return err
+	items := order.Items
+	if items == nil {
+		items = []domain.OrderItem{}
+	}
+
+	return tx.QueryRow(ctx, query, order.UserID, productIDs, items, order.Quantity, order.TotalPrice.Decimal(), order.TotalPrice.Currency(),
+		baseTotal, baseCurrency, exchangeRate, order.ShippingAddress, order.Status, order.ParentID, order.SellerID).Scan(&order.ID)
 }
 
 func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]domain.Order, error) {
-	query := `SELECT id, user_id, product_ids, quantity, total_price, status, created_at, updated_at FROM order_service.orders`
+	query := `SELECT ` + orderColumns + ` FROM order_service.orders WHERE parent_id IS NULL`
+
+	orders, err := r.queryOrders(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return orders, r.attachSubOrders(ctx, orders)
+}
+
+func (r *OrderRepository) GetOrdersByUserID(ctx context.Context, userID int64) ([]domain.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM order_service.orders
+		WHERE user_id = $1 AND parent_id IS NULL
+		ORDER BY id
+	`
+	orders, err := r.queryOrders(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []domain.Order{}
+	}
+	return orders, r.attachSubOrders(ctx, orders)
+}
 
-	rows, err := r.db.Query(ctx, query)
+// GetOrdersBySellerID возвращает подзаказы продавца, новые первыми
+func (r *OrderRepository) GetOrdersBySellerID(ctx context.Context, sellerID int64) ([]domain.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM order_service.orders
+		WHERE seller_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	orders, err := r.queryOrders(ctx, query, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []domain.Order{}
+	}
+	return orders, nil
+}
+
+func (r *OrderRepository) queryOrders(ctx context.Context, query string, args ...any) ([]domain.Order, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,45 +129,113 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]domain.Order, err
 
 	var orders []domain.Order
 	for rows.Next() {
-		var o domain.Order
-		var productIDs []int64
-
-		err := rows.Scan(
-			&o.ID,
-			&o.UserID,
-			&productIDs,
-			&o.Quantity,
-			&o.TotalPrice,
-			&o.Status,
-			&o.CreatedAt,
-			&o.UpdatedAt,
-		)
+		o, err := scanOrder(rows)
 		if err != nil {
 			return nil, err
 		}
-		o.ProductIDs = productIDs
 		orders = append(orders, o)
 	}
+	return orders, rows.Err()
+}
 
-	return orders, nil
+// attachSubOrders заполняет SubOrders у заказов покупателя одним запросом
+func (r *OrderRepository) attachSubOrders(ctx context.Context, orders []domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(orders))
+	index := make(map[int64]int, len(orders))
+	for i, o := range orders {
+		ids[i], index[o.ID] = o.ID, i
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM order_service.orders WHERE parent_id = ANY($1) ORDER BY id`
+	subs, err := r.queryOrders(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		i := index[*sub.ParentID]
+		orders[i].SubOrders = append(orders[i].SubOrders, sub)
+	}
+	return nil
+}
+
+// PseudonymizeUserOrders убирает из адресов доставки получателя, телефон, улицу и индекс.
+// Заказы остаются для бухгалтерии и статистики, страна и город сохраняются.
+// Возвращает ID изменённых заказов, чтобы сбросить их кеш.
+func (r *OrderRepository) PseudonymizeUserOrders(ctx context.Context, userID int64) ([]int64, error) {
+	query := `
+		UPDATE order_service.orders
+		SET shipping_address = jsonb_build_object(
+				'recipient', '', 'country', shipping_address->'country', 'city', shipping_address->'city',
+				'line1', '', 'postal_code', ''),
+			updated_at = NOW()
+		WHERE user_id = $1 AND shipping_address IS NOT NULL AND shipping_address->>'recipient' <> ''
+		RETURNING id
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
 }
 
 func (r *OrderRepository) GetOrderByID(ctx context.Context, id int64) (domain.Order, error) {
 	query := `
-		SELECT id, user_id, product_ids, quantity, total_price, status, created_at, updated_at 
+		SELECT ` + orderColumns + `
 		FROM order_service.orders
 		WHERE id = $1
 	`
+	order, err := scanOrder(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Order{}, domain.ErrOrderNotFound
+	}
+	if err != nil || order.ParentID != nil {
+		return order, err
+	}
+	orders := []domain.Order{order}
+	if err := r.attachSubOrders(ctx, orders); err != nil {
+		return domain.Order{}, err
+	}
+	return orders[0], nil
+}
+
+const orderColumns = `id, user_id, product_ids, items, quantity, total_price, currency, base_total, base_currency, exchange_rate, shipping_address, status, parent_id, seller_id, created_at, updated_at`
+
+// scanOrder читает строку orders: сумма хранится в NUMERIC, валюта — в отдельной колонке.
+// base_total, base_currency и exchange_rate заполнены только у заказов, оформленных не в валюте товаров.
+func scanOrder(row pgx.Row) (domain.Order, error) {
 	var o domain.Order
 	var productIDs []int64
+	var totalPrice, currency string
+	var baseTotal, baseCurrency, exchangeRate *string
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := row.Scan(
 		&o.ID,
 		&o.UserID,
 		&productIDs,
+		&o.Items,
 		&o.Quantity,
-		&o.TotalPrice,
+		&totalPrice,
+		&currency,
+		&baseTotal,
+		&baseCurrency,
+		&exchangeRate,
+		&o.ShippingAddress,
 		&o.Status,
+		&o.ParentID,
+		&o.SellerID,
 		&o.CreatedAt,
 		&o.UpdatedAt,
 	)
@@ -94,6 +243,25 @@ func (r *OrderRepository) GetOrderByID(ctx context.Context, id int64) (domain.Or
 		return domain.Order{}, err
 	}
 	o.ProductIDs = productIDs
+	if len(o.Items) == 0 {
+		o.Items = nil
+	}
+
+	o.TotalPrice, err = money.Parse(totalPrice, money.Currency(currency))
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if baseTotal != nil && baseCurrency != nil && exchangeRate != nil {
+		if o.BaseTotal, err = money.Parse(*baseTotal, money.Currency(*baseCurrency)); err != nil {
+			return domain.Order{}, err
+		}
+		rate, err := money.ParseRate(*exchangeRate)
+		if err != nil {
+			return domain.Order{}, err
+		}
+		o.ExchangeRate = money.FormatRate(rate)
+	}
 	return o, nil
 }
 
@@ -11,42 +11,86 @@ import (
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/cache"
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/userclient"
 	"github.com/OvsyannikovAlexandr/marketplace/order-service/pkg/kafka"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 )
 
 type OrderServise struct {
 	repo     repository.OrderRepositoryInterface
 	producer kafka.Producer
 	cache    *cache.RedisCache
+	sellers  userclient.SellersInterface
 }
 
 type OrderServiceInterface interface {
 	Create(ctx context.Context, order domain.Order) error
-	GetByID(ctx context.Context, id int64) (domain.Order, error)
-	GetAll(ctx context.Context) ([]domain.Order, error)
-	Delete(ctx context.Context, id int64) error
+	// GetByID возвращает заказ его покупателю и администратору, а подзаказ — ещё и его продавцу
+	GetByID(ctx context.Context, userID, id int64) (domain.Order, error)
+	// GetAll возвращает заказы всех покупателей; доступно только администратору
+	GetAll(ctx context.Context, userID int64) ([]domain.Order, error)
+	Delete(ctx context.Context, userID, id int64) error
+	GetByUserID(ctx context.Context, userID int64) ([]domain.Order, error)
+	GetForSeller(ctx context.Context, userID int64) ([]domain.Order, error)
 }
 
 func NewOrderService(repo repository.OrderRepositoryInterface, producer kafka.Producer, cache *cache.RedisCache) *OrderServise {
 	return &OrderServise{repo: repo, producer: producer, cache: cache}
 }
 
+// WithSellers подключает user-service, через который продавец получает свои подзаказы,
+// а администратор — доступ ко всем заказам. Без него заказ видит только покупатель.
+func (s *OrderServise) WithSellers(sellers userclient.SellersInterface) *OrderServise {
+	s.sellers = sellers
+	return s
+}
+
 func (s *OrderServise) Create(ctx context.Context, order domain.Order) error {
 	if order.UserID == 0 {
 		return errors.New("user id must be set")
 	}
+	// Если переданы позиции, список товаров и общее количество выводятся из них
+	if len(order.Items) > 0 {
+		order.ProductIDs, order.Quantity = nil, 0
+		for _, item := range order.Items {
+			if item.ProductID <= 0 || item.VariantID < 0 || item.Quantity <= 0 {
+				return errors.New("order items must have product id and positive quantity")
+			}
+			order.ProductIDs = append(order.ProductIDs, item.ProductID)
+			order.Quantity += item.Quantity
+		}
+	}
 	if len(order.ProductIDs) == 0 {
 		return errors.New("product IDs can't be empty")
 	}
 	if order.Quantity <= 0 {
 		return errors.New("quantity must be positive")
 	}
-	if order.TotalPrice < 0 {
+	if order.TotalPrice.IsNegative() {
 		return errors.New("total price can't be negative")
 	}
+	if order.TotalPrice.Currency() == "" {
+		order.TotalPrice = money.Zero(money.DefaultCurrency)
+	}
+	if (order.BaseTotal.Currency() != "") != (order.ExchangeRate != "") {
+		return errors.New("base total and exchange rate must be set together")
+	}
+	if order.ExchangeRate != "" {
+		rate, err := money.ParseRate(order.ExchangeRate)
+		if err != nil {
+			return err
+		}
+		order.ExchangeRate = money.FormatRate(rate)
+	}
+	if a := order.ShippingAddress; a != nil && (a.Recipient == "" || a.Country == "" || a.City == "" || a.Line1 == "") {
+		return errors.New("shipping address must have recipient, country, city and line1")
+	}
 	if order.Status == "" {
 		order.Status = "new"
 	}
+	if err := splitBySeller(&order); err != nil {
+		return err
+	}
 
 	err := s.repo.CreateOrder(ctx, &order)
 	if err != nil {
@@ -54,12 +98,17 @@ func (s *OrderServise) Create(ctx context.Context, order domain.Order) error {
 	}
 
 	_ = s.producer.SendOrderCreated(ctx, kafka.OrderCreatedEvent{
-		OrderID:    order.ID,
-		UserID:     order.UserID,
-		ProductIDs: order.ProductIDs,
-		Quantity:   order.Quantity,
-		TotalPrice: order.TotalPrice,
-		CreatedAt:  time.Now(),
+		OrderID:         order.ID,
+		UserID:          order.UserID,
+		ProductIDs:      order.ProductIDs,
+		Items:           order.Items,
+		Quantity:        order.Quantity,
+		TotalPrice:      order.TotalPrice,
+		BaseTotal:       order.BaseTotal,
+		ExchangeRate:    order.ExchangeRate,
+		ShippingAddress: order.ShippingAddress,
+		SubOrders:       order.SubOrders,
+		CreatedAt:       time.Now(),
 	})
 
 	cacheKey := fmt.Sprintf("order:%d", order.ID)
@@ -69,11 +118,57 @@ func (s *OrderServise) Create(ctx context.Context, order domain.Order) error {
 	return nil
 }
 
-func (s *OrderServise) GetAll(ctx context.Context) ([]domain.Order, error) {
+func (s *OrderServise) GetAll(ctx context.Context, userID int64) ([]domain.Order, error) {
+	access, err := s.access(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.Admin {
+		return nil, domain.ErrForbidden
+	}
 	return s.repo.GetAllOrders(ctx)
 }
 
-func (s *OrderServise) GetByID(ctx context.Context, id int64) (domain.Order, error) {
+func (s *OrderServise) GetByID(ctx context.Context, userID, id int64) (domain.Order, error) {
+	order, err := s.getByID(ctx, id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if err := s.authorize(ctx, userID, order, true); err != nil {
+		return domain.Order{}, err
+	}
+	return order, nil
+}
+
+// authorize пропускает к заказу покупателя и администратора, а с sellerMayRead — и продавца
+// к его собственному подзаказу: заказ покупателя и чужие подзаказы продавцу не видны.
+// Остальным заказ не виден вовсе: ErrOrderNotFound, а не ErrForbidden.
+func (s *OrderServise) authorize(ctx context.Context, userID int64, order domain.Order, sellerMayRead bool) error {
+	if userID != 0 && order.UserID == userID {
+		return nil
+	}
+	access, err := s.access(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if access.Admin {
+		return nil
+	}
+	if sellerMayRead && access.SellerID != 0 && order.SellerID != nil && *order.SellerID == access.SellerID {
+		return nil
+	}
+	return domain.ErrOrderNotFound
+}
+
+// access возвращает права пользователя из user-service; без него прав нет ни у кого.
+func (s *OrderServise) access(ctx context.Context, userID int64) (domain.SellerAccess, error) {
+	if s.sellers == nil || userID == 0 {
+		return domain.SellerAccess{UserID: userID}, nil
+	}
+	return s.sellers.Access(ctx, userID)
+}
+
+func (s *OrderServise) getByID(ctx context.Context, id int64) (domain.Order, error) {
 	cacheKey := fmt.Sprintf("order:%d", id)
 
 	if cached, err := s.cache.Get(ctx, cacheKey); err == nil {
@@ -95,13 +190,125 @@ func (s *OrderServise) GetByID(ctx context.Context, id int64) (domain.Order, err
 	return order, nil
 }
 
-func (s *OrderServise) Delete(ctx context.Context, id int64) error {
-	err := s.repo.DeleteOrder(ctx, id)
+func (s *OrderServise) Delete(ctx context.Context, userID, id int64) error {
+	order, err := s.repo.GetOrderByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, userID, order, false); err != nil {
+		return err
+	}
+
+	// Подзаказы удаляются каскадно, их кеш тоже нужно сбросить
+	err = s.repo.DeleteOrder(ctx, id)
 	if err != nil {
 		return err
 	}
 	cacheKey := fmt.Sprintf("order:%d", id)
 	_ = s.cache.Delete(ctx, cacheKey)
+	for _, sub := range order.SubOrders {
+		_ = s.cache.Delete(ctx, fmt.Sprintf("order:%d", sub.ID))
+	}
+
+	return nil
+}
+
+// GetByUserID возвращает все заказы пользователя, в том числе для выгрузки его данных
+func (s *OrderServise) GetByUserID(ctx context.Context, userID int64) ([]domain.Order, error) {
+	return s.repo.GetOrdersByUserID(ctx, userID)
+}
+
+// GetForSeller возвращает подзаказы продавца, которым является пользователь.
+// Чужие подзаказы и заказ покупателя целиком продавцу не видны.
+func (s *OrderServise) GetForSeller(ctx context.Context, userID int64) ([]domain.Order, error) {
+	if s.sellers == nil {
+		return nil, errors.New("seller access is not configured")
+	}
+	if userID == 0 {
+		return nil, domain.ErrForbidden
+	}
+	access, err := s.sellers.Access(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if access.SellerID == 0 {
+		return nil, domain.ErrForbidden
+	}
+	return s.repo.GetOrdersBySellerID(ctx, access.SellerID)
+}
+
+// splitBySeller делит заказ с товарами продавцов на подзаказы, по одному на продавца
+// (позиции без продавца — подзаказ маркетплейса). Итог заказа, уже со скидками и налогами,
+// распределяется между подзаказами пропорционально стоимости позиций без потери копеек.
+func splitBySeller(order *domain.Order) error {
+	hasSellers := false
+	for _, item := range order.Items {
+		if item.SellerID < 0 {
+			return errors.New("seller id can't be negative")
+		}
+		if c := item.LineTotal.Currency(); c != "" && c != order.TotalPrice.Currency() {
+			return errors.New("line totals must be in the order currency")
+		}
+		hasSellers = hasSellers || item.SellerID != 0
+	}
+	if !hasSellers {
+		return nil
+	}
 
+	var sellerIDs []int64
+	groups := map[int64][]domain.OrderItem{}
+	for _, item := range order.Items {
+		if _, ok := groups[item.SellerID]; !ok {
+			sellerIDs = append(sellerIDs, item.SellerID)
+		}
+		groups[item.SellerID] = append(groups[item.SellerID], item)
+	}
+
+	weights := make([]int64, len(sellerIDs))
+	for i, sellerID := range sellerIDs {
+		for _, item := range groups[sellerID] {
+			weights[i] += item.LineTotal.Minor()
+		}
+	}
+	totals := order.TotalPrice.Allocate(weights)
+	var baseTotals []money.Money
+	if order.ExchangeRate != "" {
+		baseTotals = order.BaseTotal.Allocate(weights)
+	}
+
+	order.SubOrders = make([]domain.Order, len(sellerIDs))
+	for i, sellerID := range sellerIDs {
+		sub := domain.Order{
+			UserID:          order.UserID,
+			Items:           groups[sellerID],
+			TotalPrice:      totals[i],
+			ExchangeRate:    order.ExchangeRate,
+			ShippingAddress: order.ShippingAddress,
+			Status:          order.Status,
+		}
+		if baseTotals != nil {
+			sub.BaseTotal = baseTotals[i]
+		}
+		if sellerID != 0 {
+			sub.SellerID = &sellerID
+		}
+		for _, item := range sub.Items {
+			sub.ProductIDs = append(sub.ProductIDs, item.ProductID)
+			sub.Quantity += item.Quantity
+		}
+		order.SubOrders[i] = sub
+	}
+	return nil
+}
+
+// HandleUserDeleted обезличивает адреса в заказах удалённого пользователя; повторный вызов безопасен
+func (s *OrderServise) HandleUserDeleted(ctx context.Context, userID int64) error {
+	ids, err := s.repo.PseudonymizeUserOrders(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		_ = s.cache.Delete(ctx, fmt.Sprintf("order:%d", id))
+	}
 	return nil
 }
@@ -0,0 +1,53 @@
+// Package userclient — клиент user-service для order-service: права продавцов на подзаказы.
+package userclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/domain"
+)
+
+type SellersInterface interface {
+	// Access возвращает права пользователя: администратор ли он и id профиля, если он действующий продавец
+	Access(ctx context.Context, userID int64) (domain.SellerAccess, error)
+}
+
+type SellerClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewSellerClient(baseURL string, timeout time.Duration) *SellerClient {
+	return &SellerClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *SellerClient) Access(ctx context.Context, userID int64) (domain.SellerAccess, error) {
+	endpoint := fmt.Sprintf("%s/internal/users/%d/seller", c.baseURL, userID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return domain.SellerAccess{}, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return domain.SellerAccess{}, fmt.Errorf("failed to fetch seller access: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return domain.SellerAccess{}, fmt.Errorf("user-service returned status %d", resp.StatusCode)
+	}
+	var access domain.SellerAccess
+	if err := json.NewDecoder(resp.Body).Decode(&access); err != nil {
+		return domain.SellerAccess{}, err
+	}
+	return access, nil
+}
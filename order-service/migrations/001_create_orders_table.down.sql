@@ -0,0 +1 @@
+DROP TABLE IF EXISTS order_service.orders;
\ No newline at end of file
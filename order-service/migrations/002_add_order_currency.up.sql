@@ -0,0 +1 @@
+ALTER TABLE order_service.orders ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD';
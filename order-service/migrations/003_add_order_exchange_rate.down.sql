@@ -0,0 +1,3 @@
+ALTER TABLE order_service.orders DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE order_service.orders DROP COLUMN IF EXISTS base_currency;
+ALTER TABLE order_service.orders DROP COLUMN IF EXISTS base_total;
@@ -0,0 +1,5 @@
+-- Снимок пересчёта в валюту покупателя: итог в валюте товаров и курс на момент оформления.
+-- Для заказов в валюте товаров колонки остаются пустыми.
+ALTER TABLE order_service.orders ADD COLUMN IF NOT EXISTS base_total NUMERIC(10,2);
+ALTER TABLE order_service.orders ADD COLUMN IF NOT EXISTS base_currency TEXT;
+ALTER TABLE order_service.orders ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(20,10);
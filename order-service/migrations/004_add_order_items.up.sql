@@ -0,0 +1,2 @@
+-- Позиции заказа: товар, выбранный вариант (SKU) и количество
+ALTER TABLE order_service.orders ADD COLUMN IF NOT EXISTS items JSONB NOT NULL DEFAULT '[]';
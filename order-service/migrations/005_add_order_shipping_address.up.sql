@@ -0,0 +1,3 @@
+-- Снимок адреса доставки на момент оформления: изменения в адресной книге не переписывают историю.
+-- У заказов, оформленных до появления адресной книги, колонка пустая.
+ALTER TABLE order_service.orders ADD COLUMN IF NOT EXISTS shipping_address JSONB;
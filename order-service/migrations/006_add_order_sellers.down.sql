@@ -0,0 +1 @@
+ALTER TABLE order_service.orders DROP COLUMN IF EXISTS seller_id, DROP COLUMN IF EXISTS parent_id;
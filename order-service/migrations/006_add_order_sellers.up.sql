@@ -0,0 +1,6 @@
+-- Заказ с товарами нескольких продавцов делится на подзаказы: по одному на продавца.
+-- У подзаказа parent_id указывает на заказ покупателя, seller_id — на профиль продавца
+-- в user-service (NULL — товары самого маркетплейса). У обычных заказов обе колонки пустые.
+ALTER TABLE order_service.orders
+    ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES order_service.orders (id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS seller_id INTEGER;
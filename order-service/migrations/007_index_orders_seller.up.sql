@@ -0,0 +1,2 @@
+-- Подзаказы продавца: GET /orders/seller
+CREATE INDEX CONCURRENTLY IF NOT EXISTS orders_seller_id_idx ON order_service.orders (seller_id, created_at);
@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS order_service.orders_parent_id_idx;
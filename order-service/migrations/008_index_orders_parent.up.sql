@@ -0,0 +1,2 @@
+-- Подзаказы заказа покупателя
+CREATE INDEX CONCURRENTLY IF NOT EXISTS orders_parent_id_idx ON order_service.orders (parent_id);
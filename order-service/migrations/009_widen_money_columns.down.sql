@@ -0,0 +1,4 @@
+-- Суммы с тремя знаками после запятой округляются до двух
+ALTER TABLE order_service.orders
+    ALTER COLUMN total_price TYPE NUMERIC(10,2),
+    ALTER COLUMN base_total TYPE NUMERIC(10,2);
@@ -0,0 +1,7 @@
+-- Суммы в валютах с тремя знаками после запятой (KWD, BHD) не помещались в NUMERIC(10,2)
+-- и округлялись при записи. Смена масштаба переписывает таблицу, поэтому ограничение
+-- на время выполнения для этой миграции больше обычного.
+-- migrate:statement_timeout=30min
+ALTER TABLE order_service.orders
+    ALTER COLUMN total_price TYPE NUMERIC(19,4),
+    ALTER COLUMN base_total TYPE NUMERIC(19,4);
@@ -0,0 +1,3 @@
+-- Ключ фикстуры из migration-service/fixtures: по нему seed обновляет уже загруженный
+-- заказ, а не создаёт новый. У заказов, оформленных покупателями, колонка пустая.
+ALTER TABLE order_service.orders ADD COLUMN IF NOT EXISTS fixture_key TEXT;
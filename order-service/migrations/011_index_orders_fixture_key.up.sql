@@ -0,0 +1,2 @@
+-- Заказы фикстур: seed делает upsert по fixture_key
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS orders_fixture_key_idx ON order_service.orders (fixture_key) WHERE fixture_key IS NOT NULL;
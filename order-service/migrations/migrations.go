@@ -0,0 +1,10 @@
+// Package migrations содержит SQL-миграции схемы order_service, которой владеет order-service.
+package migrations
+
+import "embed"
+
+// Schema — схема PostgreSQL сервиса. Версия миграций хранится в order_service.schema_migrations.
+const Schema = "order_service"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// UserDeletedType — событие user-service об удалении аккаунта
+const UserDeletedType = "UserDeleted"
+
+// UserDeletedHandler удаляет или обезличивает данные пользователя; обработка должна быть идемпотентной,
+// потому что событие доставляется как минимум один раз
+type UserDeletedHandler interface {
+	HandleUserDeleted(ctx context.Context, userID int64) error
+}
+
+type userEvent struct {
+	Type   string `json:"type"`
+	UserID int64  `json:"user_id"`
+}
+
+// UserEventsConsumer читает события user-service и передаёт UserDeleted обработчику.
+// Смещение фиксируется только после успешной обработки.
+type UserEventsConsumer struct {
+	reader  *kafka.Reader
+	handler UserDeletedHandler
+}
+
+func NewUserEventsConsumer(brokerAddress, topic, groupID string, handler UserDeletedHandler) *UserEventsConsumer {
+	return &UserEventsConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{brokerAddress},
+			Topic:   topic,
+			GroupID: groupID,
+		}),
+		handler: handler,
+	}
+}
+
+// Run обрабатывает события до отмены ctx. Ошибка обработки повторяется с растущей
+// паузой, чтобы удаление данных не терялось при временной недоступности базы.
+func (c *UserEventsConsumer) Run(ctx context.Context) {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Ошибка чтения событий пользователей: %v", err)
+			}
+			return
+		}
+
+		for delay := time.Second; ; delay = min(delay*2, time.Minute) {
+			err := handleUserEvent(ctx, msg.Value, c.handler)
+			if err == nil {
+				break
+			}
+			log.Printf("Не удалось обработать событие пользователя %s: %v", string(msg.Key), err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			log.Printf("Не удалось зафиксировать смещение событий пользователей: %v", err)
+		}
+	}
+}
+
+func (c *UserEventsConsumer) Close() error {
+	return c.reader.Close()
+}
+
+// handleUserEvent пропускает события других типов и сообщения, которые не удаётся разобрать
+func handleUserEvent(ctx context.Context, value []byte, handler UserDeletedHandler) error {
+	var event userEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("Пропущено нечитаемое событие пользователя: %v", err)
+		return nil
+	}
+	if event.Type != UserDeletedType || event.UserID == 0 {
+		return nil
+	}
+	if err := handler.HandleUserDeleted(ctx, event.UserID); err != nil {
+		return err
+	}
+	log.Printf("Заказы пользователя %d обезличены", event.UserID)
+	return nil
+}
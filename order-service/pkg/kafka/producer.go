@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/order-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/segmentio/kafka-go"
 )
 
@@ -17,12 +19,20 @@ type Producer interface {
 }
 
 type OrderCreatedEvent struct {
-	OrderID    int64     `json:"order_id"`
-	UserID     int64     `json:"user_id"`
-	ProductIDs []int64   `json:"product_ids"`
-	Quantity   int       `json:"quantity"`
-	TotalPrice float64   `json:"total_price"`
-	CreatedAt  time.Time `json:"created_at"`
+	OrderID    int64   `json:"order_id"`
+	UserID     int64   `json:"user_id"`
+	ProductIDs []int64 `json:"product_ids"`
+	// Items — позиции заказа с вариантами товаров
+	Items      []domain.OrderItem `json:"items,omitempty"`
+	Quantity   int                `json:"quantity"`
+	TotalPrice money.Money        `json:"total_price"`
+	// BaseTotal и ExchangeRate заполнены, если заказ оформлен не в валюте товаров
+	BaseTotal       money.Money     `json:"base_total,omitzero"`
+	ExchangeRate    string          `json:"exchange_rate,omitempty"`
+	ShippingAddress *domain.Address `json:"shipping_address,omitempty"`
+	// SubOrders — подзаказы продавцов, если в заказе товары нескольких продавцов
+	SubOrders []domain.Order `json:"sub_orders,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 func NewOrderProducer(brokerAddress, topic string) *OrderProducer {
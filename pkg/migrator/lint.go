@@ -0,0 +1,174 @@
+package migrator
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding — потенциально опасная операция в файле миграции.
+type Finding struct {
+	Service  string
+	File     string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s/%s: %s", f.Severity, f.Service, f.File, f.Message)
+}
+
+var (
+	reDropSchemaCascade = regexp.MustCompile(`(?is)\bDROP\s+SCHEMA\b.*\bCASCADE\b`)
+	reCreateIndex       = regexp.MustCompile(`(?is)^CREATE\s+(UNIQUE\s+)?INDEX\b`)
+	reConcurrently      = regexp.MustCompile(`(?is)^CREATE\s+(UNIQUE\s+)?INDEX\s+CONCURRENTLY\b`)
+	reIndexTable        = regexp.MustCompile(`(?is)\bON\s+(ONLY\s+)?([\w."]+)`)
+	reCreateTable       = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+(IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+	reAddColumn         = regexp.MustCompile(`(?is)\bADD\s+(COLUMN\s+)?(IF\s+NOT\s+EXISTS\s+)?[\w"]+\s+(?:\([^)]*\)|[^,;(])*`)
+	reAddConstraint     = regexp.MustCompile(`(?i)^ADD\s+(CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK|EXCLUDE)\b`)
+	reNotNull           = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	reDefault           = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	reAlterTable        = regexp.MustCompile(`(?is)^ALTER\s+TABLE\b`)
+	reDropTableOrColumn = regexp.MustCompile(`(?is)^(DROP\s+TABLE\b|ALTER\s+TABLE\b.*\bDROP\s+COLUMN\b)`)
+)
+
+// Lint проверяет миграции сервиса на операции, опасные для продакшена:
+// DROP SCHEMA ... CASCADE в down-файлах (удаляет и таблицу версий),
+// создание индекса без CONCURRENTLY на существующей таблице,
+// добавление NOT NULL колонки без DEFAULT и удаление данных в up-файлах.
+func Lint(svc Service) ([]Finding, error) {
+	entries, err := fs.ReadDir(svc.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sql") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var findings []Finding
+	for _, name := range names {
+		data, err := fs.ReadFile(svc.FS, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range lintFile(name, string(data)) {
+			f.Service = svc.Name
+			findings = append(findings, f)
+		}
+	}
+
+	return findings, nil
+}
+
+// HasErrors сообщает, есть ли среди замечаний блокирующие.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func lintFile(name, sql string) []Finding {
+	isDown := strings.HasSuffix(name, ".down.sql")
+	statements := splitStatements(sql)
+
+	createdTables := map[string]bool{}
+	for _, stmt := range statements {
+		if m := reCreateTable.FindStringSubmatch(stmt); m != nil {
+			createdTables[normalizeIdent(m[2])] = true
+		}
+	}
+
+	var findings []Finding
+	add := func(sev Severity, format string, args ...any) {
+		findings = append(findings, Finding{File: name, Severity: sev, Message: fmt.Sprintf(format, args...)})
+	}
+
+	hasConcurrently := false
+	for _, stmt := range statements {
+		if isDown && reDropSchemaCascade.MatchString(stmt) {
+			add(SeverityError, "DROP SCHEMA ... CASCADE in down migration also drops the schema_migrations table")
+		}
+
+		if reCreateIndex.MatchString(stmt) {
+			if reConcurrently.MatchString(stmt) {
+				hasConcurrently = true
+			} else if m := reIndexTable.FindStringSubmatch(stmt); m != nil && !createdTables[normalizeIdent(m[2])] {
+				add(SeverityWarning, "CREATE INDEX on existing table %s without CONCURRENTLY locks writes", m[2])
+			}
+		}
+
+		if reAlterTable.MatchString(stmt) {
+			for _, col := range reAddColumn.FindAllString(stmt, -1) {
+				if reAddConstraint.MatchString(col) {
+					continue
+				}
+				if reNotNull.MatchString(col) && !reDefault.MatchString(col) {
+					add(SeverityError, "adding NOT NULL column without DEFAULT fails on non-empty table: %s", strings.TrimSpace(col))
+				}
+			}
+		}
+
+		if !isDown && reDropTableOrColumn.MatchString(stmt) {
+			add(SeverityWarning, "up migration drops data: %s", firstLine(stmt))
+		}
+	}
+
+	// CREATE INDEX CONCURRENTLY нельзя выполнять внутри транзакции,
+	// а многооператорный файл выполняется как одна неявная транзакция.
+	if hasConcurrently && len(statements) > 1 {
+		add(SeverityError, "CREATE INDEX CONCURRENTLY must be the only statement in its migration file")
+	}
+	if hasConcurrently && len(parseDirectives(sql)) > 0 {
+		add(SeverityError, "timeout directives cannot be combined with CREATE INDEX CONCURRENTLY")
+	}
+
+	return findings
+}
+
+// splitStatements убирает комментарии и делит SQL на операторы по ';'.
+// Строковые литералы с ';' в миграциях не встречаются, поэтому полноценный парсер не нужен.
+func splitStatements(sql string) []string {
+	var lines []string
+	for _, line := range strings.Split(sql, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+
+	var statements []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+func normalizeIdent(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, `"`, ""))
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package migrator
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+func lintSQL(t *testing.T, name, sql string) []Finding {
+	t.Helper()
+	svc := Service{Name: "test-service", FS: fstest.MapFS{name: {Data: []byte(sql)}}}
+	findings, err := Lint(svc)
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	return findings
+}
+
+func TestLint_DropSchemaCascadeInDown(t *testing.T) {
+	findings := lintSQL(t, "001_init.down.sql", `DROP SCHEMA IF EXISTS user_service CASCADE;`)
+	if !HasErrors(findings) {
+		t.Fatalf("expected error for DROP SCHEMA CASCADE, got %v", findings)
+	}
+}
+
+func TestLint_IndexWithoutConcurrently(t *testing.T) {
+	findings := lintSQL(t, "002_idx.up.sql", `CREATE INDEX idx_users_email ON user_service.users (email);`)
+	if len(findings) != 1 || findings[0].Severity != SeverityWarning {
+		t.Fatalf("expected one warning, got %v", findings)
+	}
+}
+
+func TestLint_IndexOnNewTableIsAllowed(t *testing.T) {
+	sql := `
+		CREATE TABLE user_service.sessions (id SERIAL PRIMARY KEY, user_id INT NOT NULL);
+		CREATE INDEX idx_sessions_user ON user_service.sessions (user_id);
+	`
+	if findings := lintSQL(t, "002_sessions.up.sql", sql); len(findings) != 0 {
+		t.Fatalf("expected no findings, got %v", findings)
+	}
+}
+
+func TestLint_ConcurrentIndexMustBeAlone(t *testing.T) {
+	sql := `
+		CREATE INDEX CONCURRENTLY idx_users_name ON user_service.users (name);
+		ALTER TABLE user_service.users ADD COLUMN phone TEXT;
+	`
+	if findings := lintSQL(t, "003_idx.up.sql", sql); !HasErrors(findings) {
+		t.Fatalf("expected error for CONCURRENTLY with other statements, got %v", findings)
+	}
+}
+
+func TestLint_NotNullColumnWithoutDefault(t *testing.T) {
+	findings := lintSQL(t, "004_col.up.sql", `ALTER TABLE user_service.users ADD COLUMN locale TEXT NOT NULL;`)
+	if !HasErrors(findings) {
+		t.Fatalf("expected error for NOT NULL without DEFAULT, got %v", findings)
+	}
+
+	findings = lintSQL(t, "004_col.up.sql", `ALTER TABLE user_service.users ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';`)
+	if len(findings) != 0 {
+		t.Fatalf("expected no findings with DEFAULT, got %v", findings)
+	}
+	// Запятая внутри NUMERIC(10,2) не должна обрывать описание колонки
+	findings = lintSQL(t, "005_price.up.sql", `ALTER TABLE cart_service.cart_items ADD COLUMN x NUMERIC(10,2) NOT NULL;`)
+	if !HasErrors(findings) {
+		t.Fatalf("expected error for NUMERIC(10,2) NOT NULL without DEFAULT, got %v", findings)
+	}
+
+	findings = lintSQL(t, "005_price.up.sql", `ALTER TABLE cart_service.cart_items
+		ADD COLUMN x NUMERIC(10,2) NOT NULL DEFAULT 0,
+		ADD COLUMN y NUMERIC(10,2) NOT NULL;`)
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "y NUMERIC(10,2)") {
+		t.Fatalf("expected one error for column y, got %v", findings)
+	}
+}
+
+func TestTimeoutSource_WrapsDirectives(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_a.up.sql": {Data: []byte("-- migrate:lock_timeout=30s\nALTER TABLE t ADD COLUMN c TEXT;")},
+		"002_b.up.sql": {Data: []byte("ALTER TABLE t ADD COLUMN d TEXT;")},
+	}
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		t.Fatalf("iofs.New failed: %v", err)
+	}
+	wrapped := withTimeouts(src, Timeouts{LockTimeout: "5s"})
+
+	r, _, err := wrapped.ReadUp(1)
+	if err != nil {
+		t.Fatalf("ReadUp failed: %v", err)
+	}
+	body, _ := io.ReadAll(r)
+	if !strings.HasPrefix(string(body), "SET lock_timeout = '30s';") || !strings.HasSuffix(string(body), "SET lock_timeout = '5s';") {
+		t.Fatalf("expected override and restore of lock_timeout, got %q", body)
+	}
+
+	r, _, err = wrapped.ReadUp(2)
+	if err != nil {
+		t.Fatalf("ReadUp failed: %v", err)
+	}
+	body, _ = io.ReadAll(r)
+	if string(body) != "ALTER TABLE t ADD COLUMN d TEXT;" {
+		t.Fatalf("expected migration without directives to be unchanged, got %q", body)
+	}
+}
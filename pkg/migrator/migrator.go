@@ -0,0 +1,100 @@
+// Package migrator применяет встроенные миграции сервисов. Им пользуются и
+// migration-service (по умолчанию), и сами сервисы при старте с MIGRATE_ON_START=true:
+// в обоих случаях миграции проходят один и тот же линтер и выполняются с
+// одинаковыми lock_timeout и statement_timeout.
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Service — набор миграций, которым владеет один сервис.
+// Каждый сервис ведёт собственную версию в <schema>.schema_migrations.
+type Service struct {
+	Name   string
+	Schema string
+	FS     fs.FS
+}
+
+// VersionTable — экранированное имя таблицы версий сервиса.
+func (s Service) VersionTable() string {
+	return pgx.Identifier{s.Schema, postgres.DefaultMigrationsTable}.Sanitize()
+}
+
+// Apply проверяет миграции сервиса линтером и применяет их через соединение с
+// настройками пула (хост, TLS, учётные данные) и заданными ограничениями.
+// Используется сервисом при старте; замечания уровня error блокируют запуск.
+func Apply(ctx context.Context, pool *pgxpool.Pool, svc Service, timeouts Timeouts) error {
+	findings, err := Lint(svc)
+	if err != nil {
+		return err
+	}
+	for _, f := range findings {
+		log.Println(f)
+	}
+	if HasErrors(findings) {
+		return errors.New("migration lint failed")
+	}
+
+	connConfig := pool.Config().ConnConfig
+	for k, v := range timeouts.RuntimeParams() {
+		connConfig.RuntimeParams[k] = v
+	}
+	db := stdlib.OpenDB(*connConfig)
+	defer db.Close()
+
+	return Up(ctx, db, svc, timeouts)
+}
+
+// Up создаёт схему сервиса и применяет ещё не применённые up-миграции.
+// Линтер не вызывает: вызывающий проверяет миграции заранее.
+func Up(ctx context.Context, db *sql.DB, svc Service, timeouts Timeouts) error {
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{svc.Schema}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	src, err := iofs.New(svc.FS, ".")
+	if err != nil {
+		return err
+	}
+
+	// Отдельное соединение, а не WithInstance: при закрытии драйвер закрыл бы весь db,
+	// а migration-service применяет на нём миграции нескольких сервисов подряд.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{
+		MigrationsTable:       svc.VersionTable(),
+		MigrationsTableQuoted: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", withTimeouts(src, timeouts), "postgres", driver)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package migrator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// Timeouts — ограничения, с которыми выполняется каждая миграция.
+// Значения в формате PostgreSQL ("5s", "1min"); "0" отключает ограничение.
+type Timeouts struct {
+	LockTimeout      string
+	StatementTimeout string
+}
+
+// DefaultTimeouts — ограничения по умолчанию для migration-service и запуска миграций сервисом.
+var DefaultTimeouts = Timeouts{LockTimeout: "5s", StatementTimeout: "1min"}
+
+// TimeoutsFromEnv берёт ограничения из MIGRATE_LOCK_TIMEOUT и MIGRATE_STATEMENT_TIMEOUT,
+// как migration-service, и подставляет DefaultTimeouts для незаданных.
+func TimeoutsFromEnv() Timeouts {
+	t := DefaultTimeouts
+	if v := os.Getenv("MIGRATE_LOCK_TIMEOUT"); v != "" {
+		t.LockTimeout = v
+	}
+	if v := os.Getenv("MIGRATE_STATEMENT_TIMEOUT"); v != "" {
+		t.StatementTimeout = v
+	}
+	return t
+}
+
+// RuntimeParams возвращает параметры подключения, которые PostgreSQL применяет
+// ко всей сессии миграций, включая файлы с CREATE INDEX CONCURRENTLY.
+func (t Timeouts) RuntimeParams() map[string]string {
+	params := map[string]string{}
+	if t.LockTimeout != "" {
+		params["lock_timeout"] = t.LockTimeout
+	}
+	if t.StatementTimeout != "" {
+		params["statement_timeout"] = t.StatementTimeout
+	}
+	return params
+}
+
+// Директива в файле миграции переопределяет ограничение только для этого файла:
+//
+//	-- migrate:lock_timeout=30s
+//	-- migrate:statement_timeout=10min
+var reDirective = regexp.MustCompile(`(?m)^\s*--\s*migrate:(lock_timeout|statement_timeout)\s*=\s*([0-9]+(?:ms|s|min|h|d)?)\s*$`)
+
+func parseDirectives(sql string) map[string]string {
+	directives := map[string]string{}
+	for _, m := range reDirective.FindAllStringSubmatch(sql, -1) {
+		directives[m[1]] = m[2]
+	}
+	return directives
+}
+
+// timeoutSource оборачивает источник миграций и для файлов с директивами
+// выставляет переопределённые ограничения перед миграцией и возвращает умолчания после неё.
+type timeoutSource struct {
+	source.Driver
+	defaults Timeouts
+}
+
+func withTimeouts(src source.Driver, defaults Timeouts) source.Driver {
+	return &timeoutSource{Driver: src, defaults: defaults}
+}
+
+func (s *timeoutSource) ReadUp(version uint) (io.ReadCloser, string, error) {
+	r, identifier, err := s.Driver.ReadUp(version)
+	if err != nil {
+		return nil, identifier, err
+	}
+	body, err := s.wrap(r)
+	return body, identifier, err
+}
+
+func (s *timeoutSource) ReadDown(version uint) (io.ReadCloser, string, error) {
+	r, identifier, err := s.Driver.ReadDown(version)
+	if err != nil {
+		return nil, identifier, err
+	}
+	body, err := s.wrap(r)
+	return body, identifier, err
+}
+
+func (s *timeoutSource) wrap(r io.ReadCloser) (io.ReadCloser, error) {
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	directives := parseDirectives(string(data))
+	if len(directives) == 0 {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	defaults := s.defaults.RuntimeParams()
+	var before, after strings.Builder
+	for _, name := range []string{"lock_timeout", "statement_timeout"} {
+		value, ok := directives[name]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&before, "SET %s = '%s';\n", name, value)
+		if def, ok := defaults[name]; ok {
+			fmt.Fprintf(&after, "\nSET %s = '%s';", name, def)
+		} else {
+			fmt.Fprintf(&after, "\nRESET %s;", name)
+		}
+	}
+
+	sql := before.String() + strings.TrimRight(string(data), "; \n\t") + ";" + after.String()
+	return io.NopCloser(strings.NewReader(sql)), nil
+}
@@ -0,0 +1,472 @@
+// Package money — денежные суммы в минимальных единицах валюты (центах, копейках)
+// вместе с кодом валюты ISO 4217.
+//
+// Правила:
+//   - сложение и сравнение возможны только в одной валюте, иначе ErrCurrencyMismatch;
+//     нулевое значение Money{} (ноль без валюты) совместимо с любой валютой;
+//   - умножение на количество точное, умножение на дробный коэффициент (налог, процент
+//     скидки) округляется явно переданным правилом Rounding;
+//   - результат, не помещающийся в int64 минимальных единиц, — ошибка ErrOverflow,
+//     а не переход через ноль: это касается сложения, вычитания, умножения и пересчёта;
+//   - Parse не округляет: сумма с лишними значащими знаками после запятой — ошибка.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	// ErrOverflow — результат не помещается в int64 минимальных единиц
+	ErrOverflow = errors.New("amount overflow")
+)
+
+// Currency — трёхбуквенный код валюты ISO 4217
+type Currency string
+
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	RUB Currency = "RUB"
+)
+
+// DefaultCurrency — валюта сумм, для которых она не указана явно
+const DefaultCurrency = USD
+
+// exponents — число знаков после запятой у валют, для которых оно отличается от двух
+var exponents = map[Currency]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"BHD": 3,
+	"JOD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+}
+
+// ParseCurrency проверяет код валюты; регистр и пробелы по краям не важны.
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !c.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	return c, nil
+}
+
+func (c Currency) Valid() bool {
+	if len(c) != 3 {
+		return false
+	}
+	for i := 0; i < len(c); i++ {
+		if c[i] < 'A' || c[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Exponent — число знаков после запятой (2 для USD: 1 доллар = 100 центов).
+func (c Currency) Exponent() int {
+	if e, ok := exponents[c]; ok {
+		return e
+	}
+	return 2
+}
+
+// Rounding — правило округления до минимальной единицы валюты
+type Rounding int
+
+const (
+	// HalfUp — половина округляется от нуля: 0.125 → 0.13, -0.125 → -0.13
+	HalfUp Rounding = iota
+	// HalfEven — банковское округление, половина к чётному: 0.125 → 0.12, 0.135 → 0.14
+	HalfEven
+	// Down — отбрасывание дробной части (к нулю): 0.129 → 0.12
+	Down
+)
+
+// Money — сумма в минимальных единицах валюты. Нулевое значение — ноль без валюты.
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// New создаёт сумму из минимальных единиц: New(1999, USD) — 19.99 USD.
+func New(minor int64, currency Currency) Money {
+	return Money{amount: minor, currency: currency}
+}
+
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// Parse разбирает десятичную запись суммы ("19.99", "-5", "10.500").
+// Нули после значащих знаков допускаются, лишние значащие знаки — нет.
+func Parse(amount string, currency Currency) (Money, error) {
+	if !currency.Valid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+	}
+
+	s := strings.TrimSpace(amount)
+	neg := false
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		neg, s = true, rest
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+
+	intPart, frac, _ := strings.Cut(s, ".")
+	if (intPart == "" && frac == "") || !digits(intPart) || !digits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+
+	exp := currency.Exponent()
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places for %s", ErrInvalidAmount, amount, exp, currency)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	var minor int64
+	if d := strings.TrimLeft(intPart+frac, "0"); d != "" {
+		var err error
+		if minor, err = strconv.ParseInt(d, 10, 64); err != nil {
+			return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, amount)
+		}
+	}
+	if neg {
+		minor = -minor
+	}
+	return Money{amount: minor, currency: currency}, nil
+}
+
+// MustParse — Parse для констант и тестов, паникует при ошибке.
+func MustParse(amount string, currency Currency) Money {
+	m, err := Parse(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor — сумма в минимальных единицах валюты.
+func (m Money) Minor() int64 {
+	return m.amount
+}
+
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// common возвращает общую валюту двух сумм.
+func (m Money) common(o Money) (Currency, error) {
+	switch {
+	case m.currency == o.currency:
+		return m.currency, nil
+	case m.currency == "" && m.amount == 0:
+		return o.currency, nil
+	case o.currency == "" && o.amount == 0:
+		return m.currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+}
+
+// Add складывает суммы одной валюты; при переполнении int64 возвращается ErrOverflow.
+func (m Money) Add(o Money) (Money, error) {
+	c, err := m.common(o)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + o.amount
+	if (o.amount > 0 && sum < m.amount) || (o.amount < 0 && sum > m.amount) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, o)
+	}
+	return Money{amount: sum, currency: c}, nil
+}
+
+// Sub вычитает суммы одной валюты; при переполнении int64 возвращается ErrOverflow.
+func (m Money) Sub(o Money) (Money, error) {
+	c, err := m.common(o)
+	if err != nil {
+		return Money{}, err
+	}
+	diff := m.amount - o.amount
+	if (o.amount > 0 && diff > m.amount) || (o.amount < 0 && diff < m.amount) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, o)
+	}
+	return Money{amount: diff, currency: c}, nil
+}
+
+// Cmp сравнивает суммы: -1, если m < o, 0 при равенстве, +1, если m > o.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.common(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < o.amount:
+		return -1, nil
+	case m.amount > o.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Mul умножает сумму на целое количество, округление не требуется.
+// Если произведение не помещается в int64, возвращается ErrOverflow.
+func (m Money) Mul(n int64) (Money, error) {
+	p := new(big.Int).Mul(big.NewInt(m.amount), big.NewInt(n))
+	if !p.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, n)
+	}
+	return Money{amount: p.Int64(), currency: m.currency}, nil
+}
+
+// MulRat умножает сумму на точный дробный коэффициент и округляет по правилу mode.
+// Если результат не помещается в int64, возвращается ErrOverflow.
+func (m Money) MulRat(r *big.Rat, mode Rounding) (Money, error) {
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(m.amount), r)
+	amount, err := round(v, mode)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %s * %s", err, m, r.FloatString(RatePrecision))
+	}
+	return Money{amount: amount, currency: m.currency}, nil
+}
+
+// Scale умножает сумму на коэффициент (ставку налога, курс). Коэффициент берётся
+// в кратчайшей десятичной записи, поэтому 0.2 означает ровно 1/5, а не ближайший double.
+func (m Money) Scale(factor float64, mode Rounding) (Money, error) {
+	return m.MulRat(ratio(factor), mode)
+}
+
+// Percent возвращает p процентов от суммы, округлённые по правилу mode.
+func (m Money) Percent(p float64, mode Rounding) (Money, error) {
+	r := ratio(p)
+	return m.MulRat(r.Quo(r, big.NewRat(100, 1)), mode)
+}
+
+func ratio(f float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	if !ok {
+		panic(fmt.Sprintf("money: invalid factor %v", f))
+	}
+	return r
+}
+
+// round округляет до целого числа минимальных единиц; ErrOverflow, если оно не помещается в int64.
+func round(r *big.Rat, mode Rounding) (int64, error) {
+	q := roundInt(r, mode)
+	if !q.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return q.Int64(), nil
+}
+
+func roundInt(r *big.Rat, mode Rounding) *big.Int {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 || mode == Down {
+		return q
+	}
+
+	// сравниваем остаток с половиной делителя
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	c := twice.Cmp(r.Denom())
+	if c > 0 || (c == 0 && (mode == HalfUp || q.Bit(0) == 1)) {
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Min возвращает меньшую из двух сумм одной валюты.
+func Min(a, b Money) (Money, error) {
+	c, err := a.Cmp(b)
+	if err != nil {
+		return Money{}, err
+	}
+	if c > 0 {
+		return b, nil
+	}
+	return a, nil
+}
+
+// Sum складывает суммы; результат в валюте currency, даже если amounts пуст.
+func Sum(currency Currency, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, a := range amounts {
+		var err error
+		if total, err = total.Add(a); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Allocate делит сумму на части пропорционально весам (например, стоимостям позиций)
+// без потери копеек: части округляются вниз, а остаток по одной минимальной единице
+// получают части с наибольшей отброшенной дробью. Сумма частей всегда равна m.
+// Если все веса нулевые, сумма делится поровну.
+func (m Money) Allocate(weights []int64) []Money {
+	parts := make([]Money, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+
+	// Веса и сумма считаются в big.Int: сумма весов может не поместиться в int64,
+	// а модуль math.MinInt64 — тем более. Каждая часть по модулю не больше m.
+	total := new(big.Int)
+	for _, w := range weights {
+		total.Add(total, big.NewInt(max(w, 0)))
+	}
+	shares := make([]*big.Int, len(weights))
+	for i, w := range weights {
+		switch {
+		case total.Sign() == 0:
+			shares[i] = big.NewInt(1)
+		case w > 0:
+			shares[i] = big.NewInt(w)
+		default:
+			shares[i] = new(big.Int)
+		}
+	}
+	if total.Sign() == 0 {
+		total.SetInt64(int64(len(weights)))
+	}
+
+	amount := new(big.Int).Abs(big.NewInt(m.amount))
+	quotients := make([]*big.Int, len(weights))
+	rems := make([]*big.Int, len(weights))
+	left := new(big.Int).Set(amount)
+	for i, w := range shares {
+		quotients[i], rems[i] = new(big.Int).QuoRem(new(big.Int).Mul(amount, w), total, new(big.Int))
+		left.Sub(left, quotients[i])
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return rems[order[a]].Cmp(rems[order[b]]) > 0 })
+	// Остаток меньше числа частей: каждая дробь меньше единицы
+	for _, i := range order[:left.Int64()] {
+		quotients[i].Add(quotients[i], big.NewInt(1))
+	}
+	for i, q := range quotients {
+		if m.amount < 0 {
+			q.Neg(q)
+		}
+		parts[i] = Money{amount: q.Int64(), currency: m.currency}
+	}
+	return parts
+}
+
+// Decimal — десятичная запись суммы с точностью валюты: "19.99", "-0.50", "100" для JPY.
+func (m Money) Decimal() string {
+	exp := m.currency.Exponent()
+
+	sign, s := "", strconv.FormatInt(m.amount, 10)
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = "-", rest
+	}
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + string(m.currency)
+}
+
+type jsonMoney struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON кодирует сумму как {"amount":"19.99","currency":"USD"}: сумма
+// строкой, чтобы клиенты не теряли точность при разборе в float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Amount: m.Decimal(), Currency: m.currency})
+}
+
+// UnmarshalJSON принимает объект {"amount":"19.99","currency":"USD"} (amount может
+// быть и числом), а для совместимости со старыми клиентами — просто число или
+// строку, которые считаются суммой в DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	var amount json.Number
+	currency := DefaultCurrency
+	switch data[0] {
+	case '{':
+		var v struct {
+			Amount   json.Number `json:"amount"`
+			Currency string      `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		if v.Currency != "" {
+			c, err := ParseCurrency(v.Currency)
+			if err != nil {
+				return err
+			}
+			currency = c
+		}
+		amount = v.Amount
+	default:
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+		}
+	}
+
+	parsed, err := Parse(amount.String(), currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,284 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency Currency
+		minor    int64
+		decimal  string
+	}{
+		{"19.99", USD, 1999, "19.99"},
+		{"0.1", USD, 10, "0.10"},
+		{"10.500", USD, 1050, "10.50"},
+		{"-0.05", USD, -5, "-0.05"},
+		{"+3", EUR, 300, "3.00"},
+		{".5", USD, 50, "0.50"},
+		{"100", "JPY", 100, "100"},
+		{"1.234", "KWD", 1234, "1.234"},
+	}
+
+	for _, tt := range tests {
+		m, err := Parse(tt.amount, tt.currency)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.amount, err)
+		}
+		if m.Minor() != tt.minor || m.Decimal() != tt.decimal {
+			t.Fatalf("Parse(%q) = %d (%s), want %d (%s)", tt.amount, m.Minor(), m.Decimal(), tt.minor, tt.decimal)
+		}
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	for _, amount := range []string{"", ".", "1.999", "1e3", "1/3", "abc", "1.2.3", "99999999999999999999"} {
+		if _, err := Parse(amount, USD); !errors.Is(err, ErrInvalidAmount) {
+			t.Fatalf("Parse(%q): expected ErrInvalidAmount, got %v", amount, err)
+		}
+	}
+	if _, err := Parse("1", "usd"); !errors.Is(err, ErrInvalidCurrency) {
+		t.Fatalf("expected ErrInvalidCurrency, got %v", err)
+	}
+}
+
+func TestAdd_CurrencyMismatch(t *testing.T) {
+	usd := MustParse("1.00", USD)
+
+	if _, err := usd.Add(MustParse("1.00", EUR)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := Sum(EUR, usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected ErrCurrencyMismatch, got %v", err)
+	}
+
+	sum, err := Money{}.Add(usd)
+	if err != nil || sum != usd {
+		t.Fatalf("zero value must be neutral, got %v, %v", sum, err)
+	}
+}
+
+func TestSum_IsExact(t *testing.T) {
+	// 0.1 + 0.2 в float64 даёт 0.30000000000000004
+	total, err := Sum(USD, MustParse("0.10", USD), MustParse("0.20", USD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != MustParse("0.30", USD) {
+		t.Fatalf("expected 0.30, got %s", total)
+	}
+}
+
+func TestAddSub_Overflow(t *testing.T) {
+	tests := []struct {
+		a, b   int64
+		sum    int64
+		sumOK  bool
+		diff   int64
+		diffOK bool
+	}{
+		{math.MaxInt64, 0, math.MaxInt64, true, math.MaxInt64, true},
+		{math.MaxInt64, 1, 0, false, math.MaxInt64 - 1, true},
+		{math.MaxInt64, -1, math.MaxInt64 - 1, true, 0, false},
+		{math.MinInt64, 0, math.MinInt64, true, math.MinInt64, true},
+		{math.MinInt64, -1, 0, false, math.MinInt64 + 1, true},
+		{math.MinInt64, 1, math.MinInt64 + 1, true, 0, false},
+		{math.MaxInt64, math.MinInt64, -1, true, 0, false},
+		{math.MinInt64, math.MinInt64, 0, false, 0, true},
+		{math.MaxInt64, math.MaxInt64, 0, false, 0, true},
+		{0, math.MinInt64, math.MinInt64, true, 0, false},
+		{-1, math.MinInt64, math.MaxInt64, false, math.MaxInt64, true},
+	}
+
+	for _, tt := range tests {
+		a, b := New(tt.a, USD), New(tt.b, USD)
+
+		sum, err := a.Add(b)
+		if tt.sumOK && (err != nil || sum.Minor() != tt.sum) {
+			t.Fatalf("%d + %d = %v, %v, want %d", tt.a, tt.b, sum.Minor(), err, tt.sum)
+		}
+		if !tt.sumOK && !errors.Is(err, ErrOverflow) {
+			t.Fatalf("%d + %d: expected ErrOverflow, got %v, %v", tt.a, tt.b, sum.Minor(), err)
+		}
+
+		diff, err := a.Sub(b)
+		if tt.diffOK && (err != nil || diff.Minor() != tt.diff) {
+			t.Fatalf("%d - %d = %v, %v, want %d", tt.a, tt.b, diff.Minor(), err, tt.diff)
+		}
+		if !tt.diffOK && !errors.Is(err, ErrOverflow) {
+			t.Fatalf("%d - %d: expected ErrOverflow, got %v, %v", tt.a, tt.b, diff.Minor(), err)
+		}
+	}
+
+	// Большой итог корзины не должен молча стать отрицательным
+	if _, err := Sum(USD, New(math.MaxInt64, USD), MustParse("0.01", USD)); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("expected ErrOverflow from Sum, got %v", err)
+	}
+}
+
+func TestScale_Overflow(t *testing.T) {
+	tests := []struct {
+		amount int64
+		factor float64
+		want   int64
+		ok     bool
+	}{
+		{math.MaxInt64, 1, math.MaxInt64, true},
+		{math.MaxInt64, 0.5, math.MaxInt64/2 + 1, true},
+		{math.MaxInt64, 1.5, 0, false},
+		{math.MinInt64, 1, math.MinInt64, true},
+		{math.MinInt64, -1, 0, false},
+		{math.MinInt64, 2, 0, false},
+		{math.MaxInt64, -1, -math.MaxInt64, true},
+	}
+
+	for _, tt := range tests {
+		got, err := New(tt.amount, USD).Scale(tt.factor, HalfUp)
+		if tt.ok && (err != nil || got.Minor() != tt.want) {
+			t.Fatalf("%d * %v = %v, %v, want %d", tt.amount, tt.factor, got.Minor(), err, tt.want)
+		}
+		if !tt.ok && !errors.Is(err, ErrOverflow) {
+			t.Fatalf("%d * %v: expected ErrOverflow, got %v, %v", tt.amount, tt.factor, got.Minor(), err)
+		}
+	}
+
+	if _, err := New(math.MaxInt64, USD).Percent(200, HalfUp); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("expected ErrOverflow from Percent, got %v", err)
+	}
+}
+
+func TestMul_Overflow(t *testing.T) {
+	got, err := MustParse("19.99", USD).Mul(3)
+	if err != nil || got != MustParse("59.97", USD) {
+		t.Fatalf("expected 59.97, got %v, %v", got, err)
+	}
+
+	for _, n := range []int64{math.MaxInt64/1000 + 1, -(math.MaxInt64/1000 + 1), math.MinInt64} {
+		if _, err := MustParse("10.00", USD).Mul(n); !errors.Is(err, ErrOverflow) {
+			t.Fatalf("expected ErrOverflow for %d, got %v", n, err)
+		}
+	}
+	if _, err := New(math.MinInt64, USD).Mul(-1); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("expected ErrOverflow for MinInt64 * -1, got %v", err)
+	}
+}
+
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		amount string
+		factor float64
+		mode   Rounding
+		want   string
+	}{
+		{"0.25", 0.5, HalfUp, "0.13"},
+		{"0.25", 0.5, HalfEven, "0.12"},
+		{"0.27", 0.5, HalfEven, "0.14"},
+		{"0.29", 0.5, Down, "0.14"},
+		{"-0.25", 0.5, HalfUp, "-0.13"},
+		{"10.05", 0.2, HalfUp, "2.01"},
+		{"19.99", 0.07, HalfUp, "1.40"},
+	}
+
+	for _, tt := range tests {
+		got, err := MustParse(tt.amount, USD).Scale(tt.factor, tt.mode)
+		if err != nil || got.Decimal() != tt.want {
+			t.Fatalf("%s * %v (mode %d) = %s, want %s", tt.amount, tt.factor, tt.mode, got.Decimal(), tt.want)
+		}
+	}
+
+	if got, err := MustParse("33.33", USD).Percent(12.5, HalfUp); err != nil || got.Decimal() != "4.17" {
+		t.Fatalf("12.5%% of 33.33 = %s, want 4.17", got.Decimal())
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount  string
+		weights []int64
+		want    []string
+	}{
+		{"10.00", []int64{1, 1, 1}, []string{"3.34", "3.33", "3.33"}},
+		{"9.00", []int64{3000, 1500}, []string{"6.00", "3.00"}},
+		{"0.05", []int64{1, 0, 1}, []string{"0.03", "0.00", "0.02"}},
+		{"1.00", []int64{0, 0}, []string{"0.50", "0.50"}},
+		{"-1.00", []int64{1, 2}, []string{"-0.33", "-0.67"}},
+	}
+
+	for _, tt := range tests {
+		parts := MustParse(tt.amount, USD).Allocate(tt.weights)
+		sum, err := Sum(USD, parts...)
+		if err != nil || sum != MustParse(tt.amount, USD) {
+			t.Fatalf("parts of %s must add up, got %v, %v", tt.amount, sum, err)
+		}
+		for i, part := range parts {
+			if part.Decimal() != tt.want[i] {
+				t.Fatalf("Allocate(%s, %v)[%d] = %s, want %s", tt.amount, tt.weights, i, part.Decimal(), tt.want[i])
+			}
+		}
+	}
+}
+
+func TestAllocate_Boundaries(t *testing.T) {
+	tests := []struct {
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		// Сумма весов больше math.MaxInt64
+		{100, []int64{math.MaxInt64, math.MaxInt64, math.MaxInt64}, []int64{34, 33, 33}},
+		{math.MaxInt64, []int64{math.MaxInt64, 1}, []int64{math.MaxInt64 - 1, 1}},
+		{math.MaxInt64, []int64{1}, []int64{math.MaxInt64}},
+		// Модуль math.MinInt64 не помещается в int64
+		{math.MinInt64, []int64{1}, []int64{math.MinInt64}},
+		{math.MinInt64, []int64{1, 1}, []int64{math.MinInt64 / 2, math.MinInt64 / 2}},
+		{math.MinInt64, []int64{math.MaxInt64, math.MaxInt64, 0}, []int64{math.MinInt64 / 2, math.MinInt64 / 2, 0}},
+	}
+
+	for _, tt := range tests {
+		parts := New(tt.amount, USD).Allocate(tt.weights)
+		sum, err := Sum(USD, parts...)
+		if err != nil || sum.Minor() != tt.amount {
+			t.Fatalf("parts of %d must add up, got %v, %v", tt.amount, sum, err)
+		}
+		for i, part := range parts {
+			if part.Minor() != tt.want[i] {
+				t.Fatalf("Allocate(%d, %v)[%d] = %d, want %d", tt.amount, tt.weights, i, part.Minor(), tt.want[i])
+			}
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(MustParse("19.9", EUR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":"19.90","currency":"EUR"}` {
+		t.Fatalf("unexpected json %s", data)
+	}
+
+	inputs := map[string]Money{
+		`{"amount":"19.90","currency":"eur"}`: MustParse("19.90", EUR),
+		`{"amount":5,"currency":"RUB"}`:       MustParse("5", RUB),
+		`99.99`:                               MustParse("99.99", DefaultCurrency),
+		`"12.30"`:                             MustParse("12.30", DefaultCurrency),
+	}
+	for input, want := range inputs {
+		var m Money
+		if err := json.Unmarshal([]byte(input), &m); err != nil {
+			t.Fatalf("unmarshal %s: %v", input, err)
+		}
+		if m != want {
+			t.Fatalf("unmarshal %s = %s, want %s", input, m, want)
+		}
+	}
+
+	var m Money
+	if err := json.Unmarshal([]byte(`{"amount":"1.001","currency":"USD"}`), &m); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("expected ErrInvalidAmount, got %v", err)
+	}
+}
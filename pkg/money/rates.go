@@ -0,0 +1,173 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrUnknownRate = errors.New("unknown exchange rate")
+	ErrInvalidRate = errors.New("invalid exchange rate")
+)
+
+// RatePrecision — число знаков после запятой у курсов. Кросс-курсы округляются
+// до этой точности, чтобы сохранённый вместе с заказом курс давал ту же сумму.
+const RatePrecision = 10
+
+// ParseRate разбирает курс — положительное десятичное число не более чем с RatePrecision знаками.
+func ParseRate(s string) (*big.Rat, error) {
+	intPart, frac, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if (intPart == "" && frac == "") || !digits(intPart) || !digits(frac) ||
+		len(strings.TrimRight(frac, "0")) > RatePrecision {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return r, nil
+}
+
+// FormatRate — десятичная запись курса без лишних нулей.
+func FormatRate(r *big.Rat) string {
+	s := r.FloatString(RatePrecision)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func roundRate(r *big.Rat) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(RatePrecision), nil)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+	return new(big.Rat).SetFrac(roundInt(scaled, HalfUp), scale)
+}
+
+// Convert переводит сумму в валюту to по курсу rate — числу единиц to за единицу
+// валюты суммы. Результат округляется по правилу mode с учётом точности обеих валют;
+// если он не помещается в int64, возвращается ErrOverflow.
+func (m Money) Convert(to Currency, rate *big.Rat, mode Rounding) (Money, error) {
+	factor := new(big.Rat).Set(rate)
+	shift := to.Exponent() - m.currency.Exponent()
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(shift, -shift))), nil))
+	if shift > 0 {
+		factor.Mul(factor, pow)
+	} else if shift < 0 {
+		factor.Quo(factor, pow)
+	}
+	converted, err := m.MulRat(factor, mode)
+	if err != nil {
+		return Money{}, err
+	}
+	return converted.withCurrency(to), nil
+}
+
+func (m Money) withCurrency(c Currency) Money {
+	m.currency = c
+	return m
+}
+
+// RateTable — курсы валют относительно базовой: курс EUR 0.92 означает,
+// что за 1 единицу базовой валюты дают 0.92 EUR. Курс базовой валюты всегда 1.
+type RateTable struct {
+	base  Currency
+	rates map[Currency]*big.Rat
+}
+
+func NewRateTable(base Currency, rates map[Currency]string) (*RateTable, error) {
+	if !base.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCurrency, base)
+	}
+
+	t := &RateTable{base: base, rates: map[Currency]*big.Rat{base: big.NewRat(1, 1)}}
+	for c, s := range rates {
+		if !c.Valid() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCurrency, c)
+		}
+		r, err := ParseRate(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c, err)
+		}
+		if c == base && r.Cmp(big.NewRat(1, 1)) != 0 {
+			return nil, fmt.Errorf("%w: rate of base currency %s must be 1", ErrInvalidRate, c)
+		}
+		t.rates[c] = r
+	}
+	return t, nil
+}
+
+func (t *RateTable) Base() Currency {
+	return t.base
+}
+
+// Rates возвращает курсы всех валют, кроме базовой.
+func (t *RateTable) Rates() map[Currency]string {
+	rates := make(map[Currency]string, len(t.rates))
+	for c, r := range t.rates {
+		if c != t.base {
+			rates[c] = FormatRate(r)
+		}
+	}
+	return rates
+}
+
+// Rate — сколько единиц to дают за единицу from. Кросс-курс округляется до RatePrecision знаков.
+func (t *RateTable) Rate(from, to Currency) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	rf, ok := t.rates[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRate, from)
+	}
+	rt, ok := t.rates[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRate, to)
+	}
+	return roundRate(new(big.Rat).Quo(rt, rf)), nil
+}
+
+// Convert переводит сумму в валюту to (округление money.HalfUp) и возвращает использованный курс.
+func (t *RateTable) Convert(m Money, to Currency) (Money, *big.Rat, error) {
+	if m.currency == "" {
+		return Zero(to), big.NewRat(1, 1), nil
+	}
+	rate, err := t.Rate(m.currency, to)
+	if err != nil {
+		return Money{}, nil, err
+	}
+	converted, err := m.Convert(to, rate, HalfUp)
+	if err != nil {
+		return Money{}, nil, err
+	}
+	return converted, rate, nil
+}
+
+type jsonRates struct {
+	Base  Currency            `json:"base"`
+	Rates map[Currency]string `json:"rates"`
+}
+
+// MarshalJSON кодирует таблицу как {"base":"USD","rates":{"EUR":"0.92"}}.
+func (t *RateTable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonRates{Base: t.base, Rates: t.Rates()})
+}
+
+// UnmarshalJSON читает таблицу; без base курсы считаются относительно DefaultCurrency.
+func (t *RateTable) UnmarshalJSON(data []byte) error {
+	var v jsonRates
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Base == "" {
+		v.Base = DefaultCurrency
+	}
+	parsed, err := NewRateTable(v.Base, v.Rates)
+	if err != nil {
+		return err
+	}
+	*t = *parsed
+	return nil
+}
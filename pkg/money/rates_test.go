@@ -0,0 +1,83 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func testRates(t *testing.T) *RateTable {
+	t.Helper()
+	table, err := NewRateTable(USD, map[Currency]string{EUR: "0.92", RUB: "92.5", "JPY": "150"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestRateTable_Convert(t *testing.T) {
+	table := testRates(t)
+
+	tests := []struct {
+		amount Money
+		to     Currency
+		want   Money
+		rate   string
+	}{
+		{MustParse("100", USD), EUR, MustParse("92", EUR), "0.92"},
+		{MustParse("19.99", USD), EUR, MustParse("18.39", EUR), "0.92"},
+		{MustParse("10", USD), "JPY", MustParse("1500", "JPY"), "150"},
+		{MustParse("150", "JPY"), USD, MustParse("1", USD), "0.0066666667"},
+		{MustParse("92", EUR), RUB, MustParse("9250", RUB), "100.5434782609"},
+		{MustParse("5", EUR), EUR, MustParse("5", EUR), "1"},
+	}
+
+	for _, tt := range tests {
+		got, rate, err := table.Convert(tt.amount, tt.to)
+		if err != nil {
+			t.Fatalf("convert %s to %s: %v", tt.amount, tt.to, err)
+		}
+		if got != tt.want || FormatRate(rate) != tt.rate {
+			t.Fatalf("convert %s to %s = %s at %s, want %s at %s", tt.amount, tt.to, got, FormatRate(rate), tt.want, tt.rate)
+		}
+	}
+}
+
+func TestRateTable_ConvertOverflow(t *testing.T) {
+	// 1 USD = 92.5 RUB: сумма в копейках растёт в 92.5 раза
+	if _, _, err := testRates(t).Convert(New(math.MaxInt64/10, USD), RUB); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("expected ErrOverflow, got %v", err)
+	}
+}
+
+func TestRateTable_UnknownCurrency(t *testing.T) {
+	if _, _, err := testRates(t).Convert(MustParse("1", USD), "GBP"); !errors.Is(err, ErrUnknownRate) {
+		t.Fatalf("expected ErrUnknownRate, got %v", err)
+	}
+}
+
+func TestNewRateTable_Validates(t *testing.T) {
+	for _, rate := range []string{"0", "-1", "abc", "0.00000000001"} {
+		if _, err := NewRateTable(USD, map[Currency]string{EUR: rate}); !errors.Is(err, ErrInvalidRate) {
+			t.Fatalf("rate %q: expected ErrInvalidRate, got %v", rate, err)
+		}
+	}
+	if _, err := NewRateTable(USD, map[Currency]string{USD: "2"}); !errors.Is(err, ErrInvalidRate) {
+		t.Fatalf("expected base rate to be rejected, got %v", err)
+	}
+}
+
+func TestRateTable_JSON(t *testing.T) {
+	var table RateTable
+	if err := json.Unmarshal([]byte(`{"rates":{"EUR":"0.920"}}`), &table); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(&table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"base":"USD","rates":{"EUR":"0.92"}}` {
+		t.Fatalf("unexpected json %s", data)
+	}
+}
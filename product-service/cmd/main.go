@@ -12,13 +12,17 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/migrator"
 	_ "github.com/OvsyannikovAlexandr/marketplace/product-service/docs"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/cache"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/db"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/handler"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/repository"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/userclient"
+	"github.com/OvsyannikovAlexandr/marketplace/product-service/migrations"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 
@@ -40,12 +44,37 @@ func main() {
 
 	fmt.Println("Connected to PostgreSQl")
 
+	// По умолчанию миграции накатывает migration-service; флаг позволяет сервису
+	// применить свои миграции самому с теми же проверками и ограничениями
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		svc := migrator.Service{Name: "product-service", Schema: migrations.Schema, FS: migrations.FS}
+		if err := migrator.Apply(ctx, dbpool, svc, migrator.TimeoutsFromEnv()); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		log.Println("Migrations applied")
+	}
+
 	redisAddr := os.Getenv("REDIS_ADDR")
 	redisCache := cache.NewRedisCache(redisAddr)
 
 	repo := repository.NewProductRepository(dbpool)
 	svc := service.NewProductService(repo, redisCache)
-	h := handler.NewProductHandler(svc)
+	// Права на изменение каталога проверяются в user-service, без него сервис не запускается
+	userServiceURL := os.Getenv("USER_SERVICE_URL")
+	if userServiceURL == "" {
+		log.Fatal("USER_SERVICE_URL is required to check catalog permissions")
+	}
+	svc.WithSellers(userclient.NewSellerClient(userServiceURL, 2*time.Second))
+	rateSvc := service.NewRateService(repository.NewRateRepository(dbpool))
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		if err := rateSvc.LoadFile(ctx, path); err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+		log.Printf("Exchange rates loaded from %s", path)
+	}
+
+	h := handler.NewProductHandler(svc).WithRates(rateSvc)
+	rh := handler.NewRateHandler(rateSvc)
 
 	router := mux.NewRouter()
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -55,6 +84,10 @@ func main() {
 	router.HandleFunc("/products", h.GetAll).Methods("GET")
 	router.HandleFunc("/products/{id}", h.GetByID).Methods("GET")
 	router.HandleFunc("/products/{id}", h.Delete).Methods("DELETE")
+	router.HandleFunc("/products/{id}/variants", h.CreateVariant).Methods("POST")
+	router.HandleFunc("/products/{id}/variants/{variant_id}", h.DeleteVariant).Methods("DELETE")
+	router.HandleFunc("/rates", rh.Get).Methods("GET")
+	router.HandleFunc("/rates", rh.Set).Methods("PUT")
 
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
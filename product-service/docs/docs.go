@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/products": {
             "get": {
-                "description": "Получает все продукты из базы",
+                "description": "Получает все продукты из базы вместе с вариантами. С параметром ids возвращает только перечисленные продукты (до 100), несуществующие ID пропускаются.\nС параметром q ищет по названию и описанию, а также по точному SKU или штрихкоду варианта.\nС параметром seller_id возвращает каталог продавца",
                 "produces": [
                     "application/json"
                 ],
@@ -25,6 +25,38 @@ const docTemplate = `{
                     "products"
                 ],
                 "summary": "Получить все продукты",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID продуктов через запятую, например 1,2,3",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID продавца",
+                        "name": "seller_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отображения цен, например EUR",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отображения цен, если не задан параметр currency",
+                        "name": "X-Currency",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -35,6 +67,12 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "400": {
+                        "description": "invalid ids, seller_id or currency",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "internal error",
                         "schema": {
@@ -44,7 +82,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Добавляет новый продукт в базу",
+                "description": "Добавляет новый продукт в базу. Продукт с вариантами задаёт оси options и список variants.\nТовар продавца принадлежит ему самому, owner_seller_id может задать только администратор",
                 "consumes": [
                     "application/json"
                 ],
@@ -71,7 +109,19 @@ const docTemplate = `{
                         "description": "Created"
                     },
                     "400": {
-                        "description": "invalid body",
+                        "description": "invalid body, price or variant",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "not an admin or active seller",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "variant already exists",
                         "schema": {
                             "type": "string"
                         }
@@ -102,6 +152,18 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отображения цены, например EUR",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отображения цены, если не задан параметр currency",
+                        "name": "X-Currency",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -112,7 +174,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "invalid ID",
+                        "description": "invalid ID or currency",
                         "schema": {
                             "type": "string"
                         }
@@ -126,7 +188,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Удаляет продукт по ID",
+                "description": "Удаляет продукт по ID. Продавец может удалить только свой товар",
                 "tags": [
                     "products"
                 ],
@@ -150,6 +212,207 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "product belongs to another seller",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/variants": {
+            "post": {
+                "description": "Добавляет продукту вариант (SKU). Значения options задаются ровно для осей продукта, цена необязательна",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Добавить вариант",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Вариант",
+                        "name": "variant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Variant"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Variant"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid body or variant",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "product belongs to another seller",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "variant already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/variants/{variant_id}": {
+            "delete": {
+                "description": "Удаляет вариант продукта",
+                "tags": [
+                    "products"
+                ],
+                "summary": "Удалить вариант",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID продукта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID варианта",
+                        "name": "variant_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "product belongs to another seller",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "variant not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rates": {
+            "get": {
+                "description": "Возвращает курсы валют относительно базовой: {\"base\":\"USD\",\"rates\":{\"EUR\":\"0.92\"}}",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rates"
+                ],
+                "summary": "Курсы валют",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет перечисленные курсы, остальные остаются прежними. Базовая валюта — USD. Цены уже оформленных заказов не меняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rates"
+                ],
+                "summary": "Обновить курсы валют",
+                "parameters": [
+                    {
+                        "description": "Курсы, например {\\",
+                        "name": "rates",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid rates",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "internal error",
                         "schema": {
@@ -164,6 +427,14 @@ const docTemplate = `{
         "domain.Product": {
             "type": "object",
             "properties": {
+                "base_price": {
+                    "description": "BasePrice цена в базовой валюте продукта; заполняется, если Price пересчитана в валюту отображения",
+                    "type": "object"
+                },
+                "category": {
+                    "description": "Category категория продукта, используется для таргетинга акций",
+                    "type": "string"
+                },
                 "created_at": {
                     "description": "CreatedAt дата и время создания продукта",
                     "type": "string"
@@ -172,6 +443,10 @@ const docTemplate = `{
                     "description": "Description описание продукта",
                     "type": "string"
                 },
+                "exchange_rate": {
+                    "description": "ExchangeRate курс пересчёта: сколько единиц валюты отображения за единицу базовой валюты",
+                    "type": "string"
+                },
                 "id": {
                     "description": "ID уникальный идентификатор продукта",
                     "type": "integer"
@@ -180,13 +455,79 @@ const docTemplate = `{
                     "description": "Name название продукта",
                     "type": "string"
                 },
+                "options": {
+                    "description": "Options оси вариантов, например [\"size\",\"color\"]; пусто — у продукта нет вариантов",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "owner_seller_id": {
+                    "description": "OwnerSellerID продавец-владелец товара; не задан у товаров самого маркетплейса",
+                    "type": "integer"
+                },
                 "price": {
-                    "description": "Price цена продукта в валюте USD",
-                    "type": "number"
+                    "description": "Price цена продукта: {\"amount\":\"99.99\",\"currency\":\"USD\"}; число без валюты считается суммой в USD",
+                    "type": "object"
                 },
                 "updated_at": {
                     "description": "UpdatedAt дата и время последнего обновления продукта",
                     "type": "string"
+                },
+                "variants": {
+                    "description": "Variants варианты (SKU) продукта; если они есть, в корзину кладётся конкретный вариант",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Variant"
+                    }
+                }
+            }
+        },
+        "domain.Variant": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "description": "Barcode штрихкод варианта (EAN, UPC)",
+                    "type": "string"
+                },
+                "base_price": {
+                    "description": "BasePrice цена варианта в базовой валюте; заполняется при пересчёте в валюту отображения",
+                    "type": "object"
+                },
+                "created_at": {
+                    "description": "CreatedAt дата и время создания варианта",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID уникальный идентификатор варианта",
+                    "type": "integer"
+                },
+                "options": {
+                    "description": "Options значения осей продукта, например {\"size\":\"M\",\"color\":\"red\"}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "price": {
+                    "description": "Price цена варианта; если не задана, действует цена продукта",
+                    "type": "object"
+                },
+                "product_id": {
+                    "description": "ProductID продукт, к которому относится вариант",
+                    "type": "integer"
+                },
+                "sku": {
+                    "description": "SKU артикул варианта, уникален во всём каталоге",
+                    "type": "string"
+                },
+                "stock_ref": {
+                    "description": "StockRef ссылка на складскую позицию варианта",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt дата и время последнего обновления варианта",
+                    "type": "string"
                 }
             }
         }
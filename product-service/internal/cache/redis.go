@@ -29,3 +29,29 @@ func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
+
+// MGet возвращает значения в порядке ключей; для отсутствующих ключей — пустая строка.
+func (r *RedisCache) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			result[i] = s
+		}
+	}
+	return result, nil
+}
+
+// SetMany записывает несколько значений с одинаковым TTL за один round trip.
+func (r *RedisCache) SetMany(ctx context.Context, values map[string]string, ttl time.Duration) error {
+	pipe := r.client.Pipeline()
+	for key, value := range values {
+		pipe.Set(ctx, key, value, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -1,6 +1,32 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+)
+
+// ErrProductNotFound возвращается, если продукта с указанным ID нет
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrInvalidPrice возвращается при отрицательной цене
+var ErrInvalidPrice = errors.New("invalid price")
+
+var (
+	// ErrVariantNotFound возвращается, если у продукта нет варианта с указанным ID
+	ErrVariantNotFound = errors.New("variant not found")
+	// ErrInvalidVariant возвращается, если вариант не соответствует осям продукта
+	ErrInvalidVariant = errors.New("invalid variant")
+	// ErrVariantExists возвращается при повторе SKU, штрихкода или набора опций
+	ErrVariantExists = errors.New("variant already exists")
+)
+
+// ErrForbidden возвращается, если пользователь не может управлять товаром: он не
+// администратор и не действующий продавец-владелец
+var ErrForbidden = errors.New("forbidden")
 
 // Product представляет товар на маркетплейсе
 // swagger:model
@@ -11,10 +37,92 @@ type Product struct {
 	Name string `json:"name"`
 	// Description описание продукта
 	Description string `json:"description"`
-	// Price цена продукта в валюте USD
-	Price float64 `json:"price"`
+	// Price цена продукта: {"amount":"99.99","currency":"USD"}; число без валюты считается суммой в USD
+	Price money.Money `json:"price" swaggertype:"object"`
+	// BasePrice цена в базовой валюте продукта; заполняется, если Price пересчитана в валюту отображения
+	BasePrice *money.Money `json:"base_price,omitempty" swaggertype:"object"`
+	// ExchangeRate курс пересчёта: сколько единиц валюты отображения за единицу базовой валюты
+	ExchangeRate string `json:"exchange_rate,omitempty"`
+	// Category категория продукта, используется для таргетинга акций
+	Category string `json:"category,omitempty"`
+	// Options оси вариантов, например ["size","color"]; пусто — у продукта нет вариантов
+	Options []string `json:"options,omitempty"`
+	// Variants варианты (SKU) продукта; если они есть, в корзину кладётся конкретный вариант
+	Variants []Variant `json:"variants,omitempty"`
+	// OwnerSellerID продавец-владелец товара; не задан у товаров самого маркетплейса
+	OwnerSellerID *int64 `json:"owner_seller_id,omitempty"`
 	// CreatedAt дата и время создания продукта
 	CreatedAt time.Time `json:"created_at"`
 	// UpdatedAt дата и время последнего обновления продукта
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// Variant — вариант продукта (SKU) с конкретными значениями его осей
+// swagger:model
+type Variant struct {
+	// ID уникальный идентификатор варианта
+	ID int64 `json:"id"`
+	// ProductID продукт, к которому относится вариант
+	ProductID int64 `json:"product_id"`
+	// SKU артикул варианта, уникален во всём каталоге
+	SKU string `json:"sku"`
+	// Options значения осей продукта, например {"size":"M","color":"red"}
+	Options map[string]string `json:"options"`
+	// Price цена варианта; если не задана, действует цена продукта
+	Price *money.Money `json:"price,omitempty" swaggertype:"object"`
+	// BasePrice цена варианта в базовой валюте; заполняется при пересчёте в валюту отображения
+	BasePrice *money.Money `json:"base_price,omitempty" swaggertype:"object"`
+	// StockRef ссылка на складскую позицию варианта
+	StockRef string `json:"stock_ref,omitempty"`
+	// Barcode штрихкод варианта (EAN, UPC)
+	Barcode string `json:"barcode,omitempty"`
+	// CreatedAt дата и время создания варианта
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt дата и время последнего обновления варианта
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ValidateVariant проверяет, что вариант подходит продукту: задан SKU, значения указаны ровно
+// для осей продукта, цена не отрицательна и совпадает по валюте с ценой продукта.
+func (p Product) ValidateVariant(v Variant) error {
+	if strings.TrimSpace(v.SKU) == "" {
+		return fmt.Errorf("%w: sku is required", ErrInvalidVariant)
+	}
+	if len(p.Options) == 0 {
+		return fmt.Errorf("%w: product %d has no option axes", ErrInvalidVariant, p.ID)
+	}
+	if len(v.Options) != len(p.Options) {
+		return fmt.Errorf("%w: options must be set for %v", ErrInvalidVariant, p.Options)
+	}
+	for _, axis := range p.Options {
+		if strings.TrimSpace(v.Options[axis]) == "" {
+			return fmt.Errorf("%w: option %q is required", ErrInvalidVariant, axis)
+		}
+	}
+	if v.Price != nil {
+		if v.Price.IsNegative() {
+			return fmt.Errorf("%w: price can't be negative", ErrInvalidPrice)
+		}
+		if v.Price.Currency() != p.Price.Currency() {
+			return fmt.Errorf("%w: variant price must be in %s", ErrInvalidPrice, p.Price.Currency())
+		}
+	}
+	return nil
+}
+
+// SellerAccess — права пользователя в каталоге по данным user-service.
+// SellerID задан только у действующего продавца.
+type SellerAccess struct {
+	UserID   int64 `json:"user_id"`
+	SellerID int64 `json:"seller_id,omitempty"`
+	Admin    bool  `json:"admin"`
+}
+
+// CanManage сообщает, может ли пользователь менять и удалять продукт: администратор —
+// любой, продавец — только свой
+func (a SellerAccess) CanManage(p Product) bool {
+	if a.Admin {
+		return true
+	}
+	return a.SellerID != 0 && p.OwnerSellerID != nil && *p.OwnerSellerID == a.SellerID
+}
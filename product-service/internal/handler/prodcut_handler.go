@@ -1,33 +1,111 @@
 package handler
 
 import (
-	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/service"
 	"github.com/gorilla/mux"
 )
 
+// maxBatchIDs ограничивает размер пакетного запроса продуктов
+const maxBatchIDs = 100
+
 type ProductHandler struct {
 	service service.ProductServiceInterface
+	rates   service.RateServiceInterface
 }
 
 func NewProductHandler(service service.ProductServiceInterface) *ProductHandler {
 	return &ProductHandler{service: service}
 }
 
+// WithRates включает пересчёт цен в валюту отображения (?currency= или заголовок X-Currency).
+func (h *ProductHandler) WithRates(rates service.RateServiceInterface) *ProductHandler {
+	h.rates = rates
+	return h
+}
+
+// displayCurrency возвращает запрошенную клиентом валюту отображения; пустую, если она не задана.
+// Параметр запроса currency важнее заголовка X-Currency.
+func displayCurrency(r *http.Request) (money.Currency, error) {
+	code := r.URL.Query().Get("currency")
+	if code == "" {
+		code = r.Header.Get("X-Currency")
+	}
+	if code == "" {
+		return "", nil
+	}
+	return money.ParseCurrency(code)
+}
+
+// localize пересчитывает цены в валюту отображения и сам отвечает клиенту при ошибке.
+func (h *ProductHandler) localize(w http.ResponseWriter, r *http.Request, products []domain.Product) bool {
+	currency, err := displayCurrency(r)
+	if err == nil && currency != "" {
+		if h.rates == nil {
+			err = fmt.Errorf("%w: %s", money.ErrUnknownRate, currency)
+		} else {
+			var table *money.RateTable
+			if table, err = h.rates.GetRates(r.Context()); err == nil {
+				err = service.ConvertPrices(table, products, currency)
+			}
+		}
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, money.ErrInvalidCurrency), errors.Is(err, money.ErrUnknownRate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
+
+// requestUserID возвращает пользователя из X-User-ID, который проставляет api-gateway;
+// 0, если заголовка нет
+func requestUserID(r *http.Request) int64 {
+	id, _ := strconv.ParseInt(r.Header.Get("X-User-ID"), 10, 64)
+	return id
+}
+
+// writeVariantError отвечает 400 на неверную цену или вариант, 403, если товар чужой,
+// 404 на ненайденный продукт или вариант, 409 на повтор SKU, штрихкода или набора опций
+// и 500 на остальные ошибки
+func writeVariantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPrice), errors.Is(err, domain.ErrInvalidVariant):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrProductNotFound), errors.Is(err, domain.ErrVariantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrVariantExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // @Summary      Создать продукт
-// @Description  Добавляет новый продукт в базу
+// @Description  Добавляет новый продукт в базу. Продукт с вариантами задаёт оси options и список variants.
+// @Description  Товар продавца принадлежит ему самому, owner_seller_id может задать только администратор
 // @Tags         products
 // @Accept       json
 // @Produce      json
 // @Param        product  body      domain.Product  true  "Продукт"
 // @Success      201
-// @Failure      400  {string}  string "invalid body"
+// @Failure      400  {string}  string "invalid body, price or variant"
+// @Failure      403  {string}  string "not an admin or active seller"
+// @Failure      409  {string}  string "variant already exists"
 // @Failure      500  {string}  string "internal error"
 // @Router       /products [post]
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -37,36 +115,104 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Create(r.Context(), p); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.Create(r.Context(), requestUserID(r), p); err != nil {
+		writeVariantError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
 }
 
 // @Summary      Получить все продукты
-// @Description  Получает все продукты из базы
+// @Description  Получает все продукты из базы вместе с вариантами. С параметром ids возвращает только перечисленные продукты (до 100), несуществующие ID пропускаются.
+// @Description  С параметром q ищет по названию и описанию, а также по точному SKU или штрихкоду варианта.
+// @Description  С параметром seller_id возвращает каталог продавца
 // @Tags         products
 // @Produce      json
+// @Param        ids         query     string  false  "ID продуктов через запятую, например 1,2,3"
+// @Param        q           query     string  false  "Поисковый запрос"
+// @Param        seller_id   query     int     false  "ID продавца"
+// @Param        currency    query     string  false  "Валюта отображения цен, например EUR"
+// @Param        X-Currency  header    string  false  "Валюта отображения цен, если не задан параметр currency"
 // @Success      200  {array}   domain.Product
+// @Failure      400  {string}  string "invalid ids, seller_id or currency"
 // @Failure      500  {string}  string "internal error"
 // @Router       /products [get]
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	products, err := h.service.GetAll(r.Context())
+	if r.URL.Query().Has("ids") {
+		h.getByIDs(w, r)
+		return
+	}
+
+	var products []domain.Product
+	var err error
+	if v := r.URL.Query().Get("seller_id"); v != "" {
+		sellerID, parseErr := strconv.ParseInt(v, 10, 64)
+		if parseErr != nil {
+			http.Error(w, "invalid seller_id", http.StatusBadRequest)
+			return
+		}
+		products, err = h.service.GetBySeller(r.Context(), sellerID)
+	} else if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		products, err = h.service.Search(r.Context(), q)
+	} else {
+		products, err = h.service.GetAll(r.Context())
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if !h.localize(w, r, products) {
+		return
+	}
 	json.NewEncoder(w).Encode(products)
 }
 
+// getByIDs обслуживает пакетный запрос GET /products?ids=1,2,3
+func (h *ProductHandler) getByIDs(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseIDs(r.URL.Query().Get("ids"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.service.GetByIDs(r.Context(), ids)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !h.localize(w, r, products) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+func parseIDs(s string) ([]int64, error) {
+	parts := strings.Split(s, ",")
+	if s == "" || len(parts) > maxBatchIDs {
+		return nil, fmt.Errorf("invalid ids: expected 1..%d comma-separated IDs", maxBatchIDs)
+	}
+
+	ids := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ids: %q is not an ID", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 // @Summary      Получить продукт по ID
 // @Description  Получает продукт по ID
 // @Tags         products
 // @Produce      json
-// @Param        id   path      int  true  "ID продукта"
+// @Param        id          path      int     true   "ID продукта"
+// @Param        currency    query     string  false  "Валюта отображения цены, например EUR"
+// @Param        X-Currency  header    string  false  "Валюта отображения цены, если не задан параметр currency"
 // @Success      200  {object}  domain.Product
-// @Failure      400  {string}  string "invalid ID"
+// @Failure      400  {string}  string "invalid ID or currency"
 // @Failure      404  {string}  string "not found"
 // @Router       /products/{id} [get]
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -79,23 +225,29 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	product, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, domain.ErrProductNotFound) {
 			http.Error(w, "product not found", http.StatusNotFound)
 			return
 		}
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
+	products := []domain.Product{product}
+	if !h.localize(w, r, products) {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	json.NewEncoder(w).Encode(products[0])
 }
 
 // @Summary      Удаление продукта
-// @Description  Удаляет продукт по ID
+// @Description  Удаляет продукт по ID. Продавец может удалить только свой товар
 // @Tags         products
 // @Param        id   path      int  true  "ID продукта"
 // @Success      204
 // @Failure      400  {string}  string "invalid ID"
+// @Failure      403  {string}  string "product belongs to another seller"
+// @Failure      404  {string}  string "product not found"
 // @Failure      500  {string}  string "internal error"
 // @Router       /products/{id} [delete]
 func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -105,8 +257,77 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid ID", http.StatusBadRequest)
 		return
 	}
-	if err := h.service.Delete(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.Delete(r.Context(), requestUserID(r), id); err != nil {
+		writeVariantError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      Добавить вариант
+// @Description  Добавляет продукту вариант (SKU). Значения options задаются ровно для осей продукта, цена необязательна
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int             true  "ID продукта"
+// @Param        variant  body      domain.Variant  true  "Вариант"
+// @Success      201  {object}  domain.Variant
+// @Failure      400  {string}  string "invalid body or variant"
+// @Failure      403  {string}  string "product belongs to another seller"
+// @Failure      404  {string}  string "product not found"
+// @Failure      409  {string}  string "variant already exists"
+// @Failure      500  {string}  string "internal error"
+// @Router       /products/{id}/variants [post]
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var v domain.Variant
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	v.ProductID = productID
+
+	created, err := h.service.CreateVariant(r.Context(), requestUserID(r), v)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// @Summary      Удалить вариант
+// @Description  Удаляет вариант продукта
+// @Tags         products
+// @Param        id          path  int  true  "ID продукта"
+// @Param        variant_id  path  int  true  "ID варианта"
+// @Success      204
+// @Failure      400  {string}  string "invalid ID"
+// @Failure      403  {string}  string "product belongs to another seller"
+// @Failure      404  {string}  string "variant not found"
+// @Failure      500  {string}  string "internal error"
+// @Router       /products/{id}/variants/{variant_id} [delete]
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid ID", http.StatusBadRequest)
+		return
+	}
+	variantID, err := strconv.ParseInt(mux.Vars(r)["variant_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid variant ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteVariant(r.Context(), requestUserID(r), productID, variantID); err != nil {
+		writeVariantError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
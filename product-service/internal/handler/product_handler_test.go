@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/handler"
 	"github.com/gorilla/mux"
@@ -19,7 +20,7 @@ type mockService struct {
 	nextID   int64
 }
 
-func (m *mockService) Create(ctx context.Context, p domain.Product) error {
+func (m *mockService) Create(ctx context.Context, userID int64, p domain.Product) error {
 	m.nextID++
 	p.ID = m.nextID
 	m.products[p.ID] = p
@@ -38,10 +39,34 @@ func (m *mockService) GetByID(ctx context.Context, id int64) (domain.Product, er
 	if p, ok := m.products[id]; ok {
 		return p, nil
 	}
-	return domain.Product{}, errors.New("product not found")
+	return domain.Product{}, domain.ErrProductNotFound
 }
 
-func (m *mockService) Delete(ctx context.Context, id int64) error {
+func (m *mockService) GetByIDs(ctx context.Context, ids []int64) ([]domain.Product, error) {
+	var result []domain.Product
+	for _, id := range ids {
+		if p, ok := m.products[id]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockService) GetBySeller(ctx context.Context, sellerID int64) ([]domain.Product, error) {
+	var result []domain.Product
+	for _, p := range m.products {
+		if p.OwnerSellerID != nil && *p.OwnerSellerID == sellerID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockService) Delete(ctx context.Context, userID, id int64) error {
+	// В моке id продавца совпадает с id пользователя
+	if p, ok := m.products[id]; ok && p.OwnerSellerID != nil && *p.OwnerSellerID != userID {
+		return domain.ErrForbidden
+	}
 	if _, ok := m.products[id]; ok {
 		delete(m.products, id)
 		return nil
@@ -67,8 +92,8 @@ func TestCreateProductHandler(t *testing.T) {
 
 func TestGetAllProductsHandler(t *testing.T) {
 	s := &mockService{products: make(map[int64]domain.Product)}
-	_ = s.Create(context.Background(), domain.Product{Name: "A"})
-	_ = s.Create(context.Background(), domain.Product{Name: "B"})
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "A"})
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "B"})
 
 	h := handler.NewProductHandler(s)
 
@@ -93,7 +118,7 @@ func TestGetAllProductsHandler(t *testing.T) {
 
 func TestGetByIDProductHandler(t *testing.T) {
 	s := &mockService{products: make(map[int64]domain.Product)}
-	_ = s.Create(context.Background(), domain.Product{Name: "Item 1"})
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "Item 1"})
 
 	h := handler.NewProductHandler(s)
 
@@ -110,7 +135,7 @@ func TestGetByIDProductHandler(t *testing.T) {
 
 func TestDeleteProductHandler(t *testing.T) {
 	s := &mockService{products: make(map[int64]domain.Product)}
-	_ = s.Create(context.Background(), domain.Product{Name: "ToDelete"})
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "ToDelete"})
 
 	h := handler.NewProductHandler(s)
 
@@ -179,3 +204,218 @@ func TestDeleteProductHandler_InvalidID(t *testing.T) {
 		t.Fatalf("expected invalid ID error, got %s", rec.Body.String())
 	}
 }
+
+func TestGetProductsByIDsHandler(t *testing.T) {
+	s := &mockService{products: make(map[int64]domain.Product)}
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "A"})
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "B"})
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "C"})
+
+	h := handler.NewProductHandler(s)
+
+	req := httptest.NewRequest(http.MethodGet, "/products?ids=3,1,42", nil)
+	rec := httptest.NewRecorder()
+
+	h.GetAll(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var products []domain.Product
+	if err := json.NewDecoder(rec.Body).Decode(&products); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(products) != 2 || products[0].Name != "C" || products[1].Name != "A" {
+		t.Fatalf("expected products C and A in request order, got %+v", products)
+	}
+}
+
+func TestGetProductsByIDsHandler_InvalidIDs(t *testing.T) {
+	s := &mockService{products: make(map[int64]domain.Product)}
+	h := handler.NewProductHandler(s)
+
+	req := httptest.NewRequest(http.MethodGet, "/products?ids=1,abc", nil)
+	rec := httptest.NewRecorder()
+
+	h.GetAll(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid ids, got %d", rec.Code)
+	}
+}
+
+func (m *mockService) Search(ctx context.Context, query string) ([]domain.Product, error) {
+	var result []domain.Product
+	for _, p := range m.products {
+		if strings.Contains(p.Name, query) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockService) CreateVariant(ctx context.Context, userID int64, v domain.Variant) (domain.Variant, error) {
+	p, ok := m.products[v.ProductID]
+	if !ok {
+		return domain.Variant{}, domain.ErrProductNotFound
+	}
+	for _, existing := range p.Variants {
+		if existing.SKU == v.SKU {
+			return domain.Variant{}, domain.ErrVariantExists
+		}
+	}
+	m.nextID++
+	v.ID = m.nextID
+	p.Variants = append(p.Variants, v)
+	m.products[p.ID] = p
+	return v, nil
+}
+
+func (m *mockService) DeleteVariant(ctx context.Context, userID, productID, variantID int64) error {
+	return domain.ErrVariantNotFound
+}
+
+type mockRates struct {
+	table *money.RateTable
+}
+
+func (m *mockRates) GetRates(ctx context.Context) (*money.RateTable, error) {
+	return m.table, nil
+}
+
+func (m *mockRates) SetRates(ctx context.Context, table *money.RateTable) error {
+	m.table = table
+	return nil
+}
+
+func TestGetByIDProductHandler_DisplayCurrency(t *testing.T) {
+	s := &mockService{products: make(map[int64]domain.Product)}
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "Item", Price: money.MustParse("19.99", money.USD)})
+
+	table, err := money.NewRateTable(money.USD, map[money.Currency]string{money.EUR: "0.92"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.NewProductHandler(s).WithRates(&mockRates{table: table})
+
+	req := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+	req.Header.Set("X-Currency", "eur")
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	rec := httptest.NewRecorder()
+
+	h.GetByID(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var p domain.Product
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if p.Price != money.MustParse("18.39", money.EUR) || p.BasePrice == nil ||
+		*p.BasePrice != money.MustParse("19.99", money.USD) || p.ExchangeRate != "0.92" {
+		t.Fatalf("unexpected converted product %+v", p)
+	}
+}
+
+func TestGetAllProductsHandler_UnknownCurrency(t *testing.T) {
+	s := &mockService{products: make(map[int64]domain.Product)}
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "A", Price: money.MustParse("1", money.USD)})
+
+	table, _ := money.NewRateTable(money.USD, nil)
+	h := handler.NewProductHandler(s).WithRates(&mockRates{table: table})
+
+	req := httptest.NewRequest(http.MethodGet, "/products?currency=GBP", nil)
+	rec := httptest.NewRecorder()
+
+	h.GetAll(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown currency, got %d", rec.Code)
+	}
+}
+
+func TestCreateVariantHandler(t *testing.T) {
+	s := &mockService{products: make(map[int64]domain.Product)}
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "T-shirt", Options: []string{"size"}})
+	h := handler.NewProductHandler(s)
+
+	create := func() int {
+		body := `{"sku":"TS-M","options":{"size":"M"},"price":{"amount":"12","currency":"USD"}}`
+		req := httptest.NewRequest(http.MethodPost, "/products/1/variants", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		rec := httptest.NewRecorder()
+		h.CreateVariant(rec, req)
+		return rec.Code
+	}
+
+	if code := create(); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if code := create(); code != http.StatusConflict {
+		t.Fatalf("expected 409 for duplicate sku, got %d", code)
+	}
+}
+
+func TestGetAllProductsHandler_Search(t *testing.T) {
+	s := &mockService{products: make(map[int64]domain.Product)}
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "Red shirt"})
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "Blue jeans"})
+	h := handler.NewProductHandler(s)
+
+	req := httptest.NewRequest(http.MethodGet, "/products?q=shirt", nil)
+	rec := httptest.NewRecorder()
+
+	h.GetAll(rec, req)
+
+	var products []domain.Product
+	if err := json.NewDecoder(rec.Body).Decode(&products); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(products) != 1 || products[0].Name != "Red shirt" {
+		t.Fatalf("expected only Red shirt, got %+v", products)
+	}
+}
+
+func TestSellerCatalogHandler(t *testing.T) {
+	s := &mockService{products: make(map[int64]domain.Product)}
+	seller := int64(7)
+	_ = s.Create(context.Background(), 7, domain.Product{Name: "Seller item", OwnerSellerID: &seller})
+	_ = s.Create(context.Background(), 1, domain.Product{Name: "Marketplace item"})
+	h := handler.NewProductHandler(s)
+
+	req := httptest.NewRequest(http.MethodGet, "/products?seller_id=7", nil)
+	rec := httptest.NewRecorder()
+	h.GetAll(rec, req)
+
+	var products []domain.Product
+	if err := json.NewDecoder(rec.Body).Decode(&products); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(products) != 1 || products[0].Name != "Seller item" {
+		t.Fatalf("expected only seller's product, got %+v", products)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/products?seller_id=abc", nil)
+	rec = httptest.NewRecorder()
+	h.GetAll(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid seller_id, got %d", rec.Code)
+	}
+
+	del := func(userID string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/products/1", nil)
+		req.Header.Set("X-User-ID", userID)
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		rec := httptest.NewRecorder()
+		h.Delete(rec, req)
+		return rec.Code
+	}
+	if code := del("8"); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another seller, got %d", code)
+	}
+	if code := del("7"); code != http.StatusNoContent {
+		t.Fatalf("expected 204 for the owner, got %d", code)
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/service"
+)
+
+type RateHandler struct {
+	service service.RateServiceInterface
+}
+
+func NewRateHandler(service service.RateServiceInterface) *RateHandler {
+	return &RateHandler{service: service}
+}
+
+// @Summary      Курсы валют
+// @Description  Возвращает курсы валют относительно базовой: {"base":"USD","rates":{"EUR":"0.92"}}
+// @Tags         rates
+// @Produce      json
+// @Success      200  {object}  object
+// @Failure      500  {string}  string "internal error"
+// @Router       /rates [get]
+func (h *RateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	table, err := h.service.GetRates(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
+
+// @Summary      Обновить курсы валют
+// @Description  Обновляет перечисленные курсы, остальные остаются прежними. Базовая валюта — USD. Цены уже оформленных заказов не меняются
+// @Tags         rates
+// @Accept       json
+// @Param        rates  body  object  true  "Курсы, например {\"base\":\"USD\",\"rates\":{\"EUR\":\"0.92\"}}"
+// @Success      204
+// @Failure      400  {string}  string "invalid rates"
+// @Failure      500  {string}  string "internal error"
+// @Router       /rates [put]
+func (h *RateHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var table money.RateTable
+	if err := json.NewDecoder(r.Body).Decode(&table); err != nil {
+		http.Error(w, "invalid rates: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetRates(r.Context(), &table); err != nil {
+		if errors.Is(err, money.ErrInvalidRate) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
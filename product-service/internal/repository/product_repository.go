@@ -3,9 +3,13 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -21,22 +25,102 @@ type ProductRepositoryInterface interface {
 	CreateProduct(ctx context.Context, p domain.Product) error
 	GetAllProducts(ctx context.Context) ([]domain.Product, error)
 	GetProductByID(ctx context.Context, id int64) (domain.Product, error)
+	GetProductsByIDs(ctx context.Context, ids []int64) ([]domain.Product, error)
+	GetProductsBySeller(ctx context.Context, sellerID int64) ([]domain.Product, error)
 	DeleteProduct(ctx context.Context, id int64) error
+	SearchProducts(ctx context.Context, query string) ([]domain.Product, error)
+	CreateVariant(ctx context.Context, v domain.Variant) (domain.Variant, error)
+	DeleteVariant(ctx context.Context, productID, variantID int64) error
 }
 
+const productColumns = `id, name, description, price, currency, category, options, owner_seller_id, created_at, updated_at`
+
+// CreateProduct сохраняет продукт вместе с вариантами в одной транзакции
 func (r *ProductRepository) CreateProduct(ctx context.Context, p domain.Product) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO product_service.products (name, description, price, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO product_service.products (name, description, price, currency, category, options, owner_seller_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
 	`
-	_, err := r.db.Exec(ctx, query, p.Name, p.Description, p.Price, time.Now(), time.Now())
+	options := p.Options
+	if options == nil {
+		options = []string{}
+	}
+	var id int64
+	err = tx.QueryRow(ctx, query, p.Name, p.Description, p.Price.Decimal(), p.Price.Currency(), p.Category, options, p.OwnerSellerID, time.Now(), time.Now()).Scan(&id)
+	if err != nil {
+		return err
+	}
 
-	return err
+	for _, v := range p.Variants {
+		v.ProductID = id
+		if _, err := insertVariant(ctx, tx, v); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *ProductRepository) GetAllProducts(ctx context.Context) ([]domain.Product, error) {
-	query := `SELECT id, name, description, price, created_at, updated_at FROM product_service.products`
-	rows, err := r.db.Query(ctx, query)
+	query := `SELECT ` + productColumns + ` FROM product_service.products ORDER BY id`
+	return r.queryProducts(ctx, query)
+}
+
+func (r *ProductRepository) GetProductByID(ctx context.Context, id int64) (domain.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM product_service.products WHERE id = $1`
+	p, err := scanProduct(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return p, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return p, err
+	}
+
+	products := []domain.Product{p}
+	if err := r.attachVariants(ctx, products); err != nil {
+		return domain.Product{}, err
+	}
+	return products[0], nil
+}
+
+func (r *ProductRepository) GetProductsByIDs(ctx context.Context, ids []int64) ([]domain.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM product_service.products WHERE id = ANY($1)`
+	return r.queryProducts(ctx, query, ids)
+}
+
+// GetProductsBySeller возвращает каталог продавца
+func (r *ProductRepository) GetProductsBySeller(ctx context.Context, sellerID int64) ([]domain.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM product_service.products WHERE owner_seller_id = $1 ORDER BY id`
+	return r.queryProducts(ctx, query, sellerID)
+}
+
+// SearchProducts ищет продукты по названию и описанию, а также по точному SKU или штрихкоду варианта.
+// Продукт возвращается один раз со всеми своими вариантами.
+func (r *ProductRepository) SearchProducts(ctx context.Context, search string) ([]domain.Product, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM product_service.products p
+		WHERE p.name ILIKE '%' || $1 || '%'
+			OR p.description ILIKE '%' || $1 || '%'
+			OR EXISTS (
+				SELECT 1 FROM product_service.product_variants v
+				WHERE v.product_id = p.id AND (v.sku = $1 OR v.barcode = $1)
+			)
+		ORDER BY p.id
+	`
+	return r.queryProducts(ctx, query, search)
+}
+
+// queryProducts выполняет запрос продуктов и дополняет их вариантами одним запросом
+func (r *ProductRepository) queryProducts(ctx context.Context, query string, args ...any) ([]domain.Product, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -44,26 +128,139 @@ func (r *ProductRepository) GetAllProducts(ctx context.Context) ([]domain.Produc
 
 	var products []domain.Product
 	for rows.Next() {
-		var p domain.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		p, err := scanProduct(rows)
+		if err != nil {
 			return nil, err
 		}
 		products = append(products, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachVariants(ctx, products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
-func (r *ProductRepository) GetProductByID(ctx context.Context, id int64) (domain.Product, error) {
-	query := `SELECT id, name, description, price, created_at, updated_at FROM product_service.products WHERE id = $1`
-	var p domain.Product
-	err := r.db.QueryRow(ctx, query, id).Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.CreatedAt, &p.UpdatedAt)
+// attachVariants загружает варианты всех продуктов одним запросом и раскладывает их по продуктам
+func (r *ProductRepository) attachVariants(ctx context.Context, products []domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(products))
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		index[p.ID] = i
+		ids[i] = p.ID
+	}
+
+	query := `SELECT ` + variantColumns + ` FROM product_service.product_variants WHERE product_id = ANY($1) ORDER BY id`
+	rows, err := r.db.Query(ctx, query, ids)
 	if err != nil {
-		return p, errors.New("product not fuond")
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return err
+		}
+		i := index[v.ProductID]
+		products[i].Variants = append(products[i].Variants, v)
 	}
-	return p, nil
+	return rows.Err()
 }
 
 func (r *ProductRepository) DeleteProduct(ctx context.Context, id int64) error {
 	_, err := r.db.Exec(ctx, `DELETE FROM product_service.products WHERE id = $1`, id)
 	return err
 }
+
+// scanProduct читает строку products: цена хранится в NUMERIC, валюта — в отдельной колонке.
+func scanProduct(row pgx.Row) (domain.Product, error) {
+	var p domain.Product
+	var price, currency string
+	if err := row.Scan(&p.ID, &p.Name, &p.Description, &price, &currency, &p.Category, &p.Options, &p.OwnerSellerID, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return p, err
+	}
+	if len(p.Options) == 0 {
+		p.Options = nil
+	}
+
+	var err error
+	p.Price, err = money.Parse(price, money.Currency(currency))
+	return p, err
+}
+
+const variantColumns = `id, product_id, sku, options, price, currency, stock_ref, barcode, created_at, updated_at`
+
+func (r *ProductRepository) CreateVariant(ctx context.Context, v domain.Variant) (domain.Variant, error) {
+	return insertVariant(ctx, r.db, v)
+}
+
+// DeleteVariant удаляет вариант продукта; domain.ErrVariantNotFound, если такого варианта нет
+func (r *ProductRepository) DeleteVariant(ctx context.Context, productID, variantID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM product_service.product_variants WHERE id = $1 AND product_id = $2`, variantID, productID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrVariantNotFound
+	}
+	return nil
+}
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertVariant сохраняет вариант; нарушение уникальности SKU, штрихкода или набора опций
+// возвращается как domain.ErrVariantExists
+func insertVariant(ctx context.Context, db queryRower, v domain.Variant) (domain.Variant, error) {
+	var price, currency, barcode *string
+	if v.Price != nil {
+		amount, c := v.Price.Decimal(), string(v.Price.Currency())
+		price, currency = &amount, &c
+	}
+	if v.Barcode != "" {
+		barcode = &v.Barcode
+	}
+
+	query := `
+		INSERT INTO product_service.product_variants (product_id, sku, options, price, currency, stock_ref, barcode, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + variantColumns
+	created, err := scanVariant(db.QueryRow(ctx, query,
+		v.ProductID, v.SKU, v.Options, price, currency, v.StockRef, barcode, time.Now(), time.Now(),
+	))
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return domain.Variant{}, fmt.Errorf("%w: %s", domain.ErrVariantExists, pgErr.ConstraintName)
+	}
+	return created, err
+}
+
+// scanVariant читает строку product_variants: цена и валюта заданы, только если цена переопределена.
+func scanVariant(row pgx.Row) (domain.Variant, error) {
+	var v domain.Variant
+	var price, currency, barcode *string
+	if err := row.Scan(&v.ID, &v.ProductID, &v.SKU, &v.Options, &price, &currency, &v.StockRef, &barcode, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		return v, err
+	}
+	if barcode != nil {
+		v.Barcode = *barcode
+	}
+	if price != nil && currency != nil {
+		m, err := money.Parse(*price, money.Currency(*currency))
+		if err != nil {
+			return v, err
+		}
+		v.Price = &m
+	}
+	return v, nil
+}
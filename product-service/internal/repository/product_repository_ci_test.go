@@ -4,10 +4,12 @@ package repository_test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/repository"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -50,9 +52,27 @@ func TestMain(m *testing.M) {
 			id SERIAL PRIMARY KEY,
 			name TEXT NOT NULL,
 			description TEXT,
-			price NUMERIC(10,2) NOT NULL,
+			price NUMERIC(19,4) NOT NULL,
+			currency TEXT NOT NULL DEFAULT 'USD',
+			category TEXT NOT NULL DEFAULT '',
+			options TEXT[] NOT NULL DEFAULT '{}',
+			owner_seller_id INTEGER,
 			created_at TIMESTAMP NOT NULL DEFAULT now(),
 			updated_at TIMESTAMP NOT NULL DEFAULT now()
+		);
+
+		CREATE TABLE product_service.product_variants (
+			id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES product_service.products(id) ON DELETE CASCADE,
+			sku TEXT NOT NULL UNIQUE,
+			options JSONB NOT NULL DEFAULT '{}',
+			price NUMERIC(19,4),
+			currency TEXT,
+			stock_ref TEXT NOT NULL DEFAULT '',
+			barcode TEXT UNIQUE,
+			created_at TIMESTAMP NOT NULL DEFAULT now(),
+			updated_at TIMESTAMP NOT NULL DEFAULT now(),
+			UNIQUE (product_id, options)
 		);`
 
 	_, err = dbpool.Exec(ctx, schema)
@@ -87,7 +107,7 @@ func TestCreateAndGetALLProduct(t *testing.T) {
 	product := domain.Product{
 		Name:        "Test Product",
 		Description: "Cool product",
-		Price:       99.99,
+		Price:       money.MustParse("99.99", money.USD),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -133,7 +153,7 @@ func TestDeleteProduct_Success(t *testing.T) {
 	product := domain.Product{
 		Name:        "Test to delete Product",
 		Description: "To be deleted product",
-		Price:       99.99,
+		Price:       money.MustParse("99.99", money.USD),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -169,3 +189,71 @@ func TestDeleteProduct_Success(t *testing.T) {
 		t.Fatal("expected error after delete, got nil")
 	}
 }
+
+func TestProductVariants_GroupedAndSearchable(t *testing.T) {
+	clearProductsTable(t)
+	ctx := context.Background()
+	repo := repository.NewProductRepository(dbpool)
+
+	override := money.MustParse("12.00", money.USD)
+	err := repo.CreateProduct(ctx, domain.Product{
+		Name:    "T-shirt",
+		Price:   money.MustParse("10.00", money.USD),
+		Options: []string{"size"},
+		Variants: []domain.Variant{
+			{SKU: "TS-S", Options: map[string]string{"size": "S"}},
+			{SKU: "TS-XL", Options: map[string]string{"size": "XL"}, Price: &override, Barcode: "4600000000017"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateProduct failed: %v", err)
+	}
+
+	products, err := repo.SearchProducts(ctx, "4600000000017")
+	if err != nil {
+		t.Fatalf("SearchProducts failed: %v", err)
+	}
+	if len(products) != 1 || len(products[0].Variants) != 2 {
+		t.Fatalf("expected one product with 2 variants, got %+v", products)
+	}
+	xl := products[0].Variants[1]
+	if xl.SKU != "TS-XL" || xl.Price == nil || *xl.Price != override || products[0].Variants[0].Price != nil {
+		t.Fatalf("unexpected variants %+v", products[0].Variants)
+	}
+
+	_, err = repo.CreateVariant(ctx, domain.Variant{ProductID: products[0].ID, SKU: "TS-S-2", Options: map[string]string{"size": "S"}})
+	if !errors.Is(err, domain.ErrVariantExists) {
+		t.Fatalf("expected ErrVariantExists for duplicate options, got %v", err)
+	}
+}
+
+func TestGetProductsBySeller(t *testing.T) {
+	clearProductsTable(t)
+	ctx := context.Background()
+	repo := repository.NewProductRepository(dbpool)
+
+	seller := int64(7)
+	for _, p := range []domain.Product{
+		{Name: "Seller item", Price: money.MustParse("5.00", money.USD), OwnerSellerID: &seller},
+		{Name: "Marketplace item", Price: money.MustParse("6.00", money.USD)},
+	} {
+		if err := repo.CreateProduct(ctx, p); err != nil {
+			t.Fatalf("CreateProduct failed: %v", err)
+		}
+	}
+
+	products, err := repo.GetProductsBySeller(ctx, seller)
+	if err != nil {
+		t.Fatalf("GetProductsBySeller failed: %v", err)
+	}
+	if len(products) != 1 || products[0].OwnerSellerID == nil || *products[0].OwnerSellerID != seller {
+		t.Fatalf("expected only seller's product, got %+v", products)
+	}
+
+	all, _ := repo.GetAllProducts(ctx)
+	for _, p := range all {
+		if p.Name == "Marketplace item" && p.OwnerSellerID != nil {
+			t.Fatalf("expected marketplace product without owner, got %v", *p.OwnerSellerID)
+		}
+	}
+}
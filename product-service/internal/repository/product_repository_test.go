@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/repository"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -55,9 +56,27 @@ func TestMain(m *testing.M) {
     	id SERIAL PRIMARY KEY,
     	name TEXT NOT NULL,
     	description TEXT,
-    	price NUMERIC(10,2) NOT NULL,
+    	price NUMERIC(19,4) NOT NULL,
+    	currency TEXT NOT NULL DEFAULT 'USD',
+    	category TEXT NOT NULL DEFAULT '',
+    	options TEXT[] NOT NULL DEFAULT '{}',
+    	owner_seller_id INTEGER,
     	created_at TIMESTAMP NOT NULL DEFAULT now(),
     	updated_at TIMESTAMP NOT NULL DEFAULT now()
+	);
+
+	CREATE TABLE product_variants (
+    	id SERIAL PRIMARY KEY,
+    	product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    	sku TEXT NOT NULL UNIQUE,
+    	options JSONB NOT NULL DEFAULT '{}',
+    	price NUMERIC(19,4),
+    	currency TEXT,
+    	stock_ref TEXT NOT NULL DEFAULT '',
+    	barcode TEXT UNIQUE,
+    	created_at TIMESTAMP NOT NULL DEFAULT now(),
+    	updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    	UNIQUE (product_id, options)
 	)`
 	_, err = dbpool.Exec(ctx, schema)
 	if err != nil {
@@ -86,7 +105,7 @@ func TestCreateAndGetALLProduct(t *testing.T) {
 	product := domain.Product{
 		Name:        "Test Product",
 		Description: "Cool product",
-		Price:       99.99,
+		Price:       money.MustParse("99.99", money.USD),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -131,7 +150,7 @@ func TestDeleteProduct_Success(t *testing.T) {
 	product := domain.Product{
 		Name:        "Test to delete Product",
 		Description: "To be deleted product",
-		Price:       99.99,
+		Price:       money.MustParse("99.99", money.USD),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRateRepository(db *pgxpool.Pool) *RateRepository {
+	return &RateRepository{db: db}
+}
+
+// RateRepositoryInterface хранит курсы валют относительно money.DefaultCurrency
+type RateRepositoryInterface interface {
+	GetRates(ctx context.Context) (map[money.Currency]string, error)
+	UpsertRates(ctx context.Context, rates map[money.Currency]string) error
+}
+
+func (r *RateRepository) GetRates(ctx context.Context) (map[money.Currency]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT currency, rate FROM product_service.exchange_rates`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make(map[money.Currency]string)
+	for rows.Next() {
+		var currency, rate string
+		if err := rows.Scan(&currency, &rate); err != nil {
+			return nil, err
+		}
+		rates[money.Currency(currency)] = rate
+	}
+	return rates, rows.Err()
+}
+
+// UpsertRates обновляет курсы в одной транзакции; валюты, которых нет в rates, не меняются
+func (r *RateRepository) UpsertRates(ctx context.Context, rates map[money.Currency]string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO product_service.exchange_rates (currency, rate, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at
+	`
+	for currency, rate := range rates {
+		if _, err := tx.Exec(ctx, query, currency, rate, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -7,35 +7,150 @@ import (
 	"log"
 	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/cache"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/userclient"
 )
 
 type ProductService struct {
-	repo  repository.ProductRepositoryInterface
-	cache *cache.RedisCache
+	repo    repository.ProductRepositoryInterface
+	cache   *cache.RedisCache
+	sellers userclient.SellersInterface
 }
 
+// ProductServiceInterface — каталог. userID в изменяющих методах — пользователь из
+// X-User-ID, по нему проверяются права продавца.
 type ProductServiceInterface interface {
-	Create(ctx context.Context, p domain.Product) error
+	Create(ctx context.Context, userID int64, p domain.Product) error
 	GetAll(ctx context.Context) ([]domain.Product, error)
 	GetByID(ctx context.Context, id int64) (domain.Product, error)
-	Delete(ctx context.Context, id int64) error
+	GetByIDs(ctx context.Context, ids []int64) ([]domain.Product, error)
+	GetBySeller(ctx context.Context, sellerID int64) ([]domain.Product, error)
+	Delete(ctx context.Context, userID, id int64) error
+	Search(ctx context.Context, query string) ([]domain.Product, error)
+	CreateVariant(ctx context.Context, userID int64, v domain.Variant) (domain.Variant, error)
+	DeleteVariant(ctx context.Context, userID, productID, variantID int64) error
 }
 
 func NewProductService(repo repository.ProductRepositoryInterface, cache *cache.RedisCache) *ProductService {
 	return &ProductService{repo: repo, cache: cache}
 }
 
-func (s *ProductService) Create(ctx context.Context, p domain.Product) error {
+// WithSellers подключает user-service для проверки прав в каталоге: создавать товары могут
+// администраторы и действующие продавцы, менять и удалять — администраторы и продавец-владелец.
+// Без него права проверить нельзя, и любые изменения каталога запрещены.
+func (s *ProductService) WithSellers(sellers userclient.SellersInterface) *ProductService {
+	s.sellers = sellers
+	return s
+}
+
+// access возвращает права пользователя в каталоге; пользователь без прав — domain.ErrForbidden
+func (s *ProductService) access(ctx context.Context, userID int64) (domain.SellerAccess, error) {
+	if s.sellers == nil || userID == 0 {
+		return domain.SellerAccess{}, domain.ErrForbidden
+	}
+	access, err := s.sellers.Access(ctx, userID)
+	if err != nil {
+		return domain.SellerAccess{}, err
+	}
+	if !access.Admin && access.SellerID == 0 {
+		return domain.SellerAccess{}, domain.ErrForbidden
+	}
+	return access, nil
+}
+
+// authorize проверяет, что пользователь может изменять продукт productID
+func (s *ProductService) authorize(ctx context.Context, userID, productID int64) error {
+	access, err := s.access(ctx, userID)
+	if err != nil {
+		return err
+	}
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if !access.CanManage(product) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// Create сохраняет продукт. Цена без валюты (нулевая) сохраняется в валюте по умолчанию.
+// Товар продавца всегда принадлежит ему самому; администратор может указать любого
+// владельца или оставить товар за маркетплейсом.
+func (s *ProductService) Create(ctx context.Context, userID int64, p domain.Product) error {
+	access, err := s.access(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !access.Admin {
+		p.OwnerSellerID = &access.SellerID
+	}
+	if p.Price.IsNegative() {
+		return fmt.Errorf("%w: price can't be negative", domain.ErrInvalidPrice)
+	}
+	if p.Price.Currency() == "" {
+		p.Price = money.Zero(money.DefaultCurrency)
+	}
+	if len(p.Variants) > 0 && len(p.Options) == 0 {
+		return fmt.Errorf("%w: options are required for a product with variants", domain.ErrInvalidVariant)
+	}
+	for _, v := range p.Variants {
+		if err := p.ValidateVariant(v); err != nil {
+			return fmt.Errorf("variant %q: %w", v.SKU, err)
+		}
+	}
 	return s.repo.CreateProduct(ctx, p)
 }
 
+// Search ищет продукты по названию, описанию, SKU или штрихкоду варианта
+func (s *ProductService) Search(ctx context.Context, query string) ([]domain.Product, error) {
+	return s.repo.SearchProducts(ctx, query)
+}
+
+// CreateVariant добавляет продукту вариант и сбрасывает кэш продукта
+func (s *ProductService) CreateVariant(ctx context.Context, userID int64, v domain.Variant) (domain.Variant, error) {
+	if err := s.authorize(ctx, userID, v.ProductID); err != nil {
+		return domain.Variant{}, err
+	}
+	product, err := s.repo.GetProductByID(ctx, v.ProductID)
+	if err != nil {
+		return domain.Variant{}, err
+	}
+	if err := product.ValidateVariant(v); err != nil {
+		return domain.Variant{}, err
+	}
+
+	created, err := s.repo.CreateVariant(ctx, v)
+	if err != nil {
+		return domain.Variant{}, err
+	}
+	_ = s.cache.Delete(ctx, fmt.Sprintf("product:%d", v.ProductID))
+	return created, nil
+}
+
+func (s *ProductService) DeleteVariant(ctx context.Context, userID, productID, variantID int64) error {
+	if err := s.authorize(ctx, userID, productID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteVariant(ctx, productID, variantID); err != nil {
+		return err
+	}
+	_ = s.cache.Delete(ctx, fmt.Sprintf("product:%d", productID))
+	return nil
+}
+
 func (s *ProductService) GetAll(ctx context.Context) ([]domain.Product, error) {
 	return s.repo.GetAllProducts(ctx)
 }
 
+// GetBySeller возвращает каталог продавца
+func (s *ProductService) GetBySeller(ctx context.Context, sellerID int64) ([]domain.Product, error) {
+	return s.repo.GetProductsBySeller(ctx, sellerID)
+}
+
 func (s *ProductService) GetByID(ctx context.Context, id int64) (domain.Product, error) {
 	cacheKey := fmt.Sprintf("product:%d", id)
 
@@ -60,7 +175,77 @@ func (s *ProductService) GetByID(ctx context.Context, id int64) (domain.Product,
 	return product, nil
 }
 
-func (s *ProductService) Delete(ctx context.Context, id int64) error {
+// GetByIDs возвращает продукты в порядке запрошенных ID, пропуская несуществующие.
+// Кэш читается одним MGET, промахи добираются из БД одним запросом.
+func (s *ProductService) GetByIDs(ctx context.Context, ids []int64) ([]domain.Product, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return []domain.Product{}, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("product:%d", id)
+	}
+
+	found := make(map[int64]domain.Product, len(ids))
+	cached, err := s.cache.MGet(ctx, keys...)
+	if err != nil {
+		cached = make([]string, len(ids))
+	}
+
+	var missing []int64
+	for i, id := range ids {
+		var product domain.Product
+		if cached[i] != "" && json.Unmarshal([]byte(cached[i]), &product) == nil {
+			found[id] = product
+			continue
+		}
+		missing = append(missing, id)
+	}
+
+	log.Printf("Cache batch: %d hit, %d miss", len(ids)-len(missing), len(missing))
+
+	if len(missing) > 0 {
+		products, err := s.repo.GetProductsByIDs(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+
+		toCache := make(map[string]string, len(products))
+		for _, p := range products {
+			found[p.ID] = p
+			data, _ := json.Marshal(p)
+			toCache[fmt.Sprintf("product:%d", p.ID)] = string(data)
+		}
+		_ = s.cache.SetMany(ctx, toCache, time.Minute*5)
+	}
+
+	result := make([]domain.Product, 0, len(found))
+	for _, id := range ids {
+		if p, ok := found[id]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func (s *ProductService) Delete(ctx context.Context, userID, id int64) error {
+	if err := s.authorize(ctx, userID, id); err != nil {
+		return err
+	}
 	err := s.repo.DeleteProduct(ctx, id)
 	if err != nil {
 		return err
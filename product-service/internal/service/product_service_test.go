@@ -3,9 +3,11 @@ package service_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/cache"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/service"
@@ -34,12 +36,32 @@ func (m *mockProductRepo) GetAllProducts(ctx context.Context) ([]domain.Product,
 func (m *mockProductRepo) GetProductByID(ctx context.Context, id int64) (domain.Product, error) {
 	p, ok := m.products[id]
 	if !ok {
-		return domain.Product{}, errors.New("product not found")
+		return domain.Product{}, domain.ErrProductNotFound
 	}
 
 	return p, nil
 }
 
+func (m *mockProductRepo) GetProductsByIDs(ctx context.Context, ids []int64) ([]domain.Product, error) {
+	var result []domain.Product
+	for _, id := range ids {
+		if p, ok := m.products[id]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockProductRepo) GetProductsBySeller(ctx context.Context, sellerID int64) ([]domain.Product, error) {
+	var result []domain.Product
+	for _, p := range m.products {
+		if p.OwnerSellerID != nil && *p.OwnerSellerID == sellerID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockProductRepo) DeleteProduct(ctx context.Context, id int64) error {
 	if _, ok := m.products[id]; !ok {
 		return errors.New("product not found")
@@ -48,27 +70,62 @@ func (m *mockProductRepo) DeleteProduct(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (m *mockProductRepo) SearchProducts(ctx context.Context, query string) ([]domain.Product, error) {
+	var result []domain.Product
+	for _, p := range m.products {
+		if strings.Contains(p.Name, query) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockProductRepo) CreateVariant(ctx context.Context, v domain.Variant) (domain.Variant, error) {
+	p, ok := m.products[v.ProductID]
+	if !ok {
+		return domain.Variant{}, domain.ErrProductNotFound
+	}
+	m.nextID++
+	v.ID = m.nextID
+	p.Variants = append(p.Variants, v)
+	m.products[p.ID] = p
+	return v, nil
+}
+
+func (m *mockProductRepo) DeleteVariant(ctx context.Context, productID, variantID int64) error {
+	return domain.ErrVariantNotFound
+}
+
 func setupService() (*service.ProductService, *mockProductRepo) {
 	mock := &mockProductRepo{
 		products: make(map[int64]domain.Product),
 	}
 	cache := cache.NewRedisCache("redis:6379")
-	svc := service.NewProductService(mock, cache)
+	svc := service.NewProductService(mock, cache).WithSellers(mockSellers{1: {Admin: true}})
 	return svc, mock
 }
 
+// mockSellers — права пользователей в каталоге по их ID
+type mockSellers map[int64]domain.SellerAccess
+
+func (m mockSellers) Access(ctx context.Context, userID int64) (domain.SellerAccess, error) {
+	access := m[userID]
+	access.UserID = userID
+	return access, nil
+}
+
 func TestCreateProduct(t *testing.T) {
 	svc, _ := setupService()
 
 	p := domain.Product{
 		Name:        "Test",
 		Description: "Test description",
-		Price:       10.5,
+		Price:       money.MustParse("10.50", money.USD),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	err := svc.Create(context.Background(), p)
+	err := svc.Create(context.Background(), 1, p)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -111,7 +168,7 @@ func TestDeleteProduct(t *testing.T) {
 	_ = mock.CreateProduct(context.Background(), domain.Product{Name: "DeleteMe"})
 	var id int64 = 1
 
-	err := svc.Delete(context.Background(), id)
+	err := svc.Delete(context.Background(), 1, id)
 	if err != nil {
 		t.Fatalf("expected no error on delete, got %v", err)
 	}
@@ -121,3 +178,147 @@ func TestDeleteProduct(t *testing.T) {
 		t.Fatalf("expected error after delete, got nil")
 	}
 }
+
+func TestGetByIDs(t *testing.T) {
+	svc, mock := setupService()
+
+	_ = mock.CreateProduct(context.Background(), domain.Product{Name: "First"})
+	_ = mock.CreateProduct(context.Background(), domain.Product{Name: "Second"})
+
+	products, err := svc.GetByIDs(context.Background(), []int64{2, 99, 1, 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(products) != 2 {
+		t.Fatalf("expected 2 products, got %d", len(products))
+	}
+	if products[0].Name != "Second" || products[1].Name != "First" {
+		t.Errorf("expected products in request order, got %+v", products)
+	}
+}
+
+func TestCreateProduct_ValidatesVariants(t *testing.T) {
+	svc, _ := setupService()
+
+	tests := []struct {
+		name    string
+		product domain.Product
+		err     error
+	}{
+		{"variants without axes", domain.Product{Variants: []domain.Variant{{SKU: "A"}}}, domain.ErrInvalidVariant},
+		{"missing sku", domain.Product{Options: []string{"size"}, Variants: []domain.Variant{{Options: map[string]string{"size": "M"}}}}, domain.ErrInvalidVariant},
+		{"missing axis value", domain.Product{Options: []string{"size", "color"}, Variants: []domain.Variant{{SKU: "A", Options: map[string]string{"size": "M"}}}}, domain.ErrInvalidVariant},
+		{"unknown axis", domain.Product{Options: []string{"size"}, Variants: []domain.Variant{{SKU: "A", Options: map[string]string{"color": "red"}}}}, domain.ErrInvalidVariant},
+		{"other currency", domain.Product{
+			Price:    money.MustParse("10", money.USD),
+			Options:  []string{"size"},
+			Variants: []domain.Variant{{SKU: "A", Options: map[string]string{"size": "M"}, Price: ptr(money.MustParse("12", money.EUR))}},
+		}, domain.ErrInvalidPrice},
+	}
+
+	for _, tt := range tests {
+		if err := svc.Create(context.Background(), 1, tt.product); !errors.Is(err, tt.err) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestCreateVariant(t *testing.T) {
+	svc, mock := setupService()
+	_ = mock.CreateProduct(context.Background(), domain.Product{
+		Name:    "T-shirt",
+		Price:   money.MustParse("10", money.USD),
+		Options: []string{"size"},
+	})
+
+	v, err := svc.CreateVariant(context.Background(), 1, domain.Variant{
+		ProductID: 1,
+		SKU:       "TS-M",
+		Options:   map[string]string{"size": "M"},
+		Price:     ptr(money.MustParse("12", money.USD)),
+		Barcode:   "4600000000017",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if v.ID == 0 || len(mock.products[1].Variants) != 1 {
+		t.Fatalf("expected variant to be stored, got %+v", mock.products[1])
+	}
+
+	if _, err := svc.CreateVariant(context.Background(), 1, domain.Variant{ProductID: 42, SKU: "X"}); !errors.Is(err, domain.ErrProductNotFound) {
+		t.Fatalf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func ptr(m money.Money) *money.Money { return &m }
+
+func TestSellerOwnership(t *testing.T) {
+	ctx := context.Background()
+	svc, mock := setupService()
+	svc.WithSellers(mockSellers{
+		1: {Admin: true},
+		2: {SellerID: 10},
+		4: {SellerID: 11},
+	})
+
+	// Продавец не может выставить товар от имени другого продавца
+	other := int64(99)
+	if err := svc.Create(ctx, 2, domain.Product{Name: "Book", OwnerSellerID: &other}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if owner := mock.products[1].OwnerSellerID; owner == nil || *owner != 10 {
+		t.Fatalf("expected product to belong to seller 10, got %v", owner)
+	}
+	if err := svc.Create(ctx, 1, domain.Product{Name: "House brand"}); err != nil {
+		t.Fatalf("expected admin to create marketplace product, got %v", err)
+	}
+	if mock.products[2].OwnerSellerID != nil {
+		t.Fatalf("expected marketplace product without owner, got %v", *mock.products[2].OwnerSellerID)
+	}
+	for _, userID := range []int64{0, 3} {
+		if err := svc.Create(ctx, userID, domain.Product{Name: "Spam"}); !errors.Is(err, domain.ErrForbidden) {
+			t.Fatalf("user %d: expected ErrForbidden, got %v", userID, err)
+		}
+	}
+
+	if err := svc.Delete(ctx, 4, 1); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for another seller, got %v", err)
+	}
+	if err := svc.Delete(ctx, 2, 2); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for marketplace product, got %v", err)
+	}
+	if _, err := svc.CreateVariant(ctx, 4, domain.Variant{ProductID: 1, SKU: "X"}); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for variant of another seller, got %v", err)
+	}
+	if err := svc.Delete(ctx, 2, 1); err != nil {
+		t.Fatalf("expected owner to delete product, got %v", err)
+	}
+	if err := svc.Delete(ctx, 1, 2); err != nil {
+		t.Fatalf("expected admin to delete any product, got %v", err)
+	}
+}
+
+func TestCatalogWritesDeniedWithoutSellers(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockProductRepo{products: map[int64]domain.Product{
+		1: {ID: 1, Name: "Book", Options: []string{"cover"}},
+	}}
+	svc := service.NewProductService(mock, cache.NewRedisCache("redis:6379"))
+
+	// Без user-service права не проверить, поэтому изменения каталога запрещены даже администратору
+	if err := svc.Create(ctx, 1, domain.Product{Name: "Spam"}); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden on create, got %v", err)
+	}
+	if _, err := svc.CreateVariant(ctx, 1, domain.Variant{ProductID: 1, SKU: "X", Options: map[string]string{"cover": "hard"}}); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden on variant create, got %v", err)
+	}
+	if err := svc.DeleteVariant(ctx, 1, 1, 1); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden on variant delete, got %v", err)
+	}
+	if err := svc.Delete(ctx, 1, 1); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden on delete, got %v", err)
+	}
+	if len(mock.products) != 1 {
+		t.Fatalf("expected catalog to stay unchanged, got %d products", len(mock.products))
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/money"
+	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/product-service/internal/repository"
+)
+
+// RateService управляет таблицей курсов. Базовая валюта таблицы — money.DefaultCurrency.
+type RateService struct {
+	repo repository.RateRepositoryInterface
+}
+
+type RateServiceInterface interface {
+	GetRates(ctx context.Context) (*money.RateTable, error)
+	SetRates(ctx context.Context, table *money.RateTable) error
+}
+
+func NewRateService(repo repository.RateRepositoryInterface) *RateService {
+	return &RateService{repo: repo}
+}
+
+func (s *RateService) GetRates(ctx context.Context) (*money.RateTable, error) {
+	rates, err := s.repo.GetRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return money.NewRateTable(money.DefaultCurrency, rates)
+}
+
+// SetRates обновляет курсы из таблицы; курсы, которых в ней нет, остаются прежними.
+func (s *RateService) SetRates(ctx context.Context, table *money.RateTable) error {
+	if table.Base() != money.DefaultCurrency {
+		return fmt.Errorf("%w: base currency must be %s", money.ErrInvalidRate, money.DefaultCurrency)
+	}
+	return s.repo.UpsertRates(ctx, table.Rates())
+}
+
+// LoadFile загружает курсы из JSON-файла вида {"base":"USD","rates":{"EUR":"0.92"}}.
+func (s *RateService) LoadFile(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var table money.RateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return s.SetRates(ctx, &table)
+}
+
+// ConvertPrices пересчитывает цены продуктов и их вариантов в валюту to; исходные цены остаются в BasePrice.
+func ConvertPrices(table *money.RateTable, products []domain.Product, to money.Currency) error {
+	for i := range products {
+		p := &products[i]
+		if p.Price.Currency() == to {
+			continue
+		}
+
+		converted, rate, err := table.Convert(p.Price, to)
+		if err != nil {
+			return err
+		}
+		base := p.Price
+		p.BasePrice, p.Price, p.ExchangeRate = &base, converted, money.FormatRate(rate)
+
+		for j := range p.Variants {
+			v := &p.Variants[j]
+			if v.Price == nil {
+				continue
+			}
+			convertedVariant, err := v.Price.Convert(to, rate, money.HalfUp)
+			if err != nil {
+				return fmt.Errorf("variant %s: %w", v.SKU, err)
+			}
+			v.BasePrice, v.Price = v.Price, &convertedVariant
+		}
+	}
+	return nil
+}
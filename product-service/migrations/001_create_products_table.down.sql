@@ -0,0 +1 @@
+DROP TABLE IF EXISTS product_service.products;
\ No newline at end of file
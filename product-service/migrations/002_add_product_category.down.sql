@@ -0,0 +1 @@
+ALTER TABLE product_service.products DROP COLUMN IF EXISTS category;
@@ -0,0 +1 @@
+ALTER TABLE product_service.products ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- Курсы валют относительно валюты по умолчанию (USD): за 1 USD дают rate единиц currency
+CREATE TABLE IF NOT EXISTS product_service.exchange_rates (
+    currency TEXT PRIMARY KEY,
+    rate NUMERIC(20,10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_service.product_variants;
+ALTER TABLE product_service.products DROP COLUMN IF EXISTS options;
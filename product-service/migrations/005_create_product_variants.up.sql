@@ -0,0 +1,17 @@
+-- Оси вариантов продукта, например {size,color}; пустой массив — продукт без вариантов
+ALTER TABLE product_service.products ADD COLUMN IF NOT EXISTS options TEXT[] NOT NULL DEFAULT '{}';
+
+-- Варианты (SKU): значения осей, необязательная своя цена, ссылка на складскую позицию и штрихкод
+CREATE TABLE IF NOT EXISTS product_service.product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES product_service.products(id) ON DELETE CASCADE,
+    sku TEXT NOT NULL UNIQUE,
+    options JSONB NOT NULL DEFAULT '{}',
+    price NUMERIC(10,2),
+    currency TEXT,
+    stock_ref TEXT NOT NULL DEFAULT '',
+    barcode TEXT UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT product_variants_options_unique UNIQUE (product_id, options)
+);
@@ -0,0 +1 @@
+ALTER TABLE product_service.products DROP COLUMN IF EXISTS owner_seller_id;
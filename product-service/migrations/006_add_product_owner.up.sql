@@ -0,0 +1,3 @@
+-- Продавец-владелец товара (id профиля продавца в user-service). NULL — товар самого
+-- маркетплейса, им управляют только администраторы.
+ALTER TABLE product_service.products ADD COLUMN IF NOT EXISTS owner_seller_id INTEGER;
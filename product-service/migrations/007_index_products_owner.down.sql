@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS product_service.products_owner_seller_id_idx;
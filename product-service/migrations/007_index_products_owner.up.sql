@@ -0,0 +1,2 @@
+-- Каталог продавца: GET /products?seller_id=
+CREATE INDEX CONCURRENTLY IF NOT EXISTS products_owner_seller_id_idx ON product_service.products (owner_seller_id);
@@ -0,0 +1,3 @@
+-- Цены с тремя знаками после запятой округляются до двух
+ALTER TABLE product_service.product_variants ALTER COLUMN price TYPE NUMERIC(10,2);
+ALTER TABLE product_service.products ALTER COLUMN price TYPE NUMERIC(10,2);
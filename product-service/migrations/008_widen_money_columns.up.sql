@@ -0,0 +1,6 @@
+-- Цены в валютах с тремя знаками после запятой (KWD, BHD) не помещались в NUMERIC(10,2)
+-- и округлялись при записи. Смена масштаба переписывает таблицы, поэтому ограничение
+-- на время выполнения для этой миграции больше обычного.
+-- migrate:statement_timeout=30min
+ALTER TABLE product_service.products ALTER COLUMN price TYPE NUMERIC(19,4);
+ALTER TABLE product_service.product_variants ALTER COLUMN price TYPE NUMERIC(19,4);
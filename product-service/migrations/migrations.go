@@ -0,0 +1,10 @@
+// Package migrations содержит SQL-миграции схемы product_service, которой владеет product-service.
+package migrations
+
+import "embed"
+
+// Schema — схема PostgreSQL сервиса. Версия миграций хранится в product_service.schema_migrations.
+const Schema = "product_service"
+
+//go:embed *.sql
+var FS embed.FS
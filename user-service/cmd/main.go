@@ -6,11 +6,19 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/OvsyannikovAlexandr/marketplace/pkg/migrator"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/cartclient"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/db"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/exportclient"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/notify"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/migrations"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/pkg/kafka"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
@@ -29,13 +37,188 @@ func main() {
 	defer dbpool.Close()
 	log.Println("Connected to PostgreSQL")
 
+	// По умолчанию миграции накатывает migration-service; флаг позволяет сервису
+	// применить свои миграции самому с теми же проверками и ограничениями
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		svc := migrator.Service{Name: "user-service", Schema: migrations.Schema, FS: migrations.FS}
+		if err := migrator.Apply(ctx, dbpool, svc, migrator.TimeoutsFromEnv()); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		log.Println("Migrations applied")
+	}
+
 	userRepo := repository.NewUserRepository(dbpool)
-	authService := service.NewAuthService(userRepo)
+	sessionService := service.NewSessionService(repository.NewSessionRepository(dbpool))
+	authService := service.NewAuthService(userRepo).
+		WithSessions(sessionService).
+		WithSessionRevoker(sessionService)
+	if cartServiceURL := os.Getenv("CART_SERVICE_URL"); cartServiceURL != "" {
+		authService.WithCartMerger(cartclient.NewClient(cartServiceURL))
+	}
+	resetTTL := service.DefaultResetTokenTTL
+	if v := os.Getenv("PASSWORD_RESET_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			resetTTL = d
+		} else {
+			log.Printf("Некорректный PASSWORD_RESET_TTL %q, используется %s", v, resetTTL)
+		}
+	}
+	// Письма уходят по SMTP; без почтового сервера пишутся в файлы NOTIFY_DIR или в лог
+	var notifier notify.Notifier = notify.LogNotifier{}
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		smtpPort := os.Getenv("SMTP_PORT")
+		if smtpPort == "" {
+			smtpPort = "587"
+		}
+		notifier = notify.NewSMTPNotifier(smtpHost, smtpPort, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+	} else if dir := os.Getenv("NOTIFY_DIR"); dir != "" {
+		notifier = notify.NewFileNotifier(dir)
+	}
+	authService.WithPasswordReset(notifier, resetTTL)
+
+	verifyURL := os.Getenv("EMAIL_VERIFY_URL")
+	if verifyURL == "" {
+		verifyURL = "http://localhost:3000/verify-email"
+	}
+	verifyTTL, _ := time.ParseDuration(os.Getenv("EMAIL_VERIFY_TTL"))
+	authService.WithEmailVerification(notifier, verifyURL, verifyTTL)
+
+	throttleConfig := service.DefaultThrottleConfig()
+	if v, err := strconv.Atoi(os.Getenv("LOGIN_MAX_FAILURES")); err == nil && v > 0 {
+		throttleConfig.MaxAccountFailures = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("LOGIN_MAX_IP_FAILURES")); err == nil && v > 0 {
+		throttleConfig.MaxIPFailures = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT")); err == nil && v > 0 {
+		throttleConfig.Lockout = v
+	}
+	// События пользователей (UserLockedOut, UserDeleted, UserBanned) публикуются, только если задан брокер
+	var userProducer *kafka.UserProducer
+	var securityEvents kafka.Producer
+	if kafkaBroker := os.Getenv("KAFKA_BROKER"); kafkaBroker != "" {
+		topic := os.Getenv("USER_EVENTS_TOPIC")
+		if topic == "" {
+			topic = "user-events"
+		}
+		producer := kafka.NewUserProducer(kafkaBroker, topic)
+		defer producer.Close()
+		userProducer, securityEvents = producer, producer
+	}
+	authService.WithLoginThrottle(repository.NewLoginAttemptRepository(dbpool), securityEvents, throttleConfig)
+
+	twoFactorRepo := repository.NewTwoFactorRepository(dbpool)
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+	if totpIssuer == "" {
+		totpIssuer = "Marketplace"
+	}
+	authService.WithTwoFactor(twoFactorRepo, totpIssuer)
+
 	authHendler := handler.NewAuthHandler(authService)
+	adminRepo := repository.NewAdminRepository(dbpool)
+	userService := service.NewUserService(userRepo).
+		WithEmailVerifier(authService).
+		WithTwoFactorPolicy(twoFactorRepo).
+		WithAuditLog(adminRepo)
+	userHandler := handler.NewUserHandler(userService)
+	adminService := service.NewAdminService(adminRepo, userService)
+	if userProducer != nil {
+		adminService.WithEvents(userProducer)
+	}
+	adminHandler := handler.NewAdminHandler(adminService)
+	sellerHandler := handler.NewSellerHandler(service.NewSellerService(repository.NewSellerRepository(dbpool), userService))
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	addressRepo := repository.NewAddressRepository(dbpool)
+	addressHandler := handler.NewAddressHandler(service.NewAddressService(addressRepo))
+
+	// Выгрузка собирает данные cart-service и order-service через их внутренние эндпоинты
+	privacyService := service.NewPrivacyService(userRepo, repository.NewPrivacyRepository(dbpool), addressRepo,
+		repository.NewSessionRepository(dbpool))
+	if cartServiceURL := os.Getenv("CART_SERVICE_URL"); cartServiceURL != "" {
+		privacyService.WithExportSource("cart", exportclient.NewClient(cartServiceURL, 10*time.Second))
+	}
+	if orderServiceURL := os.Getenv("ORDER_SERVICE_URL"); orderServiceURL != "" {
+		privacyService.WithExportSource("orders", exportclient.NewClient(orderServiceURL, 10*time.Second))
+	}
+	if userProducer != nil {
+		// UserDeleted публикуется из outbox; то, что не ушло сразу, отправляется повторно
+		relayInterval := 10 * time.Second
+		if v, err := time.ParseDuration(os.Getenv("USER_EVENTS_RELAY_INTERVAL")); err == nil && v > 0 {
+			relayInterval = v
+		}
+		privacyService.WithEvents(userProducer)
+		go privacyService.RunEventRelay(ctx, relayInterval)
+	}
+	privacyHandler := handler.NewPrivacyHandler(privacyService)
+
+	// Ключ подписи OIDC-токенов; без OIDC_SIGNING_KEY_FILE ключ создаётся при старте,
+	// и после перезапуска партнёрам придётся заново получить JWKS и токены
+	var signingKey *service.SigningKey
+	if keyFile := os.Getenv("OIDC_SIGNING_KEY_FILE"); keyFile != "" {
+		signingKey, err = service.LoadSigningKey(keyFile)
+	} else {
+		log.Println("OIDC_SIGNING_KEY_FILE не задан, используется временный ключ подписи")
+		signingKey, err = service.GenerateSigningKey()
+	}
+	if err != nil {
+		log.Fatalf("failed to init OIDC signing key: %v", err)
+	}
+	oidcIssuer := os.Getenv("OIDC_ISSUER")
+	if oidcIssuer == "" {
+		oidcIssuer = "http://localhost:8080"
+	}
+	oidcHandler := handler.NewOIDCHandler(service.NewOIDCService(
+		repository.NewOAuthRepository(dbpool), userRepo, authService, userService, signingKey, oidcIssuer).
+		WithAuditLog(adminRepo))
 
 	router := mux.NewRouter()
 	router.HandleFunc("/users/register", authHendler.RegisterHandler).Methods("POST")
 	router.HandleFunc("/users/login", authHendler.LoginHandler).Methods("POST")
+	router.HandleFunc("/users/login/2fa", authHendler.LoginTwoFactorHandler).Methods("POST")
+	router.HandleFunc("/users/me", userHandler.Me).Methods("GET")
+	router.HandleFunc("/users/me", userHandler.UpdateMe).Methods("PATCH")
+	router.HandleFunc("/users/me", privacyHandler.DeleteMe).Methods("DELETE")
+	router.HandleFunc("/users/me/export", privacyHandler.Export).Methods("POST")
+	router.HandleFunc("/users/me/password", authHendler.ChangePasswordHandler).Methods("POST")
+	router.HandleFunc("/users/me/sessions", sessionHandler.ListMine).Methods("GET")
+	router.HandleFunc("/users/me/sessions/{id}", sessionHandler.RevokeMine).Methods("DELETE")
+	router.HandleFunc("/users/me/addresses", addressHandler.List).Methods("GET")
+	router.HandleFunc("/users/me/addresses", addressHandler.Create).Methods("POST")
+	router.HandleFunc("/users/me/addresses/{id:[0-9]+}", addressHandler.Get).Methods("GET")
+	router.HandleFunc("/users/me/addresses/{id:[0-9]+}", addressHandler.Update).Methods("PUT")
+	router.HandleFunc("/users/me/addresses/{id:[0-9]+}", addressHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/users/me/seller", sellerHandler.Me).Methods("GET")
+	router.HandleFunc("/users/me/seller", sellerHandler.Apply).Methods("POST")
+	router.HandleFunc("/users/me/seller", sellerHandler.UpdateMe).Methods("PATCH")
+	router.HandleFunc("/users/me/2fa/enroll", authHendler.EnrollTwoFactorHandler).Methods("POST")
+	router.HandleFunc("/users/me/2fa/confirm", authHendler.ConfirmTwoFactorHandler).Methods("POST")
+	router.HandleFunc("/users/me/2fa/disable", authHendler.DisableTwoFactorHandler).Methods("POST")
+	router.HandleFunc("/users/roles/{role}/2fa", userHandler.SetTwoFactorPolicy).Methods("PUT")
+	router.HandleFunc("/users/verify", authHendler.VerifyEmailHandler).Methods("POST")
+	router.HandleFunc("/users/verify/resend", authHendler.ResendVerificationHandler).Methods("POST")
+	router.HandleFunc("/users/password/reset", authHendler.RequestPasswordResetHandler).Methods("POST")
+	router.HandleFunc("/users/password/reset/confirm", authHendler.ConfirmPasswordResetHandler).Methods("POST")
+	router.HandleFunc("/users", adminHandler.ListUsers).Methods("GET")
+	router.HandleFunc("/users/audit", adminHandler.AuditLog).Methods("GET")
+	router.HandleFunc("/users/sellers", sellerHandler.List).Methods("GET")
+	router.HandleFunc("/users/sellers/{id:[0-9]+}", sellerHandler.Get).Methods("GET")
+	router.HandleFunc("/users/sellers/{id:[0-9]+}/status", sellerHandler.SetStatus).Methods("PUT")
+	router.HandleFunc("/users/{id:[0-9]+}", userHandler.GetByID).Methods("GET")
+	router.HandleFunc("/users/{id:[0-9]+}/ban", adminHandler.Ban).Methods("POST")
+	router.HandleFunc("/users/{id:[0-9]+}/unban", adminHandler.Unban).Methods("POST")
+	router.HandleFunc("/users/{id:[0-9]+}/role", adminHandler.SetRole).Methods("PUT")
+
+	router.HandleFunc("/internal/sessions/{id}", sessionHandler.Check).Methods("GET")
+	router.HandleFunc("/internal/users/{user_id:[0-9]+}/addresses/{id}", addressHandler.Resolve).Methods("GET")
+	router.HandleFunc("/internal/users/{user_id:[0-9]+}/seller", sellerHandler.Access).Methods("GET")
+
+	router.HandleFunc("/.well-known/openid-configuration", oidcHandler.Discovery).Methods("GET")
+	router.HandleFunc("/oauth2/jwks", oidcHandler.JWKS).Methods("GET")
+	router.HandleFunc("/oauth2/authorize", oidcHandler.Authorize).Methods("GET")
+	router.HandleFunc("/oauth2/authorize", oidcHandler.AuthorizeSubmit).Methods("POST")
+	router.HandleFunc("/oauth2/token", oidcHandler.Token).Methods("POST")
+	router.HandleFunc("/oauth2/userinfo", oidcHandler.UserInfo).Methods("GET", "POST")
+	router.HandleFunc("/oauth2/clients", oidcHandler.RegisterClient).Methods("POST")
 
 	port := os.Getenv("PORT")
 	if port == "" {
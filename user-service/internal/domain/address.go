@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Типы адресов
+const (
+	AddressShipping = "shipping"
+	AddressBilling  = "billing"
+)
+
+// MaxAddressesPerUser ограничивает размер адресной книги
+const MaxAddressesPerUser = 20
+
+var (
+	ErrAddressNotFound = errors.New("address not found")
+	ErrInvalidAddress  = errors.New("invalid address")
+)
+
+// Address — адрес доставки или плательщика из адресной книги пользователя.
+// У каждого типа, пока есть хотя бы один адрес, ровно один адрес по умолчанию.
+type Address struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"-"`
+	Type      string `json:"type"`
+	IsDefault bool   `json:"is_default"`
+	Recipient string `json:"recipient"`
+	Phone     string `json:"phone,omitempty"`
+	// Country — код страны ISO 3166-1 alpha-2, от него зависят правила проверки индекса и региона
+	Country    string    `json:"country"`
+	Region     string    `json:"region,omitempty"`
+	City       string    `json:"city"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2,omitempty"`
+	PostalCode string    `json:"postal_code"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Состояния аккаунта, по которым администратор фильтрует пользователей
+const (
+	UserStatusActive  = "active"
+	UserStatusBanned  = "banned"
+	UserStatusDeleted = "deleted"
+)
+
+// Действия администраторов, которые попадают в журнал
+const (
+	AuditUserBan         = "user.ban"
+	AuditUserUnban       = "user.unban"
+	AuditUserRoleChange  = "user.role_change"
+	AuditTwoFactorPolicy = "role.two_factor_policy"
+	AuditOAuthClient     = "oauth.client_register"
+)
+
+// Размер страницы списка пользователей и журнала
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+var (
+	ErrUserBanned        = errors.New("account is banned")
+	ErrUserAlreadyBanned = errors.New("user is already banned")
+	ErrUserNotBanned     = errors.New("user is not banned")
+	ErrInvalidRole       = errors.New("invalid role")
+	ErrInvalidFilter     = errors.New("invalid filter")
+)
+
+// Roles — роли, которые администратор может назначить
+var Roles = []string{RoleUser, RoleSeller, RoleAdmin}
+
+// UserFilter — условия выборки пользователей для администратора; пустые поля не ограничивают
+type UserFilter struct {
+	EmailPrefix string
+	Role        string
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Limit       int
+	Offset      int
+}
+
+// UserPage — страница списка пользователей; Total — сколько всего подходит под фильтр
+type UserPage struct {
+	Users  []User `json:"users"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// AuditEntry — запись журнала действий администраторов
+type AuditEntry struct {
+	ID           int64           `json:"id"`
+	ActorID      int             `json:"actor_id"`
+	Action       string          `json:"action"`
+	TargetUserID *int            `json:"target_user_id,omitempty"`
+	Details      json.RawMessage `json:"details"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// AuditFilter — выборка из журнала; нулевые поля не ограничивают
+type AuditFilter struct {
+	ActorID      int
+	TargetUserID int
+	Action       string
+	Limit        int
+	Offset       int
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidCredentials — единая ошибка входа: по ней нельзя понять, существует ли email
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrLockedOut          = errors.New("too many failed login attempts")
+)
+
+// LoginAttempts — неудачные попытки входа по ключу: email (account:...) или IP (ip:...)
+type LoginAttempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// LockoutError — вход временно запрещён; повторить можно через RetryAfter
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLockedOut, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrLockedOut
+}
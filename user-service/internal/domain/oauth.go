@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Области доступа OIDC; openid обязательна для любого запроса авторизации
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopePhone   = "phone"
+)
+
+var (
+	ErrUnknownClient            = errors.New("unknown client")
+	ErrInvalidRedirectURI       = errors.New("redirect_uri is not registered for the client")
+	ErrInvalidClientMetadata    = errors.New("invalid client metadata")
+	ErrInvalidAuthorizationCode = errors.New("invalid or expired authorization code")
+	ErrInvalidConsentTicket     = errors.New("invalid or expired consent ticket")
+	ErrInvalidAccessToken       = errors.New("invalid access token")
+)
+
+// OAuthClient — приложение партнёра, зарегистрированное администратором.
+// У публичных клиентов (SPA, мобильные приложения) секрета нет, их защищает PKCE.
+type OAuthClient struct {
+	ID           string    `json:"client_id"`
+	Name         string    `json:"name"`
+	SecretHash   string    `json:"-"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	CreatedBy    int       `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Confidential сообщает, должен ли клиент предъявлять секрет на /oauth2/token
+func (c OAuthClient) Confidential() bool {
+	return c.SecretHash != ""
+}
+
+// OAuthClientRegistration — ответ на регистрацию; секрет показывается один раз
+type OAuthClientRegistration struct {
+	OAuthClient
+	Secret string `json:"client_secret,omitempty"`
+}
+
+// AuthorizationRequest — параметры /oauth2/authorize
+type AuthorizationRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state,omitempty"`
+	Nonce               string `json:"nonce,omitempty"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+// AuthorizeResult — итог входа на странице авторизации: адрес возврата с кодом или,
+// если согласия ещё нет, подписанный билет для экрана согласия
+type AuthorizeResult struct {
+	RedirectURL   string
+	ConsentTicket string
+	Client        OAuthClient
+	Scopes        []string
+}
+
+// AuthorizationCode — выданный после согласия пользователя код авторизации
+type AuthorizationCode struct {
+	ClientID      string
+	UserID        int
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	AuthTime      time.Time
+}
+
+// TokenRequest — параметры /oauth2/token (grant_type=authorization_code)
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	ClientID     string
+	ClientSecret string
+	CodeVerifier string
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// OAuthError — ошибка протокола OAuth 2.0 (RFC 6749, раздел 5.2)
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// NewOAuthError создаёт ошибку протокола с кодом из RFC 6749
+func NewOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// OIDCDiscovery — документ /.well-known/openid-configuration
+type OIDCDiscovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+// JWK — открытый ключ подписи токенов (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
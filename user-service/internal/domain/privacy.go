@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrExportUnavailable — один из сервисов не отдал данные пользователя, архив был бы неполным
+var ErrExportUnavailable = errors.New("data export is temporarily unavailable")
+
+// OAuthConsent — доступ, который пользователь выдал приложению партнёра
+type OAuthConsent struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	GrantedAt  time.Time `json:"granted_at"`
+}
+
+// ExportManifest — описание архива с данными пользователя (manifest.json)
+type ExportManifest struct {
+	UserID      int       `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// DeletedUserEvent — ещё не опубликованное событие UserDeleted из outbox
+type DeletedUserEvent struct {
+	ID        int64
+	UserID    int
+	DeletedAt time.Time
+}
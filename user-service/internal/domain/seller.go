@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Состояния профиля продавца. Управлять каталогом может только продавец в статусе active.
+const (
+	SellerPending   = "pending"
+	SellerActive    = "active"
+	SellerSuspended = "suspended"
+)
+
+// AuditSellerStatus — смена статуса продавца администратором
+const AuditSellerStatus = "seller.status"
+
+// MaxSellerNameLength и MaxSellerDescriptionLength ограничивают поля витрины продавца
+const (
+	MaxSellerNameLength        = 100
+	MaxSellerDescriptionLength = 2000
+)
+
+var (
+	ErrSellerNotFound  = errors.New("seller not found")
+	ErrSellerExists    = errors.New("seller profile already exists")
+	ErrSellerNameTaken = errors.New("seller name already in use")
+	ErrInvalidSeller   = errors.New("invalid seller profile")
+)
+
+// SellerStatuses — статусы, которые может назначить администратор
+var SellerStatuses = []string{SellerPending, SellerActive, SellerSuspended}
+
+// Seller — профиль продавца: витрина, под которой выставляются его товары
+type Seller struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Description string    `json:"description,omitempty"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SellerUpdate — частичное изменение профиля продавца: nil-поля не меняются
+type SellerUpdate struct {
+	DisplayName *string `json:"display_name"`
+	Description *string `json:"description"`
+}
+
+// SellerAccess — права пользователя в каталоге и заказах, которые проверяют product-service
+// и order-service. SellerID задан только у действующего продавца.
+type SellerAccess struct {
+	UserID   int  `json:"user_id"`
+	SellerID int  `json:"seller_id,omitempty"`
+	Admin    bool `json:"admin"`
+}
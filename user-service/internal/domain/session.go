@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// ClientInfo — устройство и адрес, с которых выполнен вход
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Session — вход пользователя на одном устройстве. Токен содержит ID сессии (sid),
+// и после отзыва сессии api-gateway перестаёт его принимать.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current отмечает сессию, из которой пришёл запрос
+	Current bool `json:"current"`
+}
+
+// SessionStatus — ответ на проверку сессии для api-gateway. EmailVerified берётся из базы,
+// а не из токена: смена или подтверждение email действуют без повторного входа.
+type SessionStatus struct {
+	Active        bool `json:"-"`
+	EmailVerified bool `json:"email_verified"`
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "errors"
+
+// RoleSeller — продавец; вместе с администраторами по умолчанию обязан использовать 2FA
+const RoleSeller = "seller"
+
+// RecoveryCodeCount — сколько резервных кодов выдаётся при подключении 2FA
+const RecoveryCodeCount = 10
+
+var (
+	ErrTwoFactorRequired    = errors.New("two-factor authentication required")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication already enabled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired login challenge")
+)
+
+// LoginResult — итог первого шага входа. При включённой 2FA вместо токена
+// выдаётся ChallengeToken, который обменивается на токен вместе с кодом.
+type LoginResult struct {
+	Token             string `json:"token,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	// TwoFactorSetupRequired — роль пользователя требует 2FA, но она ещё не подключена
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+}
+
+// TwoFactorEnrollment — данные для добавления аккаунта в приложение-аутентификатор
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
@@ -1,12 +1,86 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// DefaultLocale — язык интерфейса, если пользователь его не выбирал
+const DefaultLocale = "ru"
+
+// MinPasswordLength — минимальная длина нового пароля при смене и сбросе
+const MinPasswordLength = 8
+
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrForbidden      = errors.New("forbidden")
+	ErrEmailTaken     = errors.New("email already in use")
+	ErrInvalidProfile = errors.New("invalid profile")
+
+	ErrWrongPassword     = errors.New("invalid current password")
+	ErrWeakPassword      = errors.New("password must be at least 8 characters")
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+	ErrInvalidEmail             = errors.New("invalid email")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+)
 
 type User struct {
-	ID           int       `json:"id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"-"`
+	// EmailVerified сбрасывается при смене email и выставляется после подтверждения
+	EmailVerified bool   `json:"email_verified"`
+	Phone         string `json:"phone,omitempty"`
+	Locale        string `json:"locale"`
+	Role          string `json:"role"`
+	// TwoFactorEnabled — вход требует код из приложения-аутентификатора
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	// TOTPSecret — секрет TOTP; задан и при незавершённом подключении 2FA
+	TOTPSecret string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// DeletedAt — время удаления аккаунта; персональные данные такого пользователя обезличены
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// BannedAt — время блокировки администратором; заблокированный пользователь не может войти
+	BannedAt  *time.Time `json:"banned_at,omitempty"`
+	BanReason string     `json:"ban_reason,omitempty"`
+}
+
+// Status — состояние аккаунта: active, banned или deleted
+func (u User) Status() string {
+	switch {
+	case u.DeletedAt != nil:
+		return UserStatusDeleted
+	case u.BannedAt != nil:
+		return UserStatusBanned
+	default:
+		return UserStatusActive
+	}
+}
+
+// MarshalJSON добавляет к профилю вычисляемое поле status
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return json.Marshal(struct {
+		plain
+		Status string `json:"status"`
+	}{plain(u), u.Status()})
+}
+
+// ProfileUpdate — частичное изменение профиля: nil-поля не меняются
+type ProfileUpdate struct {
+	Name   *string `json:"name"`
+	Email  *string `json:"email"`
+	Phone  *string `json:"phone"`
+	Locale *string `json:"locale"`
 }
@@ -0,0 +1,55 @@
+// Package exportclient — клиент внутренних эндпоинтов выгрузки данных пользователя
+// (GET /internal/users/{id}/export) в cart-service и order-service.
+package exportclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxExportSize ограничивает ответ одного сервиса, чтобы архив не занял всю память
+const maxExportSize = 32 << 20
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) ExportUser(ctx context.Context, userID int) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/internal/users/%d/export", c.baseURL, userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", c.baseURL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxExportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxExportSize {
+		return nil, fmt.Errorf("%s export exceeds %d bytes", c.baseURL, maxExportSize)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%s returned invalid JSON", c.baseURL)
+	}
+	return json.RawMessage(data), nil
+}
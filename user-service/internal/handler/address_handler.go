@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// AddressHandler — адресная книга текущего пользователя (/users/me/addresses)
+type AddressHandler struct {
+	addressService *service.AddressService
+}
+
+func NewAddressHandler(addressService *service.AddressService) *AddressHandler {
+	return &AddressHandler{addressService: addressService}
+}
+
+func writeAddress(w http.ResponseWriter, status int, address domain.Address) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(address)
+}
+
+// List — GET /users/me/addresses
+func (h *AddressHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	addresses, err := h.addressService.ListAddresses(r.Context(), userID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(addresses)
+}
+
+// Get — GET /users/me/addresses/{id}
+func (h *AddressHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return
+	}
+
+	address, err := h.addressService.GetAddress(r.Context(), userID, id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeAddress(w, http.StatusOK, address)
+}
+
+// Create — POST /users/me/addresses
+func (h *AddressHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var address domain.Address
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.addressService.CreateAddress(r.Context(), userID, address)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeAddress(w, http.StatusCreated, created)
+}
+
+// Update — PUT /users/me/addresses/{id}: адрес заменяется целиком
+func (h *AddressHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return
+	}
+
+	var address domain.Address
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.addressService.UpdateAddress(r.Context(), userID, id, address)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeAddress(w, http.StatusOK, updated)
+}
+
+// Delete — DELETE /users/me/addresses/{id}
+func (h *AddressHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.addressService.DeleteAddress(r.Context(), userID, id); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Resolve — GET /internal/users/{user_id}/addresses/{id}: cart-service берёт адрес при оформлении
+// заказа. Вместо id можно передать default, тогда возвращается адрес по умолчанию типа ?type=
+// (shipping, если не указан). Маршрут не публикуется через gateway.
+func (h *AddressHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["user_id"])
+	if err != nil {
+		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+		return
+	}
+	id := 0
+	if vars["id"] != "default" {
+		if id, err = strconv.Atoi(vars["id"]); err != nil {
+			http.Error(w, "Invalid address ID", http.StatusBadRequest)
+			return
+		}
+	}
+	addressType := r.URL.Query().Get("type")
+	if addressType == "" {
+		addressType = domain.AddressShipping
+	}
+
+	address, err := h.addressService.ResolveAddress(r.Context(), userID, id, addressType)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeAddress(w, http.StatusOK, address)
+}
@@ -0,0 +1,60 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+func TestAddressHandler_CreateAndResolve(t *testing.T) {
+	h := handler.NewAddressHandler(service.NewAddressService(repositorytest.NewAddressRepo()))
+	r := mux.NewRouter()
+	r.HandleFunc("/users/me/addresses", h.Create).Methods("POST")
+	r.HandleFunc("/users/me/addresses/{id:[0-9]+}", h.Get).Methods("GET")
+	r.HandleFunc("/internal/users/{user_id:[0-9]+}/addresses/{id}", h.Resolve).Methods("GET")
+
+	req := httptest.NewRequest(http.MethodPost, "/users/me/addresses",
+		strings.NewReader(`{"recipient":"Alex","country":"DE","city":"Berlin","line1":"Unter den Linden 1","postal_code":"1011"}`))
+	req.Header.Set("X-User-ID", "1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid German postal code, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/users/me/addresses",
+		strings.NewReader(`{"recipient":"Alex","country":"DE","city":"Berlin","line1":"Unter den Linden 1","postal_code":"10117"}`))
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.Address
+	json.NewDecoder(rec.Body).Decode(&created)
+
+	req = httptest.NewRequest(http.MethodGet, "/users/me/addresses/"+strconv.Itoa(created.ID), nil)
+	req.Header.Set("X-User-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's address, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/internal/users/1/addresses/default", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var resolved domain.Address
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&resolved) != nil || resolved.ID != created.ID {
+		t.Fatalf("expected default shipping address %d, got %d %+v", created.ID, rec.Code, resolved)
+	}
+}
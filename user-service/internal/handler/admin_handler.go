@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler — справочник пользователей и модерация для администраторов
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// ListUsers — GET /users?email=&role=&status=&created_from=&created_to=&limit=&offset=
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := domain.UserFilter{
+		EmailPrefix: q.Get("email"),
+		Role:        q.Get("role"),
+		Status:      q.Get("status"),
+	}
+	if filter.CreatedFrom, err = timeParam(q, "created_from"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.CreatedTo, err = timeParam(q, "created_to"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Limit, filter.Offset, err = pageParams(q); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.adminService.ListUsers(r.Context(), userID, filter)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+type banRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Ban — POST /users/{id}/ban с необязательным {"reason": "..."}
+func (h *AdminHandler) Ban(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req banRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.adminService.Ban(r.Context(), userID, targetID, req.Reason); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unban — POST /users/{id}/unban
+func (h *AdminHandler) Unban(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.Unban(r.Context(), userID, targetID); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+// SetRole — PUT /users/{id}/role с {"role": "user|seller|admin"}
+func (h *AdminHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.adminService.SetRole(r.Context(), userID, targetID, req.Role); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AuditLog — GET /users/audit?actor_id=&target_id=&action=&limit=&offset=
+func (h *AdminHandler) AuditLog(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := domain.AuditFilter{Action: q.Get("action")}
+	for name, dst := range map[string]*int{"actor_id": &filter.ActorID, "target_id": &filter.TargetUserID} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if filter.Limit, filter.Offset, err = pageParams(q); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.adminService.AuditLog(r.Context(), userID, filter)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"entries": entries})
+}
+
+// adminTarget читает администратора из X-User-ID и пользователя из пути;
+// при ошибке ответ уже записан
+func adminTarget(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, targetID, true
+}
+
+func pageParams(q url.Values) (int, int, error) {
+	var limit, offset int
+	var err error
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return 0, 0, errors.New("invalid limit")
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			return 0, 0, errors.New("invalid offset")
+		}
+	}
+	return limit, offset, nil
+}
+
+// timeParam принимает дату (2006-01-02) или время в RFC 3339
+func timeParam(q url.Values, name string) (*time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s: use YYYY-MM-DD or RFC 3339", name)
+}
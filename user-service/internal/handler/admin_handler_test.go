@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+func newAdminRouter(repo *repositorytest.AdminRepo) *mux.Router {
+	users := &mockUserRepo{users: map[string]domain.User{
+		"alex@email.com":  {ID: 1, Name: "Alex", Email: "alex@email.com", Role: domain.RoleUser},
+		"admin@email.com": {ID: 2, Name: "Admin", Email: "admin@email.com", Role: domain.RoleAdmin},
+	}}
+	h := handler.NewAdminHandler(service.NewAdminService(repo, service.NewUserService(users)))
+
+	r := mux.NewRouter()
+	r.HandleFunc("/users", h.ListUsers).Methods("GET")
+	r.HandleFunc("/users/{id:[0-9]+}/ban", h.Ban).Methods("POST")
+	r.HandleFunc("/users/{id:[0-9]+}/role", h.SetRole).Methods("PUT")
+	return r
+}
+
+func TestAdminHandler_ListUsers(t *testing.T) {
+	repo := repositorytest.NewAdminRepo()
+	repo.Users = []domain.User{{ID: 1, Email: "alex@email.com", Role: domain.RoleUser}}
+	r := newAdminRouter(repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("X-User-ID", "1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for regular user, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users?created_from=yesterday", nil)
+	req.Header.Set("X-User-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid date, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users?email=al&status=active&created_from=2026-01-01&limit=10&offset=20", nil)
+	req.Header.Set("X-User-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	if repo.LastFilter.EmailPrefix != "al" || repo.LastFilter.Status != domain.UserStatusActive ||
+		repo.LastFilter.CreatedFrom == nil || repo.LastFilter.Limit != 10 || repo.LastFilter.Offset != 20 {
+		t.Fatalf("unexpected filter %+v", repo.LastFilter)
+	}
+
+	var page struct {
+		Users []map[string]any `json:"users"`
+		Total int              `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil || page.Total != 1 || page.Users[0]["status"] != "active" {
+		t.Fatalf("unexpected page %+v %v", page, err)
+	}
+}
+
+func TestAdminHandler_BanAndRole(t *testing.T) {
+	repo := repositorytest.NewAdminRepo()
+	r := newAdminRouter(repo)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1/ban", strings.NewReader(`{"reason":"spam"}`))
+	req.Header.Set("X-User-ID", "2")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || repo.Banned[1] != "spam" {
+		t.Fatalf("expected 204 and ban with reason, got %d %v", rec.Code, repo.Banned)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/users/2/ban", nil)
+	req.Header.Set("X-User-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for self-ban, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/users/1/role", strings.NewReader(`{"role":"superuser"}`))
+	req.Header.Set("X-User-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown role, got %d", rec.Code)
+	}
+}
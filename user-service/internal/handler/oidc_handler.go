@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+)
+
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService}
+}
+
+// authorizePage — страница входа и согласия. Параметры запроса авторизации
+// передаются скрытыми полями, после входа их заменяет подписанный билет согласия.
+var authorizePage = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><title>Вход в Marketplace</title></head>
+<body>
+{{if .ConsentTicket}}
+<h1>{{.Client.Name}} запрашивает доступ</h1>
+<p>Приложение получит:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+<form method="post" action="/oauth2/authorize">
+<input type="hidden" name="consent_ticket" value="{{.ConsentTicket}}">
+<button type="submit" name="decision" value="allow">Разрешить</button>
+<button type="submit" name="decision" value="deny">Отклонить</button>
+</form>
+{{else}}
+<h1>Вход в {{.Client.Name}} через Marketplace</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="/oauth2/authorize">
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}<label>Email <input type="email" name="email" value="{{.Email}}" required></label>
+<label>Пароль <input type="password" name="password" required></label>
+{{if .NeedCode}}<label>Код из приложения-аутентификатора или резервный код <input name="code" autocomplete="one-time-code" required></label>
+{{end}}<button type="submit">Войти</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type authorizePageData struct {
+	Client        domain.OAuthClient
+	Params        map[string]string
+	Email         string
+	Error         string
+	NeedCode      bool
+	ConsentTicket string
+	Scopes        []string
+}
+
+// Discovery — GET /.well-known/openid-configuration
+func (h *OIDCHandler) Discovery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.oidcService.Discovery())
+}
+
+// JWKS — GET /oauth2/jwks: открытые ключи для проверки подписи токенов
+func (h *OIDCHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.oidcService.JWKS())
+}
+
+// RegisterClient — POST /oauth2/clients: администратор регистрирует приложение партнёра
+func (h *OIDCHandler) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		// Confidential — серверное приложение, которое хранит client_secret
+		Confidential bool `json:"confidential"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	client, err := h.oidcService.RegisterClient(r.Context(), userID, req.Name, req.RedirectURIs, req.Confidential)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(client)
+}
+
+// Authorize — GET /oauth2/authorize: проверяет запрос приложения и показывает страницу входа
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	req := authorizationRequestFrom(r)
+	client, _, err := h.oidcService.ValidateAuthorization(r.Context(), req)
+	if err != nil {
+		h.writeAuthorizeError(w, r, req, err)
+		return
+	}
+	renderAuthorizePage(w, http.StatusOK, authorizePageData{Client: client, Params: authorizeParams(req)})
+}
+
+// AuthorizeSubmit — POST /oauth2/authorize: вход под учётной записью marketplace
+// или решение на экране согласия
+func (h *OIDCHandler) AuthorizeSubmit(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	if ticket := r.PostFormValue("consent_ticket"); ticket != "" {
+		redirectURL, err := h.oidcService.Consent(r.Context(), ticket, r.PostFormValue("decision") == "allow")
+		if errors.Is(err, domain.ErrInvalidConsentTicket) {
+			http.Error(w, "Сессия входа истекла, начните вход в приложении заново", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			var oauthErr *domain.OAuthError
+			if errors.As(err, &oauthErr) || errors.Is(err, domain.ErrUnknownClient) || errors.Is(err, domain.ErrInvalidRedirectURI) {
+				http.Error(w, "Приложение больше не может запрашивать вход", http.StatusBadRequest)
+				return
+			}
+			log.Printf("Ошибка согласия OIDC: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+		return
+	}
+
+	req := authorizationRequestFrom(r)
+	email := r.PostFormValue("email")
+	result, err := h.oidcService.Authorize(r.Context(), req, email, r.PostFormValue("password"), r.PostFormValue("code"), clientIP(r))
+
+	page := authorizePageData{Params: authorizeParams(req), Email: email}
+	var lockout *domain.LockoutError
+	switch {
+	case err == nil:
+	case errors.As(err, &lockout):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockout.RetryAfter.Seconds()))))
+		page.Error = "Слишком много неудачных попыток входа, попробуйте позже"
+		h.renderLoginError(w, r, req, page, http.StatusTooManyRequests)
+		return
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		page.Error = "Неверный email или пароль"
+		h.renderLoginError(w, r, req, page, http.StatusUnauthorized)
+		return
+	case errors.Is(err, domain.ErrUserBanned):
+		page.Error = "Аккаунт заблокирован"
+		h.renderLoginError(w, r, req, page, http.StatusForbidden)
+		return
+	case errors.Is(err, domain.ErrTwoFactorRequired):
+		page.Error = "Введите код из приложения-аутентификатора"
+		page.NeedCode = true
+		h.renderLoginError(w, r, req, page, http.StatusUnauthorized)
+		return
+	case errors.Is(err, domain.ErrInvalidTwoFactorCode):
+		page.Error = "Неверный код"
+		page.NeedCode = true
+		h.renderLoginError(w, r, req, page, http.StatusUnauthorized)
+		return
+	default:
+		h.writeAuthorizeError(w, r, req, err)
+		return
+	}
+
+	if result.RedirectURL != "" {
+		http.Redirect(w, r, result.RedirectURL, http.StatusFound)
+		return
+	}
+	renderAuthorizePage(w, http.StatusOK, authorizePageData{
+		Client:        result.Client,
+		ConsentTicket: result.ConsentTicket,
+		Scopes:        result.Scopes,
+	})
+}
+
+// renderLoginError показывает страницу входа снова с сообщением об ошибке
+func (h *OIDCHandler) renderLoginError(w http.ResponseWriter, r *http.Request, req domain.AuthorizationRequest, page authorizePageData, status int) {
+	client, _, err := h.oidcService.ValidateAuthorization(r.Context(), req)
+	if err != nil {
+		h.writeAuthorizeError(w, r, req, err)
+		return
+	}
+	page.Client = client
+	renderAuthorizePage(w, status, page)
+}
+
+// writeAuthorizeError сообщает об ошибке запроса авторизации. Пока redirect_uri
+// не проверен, ошибка показывается пользователю, иначе возвращается в приложение.
+func (h *OIDCHandler) writeAuthorizeError(w http.ResponseWriter, r *http.Request, req domain.AuthorizationRequest, err error) {
+	var oauthErr *domain.OAuthError
+	switch {
+	case errors.Is(err, domain.ErrUnknownClient), errors.Is(err, domain.ErrInvalidRedirectURI):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &oauthErr):
+		http.Redirect(w, r, service.AuthorizationErrorURL(req, h.oidcService.Issuer(), oauthErr), http.StatusFound)
+	default:
+		log.Printf("Ошибка авторизации OIDC: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func renderAuthorizePage(w http.ResponseWriter, status int, page authorizePageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Страницу с паролем и согласием нельзя встраивать во фреймы и кешировать
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := authorizePage.Execute(w, page); err != nil {
+		log.Printf("Ошибка отрисовки страницы входа: %v", err)
+	}
+}
+
+func authorizationRequestFrom(r *http.Request) domain.AuthorizationRequest {
+	return domain.AuthorizationRequest{
+		ResponseType:        r.FormValue("response_type"),
+		ClientID:            r.FormValue("client_id"),
+		RedirectURI:         r.FormValue("redirect_uri"),
+		Scope:               r.FormValue("scope"),
+		State:               r.FormValue("state"),
+		Nonce:               r.FormValue("nonce"),
+		CodeChallenge:       r.FormValue("code_challenge"),
+		CodeChallengeMethod: r.FormValue("code_challenge_method"),
+	}
+}
+
+func authorizeParams(req domain.AuthorizationRequest) map[string]string {
+	return map[string]string{
+		"response_type":         req.ResponseType,
+		"client_id":             req.ClientID,
+		"redirect_uri":          req.RedirectURI,
+		"scope":                 req.Scope,
+		"state":                 req.State,
+		"nonce":                 req.Nonce,
+		"code_challenge":        req.CodeChallenge,
+		"code_challenge_method": req.CodeChallengeMethod,
+	}
+}
+
+// Token — POST /oauth2/token: обмен кода авторизации на токены
+func (h *OIDCHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, domain.NewOAuthError("invalid_request", "invalid form"))
+		return
+	}
+
+	req := domain.TokenRequest{
+		GrantType:    r.PostFormValue("grant_type"),
+		Code:         r.PostFormValue("code"),
+		RedirectURI:  r.PostFormValue("redirect_uri"),
+		ClientID:     r.PostFormValue("client_id"),
+		ClientSecret: r.PostFormValue("client_secret"),
+		CodeVerifier: r.PostFormValue("code_verifier"),
+	}
+	// client_secret_basic: идентификатор и секрет URL-кодируются перед Base64 (RFC 6749, 2.3.1)
+	id, secret, basicAuth := r.BasicAuth()
+	if basicAuth {
+		req.ClientID, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	resp, err := h.oidcService.Exchange(r.Context(), req)
+	var oauthErr *domain.OAuthError
+	switch {
+	case errors.As(err, &oauthErr):
+		status := http.StatusBadRequest
+		if oauthErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+			if basicAuth {
+				w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+			}
+		}
+		writeOAuthError(w, status, oauthErr)
+		return
+	case err != nil:
+		log.Printf("Ошибка выдачи токенов OIDC: %v", err)
+		writeOAuthError(w, http.StatusInternalServerError, domain.NewOAuthError("server_error", ""))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// UserInfo — GET и POST /oauth2/userinfo: claims пользователя по access-токену
+func (h *OIDCHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		http.Error(w, "missing access token", http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := h.oidcService.UserInfo(r.Context(), strings.TrimPrefix(auth, "Bearer "))
+	if errors.Is(err, domain.ErrInvalidAccessToken) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка /userinfo: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(claims)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, err *domain.OAuthError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(err)
+}
@@ -0,0 +1,152 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/jackc/pgx/v5"
+)
+
+// singleClientRepo знает одного публичного клиента partner
+type singleClientRepo struct{}
+
+func (singleClientRepo) CreateClient(ctx context.Context, client domain.OAuthClient) error {
+	return nil
+}
+
+func (singleClientRepo) GetClient(ctx context.Context, id string) (domain.OAuthClient, error) {
+	if id != "partner" {
+		return domain.OAuthClient{}, pgx.ErrNoRows
+	}
+	return domain.OAuthClient{ID: "partner", Name: "Partner App", RedirectURIs: []string{"https://partner.example/cb"}}, nil
+}
+
+func (singleClientRepo) CreateAuthorizationCode(ctx context.Context, codeHash string, code domain.AuthorizationCode, ttl time.Duration) error {
+	return nil
+}
+
+func (singleClientRepo) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (domain.AuthorizationCode, error) {
+	return domain.AuthorizationCode{}, domain.ErrInvalidAuthorizationCode
+}
+
+func (singleClientRepo) GetConsent(ctx context.Context, userID int, clientID string) ([]string, error) {
+	return nil, nil
+}
+
+func (singleClientRepo) SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	return nil
+}
+
+func newOIDCHandler(t *testing.T) *handler.OIDCHandler {
+	t.Helper()
+	key, err := service.GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey: %v", err)
+	}
+	users := &mockUserRepo{users: make(map[string]domain.User)}
+	return handler.NewOIDCHandler(service.NewOIDCService(singleClientRepo{}, users,
+		service.NewAuthService(users), service.NewUserService(users), key, "http://localhost:8080"))
+}
+
+func authorizeQuery(clientID, redirectURI, scope string) string {
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {clientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {scope},
+		"state":                 {"xyz"},
+		"code_challenge":        {"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+}
+
+func TestDiscoveryHandler(t *testing.T) {
+	h := newOIDCHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.Discovery(rec, httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil))
+
+	var doc domain.OIDCDiscovery
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if doc.Issuer != "http://localhost:8080" || doc.JWKSURI != "http://localhost:8080/oauth2/jwks" {
+		t.Fatalf("unexpected discovery document %+v", doc)
+	}
+}
+
+func TestAuthorizeHandler(t *testing.T) {
+	h := newOIDCHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.Authorize(rec, httptest.NewRequest(http.MethodGet, "/oauth2/authorize?"+authorizeQuery("partner", "https://partner.example/cb", "openid"), nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Partner App") {
+		t.Fatalf("expected login page, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Frame-Options") != "DENY" {
+		t.Fatal("login page must not be framed")
+	}
+
+	// Неизвестный redirect_uri — ошибка на странице, а не редирект
+	rec = httptest.NewRecorder()
+	h.Authorize(rec, httptest.NewRequest(http.MethodGet, "/oauth2/authorize?"+authorizeQuery("partner", "https://evil.example/cb", "openid"), nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unregistered redirect_uri, got %d", rec.Code)
+	}
+
+	// Ошибка в остальных параметрах возвращается приложению
+	rec = httptest.NewRecorder()
+	h.Authorize(rec, httptest.NewRequest(http.MethodGet, "/oauth2/authorize?"+authorizeQuery("partner", "https://partner.example/cb", "email"), nil))
+	location := rec.Header().Get("Location")
+	if rec.Code != http.StatusFound || !strings.Contains(location, "error=invalid_scope") || !strings.Contains(location, "state=xyz") {
+		t.Fatalf("expected redirect with invalid_scope, got %d %s", rec.Code, location)
+	}
+}
+
+func TestTokenHandler_Errors(t *testing.T) {
+	h := newOIDCHandler(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader("grant_type=password"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.Token(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"error":"unsupported_grant_type"`) {
+		t.Fatalf("expected unsupported_grant_type, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader("grant_type=authorization_code&code=x&code_verifier=y"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("unknown", "secret")
+	rec = httptest.NewRecorder()
+	h.Token(rec, req)
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected 401 invalid_client with challenge, got %d", rec.Code)
+	}
+}
+
+func TestUserInfoHandler_RequiresAccessToken(t *testing.T) {
+	h := newOIDCHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.UserInfo(rec, httptest.NewRequest(http.MethodGet, "/oauth2/userinfo", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth2/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer garbage")
+	rec = httptest.NewRecorder()
+	h.UserInfo(rec, req)
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Header().Get("WWW-Authenticate"), "invalid_token") {
+		t.Fatalf("expected invalid_token, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+}
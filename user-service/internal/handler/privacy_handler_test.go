@@ -0,0 +1,50 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPrivacyHandler_ExportAndDelete(t *testing.T) {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
+	users := &mockUserRepo{users: map[string]domain.User{
+		"user@email.com": {ID: 1, Email: "user@email.com", PasswordHash: string(hashed)},
+	}}
+	privacy := &repositorytest.PrivacyRepo{}
+	h := handler.NewPrivacyHandler(service.NewPrivacyService(users, privacy, repositorytest.NewAddressRepo(), repositorytest.NewSessionRepo()))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/me/export", nil)
+	req.Header.Set("X-User-ID", "1")
+	rec := httptest.NewRecorder()
+	h.Export(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("expected zip archive, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.HasPrefix(rec.Body.String(), "PK") {
+		t.Fatal("response body is not a zip archive")
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/users/me", strings.NewReader(`{"password":"wrong"}`))
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	h.DeleteMe(rec, req)
+	if rec.Code != http.StatusForbidden || len(privacy.Anonymized) != 0 {
+		t.Fatalf("expected 403 for wrong password, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/users/me", strings.NewReader(`{"password":"secret"}`))
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	h.DeleteMe(rec, req)
+	if rec.Code != http.StatusNoContent || len(privacy.Anonymized) != 1 {
+		t.Fatalf("expected 204 and anonymized user, got %d", rec.Code)
+	}
+}
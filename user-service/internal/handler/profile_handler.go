@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// UserHandler — профиль текущего пользователя и просмотр профилей администратором.
+// Пользователь определяется по X-User-ID, который выставляет api-gateway.
+type UserHandler struct {
+	userService *service.UserService
+}
+
+func NewUserHandler(userService *service.UserService) *UserHandler {
+	return &UserHandler{userService: userService}
+}
+
+func userIDFromHeader(r *http.Request) (int, error) {
+	return strconv.Atoi(r.Header.Get("X-User-ID"))
+}
+
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidProfile), errors.Is(err, domain.ErrWeakPassword),
+		errors.Is(err, domain.ErrInvalidResetToken), errors.Is(err, domain.ErrInvalidEmail),
+		errors.Is(err, domain.ErrInvalidVerificationToken), errors.Is(err, domain.ErrInvalidTwoFactorCode),
+		errors.Is(err, domain.ErrInvalidClientMetadata), errors.Is(err, domain.ErrInvalidAddress),
+		errors.Is(err, domain.ErrInvalidRole), errors.Is(err, domain.ErrInvalidFilter),
+		errors.Is(err, domain.ErrInvalidSeller):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrForbidden), errors.Is(err, domain.ErrWrongPassword),
+		errors.Is(err, domain.ErrTwoFactorRequired), errors.Is(err, domain.ErrUserBanned):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrSessionNotFound),
+		errors.Is(err, domain.ErrAddressNotFound), errors.Is(err, domain.ErrSellerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrExportUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, domain.ErrEmailTaken), errors.Is(err, domain.ErrEmailAlreadyVerified),
+		errors.Is(err, domain.ErrTwoFactorEnabled), errors.Is(err, domain.ErrTwoFactorNotEnrolled),
+		errors.Is(err, domain.ErrUserAlreadyBanned), errors.Is(err, domain.ErrUserNotBanned),
+		errors.Is(err, domain.ErrSellerExists), errors.Is(err, domain.ErrSellerNameTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeUser(w http.ResponseWriter, user domain.User) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// Me — GET /users/me
+func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.userService.GetProfile(r.Context(), userID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeUser(w, user)
+}
+
+// UpdateMe — PATCH /users/me: меняет только переданные поля (name, email, phone, locale)
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var upd domain.ProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), userID, upd)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeUser(w, user)
+}
+
+// GetByID — GET /users/{id}, только для администраторов
+func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetUser(r.Context(), userID, id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeUser(w, user)
+}
+
+type twoFactorPolicyRequest struct {
+	Required bool `json:"required"`
+}
+
+// SetTwoFactorPolicy — PUT /users/roles/{role}/2fa: администратор требует 2FA для роли
+func (h *UserHandler) SetTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req twoFactorPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.SetTwoFactorPolicy(r.Context(), userID, mux.Vars(r)["role"], req.Required); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
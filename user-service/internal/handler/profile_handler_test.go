@@ -0,0 +1,127 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+func newUserRouter() *mux.Router {
+	repo := &mockUserRepo{users: map[string]domain.User{
+		"alex@email.com":  {ID: 1, Name: "Alex", Email: "alex@email.com", Locale: "ru", Role: domain.RoleUser},
+		"admin@email.com": {ID: 2, Name: "Admin", Email: "admin@email.com", Locale: "ru", Role: domain.RoleAdmin},
+	}}
+	h := handler.NewUserHandler(service.NewUserService(repo))
+
+	r := mux.NewRouter()
+	r.HandleFunc("/users/me", h.Me).Methods("GET")
+	r.HandleFunc("/users/me", h.UpdateMe).Methods("PATCH")
+	r.HandleFunc("/users/{id:[0-9]+}", h.GetByID).Methods("GET")
+	return r
+}
+
+func TestMeHandler(t *testing.T) {
+	r := newUserRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without X-User-ID, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var user domain.User
+	if err := json.NewDecoder(rec.Body).Decode(&user); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if user.Email != "alex@email.com" {
+		t.Fatalf("expected alex, got %+v", user)
+	}
+	if strings.Contains(rec.Body.String(), "password") {
+		t.Fatalf("password hash must not be exposed: %s", rec.Body.String())
+	}
+}
+
+func TestUpdateMeHandler(t *testing.T) {
+	r := newUserRouter()
+
+	req := httptest.NewRequest(http.MethodPatch, "/users/me", strings.NewReader(`{"name":"Alexander","locale":"en"}`))
+	req.Header.Set("X-User-ID", "1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"name":"Alexander"`) {
+		t.Fatalf("expected updated name, got %s", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/users/me", strings.NewReader(`{"email":"admin@email.com"}`))
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for taken email, got %d", rec.Code)
+	}
+}
+
+func TestGetUserByIDHandler_AdminOnly(t *testing.T) {
+	r := newUserRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/2", nil)
+	req.Header.Set("X-User-ID", "1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for regular user, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-User-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for admin, got %d", rec.Code)
+	}
+}
+
+func TestGetUserByIDHandler_AdminWithoutTwoFactor(t *testing.T) {
+	repo := &mockUserRepo{users: map[string]domain.User{
+		"admin@email.com": {ID: 2, Name: "Admin", Email: "admin@email.com", Locale: "ru", Role: domain.RoleAdmin},
+	}}
+	h := handler.NewUserHandler(service.NewUserService(repo).WithTwoFactorPolicy(stubTwoFactorRepo{}))
+	r := mux.NewRouter()
+	r.HandleFunc("/users/{id:[0-9]+}", h.GetByID).Methods("GET")
+	r.HandleFunc("/users/roles/{role}/2fa", h.SetTwoFactorPolicy).Methods("PUT")
+
+	req := httptest.NewRequest(http.MethodGet, "/users/2", nil)
+	req.Header.Set("X-User-ID", "2")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for admin without 2FA, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/users/roles/seller/2fa", strings.NewReader(`{"required":true}`))
+	req.Header.Set("X-User-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for policy change without 2FA, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// SellerHandler — профиль продавца текущего пользователя (/users/me/seller),
+// витрины продавцов и их модерация
+type SellerHandler struct {
+	sellerService *service.SellerService
+}
+
+func NewSellerHandler(sellerService *service.SellerService) *SellerHandler {
+	return &SellerHandler{sellerService: sellerService}
+}
+
+func writeSeller(w http.ResponseWriter, status int, seller domain.Seller) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(seller)
+}
+
+type sellerRequest struct {
+	DisplayName string `json:"display_name"`
+	Description string `json:"description"`
+}
+
+// Apply — POST /users/me/seller: заявка на открытие витрины
+func (h *SellerHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req sellerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	seller, err := h.sellerService.Apply(r.Context(), userID, req.DisplayName, req.Description)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeSeller(w, http.StatusCreated, seller)
+}
+
+// Me — GET /users/me/seller
+func (h *SellerHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	seller, err := h.sellerService.GetMine(r.Context(), userID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeSeller(w, http.StatusOK, seller)
+}
+
+// UpdateMe — PATCH /users/me/seller: меняет только переданные поля (display_name, description)
+func (h *SellerHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var upd domain.SellerUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	seller, err := h.sellerService.UpdateMine(r.Context(), userID, upd)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeSeller(w, http.StatusOK, seller)
+}
+
+// Get — GET /users/sellers/{id}
+func (h *SellerHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, sellerID, ok := sellerTarget(w, r)
+	if !ok {
+		return
+	}
+
+	seller, err := h.sellerService.Get(r.Context(), userID, sellerID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeSeller(w, http.StatusOK, seller)
+}
+
+// List — GET /users/sellers?status=&limit=&offset= для администраторов
+func (h *SellerHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, offset, err := pageParams(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sellers, err := h.sellerService.List(r.Context(), userID, q.Get("status"), limit, offset)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"sellers": sellers})
+}
+
+type sellerStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// SetStatus — PUT /users/sellers/{id}/status с {"status": "pending|active|suspended"}
+func (h *SellerHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	userID, sellerID, ok := sellerTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req sellerStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	seller, err := h.sellerService.SetStatus(r.Context(), userID, sellerID, req.Status)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeSeller(w, http.StatusOK, seller)
+}
+
+// Access — GET /internal/users/{user_id}/seller: права пользователя в каталоге для
+// product-service и order-service. Маршрут не публикуется через gateway.
+func (h *SellerHandler) Access(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+		return
+	}
+
+	access, err := h.sellerService.Access(r.Context(), userID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(access)
+}
+
+// sellerTarget читает пользователя из X-User-ID и продавца из пути;
+// при ошибке ответ уже записан
+func sellerTarget(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	sellerID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid seller ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, sellerID, true
+}
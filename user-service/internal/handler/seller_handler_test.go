@@ -0,0 +1,77 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+func TestSellerHandler_ApplyApproveAndAccess(t *testing.T) {
+	users := &mockUserRepo{users: map[string]domain.User{
+		"alex@email.com":  {ID: 1, Name: "Alex", Email: "alex@email.com", Role: domain.RoleUser},
+		"admin@email.com": {ID: 2, Name: "Admin", Email: "admin@email.com", Role: domain.RoleAdmin},
+	}}
+	repo := repositorytest.NewSellerRepo()
+	h := handler.NewSellerHandler(service.NewSellerService(repo, service.NewUserService(users)))
+
+	r := mux.NewRouter()
+	r.HandleFunc("/users/me/seller", h.Apply).Methods("POST")
+	r.HandleFunc("/users/sellers/{id:[0-9]+}/status", h.SetStatus).Methods("PUT")
+	r.HandleFunc("/internal/users/{user_id:[0-9]+}/seller", h.Access).Methods("GET")
+
+	do := func(method, path, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/users/me/seller", "", `{"display_name":"Лавка"}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without user, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/users/me/seller", "1", `{"display_name":""}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty name, got %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/users/me/seller", "1", `{"display_name":"Лавка","description":"Книги"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var seller domain.Seller
+	json.NewDecoder(rec.Body).Decode(&seller)
+	if seller.Status != domain.SellerPending {
+		t.Fatalf("expected pending seller, got %+v", seller)
+	}
+	if rec := do(http.MethodPost, "/users/me/seller", "1", `{"display_name":"Ещё"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for second profile, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodPut, "/users/sellers/1/status", "1", `{"status":"active"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/users/sellers/9/status", "2", `{"status":"active"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown seller, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/users/sellers/1/status", "2", `{"status":"active"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/internal/users/1/seller", "", "")
+	var access domain.SellerAccess
+	if err := json.NewDecoder(rec.Body).Decode(&access); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected access, got %d: %v", rec.Code, err)
+	}
+	if access.SellerID != seller.ID || access.Admin {
+		t.Fatalf("unexpected access %+v", access)
+	}
+}
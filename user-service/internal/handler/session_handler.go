@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// ListMine — GET /users/me/sessions. Текущую сессию api-gateway передаёт в X-Session-ID
+func (h *SessionHandler) ListMine(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(r.Context(), userID, r.Header.Get("X-Session-ID"))
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeMine — DELETE /users/me/sessions/{id}: завершает сессию на другом устройстве или текущую
+func (h *SessionHandler) RevokeMine(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Check — GET /internal/sessions/{id}?user_id=...: api-gateway проверяет сессию токена
+// и получает текущий email_verified. Маршрут не публикуется через gateway.
+func (h *SessionHandler) Check(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.sessionService.CheckSession(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		log.Printf("Ошибка проверки сессии: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !status.Active {
+		http.Error(w, "Session revoked or expired", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
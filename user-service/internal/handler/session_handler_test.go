@@ -0,0 +1,80 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+func TestSessionHandler_ListRevokeAndCheck(t *testing.T) {
+	repo := repositorytest.NewSessionRepo()
+	repo.EmailVerified = true
+	sessions := service.NewSessionService(repo)
+	ctx := context.Background()
+	current, _ := sessions.StartSession(ctx, 1, domain.ClientInfo{UserAgent: "Firefox"}, service.TokenTTL)
+	other, _ := sessions.StartSession(ctx, 1, domain.ClientInfo{UserAgent: "Safari"}, service.TokenTTL)
+
+	h := handler.NewSessionHandler(sessions)
+	r := mux.NewRouter()
+	r.HandleFunc("/users/me/sessions", h.ListMine).Methods("GET")
+	r.HandleFunc("/users/me/sessions/{id}", h.RevokeMine).Methods("DELETE")
+	r.HandleFunc("/internal/sessions/{id}", h.Check).Methods("GET")
+
+	req := httptest.NewRequest(http.MethodGet, "/users/me/sessions", nil)
+	req.Header.Set("X-User-ID", "1")
+	req.Header.Set("X-Session-ID", current)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var list []domain.Session
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list) != 2 {
+		t.Fatalf("expected two sessions, got %d %v", len(list), err)
+	}
+	for _, s := range list {
+		if s.Current != (s.ID == current) {
+			t.Fatalf("wrong current flag for %+v", s)
+		}
+	}
+
+	// Чужой пользователь не может завершить сессию
+	req = httptest.NewRequest(http.MethodDelete, "/users/me/sessions/"+other, nil)
+	req.Header.Set("X-User-ID", "2")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's session, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/users/me/sessions/"+other, nil)
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/internal/sessions/"+other+"?user_id=1", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected revoked session to be rejected, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/internal/sessions/"+current+"?user_id=1", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var status domain.SessionStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected active session, got %d: %v", rec.Code, err)
+	}
+	if !status.EmailVerified {
+		t.Fatalf("expected email_verified from the repository, got %+v", status)
+	}
+}
@@ -3,12 +3,37 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
 )
 
+// clientIP — адрес клиента. За api-gateway это последний элемент X-Forwarded-For:
+// его добавляет сам gateway, а предыдущие мог прислать клиент.
+func clientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		return strings.TrimSpace(parts[len(parts)-1])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientInfo — устройство и адрес клиента для списка сессий
+func clientInfo(r *http.Request) domain.ClientInfo {
+	return domain.ClientInfo{IP: clientIP(r), UserAgent: r.UserAgent()}
+}
+
 type AuthHandler struct {
 	authService *service.AuthService
 }
@@ -26,6 +51,36 @@ type registerRequest struct {
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// CartToken — токен гостевой корзины, которую нужно перенести в корзину пользователя
+	CartToken string `json:"cart_token,omitempty"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type resetPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type confirmResetRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type loginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	// Code — код из приложения-аутентификатора или резервный код
+	Code string `json:"code"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
 }
 
 func (h *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
@@ -54,12 +109,203 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.authService.Login(context.Background(), req.Email, req.Password)
-	if err != nil {
+	result, err := h.authService.LoginWithCart(r.Context(), req.Email, req.Password, req.CartToken, clientInfo(r))
+	writeLoginResult(w, result, err)
+}
+
+// LoginTwoFactorHandler — POST /users/login/2fa: второй шаг входа с кодом
+// из приложения-аутентификатора или резервным кодом
+func (h *AuthHandler) LoginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var req loginTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.authService.CompleteTwoFactorLogin(r.Context(), req.ChallengeToken, req.Code, clientInfo(r))
+	writeLoginResult(w, result, err)
+}
+
+func writeLoginResult(w http.ResponseWriter, result domain.LoginResult, err error) {
+	var lockout *domain.LockoutError
+	switch {
+	case errors.As(err, &lockout):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockout.RetryAfter.Seconds()))))
+		http.Error(w, "Too many failed login attempts", http.StatusTooManyRequests)
+		return
+	case errors.Is(err, domain.ErrInvalidCredentials):
 		http.Error(w, "Invalid email or password", http.StatusBadRequest)
 		return
+	case errors.Is(err, domain.ErrInvalidTwoFactorCode):
+		http.Error(w, "Invalid two-factor code", http.StatusBadRequest)
+		return
+	case errors.Is(err, domain.ErrInvalidChallenge):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, domain.ErrUserBanned):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		log.Printf("Ошибка входа: %v", err)
+		http.Error(w, "Login failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ChangePasswordHandler — POST /users/me/password. Возвращает новый токен:
+// остальные сессии пользователя отзываются.
+func (h *AuthHandler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.authService.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword, clientInfo(r))
+	if err != nil {
+		writeUserError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"token": token})
 }
+
+// RequestPasswordResetHandler — POST /users/password/reset. Ответ не зависит
+// от того, зарегистрирован ли email.
+func (h *AuthHandler) RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		log.Printf("Ошибка запроса сброса пароля: %v", err)
+		http.Error(w, "Failed to request password reset", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmPasswordResetHandler — POST /users/password/reset/confirm
+func (h *AuthHandler) ConfirmPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	var req confirmResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.ConfirmPasswordReset(r.Context(), req.Token, req.NewPassword); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyEmailHandler — POST /users/verify с токеном из ссылки подтверждения
+func (h *AuthHandler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.VerifyEmail(r.Context(), req.Token); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerificationHandler — POST /users/verify/resend для текущего пользователя
+func (h *AuthHandler) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.authService.ResendVerification(r.Context(), userID); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// EnrollTwoFactorHandler — POST /users/me/2fa/enroll: секрет и otpauth:// ссылка для QR-кода
+func (h *AuthHandler) EnrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.authService.EnrollTwoFactor(r.Context(), userID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// ConfirmTwoFactorHandler — POST /users/me/2fa/confirm: включает 2FA и один раз
+// возвращает резервные коды
+func (h *AuthHandler) ConfirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.authService.ConfirmTwoFactor(r.Context(), userID, req.Code)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
+
+// DisableTwoFactorHandler — POST /users/me/2fa/disable
+func (h *AuthHandler) DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromHeader(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(r.Context(), userID, req.Code); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -8,18 +8,22 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/handler"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/notify"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
 	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type mockUserRepo struct {
-	users map[string]domain.User
+	users       map[string]domain.User
+	resetTokens map[string]int
 }
 
 func (m *mockUserRepo) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
@@ -38,6 +42,69 @@ func (m *mockUserRepo) CreateUser(ctx context.Context, user domain.User) error {
 	return nil
 }
 
+func (m *mockUserRepo) GetUserByID(ctx context.Context, id int) (domain.User, error) {
+	for _, u := range m.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return domain.User{}, pgx.ErrNoRows
+}
+
+func (m *mockUserRepo) UpdateUser(ctx context.Context, user domain.User) error {
+	for email, u := range m.users {
+		if u.ID != user.ID && email == user.Email {
+			return domain.ErrEmailTaken
+		}
+	}
+	for email, u := range m.users {
+		if u.ID == user.ID {
+			delete(m.users, email)
+			m.users[user.Email] = user
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *mockUserRepo) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	for email, u := range m.users {
+		if u.ID == id {
+			u.PasswordHash = passwordHash
+			m.users[email] = u
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *mockUserRepo) CreateResetToken(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error {
+	if m.resetTokens == nil {
+		m.resetTokens = make(map[string]int)
+	}
+	m.resetTokens[tokenHash] = userID
+	return nil
+}
+
+func (m *mockUserRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	userID, ok := m.resetTokens[tokenHash]
+	if !ok {
+		return 0, domain.ErrInvalidResetToken
+	}
+	delete(m.resetTokens, tokenHash)
+	return userID, m.UpdatePassword(ctx, userID, passwordHash)
+}
+
+func (m *mockUserRepo) SetEmailVerified(ctx context.Context, id int, email string) error {
+	u, ok := m.users[email]
+	if !ok || u.ID != id {
+		return domain.ErrInvalidVerificationToken
+	}
+	u.EmailVerified = true
+	m.users[email] = u
+	return nil
+}
+
 func TestRegiserHandler_Success(t *testing.T) {
 	repo := &mockUserRepo{users: make(map[string]domain.User)}
 	authService := service.NewAuthService(repo)
@@ -191,3 +258,191 @@ func TestLoginHandler_UserNotFound(t *testing.T) {
 		t.Fatalf("expected 400, got %d", rec.Code)
 	}
 }
+
+func TestChangePasswordHandler(t *testing.T) {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
+	repo := &mockUserRepo{users: map[string]domain.User{
+		"user@email.com": {ID: 1, Email: "user@email.com", PasswordHash: string(hashed)},
+	}}
+	h := handler.NewAuthHandler(service.NewAuthService(repo))
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	payload := `{"current_password":"wrong","new_password":"newsecret1"}`
+	req := httptest.NewRequest(http.MethodPost, "/users/me/password", strings.NewReader(payload))
+	req.Header.Set("X-User-ID", "1")
+	rec := httptest.NewRecorder()
+	h.ChangePasswordHandler(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for wrong current password, got %d", rec.Code)
+	}
+
+	payload = `{"current_password":"secret","new_password":"newsecret1"}`
+	req = httptest.NewRequest(http.MethodPost, "/users/me/password", strings.NewReader(payload))
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	h.ChangePasswordHandler(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "token") {
+		t.Fatalf("expected 200 with token, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRequestPasswordResetHandler_UnknownEmail(t *testing.T) {
+	repo := &mockUserRepo{users: make(map[string]domain.User)}
+	authService := service.NewAuthService(repo).WithPasswordReset(notify.LogNotifier{}, time.Minute)
+	h := handler.NewAuthHandler(authService)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/password/reset", strings.NewReader(`{"email":"missing@email.com"}`))
+	rec := httptest.NewRecorder()
+	h.RequestPasswordResetHandler(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 regardless of email, got %d", rec.Code)
+	}
+}
+
+func TestConfirmPasswordResetHandler_InvalidToken(t *testing.T) {
+	repo := &mockUserRepo{users: make(map[string]domain.User)}
+	h := handler.NewAuthHandler(service.NewAuthService(repo))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/password/reset/confirm",
+		strings.NewReader(`{"token":"unknown","new_password":"newsecret1"}`))
+	rec := httptest.NewRecorder()
+	h.ConfirmPasswordResetHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown token, got %d", rec.Code)
+	}
+}
+
+func TestVerifyEmailHandler_InvalidToken(t *testing.T) {
+	repo := &mockUserRepo{users: make(map[string]domain.User)}
+	h := handler.NewAuthHandler(service.NewAuthService(repo))
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	req := httptest.NewRequest(http.MethodPost, "/users/verify", strings.NewReader(`{"token":"garbage"}`))
+	rec := httptest.NewRecorder()
+	h.VerifyEmailHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid token, got %d", rec.Code)
+	}
+}
+
+type lockedAttemptRepo struct{}
+
+func (lockedAttemptRepo) GetLoginAttempts(ctx context.Context, key string) (domain.LoginAttempts, error) {
+	return domain.LoginAttempts{Key: key, Failures: 5, LockedUntil: time.Now().Add(90 * time.Second)}, nil
+}
+
+func (lockedAttemptRepo) RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (domain.LoginAttempts, error) {
+	return domain.LoginAttempts{Key: key}, nil
+}
+
+func (lockedAttemptRepo) LockLogin(ctx context.Context, key string, until time.Time) error {
+	return nil
+}
+
+func (lockedAttemptRepo) ResetLoginAttempts(ctx context.Context, key string) error { return nil }
+
+func TestLoginHandler_LockedOut(t *testing.T) {
+	repo := &mockUserRepo{users: make(map[string]domain.User)}
+	authService := service.NewAuthService(repo).
+		WithLoginThrottle(lockedAttemptRepo{}, nil, service.DefaultThrottleConfig())
+	h := handler.NewAuthHandler(authService)
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"user@email.com","password":"secret"}`))
+	req.Header.Set("X-Forwarded-For", "1.2.3.4, 10.0.0.1")
+	rec := httptest.NewRecorder()
+	h.LoginHandler(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if v, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || v < 89 || v > 91 {
+		t.Fatalf("expected Retry-After about 90 seconds, got %q", rec.Header().Get("Retry-After"))
+	}
+}
+
+// stubTwoFactorRepo — 2FA без сохранённого состояния: коды всегда отклоняются
+type stubTwoFactorRepo struct{}
+
+func (stubTwoFactorRepo) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	return nil
+}
+
+func (stubTwoFactorRepo) EnableTwoFactor(ctx context.Context, userID int, step int64, hashes []string) error {
+	return nil
+}
+
+func (stubTwoFactorRepo) DisableTwoFactor(ctx context.Context, userID int) error { return nil }
+
+func (stubTwoFactorRepo) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	return false, nil
+}
+
+func (stubTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	return false, nil
+}
+
+func (stubTwoFactorRepo) RequiresTwoFactor(ctx context.Context, role string) (bool, error) {
+	return role == domain.RoleAdmin, nil
+}
+
+func (stubTwoFactorRepo) SetTwoFactorPolicy(ctx context.Context, role string, required bool) error {
+	return nil
+}
+
+func TestLoginTwoFactorHandler_InvalidChallenge(t *testing.T) {
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	repo := &mockUserRepo{users: make(map[string]domain.User)}
+	h := handler.NewAuthHandler(service.NewAuthService(repo).WithTwoFactor(stubTwoFactorRepo{}, "Marketplace"))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/login/2fa", strings.NewReader(`{"challenge_token":"garbage","code":"123456"}`))
+	rec := httptest.NewRecorder()
+	h.LoginTwoFactorHandler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestEnrollTwoFactorHandler(t *testing.T) {
+	repo := &mockUserRepo{users: map[string]domain.User{
+		"alex@email.com": {ID: 1, Email: "alex@email.com", Role: domain.RoleUser},
+	}}
+	h := handler.NewAuthHandler(service.NewAuthService(repo).WithTwoFactor(stubTwoFactorRepo{}, "Marketplace"))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/me/2fa/enroll", nil)
+	rec := httptest.NewRecorder()
+	h.EnrollTwoFactorHandler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without X-User-ID, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/users/me/2fa/enroll", nil)
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	h.EnrollTwoFactorHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var resp domain.TwoFactorEnrollment
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Secret == "" || !strings.HasPrefix(resp.URI, "otpauth://totp/") {
+		t.Fatalf("unexpected enrollment %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/users/me/2fa/confirm", strings.NewReader(`{"code":"000000"}`))
+	req.Header.Set("X-User-ID", "1")
+	rec = httptest.NewRecorder()
+	h.ConfirmTwoFactorHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while secret isn't stored, got %d", rec.Code)
+	}
+}
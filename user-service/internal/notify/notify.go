@@ -0,0 +1,115 @@
+// Package notify доставляет пользователям служебные письма: сброс пароля, подтверждение email.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+)
+
+// Notifier отправляет сообщение пользователю. Реализация выбирается в main:
+// по SMTP, а в локальном окружении — в лог или в файлы.
+type Notifier interface {
+	SendPasswordReset(ctx context.Context, user domain.User, token string) error
+	SendEmailVerification(ctx context.Context, user domain.User, link string) error
+}
+
+func passwordResetBody(token string) string {
+	return fmt.Sprintf("Для сброса пароля используйте токен:\n%s\n", token)
+}
+
+func emailVerificationBody(link string) string {
+	return fmt.Sprintf("Чтобы подтвердить email, перейдите по ссылке:\n%s\n", link)
+}
+
+// LogNotifier пишет сообщения в лог сервиса. Только для локальной разработки.
+type LogNotifier struct{}
+
+func (LogNotifier) SendPasswordReset(ctx context.Context, user domain.User, token string) error {
+	log.Printf("Сброс пароля для %s: токен %s", user.Email, token)
+	return nil
+}
+
+func (LogNotifier) SendEmailVerification(ctx context.Context, user domain.User, link string) error {
+	log.Printf("Подтверждение email %s: %s", user.Email, link)
+	return nil
+}
+
+// FileNotifier складывает каждое сообщение отдельным файлом в каталог dir
+type FileNotifier struct {
+	dir string
+}
+
+func NewFileNotifier(dir string) *FileNotifier {
+	return &FileNotifier{dir: dir}
+}
+
+func (n *FileNotifier) SendPasswordReset(ctx context.Context, user domain.User, token string) error {
+	return n.write("password-reset", user.Email, "Сброс пароля", passwordResetBody(token))
+}
+
+func (n *FileNotifier) SendEmailVerification(ctx context.Context, user domain.User, link string) error {
+	return n.write("verify-email", user.Email, "Подтверждение email", emailVerificationBody(link))
+}
+
+func (n *FileNotifier) write(kind, email, subject, body string) error {
+	if err := os.MkdirAll(n.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-%s.txt", time.Now().UTC().Format("20060102T150405.000000000"), kind, sanitize(email))
+	return os.WriteFile(filepath.Join(n.dir, name), message(email, subject, body), 0o600)
+}
+
+// SMTPNotifier отправляет письма через SMTP-сервер
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPNotifier создаёт отправителя писем; без username письма уходят без авторизации
+func NewSMTPNotifier(host, port, username, password, from string) *SMTPNotifier {
+	n := &SMTPNotifier{addr: net.JoinHostPort(host, port), from: from}
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return n
+}
+
+func (n *SMTPNotifier) SendPasswordReset(ctx context.Context, user domain.User, token string) error {
+	return n.send(user.Email, "Сброс пароля", passwordResetBody(token))
+}
+
+func (n *SMTPNotifier) SendEmailVerification(ctx context.Context, user domain.User, link string) error {
+	return n.send(user.Email, "Подтверждение email", emailVerificationBody(link))
+}
+
+func (n *SMTPNotifier) send(to, subject, body string) error {
+	msg := append([]byte(fmt.Sprintf("From: %s\r\n", n.from)), message(to, subject, body)...)
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{to}, msg)
+}
+
+func message(to, subject, body string) []byte {
+	return []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s", to, subject, body))
+}
+
+// sanitize оставляет в имени файла только безопасные символы адреса
+func sanitize(email string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		case r == '@':
+			return '_'
+		}
+		return -1
+	}, email)
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AddressRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAddressRepository(db *pgxpool.Pool) *AddressRepository {
+	return &AddressRepository{db: db}
+}
+
+type AddressRepositoryInterface interface {
+	ListAddresses(ctx context.Context, userID int) ([]domain.Address, error)
+	GetAddress(ctx context.Context, userID, id int) (domain.Address, error)
+	GetDefaultAddress(ctx context.Context, userID int, addressType string) (domain.Address, error)
+	CreateAddress(ctx context.Context, address *domain.Address) error
+	UpdateAddress(ctx context.Context, address *domain.Address) error
+	DeleteAddress(ctx context.Context, userID, id int) error
+}
+
+const addressColumns = `id, user_id, type, is_default, recipient, phone, country, region, city, line1, line2, postal_code, created_at, updated_at`
+
+func scanAddress(row pgx.Row) (domain.Address, error) {
+	var a domain.Address
+	err := row.Scan(&a.ID, &a.UserID, &a.Type, &a.IsDefault, &a.Recipient, &a.Phone, &a.Country, &a.Region,
+		&a.City, &a.Line1, &a.Line2, &a.PostalCode, &a.CreatedAt, &a.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Address{}, domain.ErrAddressNotFound
+	}
+	return a, err
+}
+
+// ListAddresses возвращает адреса пользователя: по типам, адрес по умолчанию первым
+func (r *AddressRepository) ListAddresses(ctx context.Context, userID int) ([]domain.Address, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+addressColumns+`
+		FROM user_service.addresses
+		WHERE user_id = $1
+		ORDER BY type DESC, is_default DESC, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []domain.Address{}
+	for rows.Next() {
+		a, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses, rows.Err()
+}
+
+func (r *AddressRepository) GetAddress(ctx context.Context, userID, id int) (domain.Address, error) {
+	return scanAddress(r.db.QueryRow(ctx, `
+		SELECT `+addressColumns+` FROM user_service.addresses WHERE id = $1 AND user_id = $2
+	`, id, userID))
+}
+
+func (r *AddressRepository) GetDefaultAddress(ctx context.Context, userID int, addressType string) (domain.Address, error) {
+	return scanAddress(r.db.QueryRow(ctx, `
+		SELECT `+addressColumns+` FROM user_service.addresses WHERE user_id = $1 AND type = $2 AND is_default
+	`, userID, addressType))
+}
+
+// CreateAddress сохраняет адрес; первый адрес своего типа становится адресом по умолчанию
+func (r *AddressRepository) CreateAddress(ctx context.Context, address *domain.Address) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if address.IsDefault {
+		if err := clearDefaultAddress(ctx, tx, address.UserID, address.Type, 0); err != nil {
+			return err
+		}
+	}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO user_service.addresses (user_id, type, is_default, recipient, phone, country, region, city, line1, line2, postal_code)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`, address.UserID, address.Type, address.IsDefault, address.Recipient, address.Phone, address.Country, address.Region,
+		address.City, address.Line1, address.Line2, address.PostalCode).Scan(&address.ID, &address.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := ensureDefaultAddresses(ctx, tx, address.UserID); err != nil {
+		return err
+	}
+	if *address, err = scanAddress(tx.QueryRow(ctx,
+		`SELECT `+addressColumns+` FROM user_service.addresses WHERE id = $1`, address.ID)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateAddress заменяет поля адреса. Снять отметку «по умолчанию» можно, только
+// назначив по умолчанию другой адрес того же типа.
+func (r *AddressRepository) UpdateAddress(ctx context.Context, address *domain.Address) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if address.IsDefault {
+		if err := clearDefaultAddress(ctx, tx, address.UserID, address.Type, address.ID); err != nil {
+			return err
+		}
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE user_service.addresses
+		SET type = $3, is_default = is_default AND type = $3 OR $4, recipient = $5, phone = $6, country = $7,
+			region = $8, city = $9, line1 = $10, line2 = $11, postal_code = $12, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`, address.ID, address.UserID, address.Type, address.IsDefault, address.Recipient, address.Phone, address.Country,
+		address.Region, address.City, address.Line1, address.Line2, address.PostalCode)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrAddressNotFound
+	}
+	if err := ensureDefaultAddresses(ctx, tx, address.UserID); err != nil {
+		return err
+	}
+	if *address, err = scanAddress(tx.QueryRow(ctx,
+		`SELECT `+addressColumns+` FROM user_service.addresses WHERE id = $1`, address.ID)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteAddress удаляет адрес; если он был адресом по умолчанию, им становится последний изменённый
+func (r *AddressRepository) DeleteAddress(ctx context.Context, userID, id int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM user_service.addresses WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrAddressNotFound
+	}
+	if err := ensureDefaultAddresses(ctx, tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// clearDefaultAddress снимает отметку «по умолчанию» с адресов типа, кроме exceptID
+func clearDefaultAddress(ctx context.Context, tx pgx.Tx, userID int, addressType string, exceptID int) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE user_service.addresses SET is_default = FALSE
+		WHERE user_id = $1 AND type = $2 AND is_default AND id <> $3
+	`, userID, addressType, exceptID)
+	return err
+}
+
+// ensureDefaultAddresses назначает адрес по умолчанию каждому типу, у которого его не осталось
+func ensureDefaultAddresses(ctx context.Context, tx pgx.Tx, userID int) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE user_service.addresses SET is_default = TRUE
+		WHERE id IN (
+			SELECT DISTINCT ON (type) id
+			FROM user_service.addresses
+			WHERE user_id = $1 AND type NOT IN (
+				SELECT type FROM user_service.addresses WHERE user_id = $1 AND is_default
+			)
+			ORDER BY type, updated_at DESC, id DESC
+		)
+	`, userID)
+	return err
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AdminRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAdminRepository(db *pgxpool.Pool) *AdminRepository {
+	return &AdminRepository{db: db}
+}
+
+type AdminRepositoryInterface interface {
+	ListUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int, error)
+	BanUser(ctx context.Context, actorID, userID int, reason string) error
+	UnbanUser(ctx context.Context, actorID, userID int) error
+	SetUserRole(ctx context.Context, actorID, userID int, role string) error
+	RecordAudit(ctx context.Context, entry domain.AuditEntry) error
+	ListAuditLog(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
+
+// ListUsers возвращает страницу пользователей, подходящих под фильтр, от новых к старым,
+// и общее число подходящих
+func (r *AdminRepository) ListUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.EmailPrefix != "" {
+		// Экранируем спецсимволы LIKE, чтобы префикс искался буквально
+		prefix := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(filter.EmailPrefix))
+		conds = append(conds, "lower(email) LIKE "+arg(prefix+"%"))
+	}
+	if filter.Role != "" {
+		conds = append(conds, "role = "+arg(filter.Role))
+	}
+	switch filter.Status {
+	case domain.UserStatusActive:
+		conds = append(conds, "deleted_at IS NULL AND banned_at IS NULL")
+	case domain.UserStatusBanned:
+		conds = append(conds, "deleted_at IS NULL AND banned_at IS NOT NULL")
+	case domain.UserStatusDeleted:
+		conds = append(conds, "deleted_at IS NOT NULL")
+	}
+	if filter.CreatedFrom != nil {
+		conds = append(conds, "created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conds = append(conds, "created_at < "+arg(*filter.CreatedTo))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM user_service.users`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + userColumns + ` FROM user_service.users` + where +
+		` ORDER BY created_at DESC, id DESC LIMIT ` + arg(filter.Limit) + ` OFFSET ` + arg(filter.Offset)
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
+// BanUser блокирует пользователя, отзывает все его сессии и пишет запись в журнал
+// в одной транзакции. Удалённый или неизвестный пользователь — domain.ErrUserNotFound.
+func (r *AdminRepository) BanUser(ctx context.Context, actorID, userID int, reason string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockUserForAdmin(ctx, tx, userID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE user_service.users SET banned_at = NOW(), ban_reason = $2, updated_at = NOW()
+		WHERE id = $1 AND banned_at IS NULL
+	`, userID, reason)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrUserAlreadyBanned
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE user_service.sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return err
+	}
+	if err := insertAudit(ctx, tx, actorID, domain.AuditUserBan, &userID, map[string]string{"reason": reason}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UnbanUser снимает блокировку. Сессии не восстанавливаются — пользователь входит заново.
+func (r *AdminRepository) UnbanUser(ctx context.Context, actorID, userID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockUserForAdmin(ctx, tx, userID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE user_service.users SET banned_at = NULL, ban_reason = '', updated_at = NOW()
+		WHERE id = $1 AND banned_at IS NOT NULL
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrUserNotBanned
+	}
+	if err := insertAudit(ctx, tx, actorID, domain.AuditUserUnban, &userID, map[string]string{}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetUserRole меняет роль пользователя и записывает прежнюю и новую роль в журнал.
+// Назначение той же роли ничего не меняет и в журнал не попадает.
+func (r *AdminRepository) SetUserRole(ctx context.Context, actorID, userID int, role string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	err = tx.QueryRow(ctx,
+		`SELECT role FROM user_service.users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, userID).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current == role {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE user_service.users SET role = $2, updated_at = NOW() WHERE id = $1`, userID, role)
+	if err != nil {
+		return err
+	}
+	details := map[string]string{"from": current, "to": role}
+	if err := insertAudit(ctx, tx, actorID, domain.AuditUserRoleChange, &userID, details); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// lockUserForAdmin блокирует строку пользователя до конца транзакции;
+// удалённый или неизвестный пользователь — domain.ErrUserNotFound
+func lockUserForAdmin(ctx context.Context, tx pgx.Tx, userID int) error {
+	var id int
+	err := tx.QueryRow(ctx,
+		`SELECT id FROM user_service.users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, userID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrUserNotFound
+	}
+	return err
+}
+
+// RecordAudit пишет в журнал действие, которое выполнялось вне транзакций этого репозитория
+func (r *AdminRepository) RecordAudit(ctx context.Context, entry domain.AuditEntry) error {
+	var details any = entry.Details
+	if len(entry.Details) == 0 {
+		details = map[string]string{}
+	}
+	return insertAudit(ctx, r.db, entry.ActorID, entry.Action, entry.TargetUserID, details)
+}
+
+func insertAudit(ctx context.Context, db execer, actorID int, action string, targetUserID *int, details any) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(ctx, `
+		INSERT INTO user_service.admin_audit_log (actor_id, action, target_user_id, details)
+		VALUES ($1, $2, $3, $4)
+	`, actorID, action, targetUserID, data)
+	return err
+}
+
+// ListAuditLog возвращает записи журнала от новых к старым
+func (r *AdminRepository) ListAuditLog(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	query := `
+		SELECT id, actor_id, action, target_user_id, details, created_at
+		FROM user_service.admin_audit_log
+		WHERE ($1 = 0 OR actor_id = $1) AND ($2 = 0 OR target_user_id = $2) AND ($3 = '' OR action = $3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4 OFFSET $5
+	`
+	rows, err := r.db.Query(ctx, query, filter.ActorID, filter.TargetUserID, filter.Action, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var e domain.AuditEntry
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &e.TargetUserID, &e.Details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LoginAttemptRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(db *pgxpool.Pool) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+type LoginAttemptRepositoryInterface interface {
+	GetLoginAttempts(ctx context.Context, key string) (domain.LoginAttempts, error)
+	RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (domain.LoginAttempts, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
+
+// GetLoginAttempts возвращает счётчик по ключу; для ключа без неудачных попыток — пустой
+func (r *LoginAttemptRepository) GetLoginAttempts(ctx context.Context, key string) (domain.LoginAttempts, error) {
+	query := `SELECT key, failures, last_failure_at, locked_until FROM user_service.login_attempts WHERE key = $1`
+	a, err := scanLoginAttempts(r.db.QueryRow(ctx, query, key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.LoginAttempts{Key: key}, nil
+	}
+	return a, err
+}
+
+// RecordLoginFailure атомарно увеличивает счётчик. Если последняя неудача была раньше
+// окна window, счёт начинается заново.
+func (r *LoginAttemptRepository) RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (domain.LoginAttempts, error) {
+	query := `
+		INSERT INTO user_service.login_attempts (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+				WHEN login_attempts.last_failure_at < $3 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at, locked_until
+	`
+	return scanLoginAttempts(r.db.QueryRow(ctx, query, key, now, now.Add(-window)))
+}
+
+// LockLogin запрещает вход по ключу до until
+func (r *LoginAttemptRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.Exec(ctx, `UPDATE user_service.login_attempts SET locked_until = $2 WHERE key = $1`, key, until)
+	return err
+}
+
+func (r *LoginAttemptRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM user_service.login_attempts WHERE key = $1`, key)
+	return err
+}
+
+func scanLoginAttempts(row pgx.Row) (domain.LoginAttempts, error) {
+	var a domain.LoginAttempts
+	var lockedUntil *time.Time
+	if err := row.Scan(&a.Key, &a.Failures, &a.LastFailureAt, &lockedUntil); err != nil {
+		return domain.LoginAttempts{}, err
+	}
+	if lockedUntil != nil {
+		a.LockedUntil = *lockedUntil
+	}
+	return a, nil
+}
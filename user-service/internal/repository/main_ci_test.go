@@ -0,0 +1,45 @@
+//go:build ci
+
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		// Fallback для GitHub Actions
+		host := os.Getenv("DB_HOST")
+		port := os.Getenv("DB_PORT")
+		user := os.Getenv("DB_USER")
+		password := os.Getenv("DB_PASSWORD")
+		dbname := os.Getenv("DB_NAME")
+
+		dsn = "postgres://" + user + ":" + password + "@" + host + ":" + port + "/" + dbname + "?sslmode=disable"
+	}
+
+	var err error
+	dbpool, err = pgxpool.New(ctx, dsn)
+	if err != nil {
+		panic("failed to connect to database: " + err.Error())
+	}
+
+	_, err = dbpool.Exec(ctx, `DROP SCHEMA IF EXISTS user_service CASCADE`)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := applyMigrations(ctx, dbpool); err != nil {
+		panic("failed to apply migrations: " + err.Error())
+	}
+
+	code := m.Run()
+	os.Exit(code)
+}
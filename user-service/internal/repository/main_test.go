@@ -0,0 +1,72 @@
+//go:build !ci
+
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var pgContainer testcontainers.Container
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:15",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_DB":       "users",
+			"POSTGRES_USER":     "postgres",
+			"POSTGRES_PASSWORD": "postgres",
+		},
+		Tmpfs:      map[string]string{"/var/lib/postgresql/data": "rw"},
+		WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(30 * time.Second),
+	}
+	var err error
+	pgContainer, err = testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		log.Fatalf("failed to start container: %v", err)
+	}
+
+	host, err := pgContainer.Host(ctx)
+	if err != nil {
+		log.Fatalf("failed to get host: %v", err)
+	}
+	mappedPort, err := pgContainer.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		log.Fatalf("failed to get port: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%s/users?sslmode=disable", host, mappedPort.Port())
+	dbpool, err = pgxpool.New(ctx, dsn)
+	if err != nil {
+		log.Fatalf("failed to connect to db: %v", err)
+	}
+
+	if err := applyMigrations(ctx, dbpool); err != nil {
+		log.Fatalf("failed to apply migrations: %v", err)
+	}
+
+	// Выполняем тесты
+	code := m.Run()
+
+	// Чистим ресурсы
+	dbpool.Close()
+	if err := pgContainer.Terminate(ctx); err != nil {
+		log.Printf("failed to terminate container: %v", err)
+	}
+
+	os.Exit(code)
+}
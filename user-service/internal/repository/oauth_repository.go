@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OAuthRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthRepository(db *pgxpool.Pool) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+type OAuthRepositoryInterface interface {
+	CreateClient(ctx context.Context, client domain.OAuthClient) error
+	GetClient(ctx context.Context, id string) (domain.OAuthClient, error)
+	CreateAuthorizationCode(ctx context.Context, codeHash string, code domain.AuthorizationCode, ttl time.Duration) error
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (domain.AuthorizationCode, error)
+	GetConsent(ctx context.Context, userID int, clientID string) ([]string, error)
+	SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error
+}
+
+func (r *OAuthRepository) CreateClient(ctx context.Context, client domain.OAuthClient) error {
+	var secretHash *string
+	if client.SecretHash != "" {
+		secretHash = &client.SecretHash
+	}
+	var createdBy *int
+	if client.CreatedBy != 0 {
+		createdBy = &client.CreatedBy
+	}
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_service.oauth_clients (id, name, secret_hash, redirect_uris, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, client.ID, client.Name, secretHash, client.RedirectURIs, createdBy, client.CreatedAt)
+	return err
+}
+
+// GetClient возвращает клиента или pgx.ErrNoRows, если он не зарегистрирован
+func (r *OAuthRepository) GetClient(ctx context.Context, id string) (domain.OAuthClient, error) {
+	var client domain.OAuthClient
+	var createdBy *int
+	err := r.db.QueryRow(ctx, `
+		SELECT id, name, COALESCE(secret_hash, ''), redirect_uris, created_by, created_at
+		FROM user_service.oauth_clients WHERE id = $1
+	`, id).Scan(&client.ID, &client.Name, &client.SecretHash, &client.RedirectURIs, &createdBy, &client.CreatedAt)
+	if createdBy != nil {
+		client.CreatedBy = *createdBy
+	}
+	return client, err
+}
+
+func (r *OAuthRepository) CreateAuthorizationCode(ctx context.Context, codeHash string, code domain.AuthorizationCode, ttl time.Duration) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_service.oauth_authorization_codes
+			(code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, auth_time, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW() + $9 * INTERVAL '1 second')
+	`, codeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scope, code.Nonce, code.CodeChallenge,
+		code.AuthTime, int64(ttl.Seconds()))
+	return err
+}
+
+// ConsumeAuthorizationCode гасит код и возвращает его данные.
+// Неизвестный, использованный или просроченный код — domain.ErrInvalidAuthorizationCode
+func (r *OAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (domain.AuthorizationCode, error) {
+	var code domain.AuthorizationCode
+	err := r.db.QueryRow(ctx, `
+		UPDATE user_service.oauth_authorization_codes
+		SET used_at = NOW()
+		WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING client_id, user_id, redirect_uri, scope, nonce, code_challenge, auth_time
+	`, codeHash).Scan(&code.ClientID, &code.UserID, &code.RedirectURI, &code.Scope, &code.Nonce,
+		&code.CodeChallenge, &code.AuthTime)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.AuthorizationCode{}, domain.ErrInvalidAuthorizationCode
+	}
+	return code, err
+}
+
+// GetConsent возвращает области, на которые пользователь уже согласился для клиента
+func (r *OAuthRepository) GetConsent(ctx context.Context, userID int, clientID string) ([]string, error) {
+	var scopes []string
+	err := r.db.QueryRow(ctx,
+		`SELECT scopes FROM user_service.oauth_consents WHERE user_id = $1 AND client_id = $2`,
+		userID, clientID).Scan(&scopes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return scopes, err
+}
+
+// SaveConsent добавляет области к уже выданному согласию
+func (r *OAuthRepository) SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_service.oauth_consents (user_id, client_id, scopes, granted_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, client_id) DO UPDATE
+		SET scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes)),
+			granted_at = NOW()
+	`, userID, clientID, scopes)
+	return err
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PrivacyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPrivacyRepository(db *pgxpool.Pool) *PrivacyRepository {
+	return &PrivacyRepository{db: db}
+}
+
+type PrivacyRepositoryInterface interface {
+	ListConsents(ctx context.Context, userID int) ([]domain.OAuthConsent, error)
+	AnonymizeUser(ctx context.Context, userID int, attemptsKey string) error
+	PublishDeletedEvents(ctx context.Context, limit int, publish func(ctx context.Context, event domain.DeletedUserEvent) error) (int, error)
+}
+
+func (r *PrivacyRepository) ListConsents(ctx context.Context, userID int) ([]domain.OAuthConsent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT c.client_id, cl.name, c.scopes, c.granted_at
+		FROM user_service.oauth_consents c
+		JOIN user_service.oauth_clients cl ON cl.id = c.client_id
+		WHERE c.user_id = $1
+		ORDER BY c.granted_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consents := []domain.OAuthConsent{}
+	for rows.Next() {
+		var c domain.OAuthConsent
+		if err := rows.Scan(&c.ClientID, &c.ClientName, &c.Scopes, &c.GrantedAt); err != nil {
+			return nil, err
+		}
+		consents = append(consents, c)
+	}
+	return consents, rows.Err()
+}
+
+// AnonymizeUser удаляет персональные данные пользователя. Строка users остаётся с
+// обезличенными полями и пустым паролем, под которым войти нельзя; адреса, сессии,
+// коды 2FA и восстановления, согласия OAuth и счётчик попыток входа (attemptsKey) удаляются.
+// В той же транзакции в outbox пишется событие UserDeleted для других сервисов.
+// Уже удалённый пользователь — domain.ErrUserNotFound.
+func (r *PrivacyRepository) AnonymizeUser(ctx context.Context, userID int, attemptsKey string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE user_service.users
+		SET name = 'Deleted user', email = $2, email_verified = FALSE, phone = '', password_hash = '',
+			totp_secret = NULL, two_factor_enabled = FALSE, deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, userID, fmt.Sprintf("deleted-%d@deleted.invalid", userID))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	for _, query := range []string{
+		`DELETE FROM user_service.addresses WHERE user_id = $1`,
+		`DELETE FROM user_service.sessions WHERE user_id = $1`,
+		`DELETE FROM user_service.recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_service.password_reset_tokens WHERE user_id = $1`,
+		`DELETE FROM user_service.oauth_consents WHERE user_id = $1`,
+		`DELETE FROM user_service.oauth_authorization_codes WHERE user_id = $1`,
+		// Витрина удалённого пользователя снимается с продажи, но остаётся для истории заказов
+		`UPDATE user_service.sellers SET status = 'suspended', updated_at = NOW() WHERE user_id = $1`,
+	} {
+		if _, err := tx.Exec(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_service.login_attempts WHERE key = $1`, attemptsKey); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO user_service.user_events_outbox (type, user_id) VALUES ('UserDeleted', $1)
+	`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// PublishDeletedEvents передаёт в publish до limit неопубликованных событий UserDeleted
+// по порядку и отмечает опубликованные. На первой ошибке проход останавливается, чтобы
+// события одного пользователя не обгоняли друг друга; ошибка и число попыток сохраняются
+// в строке. Строки блокируются (SKIP LOCKED), поэтому несколько реплик не отправят одно
+// событие одновременно. Возвращает число опубликованных событий.
+func (r *PrivacyRepository) PublishDeletedEvents(ctx context.Context, limit int,
+	publish func(ctx context.Context, event domain.DeletedUserEvent) error) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, user_id, created_at
+		FROM user_service.user_events_outbox
+		WHERE published_at IS NULL AND type = 'UserDeleted'
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var events []domain.DeletedUserEvent
+	for rows.Next() {
+		var e domain.DeletedUserEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.DeletedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	for _, e := range events {
+		if publishErr = publish(ctx, e); publishErr != nil {
+			if _, err := tx.Exec(ctx, `
+				UPDATE user_service.user_events_outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1
+			`, e.ID, publishErr.Error()); err != nil {
+				return 0, err
+			}
+			break
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE user_service.user_events_outbox SET published_at = NOW(), attempts = attempts + 1 WHERE id = $1
+		`, e.ID); err != nil {
+			return 0, err
+		}
+		published++
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return published, publishErr
+}
@@ -0,0 +1,309 @@
+// Package repositorytest содержит хранящиеся в памяти реализации репозиториев для
+// тестов сервисов и обработчиков. Поля экспортированы, чтобы тесты могли подготовить
+// данные и проверить результат.
+package repositorytest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+)
+
+// SessionRepo — репозиторий сессий. Отозванные сессии остаются в Sessions и
+// отмечаются в Revoked.
+type SessionRepo struct {
+	Sessions map[string]domain.Session
+	Revoked  map[string]bool
+	// EmailVerified возвращается в статусе активной сессии
+	EmailVerified bool
+}
+
+func NewSessionRepo() *SessionRepo {
+	return &SessionRepo{Sessions: map[string]domain.Session{}, Revoked: map[string]bool{}}
+}
+
+func (m *SessionRepo) CreateSession(ctx context.Context, session domain.Session) error {
+	m.Sessions[session.ID] = session
+	return nil
+}
+
+func (m *SessionRepo) ListActiveSessions(ctx context.Context, userID int) ([]domain.Session, error) {
+	sessions := []domain.Session{}
+	for id, s := range m.Sessions {
+		if s.UserID == userID && !m.Revoked[id] {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *SessionRepo) RevokeSession(ctx context.Context, userID int, id string) error {
+	s, ok := m.Sessions[id]
+	if !ok || s.UserID != userID || m.Revoked[id] {
+		return domain.ErrSessionNotFound
+	}
+	m.Revoked[id] = true
+	return nil
+}
+
+func (m *SessionRepo) RevokeUserSessions(ctx context.Context, userID int) error {
+	for id, s := range m.Sessions {
+		if s.UserID == userID {
+			m.Revoked[id] = true
+		}
+	}
+	return nil
+}
+
+func (m *SessionRepo) TouchSession(ctx context.Context, userID int, id string) (domain.SessionStatus, error) {
+	s, ok := m.Sessions[id]
+	if !ok || s.UserID != userID || m.Revoked[id] {
+		return domain.SessionStatus{}, nil
+	}
+	return domain.SessionStatus{Active: true, EmailVerified: m.EmailVerified}, nil
+}
+
+// AddressRepo — адресная книга. Как и в Postgres, у пользователя не больше одного
+// адреса по умолчанию каждого типа, а первый адрес типа становится адресом по умолчанию.
+type AddressRepo struct {
+	Addresses map[int]domain.Address
+	nextID    int
+}
+
+func NewAddressRepo() *AddressRepo {
+	return &AddressRepo{Addresses: map[int]domain.Address{}}
+}
+
+func (m *AddressRepo) ListAddresses(ctx context.Context, userID int) ([]domain.Address, error) {
+	addresses := []domain.Address{}
+	for _, a := range m.Addresses {
+		if a.UserID == userID {
+			addresses = append(addresses, a)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].ID < addresses[j].ID })
+	return addresses, nil
+}
+
+func (m *AddressRepo) GetAddress(ctx context.Context, userID, id int) (domain.Address, error) {
+	a, ok := m.Addresses[id]
+	if !ok || a.UserID != userID {
+		return domain.Address{}, domain.ErrAddressNotFound
+	}
+	return a, nil
+}
+
+func (m *AddressRepo) GetDefaultAddress(ctx context.Context, userID int, addressType string) (domain.Address, error) {
+	for _, a := range m.Addresses {
+		if a.UserID == userID && a.Type == addressType && a.IsDefault {
+			return a, nil
+		}
+	}
+	return domain.Address{}, domain.ErrAddressNotFound
+}
+
+func (m *AddressRepo) CreateAddress(ctx context.Context, address *domain.Address) error {
+	m.nextID++
+	address.ID = m.nextID
+	if _, err := m.GetDefaultAddress(ctx, address.UserID, address.Type); err != nil {
+		address.IsDefault = true
+	}
+	if address.IsDefault {
+		m.clearDefault(address.UserID, address.Type)
+	}
+	m.Addresses[address.ID] = *address
+	return nil
+}
+
+func (m *AddressRepo) UpdateAddress(ctx context.Context, address *domain.Address) error {
+	if _, err := m.GetAddress(ctx, address.UserID, address.ID); err != nil {
+		return err
+	}
+	if address.IsDefault {
+		m.clearDefault(address.UserID, address.Type)
+	}
+	m.Addresses[address.ID] = *address
+	return nil
+}
+
+func (m *AddressRepo) DeleteAddress(ctx context.Context, userID, id int) error {
+	if _, err := m.GetAddress(ctx, userID, id); err != nil {
+		return err
+	}
+	delete(m.Addresses, id)
+	return nil
+}
+
+func (m *AddressRepo) clearDefault(userID int, addressType string) {
+	for id, a := range m.Addresses {
+		if a.UserID == userID && a.Type == addressType {
+			a.IsDefault = false
+			m.Addresses[id] = a
+		}
+	}
+}
+
+// SellerRepo — профили продавцов с уникальностью пользователя и названия без учёта регистра
+type SellerRepo struct {
+	Sellers map[int]domain.Seller
+	nextID  int
+}
+
+func NewSellerRepo() *SellerRepo {
+	return &SellerRepo{Sellers: map[int]domain.Seller{}}
+}
+
+func (m *SellerRepo) CreateSeller(ctx context.Context, seller *domain.Seller) error {
+	for _, s := range m.Sellers {
+		if s.UserID == seller.UserID {
+			return domain.ErrSellerExists
+		}
+		if strings.EqualFold(s.DisplayName, seller.DisplayName) {
+			return domain.ErrSellerNameTaken
+		}
+	}
+	m.nextID++
+	seller.ID, seller.Status = m.nextID, domain.SellerPending
+	m.Sellers[seller.ID] = *seller
+	return nil
+}
+
+func (m *SellerRepo) GetSeller(ctx context.Context, id int) (domain.Seller, error) {
+	s, ok := m.Sellers[id]
+	if !ok {
+		return domain.Seller{}, domain.ErrSellerNotFound
+	}
+	return s, nil
+}
+
+func (m *SellerRepo) GetSellerByUserID(ctx context.Context, userID int) (domain.Seller, error) {
+	for _, s := range m.Sellers {
+		if s.UserID == userID {
+			return s, nil
+		}
+	}
+	return domain.Seller{}, domain.ErrSellerNotFound
+}
+
+func (m *SellerRepo) UpdateSeller(ctx context.Context, seller *domain.Seller) error {
+	m.Sellers[seller.ID] = *seller
+	return nil
+}
+
+func (m *SellerRepo) ListSellers(ctx context.Context, status string, limit, offset int) ([]domain.Seller, error) {
+	sellers := []domain.Seller{}
+	for _, s := range m.Sellers {
+		if status == "" || s.Status == status {
+			sellers = append(sellers, s)
+		}
+	}
+	sort.Slice(sellers, func(i, j int) bool { return sellers[i].ID < sellers[j].ID })
+	return sellers, nil
+}
+
+func (m *SellerRepo) SetSellerStatus(ctx context.Context, actorID, id int, status string) (domain.Seller, error) {
+	s, err := m.GetSeller(ctx, id)
+	if err != nil {
+		return domain.Seller{}, err
+	}
+	s.Status = status
+	m.Sellers[id] = s
+	return s, nil
+}
+
+// AdminRepo — администрирование пользователей. ListUsers отдаёт Users целиком и
+// запоминает фильтр в LastFilter; роль меняется только у пользователей из Roles.
+type AdminRepo struct {
+	Users      []domain.User
+	LastFilter domain.UserFilter
+	// Banned — причина блокировки по ID пользователя
+	Banned map[int]string
+	Roles  map[int]string
+	Audit  []domain.AuditEntry
+}
+
+func NewAdminRepo() *AdminRepo {
+	return &AdminRepo{Banned: map[int]string{}, Roles: map[int]string{}}
+}
+
+func (m *AdminRepo) ListUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, int, error) {
+	m.LastFilter = filter
+	return m.Users, len(m.Users), nil
+}
+
+func (m *AdminRepo) BanUser(ctx context.Context, actorID, userID int, reason string) error {
+	if _, ok := m.Banned[userID]; ok {
+		return domain.ErrUserAlreadyBanned
+	}
+	m.Banned[userID] = reason
+	m.Audit = append(m.Audit, domain.AuditEntry{ActorID: actorID, Action: domain.AuditUserBan, TargetUserID: &userID})
+	return nil
+}
+
+func (m *AdminRepo) UnbanUser(ctx context.Context, actorID, userID int) error {
+	if _, ok := m.Banned[userID]; !ok {
+		return domain.ErrUserNotBanned
+	}
+	delete(m.Banned, userID)
+	return nil
+}
+
+func (m *AdminRepo) SetUserRole(ctx context.Context, actorID, userID int, role string) error {
+	if _, ok := m.Roles[userID]; !ok {
+		return domain.ErrUserNotFound
+	}
+	m.Roles[userID] = role
+	return nil
+}
+
+func (m *AdminRepo) RecordAudit(ctx context.Context, entry domain.AuditEntry) error {
+	m.Audit = append(m.Audit, entry)
+	return nil
+}
+
+func (m *AdminRepo) ListAuditLog(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	return m.Audit, nil
+}
+
+// PrivacyRepo — выгрузка и удаление аккаунта. AnonymizeUser, как и в Postgres, кладёт
+// UserDeleted в Outbox, а PublishDeletedEvents убирает оттуда опубликованные события.
+type PrivacyRepo struct {
+	Consents    []domain.OAuthConsent
+	Anonymized  []int
+	AttemptKeys []string
+	Outbox      []domain.DeletedUserEvent
+	nextEventID int64
+}
+
+func (m *PrivacyRepo) ListConsents(ctx context.Context, userID int) ([]domain.OAuthConsent, error) {
+	return append([]domain.OAuthConsent{}, m.Consents...), nil
+}
+
+func (m *PrivacyRepo) AnonymizeUser(ctx context.Context, userID int, attemptsKey string) error {
+	for _, id := range m.Anonymized {
+		if id == userID {
+			return domain.ErrUserNotFound
+		}
+	}
+	m.Anonymized = append(m.Anonymized, userID)
+	m.AttemptKeys = append(m.AttemptKeys, attemptsKey)
+	m.nextEventID++
+	m.Outbox = append(m.Outbox, domain.DeletedUserEvent{ID: m.nextEventID, UserID: userID, DeletedAt: time.Now()})
+	return nil
+}
+
+func (m *PrivacyRepo) PublishDeletedEvents(ctx context.Context, limit int,
+	publish func(ctx context.Context, event domain.DeletedUserEvent) error) (int, error) {
+	published := 0
+	for len(m.Outbox) > 0 && published < limit {
+		if err := publish(ctx, m.Outbox[0]); err != nil {
+			return published, err
+		}
+		m.Outbox = m.Outbox[1:]
+		published++
+	}
+	return published, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SellerRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSellerRepository(db *pgxpool.Pool) *SellerRepository {
+	return &SellerRepository{db: db}
+}
+
+type SellerRepositoryInterface interface {
+	CreateSeller(ctx context.Context, seller *domain.Seller) error
+	GetSeller(ctx context.Context, id int) (domain.Seller, error)
+	GetSellerByUserID(ctx context.Context, userID int) (domain.Seller, error)
+	UpdateSeller(ctx context.Context, seller *domain.Seller) error
+	ListSellers(ctx context.Context, status string, limit, offset int) ([]domain.Seller, error)
+	SetSellerStatus(ctx context.Context, actorID, id int, status string) (domain.Seller, error)
+}
+
+const sellerColumns = `id, user_id, display_name, description, status, created_at, updated_at`
+
+func scanSeller(row pgx.Row) (domain.Seller, error) {
+	var s domain.Seller
+	err := row.Scan(&s.ID, &s.UserID, &s.DisplayName, &s.Description, &s.Status, &s.CreatedAt, &s.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Seller{}, domain.ErrSellerNotFound
+	}
+	return s, err
+}
+
+// sellerConflict переводит нарушение уникальности в ошибку домена: у пользователя
+// уже есть профиль или название витрины занято
+func sellerConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		if pgErr.ConstraintName == "sellers_display_name_idx" {
+			return domain.ErrSellerNameTaken
+		}
+		return domain.ErrSellerExists
+	}
+	return err
+}
+
+// CreateSeller сохраняет заявку продавца в статусе pending
+func (r *SellerRepository) CreateSeller(ctx context.Context, seller *domain.Seller) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO user_service.sellers (user_id, display_name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, status, created_at, updated_at
+	`, seller.UserID, seller.DisplayName, seller.Description).Scan(&seller.ID, &seller.Status, &seller.CreatedAt, &seller.UpdatedAt)
+	return sellerConflict(err)
+}
+
+func (r *SellerRepository) GetSeller(ctx context.Context, id int) (domain.Seller, error) {
+	return scanSeller(r.db.QueryRow(ctx, `SELECT `+sellerColumns+` FROM user_service.sellers WHERE id = $1`, id))
+}
+
+func (r *SellerRepository) GetSellerByUserID(ctx context.Context, userID int) (domain.Seller, error) {
+	return scanSeller(r.db.QueryRow(ctx, `SELECT `+sellerColumns+` FROM user_service.sellers WHERE user_id = $1`, userID))
+}
+
+// UpdateSeller сохраняет название и описание витрины; статус не меняется
+func (r *SellerRepository) UpdateSeller(ctx context.Context, seller *domain.Seller) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE user_service.sellers SET display_name = $2, description = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, seller.ID, seller.DisplayName, seller.Description).Scan(&seller.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrSellerNotFound
+	}
+	return sellerConflict(err)
+}
+
+// ListSellers возвращает продавцов в порядке подачи заявок; пустой status не ограничивает выборку
+func (r *SellerRepository) ListSellers(ctx context.Context, status string, limit, offset int) ([]domain.Seller, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+sellerColumns+`
+		FROM user_service.sellers
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sellers := []domain.Seller{}
+	for rows.Next() {
+		s, err := scanSeller(rows)
+		if err != nil {
+			return nil, err
+		}
+		sellers = append(sellers, s)
+	}
+	return sellers, rows.Err()
+}
+
+// SetSellerStatus меняет статус продавца и пишет прежний и новый статус в журнал.
+// При одобрении обычный пользователь получает роль seller, а вместе с ней и требование 2FA;
+// роль администратора не понижается. Назначение того же статуса ничего не меняет.
+func (r *SellerRepository) SetSellerStatus(ctx context.Context, actorID, id int, status string) (domain.Seller, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return domain.Seller{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	seller, err := scanSeller(tx.QueryRow(ctx, `SELECT `+sellerColumns+` FROM user_service.sellers WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return domain.Seller{}, err
+	}
+	if seller.Status == status {
+		return seller, nil
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE user_service.sellers SET status = $2, updated_at = NOW() WHERE id = $1 RETURNING updated_at
+	`, id, status).Scan(&seller.UpdatedAt)
+	if err != nil {
+		return domain.Seller{}, err
+	}
+	if status == domain.SellerActive {
+		_, err = tx.Exec(ctx, `
+			UPDATE user_service.users SET role = $2, updated_at = NOW() WHERE id = $1 AND role = $3
+		`, seller.UserID, domain.RoleSeller, domain.RoleUser)
+		if err != nil {
+			return domain.Seller{}, err
+		}
+	}
+
+	details := map[string]any{"seller_id": seller.ID, "from": seller.Status, "to": status}
+	if err := insertAudit(ctx, tx, actorID, domain.AuditSellerStatus, &seller.UserID, details); err != nil {
+		return domain.Seller{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Seller{}, err
+	}
+	seller.Status = status
+	return seller, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+type SessionRepositoryInterface interface {
+	CreateSession(ctx context.Context, session domain.Session) error
+	ListActiveSessions(ctx context.Context, userID int) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID int, id string) error
+	RevokeUserSessions(ctx context.Context, userID int) error
+	TouchSession(ctx context.Context, userID int, id string) (domain.SessionStatus, error)
+}
+
+// CreateSession записывает сессию. Время начала берётся из часов базы: TouchSession
+// сравнивает его с password_changed_at, который тоже выставляет база.
+func (r *SessionRepository) CreateSession(ctx context.Context, session domain.Session) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_service.sessions (id, user_id, user_agent, ip, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+	`, session.ID, session.UserID, session.UserAgent, session.IP, session.ExpiresAt)
+	return err
+}
+
+// ListActiveSessions возвращает неотозванные и неистёкшие сессии, последние активные — первыми
+func (r *SessionRepository) ListActiveSessions(ctx context.Context, userID int) ([]domain.Session, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at
+		FROM user_service.sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []domain.Session{}
+	for rows.Next() {
+		var s domain.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession отзывает сессию пользователя. Чужая, отозванная или неизвестная
+// сессия — domain.ErrSessionNotFound
+func (r *SessionRepository) RevokeSession(ctx context.Context, userID int, id string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE user_service.sessions SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID int) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE user_service.sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	return err
+}
+
+// TouchSession отмечает активность и сообщает, действует ли ещё сессия
+// и подтверждён ли сейчас email пользователя. Сессия, начатая до последней смены пароля,
+// не действует, даже если её не удалось отозвать.
+func (r *SessionRepository) TouchSession(ctx context.Context, userID int, id string) (domain.SessionStatus, error) {
+	status := domain.SessionStatus{Active: true}
+	err := r.db.QueryRow(ctx, `
+		UPDATE user_service.sessions s SET last_seen_at = NOW()
+		FROM user_service.users u
+		WHERE s.id = $1 AND s.user_id = $2 AND s.revoked_at IS NULL AND s.expires_at > NOW()
+			AND u.id = s.user_id
+			AND (u.password_changed_at IS NULL OR s.created_at >= u.password_changed_at)
+		RETURNING u.email_verified
+	`, id, userID).Scan(&status.EmailVerified)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.SessionStatus{}, nil
+	}
+	if err != nil {
+		return domain.SessionStatus{}, err
+	}
+	return status, nil
+}
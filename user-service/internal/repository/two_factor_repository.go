@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TwoFactorRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTwoFactorRepository(db *pgxpool.Pool) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+type TwoFactorRepositoryInterface interface {
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	DisableTwoFactor(ctx context.Context, userID int) error
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	RequiresTwoFactor(ctx context.Context, role string) (bool, error)
+	SetTwoFactorPolicy(ctx context.Context, role string, required bool) error
+}
+
+// SetTOTPSecret начинает подключение 2FA. Если она уже включена — domain.ErrTwoFactorEnabled
+func (r *TwoFactorRepository) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE user_service.users
+		SET totp_secret = $2, updated_at = NOW()
+		WHERE id = $1 AND NOT two_factor_enabled
+	`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+// EnableTwoFactor включает 2FA и заменяет резервные коды пользователя новыми
+func (r *TwoFactorRepository) EnableTwoFactor(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE user_service.users
+		SET two_factor_enabled = TRUE, totp_last_step = $2, updated_at = NOW()
+		WHERE id = $1 AND totp_secret IS NOT NULL AND NOT two_factor_enabled
+	`, userID, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_service.recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range recoveryCodeHashes {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO user_service.recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, NOW())`, userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// DisableTwoFactor отключает 2FA, удаляя секрет и резервные коды
+func (r *TwoFactorRepository) DisableTwoFactor(ctx context.Context, userID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE user_service.users
+		SET two_factor_enabled = FALSE, totp_secret = NULL, totp_last_step = 0, updated_at = NOW()
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_service.recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UseTOTPStep запоминает принятый шаг; false — код этого или более позднего шага уже использован
+func (r *TwoFactorRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE user_service.users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode гасит резервный код; false — кода нет или он уже использован
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE user_service.recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RequiresTwoFactor сообщает, обязана ли роль использовать 2FA
+func (r *TwoFactorRepository) RequiresTwoFactor(ctx context.Context, role string) (bool, error) {
+	var required bool
+	err := r.db.QueryRow(ctx,
+		`SELECT require_two_factor FROM user_service.role_policies WHERE role = $1`, role).Scan(&required)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return required, err
+}
+
+func (r *TwoFactorRepository) SetTwoFactorPolicy(ctx context.Context, role string, required bool) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_service.role_policies (role, require_two_factor, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (role) DO UPDATE SET require_two_factor = EXCLUDED.require_two_factor, updated_at = NOW()
+	`, role, required)
+	return err
+}
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,8 +22,17 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 type UserRepositoryInterface interface {
 	CreateUser(ctx context.Context, user domain.User) error
 	GetUserByEmail(ctx context.Context, email string) (domain.User, error)
+	GetUserByID(ctx context.Context, id int) (domain.User, error)
+	UpdateUser(ctx context.Context, user domain.User) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	CreateResetToken(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error)
+	SetEmailVerified(ctx context.Context, id int, email string) error
 }
 
+const userColumns = `id, name, email, password_hash, email_verified, phone, locale, role,
+	two_factor_enabled, COALESCE(totp_secret, ''), created_at, updated_at, deleted_at, banned_at, ban_reason`
+
 func (r *UserRepository) CreateUser(ctx context.Context, user domain.User) error {
 	query := `
 		INSERT INTO user_service.users (name, email, password_hash, created_at, updated_at)
@@ -31,25 +43,155 @@ func (r *UserRepository) CreateUser(ctx context.Context, user domain.User) error
 }
 
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM user_service.users WHERE email = $1`
+	return scanUser(r.db.QueryRow(ctx, query, email))
+}
+
+// GetUserByID возвращает пользователя или pgx.ErrNoRows, если его нет
+func (r *UserRepository) GetUserByID(ctx context.Context, id int) (domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM user_service.users WHERE id = $1`
+	return scanUser(r.db.QueryRow(ctx, query, id))
+}
+
+// UpdateUser сохраняет изменяемые поля профиля. Занятый email — domain.ErrEmailTaken
+func (r *UserRepository) UpdateUser(ctx context.Context, user domain.User) error {
+	query := `
+		UPDATE user_service.users
+		SET name = $2, email = $3, email_verified = $4, phone = $5, locale = $6, updated_at = NOW()
+		WHERE id = $1
+	`
+	tag, err := r.db.Exec(ctx, query, user.ID, user.Name, user.Email, user.EmailVerified, user.Phone, user.Locale)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return domain.ErrEmailTaken
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UpdatePassword меняет хеш пароля и запоминает момент смены
+func (r *UserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	return updatePassword(ctx, r.db, id, passwordHash)
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+func updatePassword(ctx context.Context, db execer, id int, passwordHash string) error {
+	query := `
+		UPDATE user_service.users
+		SET password_hash = $2, password_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	tag, err := db.Exec(ctx, query, id, passwordHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// CreateResetToken сохраняет хеш нового токена сброса. Неиспользованные токены
+// пользователя удаляются: действителен только последний запрошенный.
+func (r *UserRepository) CreateResetToken(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`DELETE FROM user_service.password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		SELECT id, name, email, password_hash, created_at, updated_at
-		FROM user_service.users
-		WHERE email = $1
+		INSERT INTO user_service.password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second', NOW())
 	`
+	if _, err := tx.Exec(ctx, query, userID, tokenHash, int64(ttl.Seconds())); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ResetPassword погашает токен и меняет пароль в одной транзакции.
+// Неизвестный, использованный или просроченный токен — domain.ErrInvalidResetToken
+func (r *UserRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE user_service.password_reset_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	var userID int
+	if err := tx.QueryRow(ctx, query, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, domain.ErrInvalidResetToken
+		}
+		return 0, err
+	}
 
+	if err := updatePassword(ctx, tx, userID, passwordHash); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit(ctx)
+}
+
+// SetEmailVerified подтверждает email, если он не менялся с момента отправки ссылки.
+// Иначе — domain.ErrInvalidVerificationToken
+func (r *UserRepository) SetEmailVerified(ctx context.Context, id int, email string) error {
+	query := `
+		UPDATE user_service.users
+		SET email_verified = TRUE, updated_at = NOW()
+		WHERE id = $1 AND email = $2
+	`
+	tag, err := r.db.Exec(ctx, query, id, email)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+func scanUser(row pgx.Row) (domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow(ctx, query, email).Scan(
+	err := row.Scan(
 		&user.ID,
 		&user.Name,
 		&user.Email,
 		&user.PasswordHash,
+		&user.EmailVerified,
+		&user.Phone,
+		&user.Locale,
+		&user.Role,
+		&user.TwoFactorEnabled,
+		&user.TOTPSecret,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.DeletedAt,
+		&user.BannedAt,
+		&user.BanReason,
 	)
-
 	if err != nil {
 		return domain.User{}, err
 	}
-
 	return user, nil
 }
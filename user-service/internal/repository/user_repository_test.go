@@ -1,99 +1,52 @@
-//go:build !ci
-
 package repository_test
 
 import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"os"
+	"io/fs"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/migrations"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/wait"
-)
-
-var (
-	pgContainer testcontainers.Container
-	dbpool      *pgxpool.Pool
 )
 
-func clearUsersTable(t *testing.T) {
-	_, err := dbpool.Exec(context.Background(), "DELETE FROM users")
-	if err != nil {
-		t.Fatalf("failed to clear users table: %v", err)
-	}
-}
-
-func TestMain(m *testing.M) {
-	ctx := context.Background()
+// Тесты общие для локального запуска (testcontainers, main_test.go) и CI (main_ci_test.go):
+// отличается только TestMain, который поднимает базу
 
-	req := testcontainers.ContainerRequest{
-		Image:        "postgres:15",
-		ExposedPorts: []string{"5432/tcp"},
-		Env: map[string]string{
-			"POSTGRES_DB":       "users",
-			"POSTGRES_USER":     "postgres",
-			"POSTGRES_PASSWORD": "postgres",
-		},
-		Tmpfs:      map[string]string{"/var/lib/postgresql/data": "rw"},
-		WaitingFor: wait.ForListeningPort("5432/tcp").WithStartupTimeout(30 * time.Second),
-	}
-	var err error
-	pgContainer, err = testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
-		ContainerRequest: req,
-		Started:          true,
-	})
-	if err != nil {
-		log.Fatalf("failed to start container: %v", err)
-	}
+var dbpool *pgxpool.Pool
 
-	host, err := pgContainer.Host(ctx)
+// applyMigrations создаёт схему user_service теми же up-миграциями, что применяет
+// migration-service, чтобы тесты не расходились с настоящей схемой
+func applyMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+	names, err := fs.Glob(migrations.FS, "*.up.sql")
 	if err != nil {
-		log.Fatalf("failed to get host: %v", err)
+		return err
 	}
-	mappedPort, err := pgContainer.MappedPort(ctx, "5432/tcp")
-	if err != nil {
-		log.Fatalf("failed to get port: %v", err)
-	}
-
-	dsn := fmt.Sprintf("postgres://postgres:postgres@%s:%s/users?sslmode=disable", host, mappedPort.Port())
-	dbpool, err = pgxpool.New(ctx, dsn)
-	if err != nil {
-		log.Fatalf("failed to connect to db: %v", err)
+	sort.Strings(names)
+	for _, name := range names {
+		sql, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			return err
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
 	}
+	return nil
+}
 
-	// Создаём таблицу
-	schema := `
-	CREATE TABLE users (
-		id SERIAL PRIMARY KEY,
-		name TEXT NOT NULL,
-		email TEXT UNIQUE NOT NULL,
-		password_hash TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
-	_, err = dbpool.Exec(ctx, schema)
+func clearUsersTable(t *testing.T) {
+	_, err := dbpool.Exec(context.Background(), "DELETE FROM user_service.users")
 	if err != nil {
-		log.Fatalf("failed to create schema: %v", err)
-	}
-
-	// Выполняем тесты
-	code := m.Run()
-
-	// Чистим ресурсы
-	dbpool.Close()
-	if err := pgContainer.Terminate(ctx); err != nil {
-		log.Printf("failed to terminate container: %v", err)
+		t.Fatalf("failed to clear users table: %v", err)
 	}
-
-	os.Exit(code)
 }
 
 func TestCreateAndGetUser(t *testing.T) {
@@ -168,3 +121,589 @@ func TestGetUserByEmail_NotFound(t *testing.T) {
 		t.Fatalf("unexpected error message: %v", err)
 	}
 }
+
+func TestGetByIDAndUpdateUser(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	repo := repository.NewUserRepository(dbpool)
+
+	for _, email := range []string{"one@example.com", "two@example.com"} {
+		if err := repo.CreateUser(ctx, domain.User{Name: "User", Email: email, PasswordHash: "hash"}); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	user, err := repo.GetUserByEmail(ctx, "one@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	if user.Locale != "ru" || user.Role != domain.RoleUser {
+		t.Fatalf("expected default locale and role, got %q %q", user.Locale, user.Role)
+	}
+
+	user.Name = "Renamed"
+	user.Phone = "+79001234567"
+	user.Locale = "en"
+	if err := repo.UpdateUser(ctx, user); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+
+	got, err := repo.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID failed: %v", err)
+	}
+	if got.Name != "Renamed" || got.Phone != "+79001234567" || got.Locale != "en" {
+		t.Errorf("profile not updated: %+v", got)
+	}
+
+	user.Email = "two@example.com"
+	if err := repo.UpdateUser(ctx, user); !errors.Is(err, domain.ErrEmailTaken) {
+		t.Fatalf("expected ErrEmailTaken, got %v", err)
+	}
+
+	if _, err := repo.GetUserByID(ctx, user.ID+100); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected pgx.ErrNoRows, got %v", err)
+	}
+}
+
+func TestResetPassword_SingleUseAndExpiring(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	repo := repository.NewUserRepository(dbpool)
+
+	if err := repo.CreateUser(ctx, domain.User{Name: "User", Email: "reset@example.com", PasswordHash: "old"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := repo.GetUserByEmail(ctx, "reset@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	if err := repo.CreateResetToken(ctx, user.ID, "expired", -time.Minute); err != nil {
+		t.Fatalf("CreateResetToken failed: %v", err)
+	}
+	if _, err := repo.ResetPassword(ctx, "expired", "new"); !errors.Is(err, domain.ErrInvalidResetToken) {
+		t.Fatalf("expected ErrInvalidResetToken for expired token, got %v", err)
+	}
+
+	if err := repo.CreateResetToken(ctx, user.ID, "valid", time.Hour); err != nil {
+		t.Fatalf("CreateResetToken failed: %v", err)
+	}
+	userID, err := repo.ResetPassword(ctx, "valid", "new")
+	if err != nil || userID != user.ID {
+		t.Fatalf("expected reset for user %d, got %d %v", user.ID, userID, err)
+	}
+	if _, err := repo.ResetPassword(ctx, "valid", "newer"); !errors.Is(err, domain.ErrInvalidResetToken) {
+		t.Fatalf("expected ErrInvalidResetToken on reuse, got %v", err)
+	}
+
+	got, err := repo.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID failed: %v", err)
+	}
+	if got.PasswordHash != "new" {
+		t.Errorf("expected password hash to be updated, got %q", got.PasswordHash)
+	}
+}
+
+func TestLoginAttempts_CountWithinWindow(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewLoginAttemptRepository(dbpool)
+	key := "account:throttle@example.com"
+	if err := repo.ResetLoginAttempts(ctx, key); err != nil {
+		t.Fatalf("ResetLoginAttempts failed: %v", err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := repo.RecordLoginFailure(ctx, key, now, time.Minute); err != nil {
+			t.Fatalf("RecordLoginFailure failed: %v", err)
+		}
+	}
+	got, err := repo.GetLoginAttempts(ctx, key)
+	if err != nil || got.Failures != 2 {
+		t.Fatalf("expected 2 failures, got %+v %v", got, err)
+	}
+
+	until := now.Add(time.Hour)
+	if err := repo.LockLogin(ctx, key, until); err != nil {
+		t.Fatalf("LockLogin failed: %v", err)
+	}
+	if got, _ = repo.GetLoginAttempts(ctx, key); !got.LockedUntil.After(now) {
+		t.Fatalf("expected lock until %v, got %v", until, got.LockedUntil)
+	}
+
+	// Неудача после окна начинает счёт заново
+	got, err = repo.RecordLoginFailure(ctx, key, now.Add(2*time.Minute), time.Minute)
+	if err != nil || got.Failures != 1 {
+		t.Fatalf("expected counter restart, got %+v %v", got, err)
+	}
+}
+
+func TestTwoFactor_EnableAndSingleUseCodes(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(dbpool)
+	repo := repository.NewTwoFactorRepository(dbpool)
+
+	if err := users.CreateUser(ctx, domain.User{Name: "User", Email: "totp@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := users.GetUserByEmail(ctx, "totp@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	if err := repo.EnableTwoFactor(ctx, user.ID, 1, nil); !errors.Is(err, domain.ErrTwoFactorNotEnrolled) {
+		t.Fatalf("expected ErrTwoFactorNotEnrolled without secret, got %v", err)
+	}
+	if err := repo.SetTOTPSecret(ctx, user.ID, "SECRET"); err != nil {
+		t.Fatalf("SetTOTPSecret failed: %v", err)
+	}
+	if err := repo.EnableTwoFactor(ctx, user.ID, 100, []string{"h1", "h2"}); err != nil {
+		t.Fatalf("EnableTwoFactor failed: %v", err)
+	}
+	if err := repo.SetTOTPSecret(ctx, user.ID, "OTHER"); !errors.Is(err, domain.ErrTwoFactorEnabled) {
+		t.Fatalf("expected ErrTwoFactorEnabled, got %v", err)
+	}
+
+	user, _ = users.GetUserByID(ctx, user.ID)
+	if !user.TwoFactorEnabled || user.TOTPSecret != "SECRET" {
+		t.Fatalf("expected enabled 2FA with stored secret, got %+v", user)
+	}
+
+	if ok, err := repo.UseTOTPStep(ctx, user.ID, 100); err != nil || ok {
+		t.Fatalf("expected confirmation step to be spent, got %v %v", ok, err)
+	}
+	if ok, err := repo.UseTOTPStep(ctx, user.ID, 101); err != nil || !ok {
+		t.Fatalf("expected next step to be accepted, got %v %v", ok, err)
+	}
+	if ok, err := repo.UseRecoveryCode(ctx, user.ID, "h1"); err != nil || !ok {
+		t.Fatalf("expected recovery code to be accepted, got %v %v", ok, err)
+	}
+	if ok, err := repo.UseRecoveryCode(ctx, user.ID, "h1"); err != nil || ok {
+		t.Fatalf("expected recovery code to be single-use, got %v %v", ok, err)
+	}
+
+	if err := repo.SetTwoFactorPolicy(ctx, domain.RoleUser, true); err != nil {
+		t.Fatalf("SetTwoFactorPolicy failed: %v", err)
+	}
+	if required, err := repo.RequiresTwoFactor(ctx, domain.RoleUser); err != nil || !required {
+		t.Fatalf("expected policy to be stored, got %v %v", required, err)
+	}
+	if required, err := repo.RequiresTwoFactor(ctx, "unknown"); err != nil || required {
+		t.Fatalf("expected unknown role to be optional, got %v %v", required, err)
+	}
+}
+
+func TestOAuth_ClientsCodesAndConsent(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(dbpool)
+	repo := repository.NewOAuthRepository(dbpool)
+
+	if err := users.CreateUser(ctx, domain.User{Name: "User", Email: "oidc@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := users.GetUserByEmail(ctx, "oidc@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	client := domain.OAuthClient{
+		ID:           "partner",
+		Name:         "Partner",
+		RedirectURIs: []string{"https://partner.example/cb"},
+		CreatedBy:    user.ID,
+		CreatedAt:    time.Now(),
+	}
+	if err := repo.CreateClient(ctx, client); err != nil {
+		t.Fatalf("CreateClient failed: %v", err)
+	}
+	got, err := repo.GetClient(ctx, client.ID)
+	if err != nil || got.Confidential() || len(got.RedirectURIs) != 1 {
+		t.Fatalf("unexpected client %+v %v", got, err)
+	}
+	if _, err := repo.GetClient(ctx, "missing"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected pgx.ErrNoRows, got %v", err)
+	}
+
+	code := domain.AuthorizationCode{
+		ClientID: client.ID, UserID: user.ID, RedirectURI: "https://partner.example/cb",
+		Scope: "openid email", Nonce: "n", CodeChallenge: "challenge", AuthTime: time.Now(),
+	}
+	if err := repo.CreateAuthorizationCode(ctx, "expired", code, -time.Minute); err != nil {
+		t.Fatalf("CreateAuthorizationCode failed: %v", err)
+	}
+	if _, err := repo.ConsumeAuthorizationCode(ctx, "expired"); !errors.Is(err, domain.ErrInvalidAuthorizationCode) {
+		t.Fatalf("expected expired code to be rejected, got %v", err)
+	}
+	if err := repo.CreateAuthorizationCode(ctx, "valid", code, time.Minute); err != nil {
+		t.Fatalf("CreateAuthorizationCode failed: %v", err)
+	}
+	consumed, err := repo.ConsumeAuthorizationCode(ctx, "valid")
+	if err != nil || consumed.UserID != user.ID || consumed.Scope != "openid email" {
+		t.Fatalf("unexpected code %+v %v", consumed, err)
+	}
+	if _, err := repo.ConsumeAuthorizationCode(ctx, "valid"); !errors.Is(err, domain.ErrInvalidAuthorizationCode) {
+		t.Fatalf("expected code to be single-use, got %v", err)
+	}
+
+	if err := repo.SaveConsent(ctx, user.ID, client.ID, []string{"openid", "email"}); err != nil {
+		t.Fatalf("SaveConsent failed: %v", err)
+	}
+	if err := repo.SaveConsent(ctx, user.ID, client.ID, []string{"openid", "profile"}); err != nil {
+		t.Fatalf("SaveConsent failed: %v", err)
+	}
+	scopes, err := repo.GetConsent(ctx, user.ID, client.ID)
+	if err != nil || len(scopes) != 3 {
+		t.Fatalf("expected merged consent of 3 scopes, got %v %v", scopes, err)
+	}
+}
+
+func TestSessions_PasswordChangeEndsOlderSessions(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(dbpool)
+	repo := repository.NewSessionRepository(dbpool)
+
+	if err := users.CreateUser(ctx, domain.User{Name: "User", Email: "password@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := users.GetUserByEmail(ctx, "password@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	expires := time.Now().Add(time.Hour)
+	if err := repo.CreateSession(ctx, domain.Session{ID: "before", UserID: user.ID, ExpiresAt: expires}); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := users.UpdatePassword(ctx, user.ID, "new-hash"); err != nil {
+		t.Fatalf("UpdatePassword failed: %v", err)
+	}
+	if err := repo.CreateSession(ctx, domain.Session{ID: "after", UserID: user.ID, ExpiresAt: expires}); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	// Сессия до смены пароля не действует, даже если её не отозвали
+	if status, err := repo.TouchSession(ctx, user.ID, "before"); err != nil || status.Active {
+		t.Fatalf("expected session from before the password change to be inactive, got %+v %v", status, err)
+	}
+	if status, err := repo.TouchSession(ctx, user.ID, "after"); err != nil || !status.Active {
+		t.Fatalf("expected new session to be active, got %+v %v", status, err)
+	}
+}
+
+func TestSessions_RevokeAndTouch(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(dbpool)
+	repo := repository.NewSessionRepository(dbpool)
+
+	if err := users.CreateUser(ctx, domain.User{Name: "User", Email: "sessions@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := users.GetUserByEmail(ctx, "sessions@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	now := time.Now()
+	for _, id := range []string{"laptop", "phone"} {
+		session := domain.Session{ID: id, UserID: user.ID, UserAgent: id, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+		if err := repo.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+	}
+	expired := domain.Session{ID: "old", UserID: user.ID, CreatedAt: now, ExpiresAt: now.Add(-time.Minute)}
+	if err := repo.CreateSession(ctx, expired); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	sessions, err := repo.ListActiveSessions(ctx, user.ID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("expected 2 active sessions, got %v %v", sessions, err)
+	}
+	if status, err := repo.TouchSession(ctx, user.ID, "old"); err != nil || status.Active {
+		t.Fatalf("expected expired session to be inactive, got %v %v", status.Active, err)
+	}
+
+	if err := repo.RevokeSession(ctx, user.ID+1, "phone"); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound for another user, got %v", err)
+	}
+	if err := repo.RevokeSession(ctx, user.ID, "phone"); err != nil {
+		t.Fatalf("RevokeSession failed: %v", err)
+	}
+	if status, _ := repo.TouchSession(ctx, user.ID, "phone"); status.Active {
+		t.Fatal("expected revoked session to be inactive")
+	}
+	if status, _ := repo.TouchSession(ctx, user.ID, "laptop"); !status.Active || status.EmailVerified {
+		t.Fatalf("expected active session of an unverified user, got %+v", status)
+	}
+	// Подтверждение email видно при следующей проверке сессии, без нового токена
+	if err := users.SetEmailVerified(ctx, user.ID, user.Email); err != nil {
+		t.Fatalf("SetEmailVerified failed: %v", err)
+	}
+	if status, _ := repo.TouchSession(ctx, user.ID, "laptop"); !status.EmailVerified {
+		t.Fatal("expected session check to return the verified email")
+	}
+
+	if err := repo.RevokeUserSessions(ctx, user.ID); err != nil {
+		t.Fatalf("RevokeUserSessions failed: %v", err)
+	}
+	sessions, err = repo.ListActiveSessions(ctx, user.ID)
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("expected no sessions after revoking all, got %v %v", sessions, err)
+	}
+}
+
+func TestAddresses_DefaultPerType(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(dbpool)
+	repo := repository.NewAddressRepository(dbpool)
+
+	if err := users.CreateUser(ctx, domain.User{Name: "User", Email: "addresses@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := users.GetUserByEmail(ctx, "addresses@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	newAddress := func(isDefault bool) *domain.Address {
+		return &domain.Address{
+			UserID: user.ID, Type: domain.AddressShipping, IsDefault: isDefault,
+			Recipient: "User", Country: "RU", City: "Москва", Line1: "ул. Тверская, 1", PostalCode: "125009",
+		}
+	}
+	first := newAddress(false)
+	if err := repo.CreateAddress(ctx, first); err != nil {
+		t.Fatalf("CreateAddress failed: %v", err)
+	}
+	if !first.IsDefault {
+		t.Fatal("expected the first address to become default")
+	}
+	second := newAddress(true)
+	if err := repo.CreateAddress(ctx, second); err != nil {
+		t.Fatalf("CreateAddress failed: %v", err)
+	}
+	def, err := repo.GetDefaultAddress(ctx, user.ID, domain.AddressShipping)
+	if err != nil || def.ID != second.ID {
+		t.Fatalf("expected second address to be default, got %+v %v", def, err)
+	}
+
+	// Перевод адреса по умолчанию в другой тип передаёт отметку оставшемуся адресу доставки
+	second.Type = domain.AddressBilling
+	if err := repo.UpdateAddress(ctx, second); err != nil {
+		t.Fatalf("UpdateAddress failed: %v", err)
+	}
+	if def, err := repo.GetDefaultAddress(ctx, user.ID, domain.AddressShipping); err != nil || def.ID != first.ID {
+		t.Fatalf("expected first address to be default shipping, got %+v %v", def, err)
+	}
+	if def, err := repo.GetDefaultAddress(ctx, user.ID, domain.AddressBilling); err != nil || def.ID != second.ID {
+		t.Fatalf("expected second address to be default billing, got %+v %v", def, err)
+	}
+
+	if err := repo.DeleteAddress(ctx, user.ID+1, first.ID); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("expected ErrAddressNotFound for another user, got %v", err)
+	}
+	if err := repo.DeleteAddress(ctx, user.ID, first.ID); err != nil {
+		t.Fatalf("DeleteAddress failed: %v", err)
+	}
+	if _, err := repo.GetDefaultAddress(ctx, user.ID, domain.AddressShipping); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("expected no shipping address left, got %v", err)
+	}
+	addresses, err := repo.ListAddresses(ctx, user.ID)
+	if err != nil || len(addresses) != 1 {
+		t.Fatalf("expected one address left, got %v %v", addresses, err)
+	}
+}
+
+func TestPrivacy_AnonymizeUser(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(dbpool)
+	addresses := repository.NewAddressRepository(dbpool)
+	sessions := repository.NewSessionRepository(dbpool)
+	repo := repository.NewPrivacyRepository(dbpool)
+
+	if err := users.CreateUser(ctx, domain.User{Name: "User", Email: "gdpr@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := users.GetUserByEmail(ctx, "gdpr@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	address := &domain.Address{
+		UserID: user.ID, Type: domain.AddressShipping,
+		Recipient: "User", Country: "RU", City: "Москва", Line1: "ул. Тверская, 1", PostalCode: "125009",
+	}
+	if err := addresses.CreateAddress(ctx, address); err != nil {
+		t.Fatalf("CreateAddress failed: %v", err)
+	}
+	if err := sessions.CreateSession(ctx, domain.Session{ID: "laptop", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	if _, err := dbpool.Exec(ctx, `DELETE FROM user_service.user_events_outbox`); err != nil {
+		t.Fatalf("failed to clear outbox: %v", err)
+	}
+
+	if err := repo.AnonymizeUser(ctx, user.ID, "account:gdpr@example.com"); err != nil {
+		t.Fatalf("AnonymizeUser failed: %v", err)
+	}
+	deleted, err := users.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID failed: %v", err)
+	}
+	if deleted.DeletedAt == nil || deleted.Email == "gdpr@example.com" || deleted.PasswordHash != "" {
+		t.Fatalf("expected anonymized user, got %+v", deleted)
+	}
+	if _, err := users.GetUserByEmail(ctx, "gdpr@example.com"); err == nil {
+		t.Fatal("expected the original email to be free")
+	}
+	if list, _ := addresses.ListAddresses(ctx, user.ID); len(list) != 0 {
+		t.Fatalf("expected addresses to be removed, got %v", list)
+	}
+	if status, _ := sessions.TouchSession(ctx, user.ID, "laptop"); status.Active {
+		t.Fatal("expected sessions to be removed")
+	}
+	if err := repo.AnonymizeUser(ctx, user.ID, "account:gdpr@example.com"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound for already deleted user, got %v", err)
+	}
+
+	// UserDeleted остаётся в outbox, пока публикация не пройдёт
+	failing := func(ctx context.Context, event domain.DeletedUserEvent) error { return errors.New("kafka unavailable") }
+	if sent, err := repo.PublishDeletedEvents(ctx, 10, failing); err == nil || sent != 0 {
+		t.Fatalf("expected publish error, got sent %d, err %v", sent, err)
+	}
+	var published []domain.DeletedUserEvent
+	collect := func(ctx context.Context, event domain.DeletedUserEvent) error {
+		published = append(published, event)
+		return nil
+	}
+	if sent, err := repo.PublishDeletedEvents(ctx, 10, collect); err != nil || sent != 1 {
+		t.Fatalf("PublishDeletedEvents: sent %d, err %v", sent, err)
+	}
+	if len(published) != 1 || published[0].UserID != user.ID {
+		t.Fatalf("expected UserDeleted for user %d, got %+v", user.ID, published)
+	}
+	if sent, err := repo.PublishDeletedEvents(ctx, 10, collect); err != nil || sent != 0 {
+		t.Fatalf("expected nothing left in outbox, got sent %d, err %v", sent, err)
+	}
+}
+
+func TestAdmin_BanRoleAndAudit(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(dbpool)
+	sessions := repository.NewSessionRepository(dbpool)
+	repo := repository.NewAdminRepository(dbpool)
+
+	for _, email := range []string{"Moderated@example.com", "other@example.com"} {
+		if err := users.CreateUser(ctx, domain.User{Name: "User", Email: email, PasswordHash: "hash"}); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	user, err := users.GetUserByEmail(ctx, "Moderated@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	if err := sessions.CreateSession(ctx, domain.Session{ID: "laptop", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	page := domain.UserFilter{EmailPrefix: "moder", Limit: 10}
+	found, total, err := repo.ListUsers(ctx, page)
+	if err != nil || total != 1 || len(found) != 1 || found[0].ID != user.ID {
+		t.Fatalf("expected to find user by email prefix, got %v %d %v", found, total, err)
+	}
+	if _, total, _ := repo.ListUsers(ctx, domain.UserFilter{EmailPrefix: "%", Limit: 10}); total != 0 {
+		t.Fatalf("expected LIKE wildcards to be matched literally, got %d users", total)
+	}
+
+	if err := repo.BanUser(ctx, 1, user.ID, "spam"); err != nil {
+		t.Fatalf("BanUser failed: %v", err)
+	}
+	if err := repo.BanUser(ctx, 1, user.ID, "spam"); !errors.Is(err, domain.ErrUserAlreadyBanned) {
+		t.Fatalf("expected ErrUserAlreadyBanned, got %v", err)
+	}
+	if status, _ := sessions.TouchSession(ctx, user.ID, "laptop"); status.Active {
+		t.Fatal("expected sessions to be revoked on ban")
+	}
+	banned, _, err := repo.ListUsers(ctx, domain.UserFilter{Status: domain.UserStatusBanned, Limit: 10})
+	if err != nil || len(banned) != 1 || banned[0].BanReason != "spam" || banned[0].Status() != domain.UserStatusBanned {
+		t.Fatalf("expected one banned user, got %+v %v", banned, err)
+	}
+
+	if err := repo.UnbanUser(ctx, 1, user.ID); err != nil {
+		t.Fatalf("UnbanUser failed: %v", err)
+	}
+	if err := repo.SetUserRole(ctx, 1, user.ID, domain.RoleSeller); err != nil {
+		t.Fatalf("SetUserRole failed: %v", err)
+	}
+	if err := repo.SetUserRole(ctx, 1, user.ID+100, domain.RoleSeller); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+
+	entries, err := repo.ListAuditLog(ctx, domain.AuditFilter{TargetUserID: user.ID, Limit: 10})
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("expected three audit entries, got %v %v", entries, err)
+	}
+	if entries[0].Action != domain.AuditUserRoleChange || !strings.Contains(string(entries[0].Details), `"to": "seller"`) {
+		t.Fatalf("unexpected latest audit entry %+v", entries[0])
+	}
+}
+
+func TestSeller_StatusAndRole(t *testing.T) {
+	clearUsersTable(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(dbpool)
+	repo := repository.NewSellerRepository(dbpool)
+
+	for _, email := range []string{"seller@example.com", "other@example.com"} {
+		if err := users.CreateUser(ctx, domain.User{Name: "User", Email: email, PasswordHash: "hash"}); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	user, _ := users.GetUserByEmail(ctx, "seller@example.com")
+	other, _ := users.GetUserByEmail(ctx, "other@example.com")
+
+	seller := domain.Seller{UserID: user.ID, DisplayName: "Лавка", Description: "Книги"}
+	if err := repo.CreateSeller(ctx, &seller); err != nil {
+		t.Fatalf("CreateSeller failed: %v", err)
+	}
+	if seller.ID == 0 || seller.Status != domain.SellerPending {
+		t.Fatalf("expected pending seller with id, got %+v", seller)
+	}
+	if err := repo.CreateSeller(ctx, &domain.Seller{UserID: user.ID, DisplayName: "Другая"}); !errors.Is(err, domain.ErrSellerExists) {
+		t.Fatalf("expected ErrSellerExists, got %v", err)
+	}
+	if err := repo.CreateSeller(ctx, &domain.Seller{UserID: other.ID, DisplayName: "лавка"}); !errors.Is(err, domain.ErrSellerNameTaken) {
+		t.Fatalf("expected ErrSellerNameTaken, got %v", err)
+	}
+
+	if _, err := repo.SetSellerStatus(ctx, 1, seller.ID, domain.SellerActive); err != nil {
+		t.Fatalf("SetSellerStatus failed: %v", err)
+	}
+	if _, err := repo.SetSellerStatus(ctx, 1, seller.ID+100, domain.SellerActive); !errors.Is(err, domain.ErrSellerNotFound) {
+		t.Fatalf("expected ErrSellerNotFound, got %v", err)
+	}
+	if got, _ := users.GetUserByID(ctx, user.ID); got.Role != domain.RoleSeller {
+		t.Fatalf("expected approved seller to get role seller, got %q", got.Role)
+	}
+	if got, _ := repo.GetSellerByUserID(ctx, user.ID); got.Status != domain.SellerActive {
+		t.Fatalf("expected active seller, got %+v", got)
+	}
+
+	pending, err := repo.ListSellers(ctx, domain.SellerPending, 10, 0)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("expected no pending sellers, got %v %v", pending, err)
+	}
+	entries, err := repository.NewAdminRepository(dbpool).ListAuditLog(ctx,
+		domain.AuditFilter{TargetUserID: user.ID, Action: domain.AuditSellerStatus, Limit: 10})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %v %v", entries, err)
+	}
+}
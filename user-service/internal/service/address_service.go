@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+)
+
+// countryRule — правила адреса страны: формат индекса и обязательность региона (штата, провинции)
+type countryRule struct {
+	postalCode     *regexp.Regexp
+	regionRequired bool
+}
+
+// addressRules — страны, в которые marketplace доставляет заказы
+var addressRules = map[string]countryRule{
+	"RU": {postalCode: regexp.MustCompile(`^[0-9]{6}$`)},
+	"BY": {postalCode: regexp.MustCompile(`^[0-9]{6}$`)},
+	"KZ": {postalCode: regexp.MustCompile(`^([0-9]{6}|[A-Z][0-9]{2}[A-Z][0-9][A-Z][0-9])$`)},
+	"AM": {postalCode: regexp.MustCompile(`^[0-9]{4}$`)},
+	"DE": {postalCode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"FR": {postalCode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"GB": {postalCode: regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]? [0-9][A-Z]{2}$`)},
+	"US": {postalCode: regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`), regionRequired: true},
+	"CA": {postalCode: regexp.MustCompile(`^[A-Z][0-9][A-Z] [0-9][A-Z][0-9]$`), regionRequired: true},
+}
+
+// maxAddressFieldLength ограничивает длину любого текстового поля адреса
+const maxAddressFieldLength = 200
+
+type AddressService struct {
+	repo repository.AddressRepositoryInterface
+}
+
+func NewAddressService(repo repository.AddressRepositoryInterface) *AddressService {
+	return &AddressService{repo: repo}
+}
+
+func (s *AddressService) ListAddresses(ctx context.Context, userID int) ([]domain.Address, error) {
+	return s.repo.ListAddresses(ctx, userID)
+}
+
+func (s *AddressService) GetAddress(ctx context.Context, userID, id int) (domain.Address, error) {
+	return s.repo.GetAddress(ctx, userID, id)
+}
+
+// ResolveAddress возвращает адрес для оформления заказа: выбранный покупателем
+// или, если id = 0, адрес по умолчанию нужного типа
+func (s *AddressService) ResolveAddress(ctx context.Context, userID, id int, addressType string) (domain.Address, error) {
+	if id != 0 {
+		address, err := s.repo.GetAddress(ctx, userID, id)
+		if err != nil {
+			return domain.Address{}, err
+		}
+		if address.Type != addressType {
+			return domain.Address{}, fmt.Errorf("%w: address %d is not a %s address", domain.ErrInvalidAddress, id, addressType)
+		}
+		return address, nil
+	}
+	return s.repo.GetDefaultAddress(ctx, userID, addressType)
+}
+
+func (s *AddressService) CreateAddress(ctx context.Context, userID int, address domain.Address) (domain.Address, error) {
+	if err := normalizeAddress(&address); err != nil {
+		return domain.Address{}, err
+	}
+	existing, err := s.repo.ListAddresses(ctx, userID)
+	if err != nil {
+		return domain.Address{}, err
+	}
+	if len(existing) >= domain.MaxAddressesPerUser {
+		return domain.Address{}, fmt.Errorf("%w: no more than %d addresses allowed", domain.ErrInvalidAddress, domain.MaxAddressesPerUser)
+	}
+
+	address.ID, address.UserID = 0, userID
+	if err := s.repo.CreateAddress(ctx, &address); err != nil {
+		return domain.Address{}, err
+	}
+	return address, nil
+}
+
+// UpdateAddress заменяет адрес целиком. Заказы хранят свою копию адреса,
+// поэтому изменение не затрагивает уже оформленные заказы.
+func (s *AddressService) UpdateAddress(ctx context.Context, userID, id int, address domain.Address) (domain.Address, error) {
+	if err := normalizeAddress(&address); err != nil {
+		return domain.Address{}, err
+	}
+	address.ID, address.UserID = id, userID
+	if err := s.repo.UpdateAddress(ctx, &address); err != nil {
+		return domain.Address{}, err
+	}
+	return address, nil
+}
+
+func (s *AddressService) DeleteAddress(ctx context.Context, userID, id int) error {
+	return s.repo.DeleteAddress(ctx, userID, id)
+}
+
+// normalizeAddress обрезает пробелы, приводит страну и индекс к верхнему регистру
+// и проверяет поля по правилам страны
+func normalizeAddress(a *domain.Address) error {
+	for _, field := range []*string{&a.Type, &a.Recipient, &a.Phone, &a.Country, &a.Region, &a.City, &a.Line1, &a.Line2, &a.PostalCode} {
+		*field = strings.TrimSpace(*field)
+		if len(*field) > maxAddressFieldLength {
+			return fmt.Errorf("%w: fields must be at most %d characters", domain.ErrInvalidAddress, maxAddressFieldLength)
+		}
+	}
+	a.Country = strings.ToUpper(a.Country)
+	a.PostalCode = strings.ToUpper(a.PostalCode)
+
+	if a.Type == "" {
+		a.Type = domain.AddressShipping
+	}
+	if a.Type != domain.AddressShipping && a.Type != domain.AddressBilling {
+		return fmt.Errorf("%w: type must be shipping or billing", domain.ErrInvalidAddress)
+	}
+	if a.Recipient == "" || a.City == "" || a.Line1 == "" {
+		return fmt.Errorf("%w: recipient, city and line1 are required", domain.ErrInvalidAddress)
+	}
+	if a.Phone != "" {
+		a.Phone = phoneSeparators.Replace(a.Phone)
+		if !phonePattern.MatchString(a.Phone) {
+			return fmt.Errorf("%w: invalid phone", domain.ErrInvalidAddress)
+		}
+	}
+
+	rule, ok := addressRules[a.Country]
+	if !ok {
+		return fmt.Errorf("%w: delivery to country %q is not supported", domain.ErrInvalidAddress, a.Country)
+	}
+	if !rule.postalCode.MatchString(a.PostalCode) {
+		return fmt.Errorf("%w: invalid postal code for %s", domain.ErrInvalidAddress, a.Country)
+	}
+	if rule.regionRequired && a.Region == "" {
+		return fmt.Errorf("%w: region is required for %s", domain.ErrInvalidAddress, a.Country)
+	}
+	return nil
+}
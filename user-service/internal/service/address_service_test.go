@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+)
+
+func moscowAddress() domain.Address {
+	return domain.Address{
+		Recipient: "Алексей", Country: "ru", City: "Москва", Line1: "ул. Тверская, 1", PostalCode: " 125009 ",
+	}
+}
+
+func TestCreateAddress_NormalizesAndDefaults(t *testing.T) {
+	addresses := service.NewAddressService(repositorytest.NewAddressRepo())
+	ctx := context.Background()
+
+	first, err := addresses.CreateAddress(ctx, 1, moscowAddress())
+	if err != nil {
+		t.Fatalf("CreateAddress: %v", err)
+	}
+	if first.Type != domain.AddressShipping || first.Country != "RU" || first.PostalCode != "125009" || !first.IsDefault {
+		t.Fatalf("unexpected address %+v", first)
+	}
+
+	second := moscowAddress()
+	second.IsDefault = true
+	if _, err := addresses.CreateAddress(ctx, 1, second); err != nil {
+		t.Fatalf("CreateAddress: %v", err)
+	}
+	resolved, err := addresses.ResolveAddress(ctx, 1, 0, domain.AddressShipping)
+	if err != nil || resolved.ID == first.ID {
+		t.Fatalf("expected the second address to become default, got %+v %v", resolved, err)
+	}
+	if _, err := addresses.ResolveAddress(ctx, 1, 0, domain.AddressBilling); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("expected no billing address, got %v", err)
+	}
+	if _, err := addresses.ResolveAddress(ctx, 1, first.ID, domain.AddressBilling); !errors.Is(err, domain.ErrInvalidAddress) {
+		t.Fatalf("expected shipping address to be rejected as billing, got %v", err)
+	}
+	if _, err := addresses.ResolveAddress(ctx, 2, first.ID, domain.AddressShipping); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("expected another user's address to be hidden, got %v", err)
+	}
+}
+
+func TestCreateAddress_CountryRules(t *testing.T) {
+	addresses := service.NewAddressService(repositorytest.NewAddressRepo())
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		modify func(a *domain.Address)
+		valid  bool
+	}{
+		{"russian postal code", func(a *domain.Address) {}, true},
+		{"short russian postal code", func(a *domain.Address) { a.PostalCode = "12500" }, false},
+		{"us without state", func(a *domain.Address) { a.Country, a.PostalCode = "US", "10001" }, false},
+		{"us with state", func(a *domain.Address) { a.Country, a.Region, a.PostalCode = "US", "NY", "10001-1234" }, true},
+		{"uk postcode", func(a *domain.Address) { a.Country, a.PostalCode = "GB", "sw1a 1aa" }, true},
+		{"unsupported country", func(a *domain.Address) { a.Country = "ZZ" }, false},
+		{"unknown type", func(a *domain.Address) { a.Type = "pickup" }, false},
+		{"missing city", func(a *domain.Address) { a.City = " " }, false},
+		{"invalid phone", func(a *domain.Address) { a.Phone = "call me" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := moscowAddress()
+			tt.modify(&address)
+			_, err := addresses.CreateAddress(ctx, 1, address)
+			if tt.valid && err != nil {
+				t.Fatalf("expected address to be valid, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, domain.ErrInvalidAddress) {
+				t.Fatalf("expected ErrInvalidAddress, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateAddress_Limit(t *testing.T) {
+	addresses := service.NewAddressService(repositorytest.NewAddressRepo())
+	ctx := context.Background()
+
+	for i := 0; i < domain.MaxAddressesPerUser; i++ {
+		if _, err := addresses.CreateAddress(ctx, 1, moscowAddress()); err != nil {
+			t.Fatalf("CreateAddress: %v", err)
+		}
+	}
+	if _, err := addresses.CreateAddress(ctx, 1, moscowAddress()); !errors.Is(err, domain.ErrInvalidAddress) {
+		t.Fatalf("expected address book limit, got %v", err)
+	}
+}
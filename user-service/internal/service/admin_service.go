@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/pkg/kafka"
+)
+
+// AuditRecorder пишет действия администраторов в журнал
+type AuditRecorder interface {
+	RecordAudit(ctx context.Context, entry domain.AuditEntry) error
+}
+
+// UserBannedPublisher сообщает api-gateway о блокировке пользователя
+type UserBannedPublisher interface {
+	SendUserBanned(ctx context.Context, event kafka.UserBannedEvent) error
+}
+
+// AdminService — справочник пользователей для администраторов: поиск, блокировка
+// и смена ролей. Каждое изменение попадает в журнал действий.
+type AdminService struct {
+	repo   repository.AdminRepositoryInterface
+	admins AdminChecker
+	events UserBannedPublisher
+}
+
+func NewAdminService(repo repository.AdminRepositoryInterface, admins AdminChecker) *AdminService {
+	return &AdminService{repo: repo, admins: admins}
+}
+
+// WithEvents включает публикацию UserBanned. Без неё api-gateway узнаёт о блокировке
+// только после истечения кеша проверки сессий.
+func (s *AdminService) WithEvents(events UserBannedPublisher) *AdminService {
+	s.events = events
+	return s
+}
+
+// ListUsers возвращает страницу пользователей по фильтру
+func (s *AdminService) ListUsers(ctx context.Context, requesterID int, filter domain.UserFilter) (domain.UserPage, error) {
+	if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+		return domain.UserPage{}, err
+	}
+
+	filter.EmailPrefix = strings.TrimSpace(filter.EmailPrefix)
+	if filter.Role != "" && !slices.Contains(domain.Roles, filter.Role) {
+		return domain.UserPage{}, fmt.Errorf("%w: unknown role %q", domain.ErrInvalidFilter, filter.Role)
+	}
+	switch filter.Status {
+	case "", domain.UserStatusActive, domain.UserStatusBanned, domain.UserStatusDeleted:
+	default:
+		return domain.UserPage{}, fmt.Errorf("%w: unknown status %q", domain.ErrInvalidFilter, filter.Status)
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return domain.UserPage{}, fmt.Errorf("%w: created_from must be before created_to", domain.ErrInvalidFilter)
+	}
+	var err error
+	if filter.Limit, filter.Offset, err = normalizePage(filter.Limit, filter.Offset); err != nil {
+		return domain.UserPage{}, err
+	}
+
+	users, total, err := s.repo.ListUsers(ctx, filter)
+	if err != nil {
+		return domain.UserPage{}, err
+	}
+	return domain.UserPage{Users: users, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// Ban блокирует пользователя: все его сессии отзываются, вход запрещается до разблокировки.
+// Заблокировать самого себя нельзя.
+func (s *AdminService) Ban(ctx context.Context, requesterID, userID int, reason string) error {
+	if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+		return err
+	}
+	if requesterID == userID {
+		return domain.ErrForbidden
+	}
+
+	if err := s.repo.BanUser(ctx, requesterID, userID, strings.TrimSpace(reason)); err != nil {
+		return err
+	}
+	log.Printf("Администратор %d заблокировал пользователя %d", requesterID, userID)
+
+	if s.events != nil {
+		event := kafka.UserBannedEvent{UserID: int64(userID), BannedAt: time.Now()}
+		if err := s.events.SendUserBanned(ctx, event); err != nil {
+			log.Printf("Не удалось опубликовать UserBanned для пользователя %d: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// Unban снимает блокировку; войти пользователь сможет заново
+func (s *AdminService) Unban(ctx context.Context, requesterID, userID int) error {
+	if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+		return err
+	}
+	if err := s.repo.UnbanUser(ctx, requesterID, userID); err != nil {
+		return err
+	}
+	log.Printf("Администратор %d разблокировал пользователя %d", requesterID, userID)
+	return nil
+}
+
+// SetRole назначает пользователю роль. Права проверяются по базе при каждом запросе,
+// поэтому новая роль действует сразу. Менять роль самому себе нельзя, чтобы
+// последний администратор не лишился доступа.
+func (s *AdminService) SetRole(ctx context.Context, requesterID, userID int, role string) error {
+	if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+		return err
+	}
+	if !slices.Contains(domain.Roles, role) {
+		return fmt.Errorf("%w: %q", domain.ErrInvalidRole, role)
+	}
+	if requesterID == userID {
+		return domain.ErrForbidden
+	}
+	return s.repo.SetUserRole(ctx, requesterID, userID, role)
+}
+
+// AuditLog возвращает записи журнала действий администраторов
+func (s *AdminService) AuditLog(ctx context.Context, requesterID int, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+		return nil, err
+	}
+	var err error
+	if filter.Limit, filter.Offset, err = normalizePage(filter.Limit, filter.Offset); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAuditLog(ctx, filter)
+}
+
+// normalizePage подставляет размер страницы по умолчанию и ограничивает его сверху
+func normalizePage(limit, offset int) (int, int, error) {
+	if limit < 0 || offset < 0 {
+		return 0, 0, fmt.Errorf("%w: limit and offset must be non-negative", domain.ErrInvalidFilter)
+	}
+	if limit == 0 {
+		limit = domain.DefaultPageLimit
+	}
+	return min(limit, domain.MaxPageLimit), offset, nil
+}
+
+// recordAudit пишет действие в журнал. Само действие уже выполнено, поэтому
+// ошибка записи только логируется.
+func recordAudit(ctx context.Context, audit AuditRecorder, actorID int, action string, targetUserID *int, details any) {
+	if audit == nil {
+		return
+	}
+	data, err := json.Marshal(details)
+	if err == nil {
+		err = audit.RecordAudit(ctx, domain.AuditEntry{
+			ActorID: actorID, Action: action, TargetUserID: targetUserID, Details: data,
+		})
+	}
+	if err != nil {
+		log.Printf("Не удалось записать в журнал действие %s администратора %d: %v", action, actorID, err)
+	}
+}
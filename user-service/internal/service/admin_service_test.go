@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/pkg/kafka"
+)
+
+// newAdminRepo — в базе есть обычный пользователь 2
+func newAdminRepo() *repositorytest.AdminRepo {
+	repo := repositorytest.NewAdminRepo()
+	repo.Users = []domain.User{{ID: 2}}
+	repo.Roles[2] = domain.RoleUser
+	return repo
+}
+
+type mockBannedEvents struct {
+	events []kafka.UserBannedEvent
+}
+
+func (m *mockBannedEvents) SendUserBanned(ctx context.Context, event kafka.UserBannedEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestAdminService_Ban(t *testing.T) {
+	repo := newAdminRepo()
+	events := &mockBannedEvents{}
+	admin := service.NewAdminService(repo, adminOnly{}).WithEvents(events)
+	ctx := context.Background()
+
+	if err := admin.Ban(ctx, 2, 3, "spam"); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for non-admin, got %v", err)
+	}
+	if err := admin.Ban(ctx, 1, 1, "oops"); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for self-ban, got %v", err)
+	}
+	if err := admin.Ban(ctx, 1, 2, "spam"); err != nil {
+		t.Fatalf("Ban: %v", err)
+	}
+	if repo.Banned[2] != "spam" || len(events.events) != 1 || events.events[0].UserID != 2 {
+		t.Fatalf("expected user 2 banned and UserBanned published, got %v %+v", repo.Banned, events.events)
+	}
+	if err := admin.Ban(ctx, 1, 2, "spam"); !errors.Is(err, domain.ErrUserAlreadyBanned) {
+		t.Fatalf("expected ErrUserAlreadyBanned, got %v", err)
+	}
+
+	if err := admin.Unban(ctx, 1, 2); err != nil {
+		t.Fatalf("Unban: %v", err)
+	}
+	if err := admin.Unban(ctx, 1, 2); !errors.Is(err, domain.ErrUserNotBanned) {
+		t.Fatalf("expected ErrUserNotBanned, got %v", err)
+	}
+}
+
+func TestAdminService_ListUsersFilter(t *testing.T) {
+	repo := newAdminRepo()
+	admin := service.NewAdminService(repo, adminOnly{})
+	ctx := context.Background()
+
+	page, err := admin.ListUsers(ctx, 1, domain.UserFilter{EmailPrefix: " alex ", Limit: 1000})
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if repo.LastFilter.EmailPrefix != "alex" || page.Limit != domain.MaxPageLimit || page.Total != 1 {
+		t.Fatalf("unexpected filter %+v page %+v", repo.LastFilter, page)
+	}
+	if page, _ := admin.ListUsers(ctx, 1, domain.UserFilter{}); page.Limit != domain.DefaultPageLimit {
+		t.Fatalf("expected default limit, got %d", page.Limit)
+	}
+
+	from := time.Now()
+	to := from.Add(-time.Hour)
+	for _, filter := range []domain.UserFilter{
+		{Status: "sleeping"},
+		{Role: "root"},
+		{Offset: -1},
+		{CreatedFrom: &from, CreatedTo: &to},
+	} {
+		if _, err := admin.ListUsers(ctx, 1, filter); !errors.Is(err, domain.ErrInvalidFilter) {
+			t.Fatalf("expected ErrInvalidFilter for %+v, got %v", filter, err)
+		}
+	}
+}
+
+func TestAdminService_SetRole(t *testing.T) {
+	repo := newAdminRepo()
+	admin := service.NewAdminService(repo, adminOnly{})
+	ctx := context.Background()
+
+	if err := admin.SetRole(ctx, 1, 2, "root"); !errors.Is(err, domain.ErrInvalidRole) {
+		t.Fatalf("expected ErrInvalidRole, got %v", err)
+	}
+	if err := admin.SetRole(ctx, 1, 1, domain.RoleUser); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for own role, got %v", err)
+	}
+	if err := admin.SetRole(ctx, 1, 2, domain.RoleSeller); err != nil || repo.Roles[2] != domain.RoleSeller {
+		t.Fatalf("expected seller role, got %v %v", repo.Roles[2], err)
+	}
+	if err := admin.SetRole(ctx, 1, 9, domain.RoleSeller); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestLogin_BannedUser(t *testing.T) {
+	repo := newPasswordRepo(t)
+	user := repo.users["alex@email.com"]
+	bannedAt := time.Now()
+	user.BannedAt = &bannedAt
+	repo.users["alex@email.com"] = user
+
+	_, err := service.NewAuthService(repo).Login(context.Background(), "alex@email.com", "secret")
+	if !errors.Is(err, domain.ErrUserBanned) {
+		t.Fatalf("expected ErrUserBanned, got %v", err)
+	}
+}
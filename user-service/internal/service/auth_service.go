@@ -2,27 +2,141 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/notify"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/pkg/kafka"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// CartMerger переносит гостевую корзину в корзину пользователя при входе
+type CartMerger interface {
+	MergeGuestCart(ctx context.Context, userID int64, cartToken string) error
+}
+
+// SessionRevoker отзывает все выданные пользователю сессии (токены)
+type SessionRevoker interface {
+	RevokeSessions(ctx context.Context, userID int64) error
+}
+
+// SessionStarter записывает сессию при каждом входе; её ID попадает в токен
+type SessionStarter interface {
+	StartSession(ctx context.Context, userID int, client domain.ClientInfo, ttl time.Duration) (string, error)
+}
+
+// TokenTTL — время жизни токена входа и его сессии
+const TokenTTL = 24 * time.Hour
+
+// DefaultResetTokenTTL — время жизни токена сброса пароля по умолчанию
+const DefaultResetTokenTTL = time.Hour
+
+// DefaultVerificationTTL — время жизни ссылки подтверждения email по умолчанию
+const DefaultVerificationTTL = 48 * time.Hour
+
+// verifyEmailPurpose отличает токен подтверждения email от токена входа
+const verifyEmailPurpose = "verify_email"
+
+// emailVerification — настройки отправки ссылок подтверждения email
+type emailVerification struct {
+	notifier notify.Notifier
+	linkURL  string
+	ttl      time.Duration
+}
+
 type AuthService struct {
-	userRepo repository.UserRepositoryInterface
+	userRepo       repository.UserRepositoryInterface
+	cartMerger     CartMerger
+	notifier       notify.Notifier
+	resetTTL       time.Duration
+	sessionRevoker SessionRevoker
+	sessions       SessionStarter
+	verification   *emailVerification
+	throttle       *loginThrottle
+	twoFactor      repository.TwoFactorRepositoryInterface
+	totpIssuer     string
 }
 
+// dummyPasswordHash сравнивается с паролем, если email не найден, чтобы время
+// ответа не выдавало, зарегистрирован ли пользователь
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	return hash
+})
+
 func NewAuthService(userRepo repository.UserRepositoryInterface) *AuthService {
-	return &AuthService{userRepo: userRepo}
+	return &AuthService{userRepo: userRepo, resetTTL: DefaultResetTokenTTL}
+}
+
+// WithCartMerger включает слияние гостевой корзины при входе
+func (s *AuthService) WithCartMerger(m CartMerger) *AuthService {
+	s.cartMerger = m
+	return s
+}
+
+// WithPasswordReset включает сброс пароля: токены доставляет notifier и живут ttl
+func (s *AuthService) WithPasswordReset(n notify.Notifier, ttl time.Duration) *AuthService {
+	s.notifier = n
+	if ttl > 0 {
+		s.resetTTL = ttl
+	}
+	return s
+}
+
+// WithSessionRevoker включает отзыв сессий при смене и сбросе пароля
+func (s *AuthService) WithSessionRevoker(r SessionRevoker) *AuthService {
+	s.sessionRevoker = r
+	return s
+}
+
+// WithSessions включает учёт сессий: каждый токен привязывается к сессии (sid)
+func (s *AuthService) WithSessions(starter SessionStarter) *AuthService {
+	s.sessions = starter
+	return s
 }
 
+// WithEmailVerification включает отправку ссылок подтверждения email.
+// Ссылка — linkURL с подписанным токеном в параметре token.
+func (s *AuthService) WithEmailVerification(n notify.Notifier, linkURL string, ttl time.Duration) *AuthService {
+	if ttl <= 0 {
+		ttl = DefaultVerificationTTL
+	}
+	s.verification = &emailVerification{notifier: n, linkURL: linkURL, ttl: ttl}
+	return s
+}
+
+// WithLoginThrottle включает учёт неудачных входов: прогрессивные паузы и временную
+// блокировку. events может быть nil — тогда UserLockedOut не публикуется.
+func (s *AuthService) WithLoginThrottle(repo repository.LoginAttemptRepositoryInterface, events kafka.Producer, cfg ThrottleConfig) *AuthService {
+	s.throttle = &loginThrottle{repo: repo, events: events, cfg: cfg}
+	return s
+}
+
+// Register создаёт пользователя с неподтверждённым email и отправляет ссылку подтверждения
 func (s *AuthService) Register(ctx context.Context, name, email, password string) error {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return domain.ErrInvalidEmail
+	}
+	email = addr.Address
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err == nil {
 		return errors.New("user already exists")
@@ -43,36 +157,372 @@ func (s *AuthService) Register(ctx context.Context, name, email, password string
 		PasswordHash: string(hash),
 	}
 
-	return s.userRepo.CreateUser(ctx, user)
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	if s.verification != nil {
+		created, err := s.userRepo.GetUserByEmail(ctx, email)
+		if err != nil {
+			return err
+		}
+		// Письмо можно запросить повторно, поэтому ошибка отправки не отменяет регистрацию
+		if err := s.SendVerification(ctx, created); err != nil {
+			log.Printf("Не удалось отправить подтверждение email пользователю %d: %v", created.ID, err)
+		}
+	}
+	return nil
 }
 
+// Login выполняет вход без гостевой корзины. Для пользователей с 2FA нужен
+// двухшаговый вход через LoginWithCart и CompleteTwoFactorLogin.
 func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
-	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	result, err := s.LoginWithCart(ctx, email, password, "", domain.ClientInfo{})
 	if err != nil {
-		return "", errors.New("invalid email, or password")
+		return "", err
+	}
+	if result.TwoFactorRequired {
+		return "", domain.ErrTwoFactorRequired
 	}
+	return result.Token, nil
+}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+// LoginWithCart выполняет вход и, если передан токен гостевой корзины, сливает её
+// с корзиной пользователя. Ошибка слияния не мешает входу: гостевая корзина
+// остаётся в cart-service до истечения TTL. clientIP учитывается при ограничении
+// неудачных попыток; неизвестный email и неверный пароль неотличимы для клиента.
+// Если у пользователя включена 2FA, вместо токена возвращается challenge-токен.
+func (s *AuthService) LoginWithCart(ctx context.Context, email, password, cartToken string, client domain.ClientInfo) (domain.LoginResult, error) {
+	user, err := s.checkPassword(ctx, email, password, client.IP)
 	if err != nil {
-		return "", errors.New("invalid password")
+		return domain.LoginResult{}, err
+	}
+
+	if s.twoFactor != nil && user.TwoFactorEnabled {
+		challenge, err := issueChallenge(user, cartToken)
+		if err != nil {
+			return domain.LoginResult{}, err
+		}
+		return domain.LoginResult{TwoFactorRequired: true, ChallengeToken: challenge}, nil
 	}
+	return s.completeLogin(ctx, user, cartToken, client)
+}
 
+// Authenticate проверяет учётные данные без выдачи токена marketplace — для входа
+// во внешние приложения через OIDC. Пользователю с 2FA нужен code из приложения
+// или резервный код, без него — domain.ErrTwoFactorRequired.
+func (s *AuthService) Authenticate(ctx context.Context, email, password, code, clientIP string) (domain.User, error) {
+	user, err := s.checkPassword(ctx, email, password, clientIP)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if s.twoFactor == nil || !user.TwoFactorEnabled {
+		return user, nil
+	}
+
+	if code == "" {
+		return domain.User{}, domain.ErrTwoFactorRequired
+	}
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, domain.ErrInvalidTwoFactorCode) && s.throttle != nil {
+			s.throttle.fail(ctx, user.Email, clientIP, user.ID)
+		}
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// checkPassword сверяет пароль с bcrypt-хешем с учётом ограничения попыток входа
+func (s *AuthService) checkPassword(ctx context.Context, email, password, clientIP string) (domain.User, error) {
+	if s.throttle != nil {
+		if err := s.throttle.check(ctx, email, clientIP); err != nil {
+			return domain.User{}, err
+		}
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return domain.User{}, err
+	}
+	hash := dummyPasswordHash()
+	if err == nil {
+		hash = []byte(user.PasswordHash)
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || err != nil {
+		if s.throttle != nil {
+			s.throttle.fail(ctx, email, clientIP, user.ID)
+		}
+		return domain.User{}, domain.ErrInvalidCredentials
+	}
+	if s.throttle != nil {
+		s.throttle.success(ctx, email)
+	}
+	if user.BannedAt != nil {
+		return domain.User{}, domain.ErrUserBanned
+	}
+	return user, nil
+}
+
+// completeLogin открывает сессию, выдаёт токен и переносит гостевую корзину
+func (s *AuthService) completeLogin(ctx context.Context, user domain.User, cartToken string, client domain.ClientInfo) (domain.LoginResult, error) {
+	signedToken, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+
+	if cartToken != "" && s.cartMerger != nil {
+		if err := s.cartMerger.MergeGuestCart(ctx, int64(user.ID), cartToken); err != nil {
+			log.Printf("Не удалось перенести гостевую корзину пользователя %d: %v", user.ID, err)
+		}
+	}
+
+	result := domain.LoginResult{Token: signedToken}
+	if s.twoFactor != nil && !user.TwoFactorEnabled {
+		required, err := s.twoFactor.RequiresTwoFactor(ctx, user.Role)
+		if err != nil {
+			log.Printf("Не удалось проверить политику 2FA для роли %s: %v", user.Role, err)
+		}
+		result.TwoFactorSetupRequired = required
+	}
+	return result, nil
+}
+
+// startSession записывает сессию, если учёт сессий включён, и выдаёт привязанный к ней токен
+func (s *AuthService) startSession(ctx context.Context, user domain.User, client domain.ClientInfo) (string, error) {
+	// Проверка и здесь: блокировка могла случиться между шагами входа с 2FA
+	if user.BannedAt != nil {
+		return "", domain.ErrUserBanned
+	}
+	var sessionID string
+	if s.sessions != nil {
+		id, err := s.sessions.StartSession(ctx, user.ID, client, TokenTTL)
+		if err != nil {
+			return "", err
+		}
+		sessionID = id
+	}
+	return issueToken(user, sessionID)
+}
+
+func issueToken(user domain.User, sessionID string) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		return "", errors.New("jwt secret not ser")
 	}
 
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		// email_verified позволяет сервисам ограничивать действия неподтверждённых пользователей
+		"email_verified": user.EmailVerified,
+		"iat":            now.Unix(),
+		"exp":            now.Add(TokenTTL).Unix(),
+	}
+	// По sid api-gateway проверяет, не отозвана ли сессия
+	if sessionID != "" {
+		claims["sid"] = sessionID
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(secret))
+	return token.SignedString([]byte(secret))
+}
+
+// ChangePassword меняет пароль после проверки текущего. Все сессии пользователя
+// отзываются, а для текущего устройства открывается новая сессия с новым токеном.
+func (s *AuthService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string, client domain.ClientInfo) (string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", domain.ErrUserNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return "", domain.ErrWrongPassword
+	}
+
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return "", err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hash); err != nil {
+		return "", err
+	}
+
+	if err := s.revokeSessions(ctx, userID); err != nil {
+		return "", err
+	}
+	return s.startSession(ctx, user, client)
+}
+
+// RequestPasswordReset выпускает одноразовый токен сброса и отправляет его пользователю.
+// Для неизвестного email ничего не происходит, чтобы ответ не выдавал наличие аккаунта.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.notifier == nil {
+		return errors.New("password reset is not configured")
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.CreateResetToken(ctx, user.ID, hashToken(token), s.resetTTL); err != nil {
+		return err
+	}
+	return s.notifier.SendPasswordReset(ctx, user, token)
+}
+
+// ConfirmPasswordReset задаёт новый пароль по токену сброса и отзывает все сессии пользователя
+func (s *AuthService) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.userRepo.ResetPassword(ctx, hashToken(token), hash)
+	if err != nil {
+		return err
+	}
+
+	return s.revokeSessions(ctx, userID)
+}
+
+// revokeSessions отзывает сессии после смены пароля. Ошибка возвращается клиенту:
+// пароль уже изменён, но об успехе сообщать нельзя, пока старые токены не отозваны.
+// Сессии, начатые до смены пароля, api-gateway и так не примет (password_changed_at).
+func (s *AuthService) revokeSessions(ctx context.Context, userID int) error {
+	if s.sessionRevoker == nil {
+		return nil
+	}
+	if err := s.sessionRevoker.RevokeSessions(ctx, int64(userID)); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < domain.MinPasswordLength {
+		return "", domain.ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return "", err
 	}
+	return string(hash), nil
+}
+
+func newResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return signedToken, nil
+// hashToken — для токенов сброса и резервных кодов в базе хранится только SHA-256
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SendVerification отправляет пользователю подписанную ссылку подтверждения текущего email
+func (s *AuthService) SendVerification(ctx context.Context, user domain.User) error {
+	if s.verification == nil {
+		return errors.New("email verification is not configured")
+	}
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return errors.New("jwt secret not ser")
+	}
+
+	// Токен подтверждения не содержит user_id, поэтому gateway не примет его как токен входа
+	claims := jwt.MapClaims{
+		"sub":     strconv.Itoa(user.ID),
+		"email":   user.Email,
+		"purpose": verifyEmailPurpose,
+		"exp":     time.Now().Add(s.verification.ttl).Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(s.verification.linkURL)
+	if err != nil {
+		return fmt.Errorf("invalid verification link url: %w", err)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return s.verification.notifier.SendEmailVerification(ctx, user, link.String())
+}
+
+// ResendVerification повторно отправляет ссылку подтверждения
+func (s *AuthService) ResendVerification(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return domain.ErrEmailAlreadyVerified
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// VerifyEmail подтверждает email по токену из ссылки. Токен действителен,
+// только пока у пользователя тот же email, на который он был отправлен.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	userID, claims, err := parsePurposeToken(token, verifyEmailPurpose)
+	if err != nil {
+		return domain.ErrInvalidVerificationToken
+	}
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return domain.ErrInvalidVerificationToken
+	}
+
+	return s.userRepo.SetEmailVerified(ctx, userID, email)
+}
+
+// parsePurposeToken проверяет подпись и назначение служебного токена (подтверждение
+// email, второй шаг входа) и возвращает ID пользователя из sub
+func parsePurposeToken(token, purpose string) (int, jwt.MapClaims, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return 0, nil, errors.New("jwt secret not ser")
+	}
+
+	claims := jwt.MapClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !parsed.Valid {
+		return 0, nil, errors.New("invalid token")
+	}
+
+	if p, _ := claims["purpose"].(string); p != purpose {
+		return 0, nil, errors.New("unexpected token purpose")
+	}
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.Atoi(sub)
+	if err != nil {
+		return 0, nil, errors.New("invalid token subject")
+	}
+	return userID, claims, nil
 }
@@ -3,8 +3,11 @@ package service_test
 import (
 	"context"
 	"errors"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
 	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
@@ -13,7 +16,8 @@ import (
 )
 
 type mockUserRepo struct {
-	users map[string]domain.User
+	users       map[string]domain.User
+	resetTokens map[string]int
 }
 
 func (m *mockUserRepo) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
@@ -32,6 +36,69 @@ func (m *mockUserRepo) CreateUser(ctx context.Context, user domain.User) error {
 	return nil
 }
 
+func (m *mockUserRepo) GetUserByID(ctx context.Context, id int) (domain.User, error) {
+	for _, u := range m.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return domain.User{}, pgx.ErrNoRows
+}
+
+func (m *mockUserRepo) UpdateUser(ctx context.Context, user domain.User) error {
+	for email, u := range m.users {
+		if u.ID != user.ID && email == user.Email {
+			return domain.ErrEmailTaken
+		}
+	}
+	for email, u := range m.users {
+		if u.ID == user.ID {
+			delete(m.users, email)
+			m.users[user.Email] = user
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *mockUserRepo) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	for email, u := range m.users {
+		if u.ID == id {
+			u.PasswordHash = passwordHash
+			m.users[email] = u
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *mockUserRepo) CreateResetToken(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error {
+	if m.resetTokens == nil {
+		m.resetTokens = make(map[string]int)
+	}
+	m.resetTokens[tokenHash] = userID
+	return nil
+}
+
+func (m *mockUserRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	userID, ok := m.resetTokens[tokenHash]
+	if !ok {
+		return 0, domain.ErrInvalidResetToken
+	}
+	delete(m.resetTokens, tokenHash)
+	return userID, m.UpdatePassword(ctx, userID, passwordHash)
+}
+
+func (m *mockUserRepo) SetEmailVerified(ctx context.Context, id int, email string) error {
+	u, ok := m.users[email]
+	if !ok || u.ID != id {
+		return domain.ErrInvalidVerificationToken
+	}
+	u.EmailVerified = true
+	m.users[email] = u
+	return nil
+}
+
 func TestRegister_Success(t *testing.T) {
 	repo := &mockUserRepo{users: make(map[string]domain.User)}
 	authService := service.NewAuthService(repo)
@@ -97,8 +164,8 @@ func TestLogin_WrongPassword(t *testing.T) {
 	defer os.Unsetenv("JWT_SECRET")
 
 	_, err := authService.Login(context.Background(), "user@email.com", "wrongpass")
-	if err == nil || err.Error() != "invalid password" {
-		t.Fatalf("expected invalid password error, got %v", err)
+	if !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected invalid credentials error, got %v", err)
 	}
 }
 
@@ -109,9 +176,10 @@ func TestLogin_UserNotFound(t *testing.T) {
 	os.Setenv("JWT_SECRET", "supersecretkey")
 	defer os.Unsetenv("JWT_SECRET")
 
+	// Неизвестный email неотличим от неверного пароля
 	_, err := authService.Login(context.Background(), "missing@email.com", "secret")
-	if err == nil || err.Error() != "invalid email, or password" {
-		t.Fatalf("expected user not found error, got %v", err)
+	if !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected invalid credentials error, got %v", err)
 	}
 }
 
@@ -134,3 +202,257 @@ func TestLogin_MissingJWTSecret(t *testing.T) {
 		t.Fatalf("expected jwt secret error, got %v", err)
 	}
 }
+
+type mockCartMerger struct {
+	userID int64
+	token  string
+	err    error
+}
+
+func (m *mockCartMerger) MergeGuestCart(ctx context.Context, userID int64, cartToken string) error {
+	m.userID, m.token = userID, cartToken
+	return m.err
+}
+
+func TestLoginWithCart_MergesGuestCart(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
+
+	repo := &mockUserRepo{users: map[string]domain.User{
+		"alex@email.com": {
+			ID:           7,
+			Email:        "alex@email.com",
+			PasswordHash: string(hashedPassword),
+		},
+	}}
+	merger := &mockCartMerger{err: errors.New("cart-service unavailable")}
+	authService := service.NewAuthService(repo).WithCartMerger(merger)
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	result, err := authService.LoginWithCart(context.Background(), "alex@email.com", "secret", "guest-token", domain.ClientInfo{})
+	if err != nil || result.Token == "" {
+		t.Fatalf("expected login to succeed despite merge error, got %v", err)
+	}
+	if merger.userID != 7 || merger.token != "guest-token" {
+		t.Fatalf("expected merge for user 7 with guest-token, got %d %q", merger.userID, merger.token)
+	}
+}
+
+type mockNotifier struct {
+	email string
+	token string
+	link  string
+}
+
+func (m *mockNotifier) SendEmailVerification(ctx context.Context, user domain.User, link string) error {
+	m.email, m.link = user.Email, link
+	return nil
+}
+
+func (m *mockNotifier) SendPasswordReset(ctx context.Context, user domain.User, token string) error {
+	m.email, m.token = user.Email, token
+	return nil
+}
+
+type mockRevoker struct {
+	revoked []int64
+	err     error
+}
+
+func (m *mockRevoker) RevokeSessions(ctx context.Context, userID int64) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.revoked = append(m.revoked, userID)
+	return nil
+}
+
+func newPasswordRepo(t *testing.T) *mockUserRepo {
+	t.Helper()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
+	return &mockUserRepo{users: map[string]domain.User{
+		"alex@email.com": {ID: 1, Email: "alex@email.com", PasswordHash: string(hashedPassword), Role: domain.RoleUser},
+	}}
+}
+
+func TestChangePassword(t *testing.T) {
+	repo := newPasswordRepo(t)
+	revoker := &mockRevoker{}
+	authService := service.NewAuthService(repo).WithSessionRevoker(revoker)
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	ctx := context.Background()
+	if _, err := authService.ChangePassword(ctx, 1, "wrong", "newsecret1", domain.ClientInfo{}); !errors.Is(err, domain.ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got %v", err)
+	}
+	if _, err := authService.ChangePassword(ctx, 1, "secret", "short", domain.ClientInfo{}); !errors.Is(err, domain.ErrWeakPassword) {
+		t.Fatalf("expected ErrWeakPassword, got %v", err)
+	}
+
+	token, err := authService.ChangePassword(ctx, 1, "secret", "newsecret1", domain.ClientInfo{})
+	if err != nil || token == "" {
+		t.Fatalf("expected new token, got %q %v", token, err)
+	}
+	if len(revoker.revoked) != 1 || revoker.revoked[0] != 1 {
+		t.Fatalf("expected sessions of user 1 to be revoked, got %v", revoker.revoked)
+	}
+	if _, err := authService.Login(ctx, "alex@email.com", "newsecret1"); err != nil {
+		t.Fatalf("expected login with new password, got %v", err)
+	}
+}
+
+func TestPasswordReset_SingleUse(t *testing.T) {
+	repo := newPasswordRepo(t)
+	notifier := &mockNotifier{}
+	revoker := &mockRevoker{}
+	authService := service.NewAuthService(repo).WithPasswordReset(notifier, time.Minute).WithSessionRevoker(revoker)
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	ctx := context.Background()
+	if err := authService.RequestPasswordReset(ctx, "missing@email.com"); err != nil {
+		t.Fatalf("expected silent success for unknown email, got %v", err)
+	}
+	if notifier.token != "" {
+		t.Fatal("expected no message for unknown email")
+	}
+
+	if err := authService.RequestPasswordReset(ctx, "alex@email.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if notifier.email != "alex@email.com" || notifier.token == "" {
+		t.Fatalf("expected token sent to alex, got %q %q", notifier.email, notifier.token)
+	}
+	for hash := range repo.resetTokens {
+		if hash == notifier.token {
+			t.Fatal("reset token must be stored hashed")
+		}
+	}
+
+	if err := authService.ConfirmPasswordReset(ctx, notifier.token, "newsecret1"); err != nil {
+		t.Fatalf("expected reset to succeed, got %v", err)
+	}
+	if err := authService.ConfirmPasswordReset(ctx, notifier.token, "othersecret"); !errors.Is(err, domain.ErrInvalidResetToken) {
+		t.Fatalf("expected ErrInvalidResetToken on reuse, got %v", err)
+	}
+	if len(revoker.revoked) != 1 {
+		t.Fatalf("expected sessions revoked once, got %v", revoker.revoked)
+	}
+	if _, err := authService.Login(ctx, "alex@email.com", "newsecret1"); err != nil {
+		t.Fatalf("expected login with new password, got %v", err)
+	}
+}
+
+func TestPasswordChange_FailsWhenSessionsNotRevoked(t *testing.T) {
+	repo := newPasswordRepo(t)
+	notifier := &mockNotifier{}
+	revoker := &mockRevoker{err: errors.New("db is down")}
+	authService := service.NewAuthService(repo).WithPasswordReset(notifier, time.Minute).WithSessionRevoker(revoker)
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	ctx := context.Background()
+	// Без отзыва сессий старые токены продолжили бы работать — об успехе сообщать нельзя
+	if token, err := authService.ChangePassword(ctx, 1, "secret", "newsecret1", domain.ClientInfo{}); err == nil || token != "" {
+		t.Fatalf("expected error when sessions can't be revoked, got %q %v", token, err)
+	}
+
+	if err := authService.RequestPasswordReset(ctx, "alex@email.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := authService.ConfirmPasswordReset(ctx, notifier.token, "newsecret2"); err == nil {
+		t.Fatal("expected error from ConfirmPasswordReset when sessions can't be revoked")
+	}
+}
+
+func verificationToken(t *testing.T, link string) string {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid link %q: %v", link, err)
+	}
+	return u.Query().Get("token")
+}
+
+func TestRegister_InvalidEmail(t *testing.T) {
+	authService := service.NewAuthService(&mockUserRepo{users: make(map[string]domain.User)})
+
+	err := authService.Register(context.Background(), "Alex", "not-an-email", "secret")
+	if !errors.Is(err, domain.ErrInvalidEmail) {
+		t.Fatalf("expected ErrInvalidEmail, got %v", err)
+	}
+}
+
+func TestRegister_SendsVerificationLink(t *testing.T) {
+	repo := &mockUserRepo{users: make(map[string]domain.User)}
+	notifier := &mockNotifier{}
+	authService := service.NewAuthService(repo).
+		WithEmailVerification(notifier, "http://shop.local/verify-email?lang=ru", time.Hour)
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	ctx := context.Background()
+	if err := authService.Register(ctx, "Alex", "alex@email.com", "secret"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if repo.users["alex@email.com"].EmailVerified {
+		t.Fatal("new user must be unverified")
+	}
+	if notifier.email != "alex@email.com" || !strings.HasPrefix(notifier.link, "http://shop.local/verify-email?") {
+		t.Fatalf("expected verification link for alex, got %q %q", notifier.email, notifier.link)
+	}
+
+	if err := authService.VerifyEmail(ctx, verificationToken(t, notifier.link)); err != nil {
+		t.Fatalf("expected verification to succeed, got %v", err)
+	}
+	if !repo.users["alex@email.com"].EmailVerified {
+		t.Fatal("expected email to be verified")
+	}
+}
+
+func TestVerifyEmail_RejectsForeignTokens(t *testing.T) {
+	repo := newPasswordRepo(t)
+	notifier := &mockNotifier{}
+	authService := service.NewAuthService(repo).WithEmailVerification(notifier, "http://shop.local/verify", time.Hour)
+	userService := service.NewUserService(repo).WithEmailVerifier(authService)
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	ctx := context.Background()
+	loginToken, err := authService.Login(ctx, "alex@email.com", "secret")
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if err := authService.VerifyEmail(ctx, loginToken); !errors.Is(err, domain.ErrInvalidVerificationToken) {
+		t.Fatalf("login token must not verify email, got %v", err)
+	}
+
+	if err := authService.ResendVerification(ctx, 1); err != nil {
+		t.Fatalf("expected resend to succeed, got %v", err)
+	}
+	oldLink := notifier.link
+
+	// После смены email старая ссылка больше не действует, а на новый адрес уходит новая
+	if _, err := userService.UpdateProfile(ctx, 1, domain.ProfileUpdate{Email: strPtr("alex@new.com")}); err != nil {
+		t.Fatalf("UpdateProfile failed: %v", err)
+	}
+	if err := authService.VerifyEmail(ctx, verificationToken(t, oldLink)); !errors.Is(err, domain.ErrInvalidVerificationToken) {
+		t.Fatalf("expected old link to be rejected, got %v", err)
+	}
+	if notifier.email != "alex@new.com" {
+		t.Fatalf("expected link sent to the new email, got %q", notifier.email)
+	}
+	if err := authService.VerifyEmail(ctx, verificationToken(t, notifier.link)); err != nil {
+		t.Fatalf("expected new link to verify, got %v", err)
+	}
+	if err := authService.ResendVerification(ctx, 1); !errors.Is(err, domain.ErrEmailAlreadyVerified) {
+		t.Fatalf("expected ErrEmailAlreadyVerified, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/pkg/kafka"
+)
+
+// ThrottleConfig — ограничения на неудачные попытки входа
+type ThrottleConfig struct {
+	// MaxAccountFailures неудач подряд по одному email блокируют вход в аккаунт на Lockout
+	MaxAccountFailures int
+	// MaxIPFailures неудач с одного IP блокируют вход с этого адреса на Lockout
+	MaxIPFailures int
+	// Window — неудачи старше окна не учитываются
+	Window  time.Duration
+	Lockout time.Duration
+	// BaseDelay — пауза после первой неудачи; после каждой следующей она удваивается до MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func DefaultThrottleConfig() ThrottleConfig {
+	return ThrottleConfig{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      50,
+		Window:             15 * time.Minute,
+		Lockout:            15 * time.Minute,
+		BaseDelay:          time.Second,
+		MaxDelay:           30 * time.Second,
+	}
+}
+
+// loginThrottle считает неудачные входы по email и по IP
+type loginThrottle struct {
+	repo   repository.LoginAttemptRepositoryInterface
+	events kafka.Producer
+	cfg    ThrottleConfig
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// delay — прогрессивная пауза после failures неудач подряд
+func (t *loginThrottle) delay(failures int) time.Duration {
+	if failures <= 0 || t.cfg.BaseDelay <= 0 {
+		return 0
+	}
+	d := t.cfg.BaseDelay
+	for i := 1; i < failures && d < t.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, t.cfg.MaxDelay)
+}
+
+// check возвращает *domain.LockoutError, если попытку входа нужно отклонить не проверяя пароль
+func (t *loginThrottle) check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+
+	account, err := t.repo.GetLoginAttempts(ctx, accountKey(email))
+	if err != nil {
+		return err
+	}
+	if now.Before(account.LockedUntil) {
+		return &domain.LockoutError{RetryAfter: account.LockedUntil.Sub(now)}
+	}
+	if account.Failures > 0 {
+		if next := account.LastFailureAt.Add(t.delay(account.Failures)); now.Before(next) {
+			return &domain.LockoutError{RetryAfter: next.Sub(now)}
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+	byIP, err := t.repo.GetLoginAttempts(ctx, ipKey(ip))
+	if err != nil {
+		return err
+	}
+	if now.Before(byIP.LockedUntil) {
+		return &domain.LockoutError{RetryAfter: byIP.LockedUntil.Sub(now)}
+	}
+	return nil
+}
+
+// fail учитывает неудачную попытку и при превышении порога блокирует вход.
+// Ошибки хранилища только логируются: ответ на попытку входа от них не меняется.
+func (t *loginThrottle) fail(ctx context.Context, email, ip string, userID int) {
+	now := time.Now()
+	event := kafka.UserLockedOutEvent{UserID: int64(userID), Email: email, IP: ip}
+
+	account, err := t.repo.RecordLoginFailure(ctx, accountKey(email), now, t.cfg.Window)
+	if err != nil {
+		log.Printf("Не удалось учесть неудачный вход %s: %v", email, err)
+	} else if account.Failures >= t.cfg.MaxAccountFailures {
+		event.Scope, event.Failures = "account", account.Failures
+		t.lock(ctx, account.Key, now, event)
+	}
+
+	if ip == "" {
+		return
+	}
+	byIP, err := t.repo.RecordLoginFailure(ctx, ipKey(ip), now, t.cfg.Window)
+	if err != nil {
+		log.Printf("Не удалось учесть неудачный вход с %s: %v", ip, err)
+	} else if byIP.Failures >= t.cfg.MaxIPFailures {
+		event.Scope, event.Failures = "ip", byIP.Failures
+		t.lock(ctx, byIP.Key, now, event)
+	}
+}
+
+func (t *loginThrottle) lock(ctx context.Context, key string, now time.Time, event kafka.UserLockedOutEvent) {
+	until := now.Add(t.cfg.Lockout)
+	if err := t.repo.LockLogin(ctx, key, until); err != nil {
+		log.Printf("Не удалось заблокировать вход по %s: %v", key, err)
+		return
+	}
+	log.Printf("Вход по %s заблокирован до %s после %d неудачных попыток", key, until.Format(time.RFC3339), event.Failures)
+
+	if t.events == nil {
+		return
+	}
+	event.LockedUntil, event.DetectedAt = until, now
+	if err := t.events.SendUserLockedOut(ctx, event); err != nil {
+		log.Printf("Не удалось отправить UserLockedOut для %s: %v", key, err)
+	}
+}
+
+// success сбрасывает счётчик аккаунта; счётчик IP продолжает копиться
+func (t *loginThrottle) success(ctx context.Context, email string) {
+	if err := t.repo.ResetLoginAttempts(ctx, accountKey(email)); err != nil {
+		log.Printf("Не удалось сбросить счётчик входов %s: %v", email, err)
+	}
+}
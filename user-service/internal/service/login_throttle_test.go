@@ -0,0 +1,184 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/pkg/kafka"
+)
+
+type mockAttemptRepo struct {
+	attempts map[string]domain.LoginAttempts
+}
+
+func newMockAttemptRepo() *mockAttemptRepo {
+	return &mockAttemptRepo{attempts: make(map[string]domain.LoginAttempts)}
+}
+
+func (m *mockAttemptRepo) GetLoginAttempts(ctx context.Context, key string) (domain.LoginAttempts, error) {
+	a, ok := m.attempts[key]
+	if !ok {
+		return domain.LoginAttempts{Key: key}, nil
+	}
+	return a, nil
+}
+
+func (m *mockAttemptRepo) RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (domain.LoginAttempts, error) {
+	a := m.attempts[key]
+	a.Key = key
+	if a.LastFailureAt.Before(now.Add(-window)) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	m.attempts[key] = a
+	return a, nil
+}
+
+func (m *mockAttemptRepo) LockLogin(ctx context.Context, key string, until time.Time) error {
+	a := m.attempts[key]
+	a.LockedUntil = until
+	m.attempts[key] = a
+	return nil
+}
+
+func (m *mockAttemptRepo) ResetLoginAttempts(ctx context.Context, key string) error {
+	delete(m.attempts, key)
+	return nil
+}
+
+type mockSecurityEvents struct {
+	events []kafka.UserLockedOutEvent
+}
+
+func (m *mockSecurityEvents) SendUserLockedOut(ctx context.Context, event kafka.UserLockedOutEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func throttleConfig() service.ThrottleConfig {
+	cfg := service.DefaultThrottleConfig()
+	cfg.MaxAccountFailures = 3
+	cfg.MaxIPFailures = 5
+	cfg.BaseDelay = 0
+	return cfg
+}
+
+func TestLogin_LocksAccountAfterFailures(t *testing.T) {
+	events := &mockSecurityEvents{}
+	authService := service.NewAuthService(newPasswordRepo(t)).
+		WithLoginThrottle(newMockAttemptRepo(), events, throttleConfig())
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := authService.LoginWithCart(ctx, "alex@email.com", "wrong", "", domain.ClientInfo{IP: "10.0.0.1"})
+		if !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: expected invalid credentials, got %v", i+1, err)
+		}
+	}
+
+	// Даже верный пароль не принимается, пока аккаунт заблокирован
+	_, err := authService.LoginWithCart(ctx, "alex@email.com", "secret", "", domain.ClientInfo{IP: "10.0.0.2"})
+	var lockout *domain.LockoutError
+	if !errors.As(err, &lockout) || lockout.RetryAfter <= 0 {
+		t.Fatalf("expected lockout error, got %v", err)
+	}
+
+	if len(events.events) != 1 {
+		t.Fatalf("expected one UserLockedOut event, got %d", len(events.events))
+	}
+	e := events.events[0]
+	if e.Scope != "account" || e.UserID != 1 || e.Email != "alex@email.com" || e.Failures != 3 {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+}
+
+func TestLogin_UnknownEmailLockedLikeExisting(t *testing.T) {
+	events := &mockSecurityEvents{}
+	authService := service.NewAuthService(newPasswordRepo(t)).
+		WithLoginThrottle(newMockAttemptRepo(), events, throttleConfig())
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := authService.LoginWithCart(ctx, "ghost@email.com", "secret", "", domain.ClientInfo{}); !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Fatalf("expected invalid credentials, got %v", err)
+		}
+	}
+	if _, err := authService.LoginWithCart(ctx, "ghost@email.com", "secret", "", domain.ClientInfo{}); !errors.Is(err, domain.ErrLockedOut) {
+		t.Fatalf("expected lockout for unknown email too, got %v", err)
+	}
+	if len(events.events) != 1 || events.events[0].UserID != 0 {
+		t.Fatalf("expected event without user id, got %+v", events.events)
+	}
+}
+
+func TestLogin_ProgressiveDelay(t *testing.T) {
+	cfg := throttleConfig()
+	cfg.BaseDelay = time.Hour
+	cfg.MaxDelay = 2 * time.Hour
+	authService := service.NewAuthService(newPasswordRepo(t)).WithLoginThrottle(newMockAttemptRepo(), nil, cfg)
+
+	ctx := context.Background()
+	if _, err := authService.LoginWithCart(ctx, "alex@email.com", "wrong", "", domain.ClientInfo{}); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+
+	_, err := authService.LoginWithCart(ctx, "alex@email.com", "secret", "", domain.ClientInfo{})
+	var lockout *domain.LockoutError
+	if !errors.As(err, &lockout) || lockout.RetryAfter < 59*time.Minute {
+		t.Fatalf("expected to wait about an hour after a failure, got %v", err)
+	}
+}
+
+func TestLogin_LocksIPAcrossAccounts(t *testing.T) {
+	events := &mockSecurityEvents{}
+	authService := service.NewAuthService(newPasswordRepo(t)).
+		WithLoginThrottle(newMockAttemptRepo(), events, throttleConfig())
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	ctx := context.Background()
+	for _, email := range []string{"a@email.com", "b@email.com", "c@email.com", "d@email.com", "e@email.com"} {
+		if _, err := authService.LoginWithCart(ctx, email, "guess", "", domain.ClientInfo{IP: "10.0.0.9"}); !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Fatalf("expected invalid credentials, got %v", err)
+		}
+	}
+
+	if _, err := authService.LoginWithCart(ctx, "alex@email.com", "secret", "", domain.ClientInfo{IP: "10.0.0.9"}); !errors.Is(err, domain.ErrLockedOut) {
+		t.Fatalf("expected IP lockout, got %v", err)
+	}
+	if _, err := authService.LoginWithCart(ctx, "alex@email.com", "secret", "", domain.ClientInfo{IP: "10.0.0.10"}); err != nil {
+		t.Fatalf("expected login from another IP to succeed, got %v", err)
+	}
+	if last := events.events[len(events.events)-1]; last.Scope != "ip" || last.IP != "10.0.0.9" {
+		t.Fatalf("expected ip lockout event, got %+v", last)
+	}
+}
+
+func TestLogin_SuccessResetsAccountFailures(t *testing.T) {
+	attempts := newMockAttemptRepo()
+	authService := service.NewAuthService(newPasswordRepo(t)).WithLoginThrottle(attempts, nil, throttleConfig())
+
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		authService.LoginWithCart(ctx, "alex@email.com", "wrong", "", domain.ClientInfo{})
+	}
+	if _, err := authService.LoginWithCart(ctx, "alex@email.com", "secret", "", domain.ClientInfo{}); err != nil {
+		t.Fatalf("expected login to succeed, got %v", err)
+	}
+	if _, ok := attempts.attempts["account:alex@email.com"]; ok {
+		t.Fatal("expected account failures to be reset after successful login")
+	}
+}
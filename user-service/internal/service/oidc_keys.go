@@ -0,0 +1,77 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+)
+
+// SigningKey — RSA-ключ, которым подписываются ID и access токены OIDC.
+// Открытая часть публикуется в JWKS, чтобы партнёры проверяли подпись сами.
+type SigningKey struct {
+	ID      string
+	private *rsa.PrivateKey
+}
+
+// LoadSigningKey читает закрытый ключ RSA в PEM (PKCS#1 или PKCS#8)
+func LoadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return newSigningKey(key), nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA key")
+	}
+	return newSigningKey(key), nil
+}
+
+// GenerateSigningKey создаёт временный ключ; выданные им токены перестают
+// проверяться после перезапуска сервиса
+func GenerateSigningKey() (*SigningKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return newSigningKey(key), nil
+}
+
+// newSigningKey вычисляет kid как отпечаток открытого ключа, чтобы при смене ключа
+// клиенты видели новый идентификатор
+func newSigningKey(key *rsa.PrivateKey) *SigningKey {
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	sum := sha256.Sum256(der)
+	return &SigningKey{ID: base64.RawURLEncoding.EncodeToString(sum[:12]), private: key}
+}
+
+func (k *SigningKey) JWK() domain.JWK {
+	return domain.JWK{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: "RS256",
+		KeyID:     k.ID,
+		N:         base64.RawURLEncoding.EncodeToString(k.private.PublicKey.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.private.PublicKey.E)).Bytes()),
+	}
+}
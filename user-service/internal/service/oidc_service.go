@@ -0,0 +1,517 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// authorizationCodeTTL — RFC 6749 рекомендует не больше 10 минут
+	authorizationCodeTTL = 10 * time.Minute
+	oidcTokenTTL         = time.Hour
+	consentTicketTTL     = 10 * time.Minute
+	// consentTicketPurpose отличает билет экрана согласия от токена входа
+	consentTicketPurpose = "oauth_consent"
+	// accessTokenType — тип access-токена в заголовке JWT (RFC 9068), чтобы ID-токен
+	// нельзя было предъявить на /userinfo
+	accessTokenType = "at+jwt"
+)
+
+var supportedScopes = []string{domain.ScopeOpenID, domain.ScopeProfile, domain.ScopeEmail, domain.ScopePhone}
+
+// Authenticator проверяет логин и пароль пользователя marketplace (AuthService)
+type Authenticator interface {
+	Authenticate(ctx context.Context, email, password, code, clientIP string) (domain.User, error)
+}
+
+// AdminChecker разрешает действие только администраторам (UserService)
+type AdminChecker interface {
+	RequireAdmin(ctx context.Context, requesterID int) error
+}
+
+// OIDCService — провайдер OpenID Connect для приложений партнёров: authorization
+// code flow с обязательным PKCE, ID-токены и /userinfo
+type OIDCService struct {
+	repo   repository.OAuthRepositoryInterface
+	users  repository.UserRepositoryInterface
+	auth   Authenticator
+	admins AdminChecker
+	key    *SigningKey
+	issuer string
+	audit  AuditRecorder
+}
+
+func NewOIDCService(repo repository.OAuthRepositoryInterface, users repository.UserRepositoryInterface,
+	auth Authenticator, admins AdminChecker, key *SigningKey, issuer string) *OIDCService {
+	return &OIDCService{
+		repo:   repo,
+		users:  users,
+		auth:   auth,
+		admins: admins,
+		key:    key,
+		issuer: strings.TrimSuffix(issuer, "/"),
+	}
+}
+
+// WithAuditLog записывает регистрацию приложений в журнал действий администраторов
+func (s *OIDCService) WithAuditLog(audit AuditRecorder) *OIDCService {
+	s.audit = audit
+	return s
+}
+
+func (s *OIDCService) Discovery() domain.OIDCDiscovery {
+	return domain.OIDCDiscovery{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/oauth2/authorize",
+		TokenEndpoint:                     s.issuer + "/oauth2/token",
+		UserInfoEndpoint:                  s.issuer + "/oauth2/userinfo",
+		JWKSURI:                           s.issuer + "/oauth2/jwks",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		ScopesSupported:                   supportedScopes,
+		ClaimsSupported:                   []string{"sub", "email", "email_verified", "name", "locale", "updated_at", "phone_number"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	}
+}
+
+func (s *OIDCService) JWKS() domain.JWKSet {
+	return domain.JWKSet{Keys: []domain.JWK{s.key.JWK()}}
+}
+
+// RegisterClient регистрирует приложение партнёра; доступно только администраторам.
+// Секрет конфиденциального клиента возвращается один раз, в базе остаётся хеш.
+func (s *OIDCService) RegisterClient(ctx context.Context, requesterID int, name string, redirectURIs []string, confidential bool) (domain.OAuthClientRegistration, error) {
+	if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+		return domain.OAuthClientRegistration{}, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.OAuthClientRegistration{}, fmt.Errorf("%w: name can't be empty", domain.ErrInvalidClientMetadata)
+	}
+	if len(redirectURIs) == 0 {
+		return domain.OAuthClientRegistration{}, fmt.Errorf("%w: at least one redirect_uri is required", domain.ErrInvalidClientMetadata)
+	}
+	for _, uri := range redirectURIs {
+		if !validRedirectURI(uri) {
+			return domain.OAuthClientRegistration{}, fmt.Errorf("%w: redirect_uri %q must be https or http on localhost", domain.ErrInvalidClientMetadata, uri)
+		}
+	}
+
+	id, err := randomToken(16)
+	if err != nil {
+		return domain.OAuthClientRegistration{}, err
+	}
+	client := domain.OAuthClient{
+		ID:           id,
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		CreatedBy:    requesterID,
+		CreatedAt:    time.Now(),
+	}
+	var secret string
+	if confidential {
+		if secret, err = randomToken(32); err != nil {
+			return domain.OAuthClientRegistration{}, err
+		}
+		client.SecretHash = hashToken(secret)
+	}
+
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return domain.OAuthClientRegistration{}, err
+	}
+	recordAudit(ctx, s.audit, requesterID, domain.AuditOAuthClient, nil, map[string]any{"client_id": client.ID, "name": client.Name})
+	return domain.OAuthClientRegistration{OAuthClient: client, Secret: secret}, nil
+}
+
+// validRedirectURI допускает https и http только для локальной отладки; фрагменты запрещены
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return false
+}
+
+// ValidateAuthorization проверяет параметры /oauth2/authorize. Неизвестный клиент
+// и чужой redirect_uri — domain.ErrUnknownClient и domain.ErrInvalidRedirectURI: о них
+// сообщают пользователю, а не редиректом. Остальные ошибки — *domain.OAuthError.
+func (s *OIDCService) ValidateAuthorization(ctx context.Context, req domain.AuthorizationRequest) (domain.OAuthClient, []string, error) {
+	client, err := s.repo.GetClient(ctx, req.ClientID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.OAuthClient{}, nil, domain.ErrUnknownClient
+	}
+	if err != nil {
+		return domain.OAuthClient{}, nil, err
+	}
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return domain.OAuthClient{}, nil, domain.ErrInvalidRedirectURI
+	}
+
+	if req.ResponseType != "code" {
+		return client, nil, domain.NewOAuthError("unsupported_response_type", "only response_type=code is supported")
+	}
+	scopes := strings.Fields(req.Scope)
+	if !slices.Contains(scopes, domain.ScopeOpenID) {
+		return client, nil, domain.NewOAuthError("invalid_scope", "scope must include openid")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(supportedScopes, scope) {
+			return client, nil, domain.NewOAuthError("invalid_scope", "unsupported scope "+scope)
+		}
+	}
+	// PKCE обязателен для всех клиентов, метод plain не поддерживается
+	if req.CodeChallengeMethod != "S256" || len(req.CodeChallenge) < 43 || len(req.CodeChallenge) > 128 {
+		return client, nil, domain.NewOAuthError("invalid_request", "code_challenge with code_challenge_method=S256 is required")
+	}
+	return client, scopes, nil
+}
+
+// Authorize входит под учётной записью marketplace на странице авторизации. Если
+// пользователь уже давал приложению согласие на эти области, сразу выдаётся код,
+// иначе — билет для экрана согласия.
+func (s *OIDCService) Authorize(ctx context.Context, req domain.AuthorizationRequest, email, password, code, clientIP string) (domain.AuthorizeResult, error) {
+	client, scopes, err := s.ValidateAuthorization(ctx, req)
+	if err != nil {
+		return domain.AuthorizeResult{}, err
+	}
+	user, err := s.auth.Authenticate(ctx, email, password, code, clientIP)
+	if err != nil {
+		return domain.AuthorizeResult{}, err
+	}
+	authTime := time.Now()
+
+	granted, err := s.repo.GetConsent(ctx, user.ID, client.ID)
+	if err != nil {
+		return domain.AuthorizeResult{}, err
+	}
+	if containsAll(granted, scopes) {
+		redirectURL, err := s.issueCode(ctx, req, user.ID, authTime)
+		if err != nil {
+			return domain.AuthorizeResult{}, err
+		}
+		return domain.AuthorizeResult{RedirectURL: redirectURL}, nil
+	}
+
+	ticket, err := issueConsentTicket(req, user.ID, authTime)
+	if err != nil {
+		return domain.AuthorizeResult{}, err
+	}
+	return domain.AuthorizeResult{ConsentTicket: ticket, Client: client, Scopes: scopes}, nil
+}
+
+// Consent применяет решение пользователя на экране согласия и возвращает адрес
+// возврата в приложение: с кодом или с ошибкой access_denied
+func (s *OIDCService) Consent(ctx context.Context, ticket string, approve bool) (string, error) {
+	userID, claims, err := parsePurposeToken(ticket, consentTicketPurpose)
+	if err != nil {
+		return "", domain.ErrInvalidConsentTicket
+	}
+	var req domain.AuthorizationRequest
+	raw, _ := json.Marshal(claims["request"])
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return "", domain.ErrInvalidConsentTicket
+	}
+	authTime, _ := claims["auth_time"].(float64)
+
+	// Клиента могли удалить или изменить, пока пользователь читал экран согласия
+	client, scopes, err := s.ValidateAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if !approve {
+		return AuthorizationErrorURL(req, s.issuer, domain.NewOAuthError("access_denied", "the user denied the request")), nil
+	}
+
+	if err := s.repo.SaveConsent(ctx, userID, client.ID, scopes); err != nil {
+		return "", err
+	}
+	return s.issueCode(ctx, req, userID, time.Unix(int64(authTime), 0))
+}
+
+// issueConsentTicket подписывает запрос авторизации вместе с вошедшим пользователем,
+// чтобы экран согласия не требовал повторного ввода пароля
+func issueConsentTicket(req domain.AuthorizationRequest, userID int, authTime time.Time) (string, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return "", errors.New("jwt secret not ser")
+	}
+
+	claims := jwt.MapClaims{
+		"sub":       strconv.Itoa(userID),
+		"purpose":   consentTicketPurpose,
+		"request":   req,
+		"auth_time": authTime.Unix(),
+		"exp":       time.Now().Add(consentTicketTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+func (s *OIDCService) issueCode(ctx context.Context, req domain.AuthorizationRequest, userID int, authTime time.Time) (string, error) {
+	code, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.repo.CreateAuthorizationCode(ctx, hashToken(code), domain.AuthorizationCode{
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      authTime,
+	}, authorizationCodeTTL)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{"code": {code}, "iss": {s.issuer}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return withQuery(req.RedirectURI, params), nil
+}
+
+// AuthorizationErrorURL — адрес возврата в приложение с ошибкой (RFC 6749, раздел 4.1.2.1)
+func AuthorizationErrorURL(req domain.AuthorizationRequest, issuer string, oauthErr *domain.OAuthError) string {
+	params := url.Values{"error": {oauthErr.Code}, "iss": {strings.TrimSuffix(issuer, "/")}}
+	if oauthErr.Description != "" {
+		params.Set("error_description", oauthErr.Description)
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return withQuery(req.RedirectURI, params)
+}
+
+// Issuer — идентификатор провайдера, он же базовый адрес его эндпоинтов
+func (s *OIDCService) Issuer() string {
+	return s.issuer
+}
+
+func withQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// Exchange обменивает код авторизации на access и ID токены
+func (s *OIDCService) Exchange(ctx context.Context, req domain.TokenRequest) (domain.TokenResponse, error) {
+	if req.GrantType != "authorization_code" {
+		return domain.TokenResponse{}, domain.NewOAuthError("unsupported_grant_type", "")
+	}
+
+	client, err := s.repo.GetClient(ctx, req.ClientID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.TokenResponse{}, domain.NewOAuthError("invalid_client", "")
+	}
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+	if client.Confidential() {
+		if subtle.ConstantTimeCompare([]byte(hashToken(req.ClientSecret)), []byte(client.SecretHash)) != 1 {
+			return domain.TokenResponse{}, domain.NewOAuthError("invalid_client", "")
+		}
+	} else if req.ClientSecret != "" {
+		return domain.TokenResponse{}, domain.NewOAuthError("invalid_client", "public client must not send a secret")
+	}
+	if req.Code == "" || req.CodeVerifier == "" {
+		return domain.TokenResponse{}, domain.NewOAuthError("invalid_request", "code and code_verifier are required")
+	}
+
+	code, err := s.repo.ConsumeAuthorizationCode(ctx, hashToken(req.Code))
+	if errors.Is(err, domain.ErrInvalidAuthorizationCode) {
+		return domain.TokenResponse{}, domain.NewOAuthError("invalid_grant", err.Error())
+	}
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI {
+		return domain.TokenResponse{}, domain.NewOAuthError("invalid_grant", "code was issued to another client or redirect_uri")
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return domain.TokenResponse{}, domain.NewOAuthError("invalid_grant", "code_verifier does not match code_challenge")
+	}
+
+	user, err := s.users.GetUserByID(ctx, code.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.TokenResponse{}, domain.NewOAuthError("invalid_grant", "user no longer exists")
+	}
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+	if user.Status() != domain.UserStatusActive {
+		return domain.TokenResponse{}, domain.NewOAuthError("invalid_grant", "user account is not active")
+	}
+
+	now := time.Now()
+	scopes := strings.Fields(code.Scope)
+	jti, err := randomToken(16)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+	accessToken, err := s.sign(jwt.MapClaims{
+		"iss":       s.issuer,
+		"sub":       strconv.Itoa(user.ID),
+		"aud":       s.issuer,
+		"client_id": client.ID,
+		"scope":     code.Scope,
+		"jti":       jti,
+		"iat":       now.Unix(),
+		"exp":       now.Add(oidcTokenTTL).Unix(),
+	}, accessTokenType)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	idClaims := jwt.MapClaims{
+		"iss":       s.issuer,
+		"aud":       client.ID,
+		"azp":       client.ID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(oidcTokenTTL).Unix(),
+		"auth_time": code.AuthTime.Unix(),
+	}
+	for k, v := range userClaims(user, scopes) {
+		idClaims[k] = v
+	}
+	if code.Nonce != "" {
+		idClaims["nonce"] = code.Nonce
+	}
+	idToken, err := s.sign(idClaims, "JWT")
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	return domain.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(oidcTokenTTL.Seconds()),
+		IDToken:     idToken,
+		Scope:       code.Scope,
+	}, nil
+}
+
+// UserInfo возвращает claims пользователя по access-токену в пределах выданных областей
+func (s *OIDCService) UserInfo(ctx context.Context, accessToken string) (map[string]any, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(accessToken, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Header["typ"] != accessTokenType {
+			return nil, errors.New("not an access token")
+		}
+		return &s.key.private.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(s.issuer), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return nil, domain.ErrInvalidAccessToken
+	}
+
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.Atoi(sub)
+	if err != nil {
+		return nil, domain.ErrInvalidAccessToken
+	}
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrInvalidAccessToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Токены партнёров живут без сессий, поэтому блокировка проверяется при каждом запросе
+	if user.Status() != domain.UserStatusActive {
+		return nil, domain.ErrInvalidAccessToken
+	}
+
+	scope, _ := claims["scope"].(string)
+	return userClaims(user, strings.Fields(scope)), nil
+}
+
+// userClaims — стандартные claims OIDC, разрешённые выданными областями
+func userClaims(user domain.User, scopes []string) map[string]any {
+	claims := map[string]any{"sub": strconv.Itoa(user.ID)}
+	if slices.Contains(scopes, domain.ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.EmailVerified
+	}
+	if slices.Contains(scopes, domain.ScopeProfile) {
+		claims["name"] = user.Name
+		claims["locale"] = user.Locale
+		claims["updated_at"] = user.UpdatedAt.Unix()
+	}
+	if slices.Contains(scopes, domain.ScopePhone) && user.Phone != "" {
+		claims["phone_number"] = user.Phone
+	}
+	return claims
+}
+
+func (s *OIDCService) sign(claims jwt.MapClaims, typ string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.key.ID
+	token.Header["typ"] = typ
+	return token.SignedString(s.key.private)
+}
+
+// verifyCodeChallenge проверяет PKCE S256: BASE64URL(SHA256(code_verifier)) == code_challenge
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func containsAll(granted, requested []string) bool {
+	for _, scope := range requested {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	if n <= 16 {
+		return hex.EncodeToString(b), nil
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
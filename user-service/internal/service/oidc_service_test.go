@@ -0,0 +1,337 @@
+package service_test
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type mockOAuthRepo struct {
+	clients  map[string]domain.OAuthClient
+	codes    map[string]domain.AuthorizationCode
+	consents map[string][]string
+}
+
+func newMockOAuthRepo() *mockOAuthRepo {
+	return &mockOAuthRepo{
+		clients:  make(map[string]domain.OAuthClient),
+		codes:    make(map[string]domain.AuthorizationCode),
+		consents: make(map[string][]string),
+	}
+}
+
+func (m *mockOAuthRepo) CreateClient(ctx context.Context, client domain.OAuthClient) error {
+	m.clients[client.ID] = client
+	return nil
+}
+
+func (m *mockOAuthRepo) GetClient(ctx context.Context, id string) (domain.OAuthClient, error) {
+	client, ok := m.clients[id]
+	if !ok {
+		return domain.OAuthClient{}, pgx.ErrNoRows
+	}
+	return client, nil
+}
+
+func (m *mockOAuthRepo) CreateAuthorizationCode(ctx context.Context, codeHash string, code domain.AuthorizationCode, ttl time.Duration) error {
+	m.codes[codeHash] = code
+	return nil
+}
+
+func (m *mockOAuthRepo) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (domain.AuthorizationCode, error) {
+	code, ok := m.codes[codeHash]
+	if !ok {
+		return domain.AuthorizationCode{}, domain.ErrInvalidAuthorizationCode
+	}
+	delete(m.codes, codeHash)
+	return code, nil
+}
+
+func (m *mockOAuthRepo) GetConsent(ctx context.Context, userID int, clientID string) ([]string, error) {
+	return m.consents[clientID], nil
+}
+
+func (m *mockOAuthRepo) SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	m.consents[clientID] = scopes
+	return nil
+}
+
+// adminOnly пускает к регистрации клиентов только пользователя 1
+type adminOnly struct{}
+
+func (adminOnly) RequireAdmin(ctx context.Context, requesterID int) error {
+	if requesterID != 1 {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+const testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func testChallenge() string {
+	sum := sha256.Sum256([]byte(testVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func newOIDCService(t *testing.T) (*service.OIDCService, *mockOAuthRepo) {
+	t.Helper()
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	t.Cleanup(func() { os.Unsetenv("JWT_SECRET") })
+
+	key, err := service.GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey: %v", err)
+	}
+	users := newPasswordRepo(t)
+	repo := newMockOAuthRepo()
+	return service.NewOIDCService(repo, users, service.NewAuthService(users), adminOnly{}, key, "http://localhost:8080/"), repo
+}
+
+func newAuthorizationRequest(clientID string) domain.AuthorizationRequest {
+	return domain.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            clientID,
+		RedirectURI:         "https://partner.example/callback",
+		Scope:               "openid email",
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       testChallenge(),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func codeFromRedirect(t *testing.T, redirectURL string) url.Values {
+	t.Helper()
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	if !strings.HasPrefix(redirectURL, "https://partner.example/callback?") {
+		t.Fatalf("unexpected redirect %s", redirectURL)
+	}
+	return u.Query()
+}
+
+// publicKeyFromJWKS восстанавливает ключ так же, как это сделает приложение партнёра
+func publicKeyFromJWKS(t *testing.T, s *service.OIDCService) *rsa.PublicKey {
+	t.Helper()
+	jwk := s.JWKS().Keys[0]
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		t.Fatalf("decode n: %v", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		t.Fatalf("decode e: %v", err)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+}
+
+func TestRegisterClient(t *testing.T) {
+	s, _ := newOIDCService(t)
+	ctx := context.Background()
+
+	if _, err := s.RegisterClient(ctx, 2, "Partner", []string{"https://partner.example/callback"}, false); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for non-admin, got %v", err)
+	}
+	for _, uri := range []string{"http://partner.example/callback", "https://partner.example/cb#frag", "javascript:alert(1)"} {
+		if _, err := s.RegisterClient(ctx, 1, "Partner", []string{uri}, false); !errors.Is(err, domain.ErrInvalidClientMetadata) {
+			t.Fatalf("expected ErrInvalidClientMetadata for %s, got %v", uri, err)
+		}
+	}
+
+	public, err := s.RegisterClient(ctx, 1, "Partner SPA", []string{"http://localhost:3000/callback"}, false)
+	if err != nil {
+		t.Fatalf("RegisterClient: %v", err)
+	}
+	if public.ID == "" || public.Secret != "" || public.Confidential() {
+		t.Fatalf("expected public client without secret, got %+v", public)
+	}
+
+	confidential, err := s.RegisterClient(ctx, 1, "Partner backend", []string{"https://partner.example/callback"}, true)
+	if err != nil {
+		t.Fatalf("RegisterClient: %v", err)
+	}
+	if confidential.Secret == "" || confidential.SecretHash == confidential.Secret {
+		t.Fatal("expected secret to be returned once and stored hashed")
+	}
+}
+
+func TestValidateAuthorization(t *testing.T) {
+	s, _ := newOIDCService(t)
+	ctx := context.Background()
+	client, _ := s.RegisterClient(ctx, 1, "Partner", []string{"https://partner.example/callback"}, false)
+
+	req := newAuthorizationRequest("unknown")
+	if _, _, err := s.ValidateAuthorization(ctx, req); !errors.Is(err, domain.ErrUnknownClient) {
+		t.Fatalf("expected ErrUnknownClient, got %v", err)
+	}
+	req = newAuthorizationRequest(client.ID)
+	req.RedirectURI = "https://evil.example/callback"
+	if _, _, err := s.ValidateAuthorization(ctx, req); !errors.Is(err, domain.ErrInvalidRedirectURI) {
+		t.Fatalf("expected ErrInvalidRedirectURI, got %v", err)
+	}
+
+	cases := map[string]func(*domain.AuthorizationRequest){
+		"invalid_scope":             func(r *domain.AuthorizationRequest) { r.Scope = "email" },
+		"unsupported_response_type": func(r *domain.AuthorizationRequest) { r.ResponseType = "token" },
+		"invalid_request":           func(r *domain.AuthorizationRequest) { r.CodeChallengeMethod = "plain" },
+	}
+	for code, mutate := range cases {
+		req := newAuthorizationRequest(client.ID)
+		mutate(&req)
+		var oauthErr *domain.OAuthError
+		if _, _, err := s.ValidateAuthorization(ctx, req); !errors.As(err, &oauthErr) || oauthErr.Code != code {
+			t.Fatalf("expected %s, got %v", code, err)
+		}
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	s, repo := newOIDCService(t)
+	ctx := context.Background()
+	client, _ := s.RegisterClient(ctx, 1, "Partner", []string{"https://partner.example/callback"}, false)
+	req := newAuthorizationRequest(client.ID)
+
+	if _, err := s.Authorize(ctx, req, "alex@email.com", "wrong", "", ""); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+
+	result, err := s.Authorize(ctx, req, "alex@email.com", "secret", "", "")
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if result.ConsentTicket == "" || result.RedirectURL != "" {
+		t.Fatalf("expected consent screen on first login, got %+v", result)
+	}
+
+	denied, err := s.Consent(ctx, result.ConsentTicket, false)
+	if err != nil {
+		t.Fatalf("Consent deny: %v", err)
+	}
+	if q := codeFromRedirect(t, denied); q.Get("error") != "access_denied" || q.Get("state") != "xyz" {
+		t.Fatalf("expected access_denied with state, got %s", denied)
+	}
+
+	approved, err := s.Consent(ctx, result.ConsentTicket, true)
+	if err != nil {
+		t.Fatalf("Consent allow: %v", err)
+	}
+	q := codeFromRedirect(t, approved)
+	if q.Get("code") == "" || q.Get("state") != "xyz" || q.Get("iss") != "http://localhost:8080" {
+		t.Fatalf("unexpected redirect %s", approved)
+	}
+	if !slices.Equal(repo.consents[client.ID], []string{"openid", "email"}) {
+		t.Fatalf("expected consent to be stored, got %v", repo.consents[client.ID])
+	}
+
+	tokenReq := domain.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         q.Get("code"),
+		RedirectURI:  req.RedirectURI,
+		ClientID:     client.ID,
+		CodeVerifier: testVerifier,
+	}
+	tokens, err := s.Exchange(ctx, tokenReq)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	// Код одноразовый
+	var oauthErr *domain.OAuthError
+	if _, err := s.Exchange(ctx, tokenReq); !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" {
+		t.Fatalf("expected invalid_grant on code reuse, got %v", err)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(tokens.IDToken, claims, func(*jwt.Token) (interface{}, error) {
+		return publicKeyFromJWKS(t, s), nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithAudience(client.ID), jwt.WithIssuer("http://localhost:8080"))
+	if err != nil {
+		t.Fatalf("id token should verify with JWKS: %v", err)
+	}
+	if claims["sub"] != "1" || claims["nonce"] != "n-0S6" || claims["email"] != "alex@email.com" {
+		t.Fatalf("unexpected id token claims %v", claims)
+	}
+	if _, ok := claims["name"]; ok {
+		t.Fatal("profile claims must not be issued without profile scope")
+	}
+
+	info, err := s.UserInfo(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("UserInfo: %v", err)
+	}
+	if info["sub"] != "1" || info["email"] != "alex@email.com" {
+		t.Fatalf("unexpected userinfo %v", info)
+	}
+	if _, err := s.UserInfo(ctx, tokens.IDToken); !errors.Is(err, domain.ErrInvalidAccessToken) {
+		t.Fatalf("expected id token to be rejected at userinfo, got %v", err)
+	}
+
+	// Согласие запомнено: повторный вход сразу возвращает код
+	again, err := s.Authorize(ctx, req, "alex@email.com", "secret", "", "")
+	if err != nil || again.RedirectURL == "" {
+		t.Fatalf("expected immediate redirect with remembered consent, got %+v %v", again, err)
+	}
+}
+
+func TestExchange_RejectsWrongVerifierAndSecret(t *testing.T) {
+	s, _ := newOIDCService(t)
+	ctx := context.Background()
+	client, _ := s.RegisterClient(ctx, 1, "Partner backend", []string{"https://partner.example/callback"}, true)
+	req := newAuthorizationRequest(client.ID)
+
+	result, err := s.Authorize(ctx, req, "alex@email.com", "secret", "", "")
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	redirect, err := s.Consent(ctx, result.ConsentTicket, true)
+	if err != nil {
+		t.Fatalf("Consent: %v", err)
+	}
+	code := codeFromRedirect(t, redirect).Get("code")
+
+	var oauthErr *domain.OAuthError
+	_, err = s.Exchange(ctx, domain.TokenRequest{
+		GrantType: "authorization_code", Code: code, RedirectURI: req.RedirectURI,
+		ClientID: client.ID, ClientSecret: "wrong", CodeVerifier: testVerifier,
+	})
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_client" {
+		t.Fatalf("expected invalid_client, got %v", err)
+	}
+
+	_, err = s.Exchange(ctx, domain.TokenRequest{
+		GrantType: "authorization_code", Code: code, RedirectURI: req.RedirectURI,
+		ClientID: client.ID, ClientSecret: client.Secret, CodeVerifier: strings.Repeat("a", 43),
+	})
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" {
+		t.Fatalf("expected invalid_grant for wrong code_verifier, got %v", err)
+	}
+}
+
+func TestConsent_RejectsForeignTicket(t *testing.T) {
+	s, _ := newOIDCService(t)
+
+	// Токен другого назначения (например, challenge второго шага входа) не принимается
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "1", "purpose": "login_2fa", "exp": time.Now().Add(time.Minute).Unix(),
+	})
+	ticket, _ := forged.SignedString([]byte("supersecretkey"))
+	if _, err := s.Consent(context.Background(), ticket, true); !errors.Is(err, domain.ErrInvalidConsentTicket) {
+		t.Fatalf("expected ErrInvalidConsentTicket, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/pkg/kafka"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ExportSource — другой сервис, данные которого о пользователе входят в архив
+type ExportSource interface {
+	ExportUser(ctx context.Context, userID int) (json.RawMessage, error)
+}
+
+// UserDeletedPublisher сообщает другим сервисам об удалении аккаунта
+type UserDeletedPublisher interface {
+	SendUserDeleted(ctx context.Context, event kafka.UserDeletedEvent) error
+}
+
+// exportFile — файл архива и данные, которые в него пишутся в JSON
+type exportFile struct {
+	name string
+	data any
+}
+
+type namedSource struct {
+	name   string
+	source ExportSource
+}
+
+// deletedEventsBatch — сколько событий UserDeleted из outbox публикуется за один проход
+const deletedEventsBatch = 100
+
+// PrivacyService отвечает на запросы субъекта данных: выгружает всё, что marketplace
+// хранит о пользователе, и удаляет аккаунт с обезличиванием данных во всех сервисах.
+type PrivacyService struct {
+	users     repository.UserRepositoryInterface
+	privacy   repository.PrivacyRepositoryInterface
+	addresses repository.AddressRepositoryInterface
+	sessions  repository.SessionRepositoryInterface
+	sources   []namedSource
+	events    UserDeletedPublisher
+}
+
+func NewPrivacyService(users repository.UserRepositoryInterface, privacy repository.PrivacyRepositoryInterface,
+	addresses repository.AddressRepositoryInterface, sessions repository.SessionRepositoryInterface) *PrivacyService {
+	return &PrivacyService{users: users, privacy: privacy, addresses: addresses, sessions: sessions}
+}
+
+// WithExportSource добавляет в архив файл name.json с данными другого сервиса
+func (s *PrivacyService) WithExportSource(name string, source ExportSource) *PrivacyService {
+	s.sources = append(s.sources, namedSource{name: name, source: source})
+	return s
+}
+
+// WithEvents включает публикацию UserDeleted из outbox; без неё события копятся
+// в outbox и данные в других сервисах не удаляются
+func (s *PrivacyService) WithEvents(events UserDeletedPublisher) *PrivacyService {
+	s.events = events
+	return s
+}
+
+// Export собирает zip-архив с данными пользователя. Если какой-то сервис недоступен,
+// возвращается domain.ErrExportUnavailable: неполный архив не выдаётся.
+func (s *PrivacyService) Export(ctx context.Context, userID int) ([]byte, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := s.addresses.ListAddresses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessions.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	consents, err := s.privacy.ListConsents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []exportFile{
+		{"profile.json", user},
+		{"addresses.json", addresses},
+		{"sessions.json", sessions},
+		{"oauth_consents.json", consents},
+	}
+	for _, src := range s.sources {
+		data, err := src.source.ExportUser(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", domain.ErrExportUnavailable, src.name, err)
+		}
+		files = append(files, exportFile{src.name + ".json", data})
+	}
+
+	manifest := domain.ExportManifest{UserID: userID, GeneratedAt: time.Now().UTC()}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, f := range files {
+		if err := writeJSONFile(archive, f.name, f.data); err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, f.name)
+	}
+	if err := writeJSONFile(archive, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSONFile(archive *zip.Writer, name string, data any) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// DeleteAccount удаляет аккаунт после подтверждения паролем: персональные данные в
+// user-service обезличиваются сразу, остальные сервисы получают событие UserDeleted
+func (s *PrivacyService) DeleteAccount(ctx context.Context, userID int, password string) error {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return domain.ErrWrongPassword
+	}
+
+	// Событие UserDeleted пишется в outbox в той же транзакции, поэтому не теряется,
+	// даже если Kafka сейчас недоступна: его повторно отправит RunEventRelay
+	if err := s.privacy.AnonymizeUser(ctx, userID, accountKey(user.Email)); err != nil {
+		return err
+	}
+	log.Printf("Аккаунт пользователя %d удалён", userID)
+
+	if s.events == nil {
+		log.Printf("События не публикуются: UserDeleted для пользователя %d ждёт в outbox", userID)
+		return nil
+	}
+	if _, err := s.PublishDeletedEvents(ctx); err != nil {
+		log.Printf("UserDeleted для пользователя %d будет отправлено повторно: %v", userID, err)
+	}
+	return nil
+}
+
+// PublishDeletedEvents публикует накопившиеся в outbox события UserDeleted и
+// возвращает число отправленных
+func (s *PrivacyService) PublishDeletedEvents(ctx context.Context) (int, error) {
+	if s.events == nil {
+		return 0, nil
+	}
+	return s.privacy.PublishDeletedEvents(ctx, deletedEventsBatch, func(ctx context.Context, e domain.DeletedUserEvent) error {
+		return s.events.SendUserDeleted(ctx, kafka.UserDeletedEvent{UserID: int64(e.UserID), DeletedAt: e.DeletedAt})
+	})
+}
+
+// RunEventRelay раз в interval повторяет публикацию событий из outbox, пока не отменён ctx
+func (s *PrivacyService) RunEventRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := s.PublishDeletedEvents(ctx); err != nil {
+			log.Printf("Не удалось опубликовать UserDeleted из outbox: %v", err)
+		} else if sent > 0 {
+			log.Printf("Из outbox опубликовано событий UserDeleted: %d", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/pkg/kafka"
+)
+
+type staticExportSource struct {
+	data string
+	err  error
+}
+
+func (s staticExportSource) ExportUser(ctx context.Context, userID int) (json.RawMessage, error) {
+	return json.RawMessage(s.data), s.err
+}
+
+type mockDeletedEvents struct {
+	events []kafka.UserDeletedEvent
+	err    error
+}
+
+func (m *mockDeletedEvents) SendUserDeleted(ctx context.Context, event kafka.UserDeletedEvent) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	return files
+}
+
+func TestPrivacyExport_CollectsAllServices(t *testing.T) {
+	addresses := repositorytest.NewAddressRepo()
+	addresses.CreateAddress(context.Background(), &domain.Address{UserID: 1, Type: domain.AddressShipping, City: "Москва"})
+	privacy := service.NewPrivacyService(newPasswordRepo(t), &repositorytest.PrivacyRepo{}, addresses, repositorytest.NewSessionRepo()).
+		WithExportSource("cart", staticExportSource{data: `{"cart_items":[{"product_id":5}]}`}).
+		WithExportSource("orders", staticExportSource{data: `{"orders":[]}`})
+
+	data, err := privacy.Export(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	files := readArchive(t, data)
+	for _, name := range []string{"profile.json", "addresses.json", "sessions.json", "oauth_consents.json", "cart.json", "orders.json", "manifest.json"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("archive misses %s, has %v", name, len(files))
+		}
+	}
+
+	var profile map[string]any
+	if err := json.Unmarshal(files["profile.json"], &profile); err != nil || profile["email"] != "alex@email.com" {
+		t.Fatalf("unexpected profile %s", files["profile.json"])
+	}
+	if _, leaked := profile["password_hash"]; leaked {
+		t.Fatal("password hash must not be exported")
+	}
+	if !bytes.Contains(files["cart.json"], []byte(`"product_id": 5`)) {
+		t.Fatalf("unexpected cart export %s", files["cart.json"])
+	}
+}
+
+func TestPrivacyExport_FailsWhenServiceUnavailable(t *testing.T) {
+	privacy := service.NewPrivacyService(newPasswordRepo(t), &repositorytest.PrivacyRepo{}, repositorytest.NewAddressRepo(), repositorytest.NewSessionRepo()).
+		WithExportSource("orders", staticExportSource{err: errors.New("connection refused")})
+
+	if _, err := privacy.Export(context.Background(), 1); !errors.Is(err, domain.ErrExportUnavailable) {
+		t.Fatalf("expected ErrExportUnavailable, got %v", err)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	repo := &repositorytest.PrivacyRepo{}
+	events := &mockDeletedEvents{}
+	privacy := service.NewPrivacyService(newPasswordRepo(t), repo, repositorytest.NewAddressRepo(), repositorytest.NewSessionRepo()).WithEvents(events)
+	ctx := context.Background()
+
+	if err := privacy.DeleteAccount(ctx, 1, "wrong"); !errors.Is(err, domain.ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got %v", err)
+	}
+	if len(repo.Anonymized) != 0 || len(events.events) != 0 {
+		t.Fatal("account must not be deleted without the password")
+	}
+
+	if err := privacy.DeleteAccount(ctx, 1, "secret"); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	if len(repo.Anonymized) != 1 || repo.Anonymized[0] != 1 || repo.AttemptKeys[0] != "account:alex@email.com" {
+		t.Fatalf("expected user 1 to be anonymized, got %v %v", repo.Anonymized, repo.AttemptKeys)
+	}
+	if len(events.events) != 1 || events.events[0].UserID != 1 {
+		t.Fatalf("expected UserDeleted event for user 1, got %+v", events.events)
+	}
+}
+
+func TestDeleteAccount_KeepsEventUntilPublished(t *testing.T) {
+	repo := &repositorytest.PrivacyRepo{}
+	events := &mockDeletedEvents{err: errors.New("kafka unavailable")}
+	privacy := service.NewPrivacyService(newPasswordRepo(t), repo, repositorytest.NewAddressRepo(), repositorytest.NewSessionRepo()).WithEvents(events)
+	ctx := context.Background()
+
+	if err := privacy.DeleteAccount(ctx, 1, "secret"); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	if len(events.events) != 0 || len(repo.Outbox) != 1 {
+		t.Fatalf("expected UserDeleted to stay in the outbox, got %d pending", len(repo.Outbox))
+	}
+
+	events.err = nil
+	sent, err := privacy.PublishDeletedEvents(ctx)
+	if err != nil || sent != 1 {
+		t.Fatalf("PublishDeletedEvents: sent %d, err %v", sent, err)
+	}
+	if len(events.events) != 1 || events.events[0].UserID != 1 || len(repo.Outbox) != 0 {
+		t.Fatalf("expected UserDeleted for user 1 to be published, got %+v", events.events)
+	}
+}
+
+func TestDeleteAccount_WithoutEventsKeepsOutbox(t *testing.T) {
+	repo := &repositorytest.PrivacyRepo{}
+	privacy := service.NewPrivacyService(newPasswordRepo(t), repo, repositorytest.NewAddressRepo(), repositorytest.NewSessionRepo())
+
+	if err := privacy.DeleteAccount(context.Background(), 1, "secret"); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	if len(repo.Outbox) != 1 {
+		t.Fatalf("expected UserDeleted to wait in the outbox, got %d pending", len(repo.Outbox))
+	}
+}
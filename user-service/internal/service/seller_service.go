@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+)
+
+// SellerService — профили продавцов: заявка пользователя, модерация администратором
+// и права в каталоге для product-service и order-service
+type SellerService struct {
+	repo   repository.SellerRepositoryInterface
+	admins AdminChecker
+}
+
+func NewSellerService(repo repository.SellerRepositoryInterface, admins AdminChecker) *SellerService {
+	return &SellerService{repo: repo, admins: admins}
+}
+
+// Apply создаёт профиль продавца в статусе pending; выставлять товары можно после одобрения
+func (s *SellerService) Apply(ctx context.Context, userID int, displayName, description string) (domain.Seller, error) {
+	seller := domain.Seller{
+		UserID:      userID,
+		DisplayName: strings.TrimSpace(displayName),
+		Description: strings.TrimSpace(description),
+	}
+	if err := validateSeller(seller); err != nil {
+		return domain.Seller{}, err
+	}
+	if err := s.repo.CreateSeller(ctx, &seller); err != nil {
+		return domain.Seller{}, err
+	}
+	log.Printf("Пользователь %d подал заявку продавца %d", userID, seller.ID)
+	return seller, nil
+}
+
+// GetMine возвращает профиль продавца текущего пользователя
+func (s *SellerService) GetMine(ctx context.Context, userID int) (domain.Seller, error) {
+	return s.repo.GetSellerByUserID(ctx, userID)
+}
+
+// Get возвращает витрину продавца; профили, которые ещё не одобрены или приостановлены,
+// видны только администраторам
+func (s *SellerService) Get(ctx context.Context, requesterID, id int) (domain.Seller, error) {
+	seller, err := s.repo.GetSeller(ctx, id)
+	if err != nil {
+		return domain.Seller{}, err
+	}
+	if seller.Status != domain.SellerActive && seller.UserID != requesterID {
+		if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+			return domain.Seller{}, domain.ErrSellerNotFound
+		}
+	}
+	return seller, nil
+}
+
+// UpdateMine меняет только переданные поля витрины
+func (s *SellerService) UpdateMine(ctx context.Context, userID int, upd domain.SellerUpdate) (domain.Seller, error) {
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if err != nil {
+		return domain.Seller{}, err
+	}
+	if upd.DisplayName != nil {
+		seller.DisplayName = strings.TrimSpace(*upd.DisplayName)
+	}
+	if upd.Description != nil {
+		seller.Description = strings.TrimSpace(*upd.Description)
+	}
+	if err := validateSeller(seller); err != nil {
+		return domain.Seller{}, err
+	}
+	if err := s.repo.UpdateSeller(ctx, &seller); err != nil {
+		return domain.Seller{}, err
+	}
+	return seller, nil
+}
+
+// List возвращает продавцов для модерации; доступно только администраторам
+func (s *SellerService) List(ctx context.Context, requesterID int, status string, limit, offset int) ([]domain.Seller, error) {
+	if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+		return nil, err
+	}
+	if status != "" && !slices.Contains(domain.SellerStatuses, status) {
+		return nil, fmt.Errorf("%w: unknown status %q", domain.ErrInvalidFilter, status)
+	}
+	var err error
+	if limit, offset, err = normalizePage(limit, offset); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSellers(ctx, status, limit, offset)
+}
+
+// SetStatus одобряет, приостанавливает или возвращает на рассмотрение продавца.
+// Приостановленный продавец сразу теряет доступ к своему каталогу и заказам.
+func (s *SellerService) SetStatus(ctx context.Context, requesterID, id int, status string) (domain.Seller, error) {
+	if err := s.admins.RequireAdmin(ctx, requesterID); err != nil {
+		return domain.Seller{}, err
+	}
+	if !slices.Contains(domain.SellerStatuses, status) {
+		return domain.Seller{}, fmt.Errorf("%w: unknown status %q", domain.ErrInvalidSeller, status)
+	}
+	seller, err := s.repo.SetSellerStatus(ctx, requesterID, id, status)
+	if err != nil {
+		return domain.Seller{}, err
+	}
+	log.Printf("Администратор %d перевёл продавца %d в статус %s", requesterID, id, status)
+	return seller, nil
+}
+
+// Access возвращает права пользователя для других сервисов: администратор управляет
+// любыми товарами, действующий продавец — только своими
+func (s *SellerService) Access(ctx context.Context, userID int) (domain.SellerAccess, error) {
+	access := domain.SellerAccess{UserID: userID}
+
+	err := s.admins.RequireAdmin(ctx, userID)
+	switch {
+	case err == nil:
+		access.Admin = true
+	case !errors.Is(err, domain.ErrForbidden) && !errors.Is(err, domain.ErrTwoFactorRequired):
+		return domain.SellerAccess{}, err
+	}
+
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrSellerNotFound) {
+		return access, nil
+	}
+	if err != nil {
+		return domain.SellerAccess{}, err
+	}
+	if seller.Status == domain.SellerActive {
+		access.SellerID = seller.ID
+	}
+	return access, nil
+}
+
+func validateSeller(seller domain.Seller) error {
+	if seller.DisplayName == "" {
+		return fmt.Errorf("%w: display_name is required", domain.ErrInvalidSeller)
+	}
+	if utf8.RuneCountInString(seller.DisplayName) > domain.MaxSellerNameLength {
+		return fmt.Errorf("%w: display_name is longer than %d characters", domain.ErrInvalidSeller, domain.MaxSellerNameLength)
+	}
+	if utf8.RuneCountInString(seller.Description) > domain.MaxSellerDescriptionLength {
+		return fmt.Errorf("%w: description is longer than %d characters", domain.ErrInvalidSeller, domain.MaxSellerDescriptionLength)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+)
+
+func TestSellerApplyAndModeration(t *testing.T) {
+	ctx := context.Background()
+	repo := repositorytest.NewSellerRepo()
+	sellers := service.NewSellerService(repo, adminOnly{})
+
+	if _, err := sellers.Apply(ctx, 2, "   ", ""); !errors.Is(err, domain.ErrInvalidSeller) {
+		t.Fatalf("expected ErrInvalidSeller for empty name, got %v", err)
+	}
+	seller, err := sellers.Apply(ctx, 2, "  Лавка Алекса ", "Книги")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seller.DisplayName != "Лавка Алекса" || seller.Status != domain.SellerPending {
+		t.Fatalf("unexpected seller %+v", seller)
+	}
+	if _, err := sellers.Apply(ctx, 2, "Другая лавка", ""); !errors.Is(err, domain.ErrSellerExists) {
+		t.Fatalf("expected ErrSellerExists, got %v", err)
+	}
+
+	// Заявка на рассмотрении не даёт прав в каталоге и не видна другим пользователям
+	access, err := sellers.Access(ctx, 2)
+	if err != nil || access.SellerID != 0 || access.Admin {
+		t.Fatalf("pending seller must have no access, got %+v, %v", access, err)
+	}
+	if _, err := sellers.Get(ctx, 3, seller.ID); !errors.Is(err, domain.ErrSellerNotFound) {
+		t.Fatalf("pending seller must be hidden, got %v", err)
+	}
+
+	if _, err := sellers.SetStatus(ctx, 2, seller.ID, domain.SellerActive); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for non-admin, got %v", err)
+	}
+	if _, err := sellers.SetStatus(ctx, 1, seller.ID, "approved"); !errors.Is(err, domain.ErrInvalidSeller) {
+		t.Fatalf("expected ErrInvalidSeller for unknown status, got %v", err)
+	}
+	if _, err := sellers.SetStatus(ctx, 1, seller.ID, domain.SellerActive); err != nil {
+		t.Fatal(err)
+	}
+
+	access, err = sellers.Access(ctx, 2)
+	if err != nil || access.SellerID != seller.ID {
+		t.Fatalf("active seller must get access, got %+v, %v", access, err)
+	}
+	if _, err := sellers.Get(ctx, 3, seller.ID); err != nil {
+		t.Fatalf("active seller must be visible, got %v", err)
+	}
+
+	if _, err := sellers.SetStatus(ctx, 1, seller.ID, domain.SellerSuspended); err != nil {
+		t.Fatal(err)
+	}
+	if access, _ = sellers.Access(ctx, 2); access.SellerID != 0 {
+		t.Fatalf("suspended seller must lose access, got %+v", access)
+	}
+
+	if access, _ = sellers.Access(ctx, 1); !access.Admin || access.SellerID != 0 {
+		t.Fatalf("expected admin access without seller, got %+v", access)
+	}
+}
+
+func TestSellerUpdateMine(t *testing.T) {
+	ctx := context.Background()
+	sellers := service.NewSellerService(repositorytest.NewSellerRepo(), adminOnly{})
+
+	if _, err := sellers.UpdateMine(ctx, 2, domain.SellerUpdate{}); !errors.Is(err, domain.ErrSellerNotFound) {
+		t.Fatalf("expected ErrSellerNotFound, got %v", err)
+	}
+	if _, err := sellers.Apply(ctx, 2, "Лавка", "Книги"); err != nil {
+		t.Fatal(err)
+	}
+
+	description := "Книги и журналы"
+	seller, err := sellers.UpdateMine(ctx, 2, domain.SellerUpdate{Description: &description})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seller.DisplayName != "Лавка" || seller.Description != description {
+		t.Fatalf("only description must change, got %+v", seller)
+	}
+
+	long := strings.Repeat("я", domain.MaxSellerNameLength+1)
+	if _, err := sellers.UpdateMine(ctx, 2, domain.SellerUpdate{DisplayName: &long}); !errors.Is(err, domain.ErrInvalidSeller) {
+		t.Fatalf("expected ErrInvalidSeller for long name, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+)
+
+// maxUserAgentLength ограничивает сохраняемый User-Agent: его присылает клиент
+const maxUserAgentLength = 512
+
+// SessionService ведёт сессии пользователей: создаёт их при входе, показывает
+// пользователю и отзывает. Реализует SessionRevoker для смены и сброса пароля.
+type SessionService struct {
+	repo repository.SessionRepositoryInterface
+}
+
+func NewSessionService(repo repository.SessionRepositoryInterface) *SessionService {
+	return &SessionService{repo: repo}
+}
+
+// StartSession записывает новую сессию и возвращает её ID для токена
+func (s *SessionService) StartSession(ctx context.Context, userID int, client domain.ClientInfo, ttl time.Duration) (string, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := time.Now()
+	err = s.repo.CreateSession(ctx, domain.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	})
+	return id, err
+}
+
+// ListSessions возвращает действующие сессии пользователя; currentID отмечает текущую
+func (s *SessionService) ListSessions(ctx context.Context, userID int, currentID string) ([]domain.Session, error) {
+	sessions, err := s.repo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession завершает одну сессию пользователя, в том числе текущую (выход)
+func (s *SessionService) RevokeSession(ctx context.Context, userID int, id string) error {
+	if id == "" {
+		return domain.ErrSessionNotFound
+	}
+	return s.repo.RevokeSession(ctx, userID, id)
+}
+
+// RevokeSessions завершает все сессии пользователя
+func (s *SessionService) RevokeSessions(ctx context.Context, userID int64) error {
+	return s.repo.RevokeUserSessions(ctx, int(userID))
+}
+
+// CheckSession вызывается api-gateway для каждого токена: действует ли сессия
+// и подтверждён ли email пользователя
+func (s *SessionService) CheckSession(ctx context.Context, userID int, id string) (domain.SessionStatus, error) {
+	if id == "" {
+		return domain.SessionStatus{}, errors.New("session id is required")
+	}
+	return s.repo.TouchSession(ctx, userID, id)
+}
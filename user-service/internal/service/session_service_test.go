@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository/repositorytest"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func sessionIDFromToken(t *testing.T, token string) string {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	}); err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	sid, _ := claims["sid"].(string)
+	return sid
+}
+
+func TestLogin_RecordsSession(t *testing.T) {
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	sessions := service.NewSessionService(repositorytest.NewSessionRepo())
+	authService := service.NewAuthService(newPasswordRepo(t)).WithSessions(sessions)
+
+	ctx := context.Background()
+	client := domain.ClientInfo{IP: "10.0.0.1", UserAgent: "Firefox"}
+	result, err := authService.LoginWithCart(ctx, "alex@email.com", "secret", "", client)
+	if err != nil {
+		t.Fatalf("LoginWithCart: %v", err)
+	}
+	sid := sessionIDFromToken(t, result.Token)
+	if sid == "" {
+		t.Fatal("expected sid claim in token")
+	}
+
+	list, err := sessions.ListSessions(ctx, 1, sid)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("expected one session, got %v %v", list, err)
+	}
+	if !list[0].Current || list[0].IP != "10.0.0.1" || list[0].UserAgent != "Firefox" {
+		t.Fatalf("unexpected session %+v", list[0])
+	}
+	if status, _ := sessions.CheckSession(ctx, 1, sid); !status.Active {
+		t.Fatal("expected session to be active")
+	}
+}
+
+func TestChangePassword_ReplacesSessions(t *testing.T) {
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	sessions := service.NewSessionService(repositorytest.NewSessionRepo())
+	authService := service.NewAuthService(newPasswordRepo(t)).WithSessions(sessions).WithSessionRevoker(sessions)
+
+	ctx := context.Background()
+	oldToken, err := authService.Login(ctx, "alex@email.com", "secret")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	newToken, err := authService.ChangePassword(ctx, 1, "secret", "newsecret1", domain.ClientInfo{})
+	if err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+
+	if status, _ := sessions.CheckSession(ctx, 1, sessionIDFromToken(t, oldToken)); status.Active {
+		t.Fatal("expected old session to be revoked")
+	}
+	if status, _ := sessions.CheckSession(ctx, 1, sessionIDFromToken(t, newToken)); !status.Active {
+		t.Fatal("expected the token returned by ChangePassword to stay signed in")
+	}
+}
+
+func TestRevokeSession_OnlyOwnSessions(t *testing.T) {
+	sessions := service.NewSessionService(repositorytest.NewSessionRepo())
+	ctx := context.Background()
+
+	sid, err := sessions.StartSession(ctx, 1, domain.ClientInfo{}, service.TokenTTL)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sessions.RevokeSession(ctx, 2, sid); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound for another user's session, got %v", err)
+	}
+	if err := sessions.RevokeSession(ctx, 1, sid); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if status, _ := sessions.CheckSession(ctx, 1, sid); status.Active {
+		t.Fatal("expected session to be revoked")
+	}
+	if err := sessions.RevokeSession(ctx, 1, sid); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound on second revoke, got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/totp"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// loginChallengePurpose отличает challenge-токен второго шага входа от токена входа
+	loginChallengePurpose = "login_2fa"
+	loginChallengeTTL     = 5 * time.Minute
+)
+
+var errTwoFactorNotConfigured = errors.New("two-factor authentication is not configured")
+
+// WithTwoFactor включает TOTP 2FA; issuer отображается в приложении-аутентификаторе
+func (s *AuthService) WithTwoFactor(repo repository.TwoFactorRepositoryInterface, issuer string) *AuthService {
+	s.twoFactor = repo
+	s.totpIssuer = issuer
+	return s
+}
+
+// issueChallenge выдаёт короткоживущий токен второго шага входа. В нём нет user_id,
+// поэтому gateway не примет его вместо токена входа.
+func issueChallenge(user domain.User, cartToken string) (string, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return "", errors.New("jwt secret not ser")
+	}
+
+	claims := jwt.MapClaims{
+		"sub":     strconv.Itoa(user.ID),
+		"purpose": loginChallengePurpose,
+		"exp":     time.Now().Add(loginChallengeTTL).Unix(),
+	}
+	if cartToken != "" {
+		claims["cart_token"] = cartToken
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// CompleteTwoFactorLogin завершает вход кодом из приложения или резервным кодом.
+// Неверные коды учитываются в ограничении попыток входа наравне с паролем.
+func (s *AuthService) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string, client domain.ClientInfo) (domain.LoginResult, error) {
+	if s.twoFactor == nil {
+		return domain.LoginResult{}, errTwoFactorNotConfigured
+	}
+
+	userID, claims, err := parsePurposeToken(challengeToken, loginChallengePurpose)
+	if err != nil {
+		return domain.LoginResult{}, domain.ErrInvalidChallenge
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.LoginResult{}, domain.ErrInvalidChallenge
+	}
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+
+	if s.throttle != nil {
+		if err := s.throttle.check(ctx, user.Email, client.IP); err != nil {
+			return domain.LoginResult{}, err
+		}
+	}
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, domain.ErrInvalidTwoFactorCode) && s.throttle != nil {
+			s.throttle.fail(ctx, user.Email, client.IP, user.ID)
+		}
+		return domain.LoginResult{}, err
+	}
+	if s.throttle != nil {
+		s.throttle.success(ctx, user.Email)
+	}
+
+	cartToken, _ := claims["cart_token"].(string)
+	return s.completeLogin(ctx, user, cartToken, client)
+}
+
+// verifySecondFactor принимает текущий TOTP-код (однократно) или неиспользованный резервный код
+func (s *AuthService) verifySecondFactor(ctx context.Context, user domain.User, code string) error {
+	if !user.TwoFactorEnabled {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now()); ok {
+		fresh, err := s.twoFactor.UseTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return domain.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	used, err := s.twoFactor.UseRecoveryCode(ctx, user.ID, hashToken(normalized))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// EnrollTwoFactor создаёт новый секрет TOTP. 2FA начинает действовать после ConfirmTwoFactor
+func (s *AuthService) EnrollTwoFactor(ctx context.Context, userID int) (domain.TwoFactorEnrollment, error) {
+	if s.twoFactor == nil {
+		return domain.TwoFactorEnrollment{}, errTwoFactorNotConfigured
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.TwoFactorEnrollment{}, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+	if err := s.twoFactor.SetTOTPSecret(ctx, userID, secret); err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+	return domain.TwoFactorEnrollment{Secret: secret, URI: totp.URI(s.totpIssuer, user.Email, secret)}, nil
+}
+
+// ConfirmTwoFactor включает 2FA по первому коду из приложения и возвращает резервные коды.
+// Коды показываются один раз: в базе остаются только их хеши.
+func (s *AuthService) ConfirmTwoFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	if s.twoFactor == nil {
+		return nil, errTwoFactorNotConfigured
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, domain.ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactor.EnableTwoFactor(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor отключает 2FA по действующему коду. Если роль пользователя
+// обязана использовать 2FA, отключить её нельзя.
+func (s *AuthService) DisableTwoFactor(ctx context.Context, userID int, code string) error {
+	if s.twoFactor == nil {
+		return errTwoFactorNotConfigured
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	required, err := s.twoFactor.RequiresTwoFactor(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return domain.ErrTwoFactorRequired
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+	return s.twoFactor.DisableTwoFactor(ctx, userID)
+}
+
+// newRecoveryCodes генерирует резервные коды вида xxxxx-xxxxx и их хеши
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, domain.RecoveryCodeCount)
+	hashes := make([]string, 0, domain.RecoveryCodeCount)
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for range domain.RecoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode убирает разделители и приводит код к нижнему регистру
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
@@ -0,0 +1,323 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/totp"
+	"github.com/jackc/pgx/v5"
+)
+
+// mockTwoFactorRepo хранит состояние 2FA прямо в пользователях mockUserRepo
+type mockTwoFactorRepo struct {
+	users         *mockUserRepo
+	lastStep      map[int]int64
+	recoveryCodes map[int]map[string]bool
+	policies      map[string]bool
+}
+
+func newMockTwoFactorRepo(users *mockUserRepo) *mockTwoFactorRepo {
+	return &mockTwoFactorRepo{
+		users:         users,
+		lastStep:      make(map[int]int64),
+		recoveryCodes: make(map[int]map[string]bool),
+		policies:      map[string]bool{domain.RoleAdmin: true},
+	}
+}
+
+func (m *mockTwoFactorRepo) update(userID int, fn func(*domain.User)) error {
+	for email, u := range m.users.users {
+		if u.ID == userID {
+			fn(&u)
+			m.users.users[email] = u
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *mockTwoFactorRepo) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	return m.update(userID, func(u *domain.User) { u.TOTPSecret = secret })
+}
+
+func (m *mockTwoFactorRepo) EnableTwoFactor(ctx context.Context, userID int, step int64, hashes []string) error {
+	m.lastStep[userID] = step
+	codes := make(map[string]bool)
+	for _, h := range hashes {
+		codes[h] = true
+	}
+	m.recoveryCodes[userID] = codes
+	return m.update(userID, func(u *domain.User) { u.TwoFactorEnabled = true })
+}
+
+func (m *mockTwoFactorRepo) DisableTwoFactor(ctx context.Context, userID int) error {
+	delete(m.recoveryCodes, userID)
+	return m.update(userID, func(u *domain.User) {
+		u.TwoFactorEnabled = false
+		u.TOTPSecret = ""
+	})
+}
+
+func (m *mockTwoFactorRepo) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	if last, ok := m.lastStep[userID]; ok && step <= last {
+		return false, nil
+	}
+	m.lastStep[userID] = step
+	return true, nil
+}
+
+func (m *mockTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	if !m.recoveryCodes[userID][codeHash] {
+		return false, nil
+	}
+	delete(m.recoveryCodes[userID], codeHash)
+	return true, nil
+}
+
+func (m *mockTwoFactorRepo) RequiresTwoFactor(ctx context.Context, role string) (bool, error) {
+	return m.policies[role], nil
+}
+
+func (m *mockTwoFactorRepo) SetTwoFactorPolicy(ctx context.Context, role string, required bool) error {
+	m.policies[role] = required
+	return nil
+}
+
+// enrollTwoFactor подключает 2FA пользователю и возвращает секрет и резервные коды.
+// Код подтверждения берётся из прошлого шага, чтобы текущий оставался свободным для входа.
+func enrollTwoFactor(t *testing.T, s *service.AuthService, userID int) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := s.EnrollTwoFactor(ctx, userID)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor: %v", err)
+	}
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now())-1)
+	codes, err := s.ConfirmTwoFactor(ctx, userID, code)
+	if err != nil {
+		t.Fatalf("ConfirmTwoFactor: %v", err)
+	}
+	return enrollment.Secret, codes
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("totp.Code: %v", err)
+	}
+	return code
+}
+
+func TestEnrollTwoFactor_ConfirmReturnsRecoveryCodes(t *testing.T) {
+	repo := newPasswordRepo(t)
+	s := service.NewAuthService(repo).WithTwoFactor(newMockTwoFactorRepo(repo), "Marketplace")
+
+	enrollment, err := s.EnrollTwoFactor(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor: %v", err)
+	}
+	if enrollment.Secret == "" || enrollment.URI == "" {
+		t.Fatalf("expected secret and otpauth uri, got %+v", enrollment)
+	}
+
+	if _, err := s.ConfirmTwoFactor(context.Background(), 1, "000000"); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected ErrInvalidTwoFactorCode, got %v", err)
+	}
+
+	codes, err := s.ConfirmTwoFactor(context.Background(), 1, currentCode(t, enrollment.Secret))
+	if err != nil {
+		t.Fatalf("ConfirmTwoFactor: %v", err)
+	}
+	if len(codes) != domain.RecoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", domain.RecoveryCodeCount, len(codes))
+	}
+	if !repo.users["alex@email.com"].TwoFactorEnabled {
+		t.Fatal("expected two-factor to be enabled")
+	}
+
+	if _, err := s.ConfirmTwoFactor(context.Background(), 1, currentCode(t, enrollment.Secret)); !errors.Is(err, domain.ErrTwoFactorEnabled) {
+		t.Fatalf("expected ErrTwoFactorEnabled on second confirm, got %v", err)
+	}
+}
+
+func TestLogin_TwoFactorChallengeWithTOTP(t *testing.T) {
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	repo := newPasswordRepo(t)
+	s := service.NewAuthService(repo).WithTwoFactor(newMockTwoFactorRepo(repo), "Marketplace")
+	secret, _ := enrollTwoFactor(t, s, 1)
+	ctx := context.Background()
+
+	result, err := s.LoginWithCart(ctx, "alex@email.com", "secret", "", domain.ClientInfo{})
+	if err != nil {
+		t.Fatalf("LoginWithCart: %v", err)
+	}
+	if result.Token != "" || !result.TwoFactorRequired || result.ChallengeToken == "" {
+		t.Fatalf("expected challenge instead of token, got %+v", result)
+	}
+
+	if _, err := s.CompleteTwoFactorLogin(ctx, "garbage", currentCode(t, secret), domain.ClientInfo{}); !errors.Is(err, domain.ErrInvalidChallenge) {
+		t.Fatalf("expected ErrInvalidChallenge, got %v", err)
+	}
+	if _, err := s.CompleteTwoFactorLogin(ctx, result.ChallengeToken, "000000", domain.ClientInfo{}); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected ErrInvalidTwoFactorCode, got %v", err)
+	}
+
+	code := currentCode(t, secret)
+	final, err := s.CompleteTwoFactorLogin(ctx, result.ChallengeToken, code, domain.ClientInfo{})
+	if err != nil {
+		t.Fatalf("CompleteTwoFactorLogin: %v", err)
+	}
+	if final.Token == "" {
+		t.Fatal("expected token after second factor")
+	}
+
+	// Повторное использование того же кода отклоняется
+	if _, err := s.CompleteTwoFactorLogin(ctx, result.ChallengeToken, code, domain.ClientInfo{}); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected replayed code to be rejected, got %v", err)
+	}
+}
+
+func TestLogin_TwoFactorRecoveryCodeIsSingleUse(t *testing.T) {
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	repo := newPasswordRepo(t)
+	s := service.NewAuthService(repo).WithTwoFactor(newMockTwoFactorRepo(repo), "Marketplace")
+	_, codes := enrollTwoFactor(t, s, 1)
+	ctx := context.Background()
+
+	result, err := s.LoginWithCart(ctx, "alex@email.com", "secret", "", domain.ClientInfo{})
+	if err != nil {
+		t.Fatalf("LoginWithCart: %v", err)
+	}
+
+	// Регистр и пробелы в резервном коде не важны
+	final, err := s.CompleteTwoFactorLogin(ctx, result.ChallengeToken, " "+codes[0]+" ", domain.ClientInfo{})
+	if err != nil || final.Token == "" {
+		t.Fatalf("expected login with recovery code, got %+v, %v", final, err)
+	}
+	if _, err := s.CompleteTwoFactorLogin(ctx, result.ChallengeToken, codes[0], domain.ClientInfo{}); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected used recovery code to be rejected, got %v", err)
+	}
+}
+
+func TestLogin_PlainLoginRefusedWhenTwoFactorEnabled(t *testing.T) {
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	repo := newPasswordRepo(t)
+	s := service.NewAuthService(repo).WithTwoFactor(newMockTwoFactorRepo(repo), "Marketplace")
+	enrollTwoFactor(t, s, 1)
+
+	if _, err := s.Login(context.Background(), "alex@email.com", "secret"); !errors.Is(err, domain.ErrTwoFactorRequired) {
+		t.Fatalf("expected ErrTwoFactorRequired, got %v", err)
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	repo := newPasswordRepo(t)
+	twoFactor := newMockTwoFactorRepo(repo)
+	s := service.NewAuthService(repo).WithTwoFactor(twoFactor, "Marketplace")
+	secret, _ := enrollTwoFactor(t, s, 1)
+	ctx := context.Background()
+
+	if err := s.DisableTwoFactor(ctx, 1, "000000"); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected ErrInvalidTwoFactorCode, got %v", err)
+	}
+
+	twoFactor.policies[domain.RoleUser] = true
+	if err := s.DisableTwoFactor(ctx, 1, currentCode(t, secret)); !errors.Is(err, domain.ErrTwoFactorRequired) {
+		t.Fatalf("expected ErrTwoFactorRequired for mandatory role, got %v", err)
+	}
+
+	twoFactor.policies[domain.RoleUser] = false
+	if err := s.DisableTwoFactor(ctx, 1, currentCode(t, secret)); err != nil {
+		t.Fatalf("DisableTwoFactor: %v", err)
+	}
+	if repo.users["alex@email.com"].TwoFactorEnabled {
+		t.Fatal("expected two-factor to be disabled")
+	}
+}
+
+func TestLogin_SetupRequiredForMandatoryRole(t *testing.T) {
+	os.Setenv("JWT_SECRET", "supersecretkey")
+	defer os.Unsetenv("JWT_SECRET")
+
+	repo := newPasswordRepo(t)
+	u := repo.users["alex@email.com"]
+	u.Role = domain.RoleAdmin
+	repo.users["alex@email.com"] = u
+	s := service.NewAuthService(repo).WithTwoFactor(newMockTwoFactorRepo(repo), "Marketplace")
+
+	result, err := s.LoginWithCart(context.Background(), "alex@email.com", "secret", "", domain.ClientInfo{})
+	if err != nil {
+		t.Fatalf("LoginWithCart: %v", err)
+	}
+	if result.Token == "" || !result.TwoFactorSetupRequired {
+		t.Fatalf("expected token with setup hint, got %+v", result)
+	}
+}
+
+func TestGetUser_AdminWithoutTwoFactorIsRefused(t *testing.T) {
+	repo := newProfileRepo()
+	twoFactor := newMockTwoFactorRepo(repo)
+	s := service.NewUserService(repo).WithTwoFactorPolicy(twoFactor)
+
+	if _, err := s.GetUser(context.Background(), 3, 1); !errors.Is(err, domain.ErrTwoFactorRequired) {
+		t.Fatalf("expected ErrTwoFactorRequired, got %v", err)
+	}
+
+	admin := repo.users["admin@email.com"]
+	admin.TwoFactorEnabled = true
+	repo.users["admin@email.com"] = admin
+	if _, err := s.GetUser(context.Background(), 3, 1); err != nil {
+		t.Fatalf("expected admin with 2FA to pass, got %v", err)
+	}
+}
+
+func TestSetTwoFactorPolicy(t *testing.T) {
+	repo := newProfileRepo()
+	admin := repo.users["admin@email.com"]
+	admin.TwoFactorEnabled = true
+	repo.users["admin@email.com"] = admin
+	twoFactor := newMockTwoFactorRepo(repo)
+	s := service.NewUserService(repo).WithTwoFactorPolicy(twoFactor)
+	ctx := context.Background()
+
+	if err := s.SetTwoFactorPolicy(ctx, 1, domain.RoleUser, true); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for non-admin, got %v", err)
+	}
+	if err := s.SetTwoFactorPolicy(ctx, 3, domain.RoleAdmin, false); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected admins to be unable to drop their own requirement, got %v", err)
+	}
+	if err := s.SetTwoFactorPolicy(ctx, 3, domain.RoleUser, true); err != nil {
+		t.Fatalf("SetTwoFactorPolicy: %v", err)
+	}
+	if !twoFactor.policies[domain.RoleUser] {
+		t.Fatal("expected policy for user role to be stored")
+	}
+}
+
+func TestAuthenticate_RequiresSecondFactor(t *testing.T) {
+	repo := newPasswordRepo(t)
+	s := service.NewAuthService(repo).WithTwoFactor(newMockTwoFactorRepo(repo), "Marketplace")
+	secret, _ := enrollTwoFactor(t, s, 1)
+	ctx := context.Background()
+
+	if _, err := s.Authenticate(ctx, "alex@email.com", "secret", "", ""); !errors.Is(err, domain.ErrTwoFactorRequired) {
+		t.Fatalf("expected ErrTwoFactorRequired, got %v", err)
+	}
+	user, err := s.Authenticate(ctx, "alex@email.com", "secret", currentCode(t, secret), "")
+	if err != nil || user.ID != 1 {
+		t.Fatalf("expected user 1, got %+v %v", user, err)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	phonePattern  = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+	localePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+	// phoneSeparators — символы, которые допускаются при вводе телефона и отбрасываются при сохранении
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+)
+
+// EmailVerifier отправляет ссылку подтверждения email
+type EmailVerifier interface {
+	SendVerification(ctx context.Context, user domain.User) error
+}
+
+// TwoFactorPolicy — какие роли обязаны использовать 2FA
+type TwoFactorPolicy interface {
+	RequiresTwoFactor(ctx context.Context, role string) (bool, error)
+	SetTwoFactorPolicy(ctx context.Context, role string, required bool) error
+}
+
+// UserService — просмотр и изменение профилей пользователей
+type UserService struct {
+	userRepo        repository.UserRepositoryInterface
+	verifier        EmailVerifier
+	twoFactorPolicy TwoFactorPolicy
+	audit           AuditRecorder
+}
+
+func NewUserService(userRepo repository.UserRepositoryInterface) *UserService {
+	return &UserService{userRepo: userRepo}
+}
+
+// WithEmailVerifier включает отправку ссылки подтверждения при смене email
+func (s *UserService) WithEmailVerifier(v EmailVerifier) *UserService {
+	s.verifier = v
+	return s
+}
+
+// GetProfile возвращает профиль пользователя
+func (s *UserService) GetProfile(ctx context.Context, id int) (domain.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return user, err
+}
+
+// WithTwoFactorPolicy включает обязательную 2FA для ролей: пока администратор
+// не подключил её, административные действия ему недоступны
+func (s *UserService) WithTwoFactorPolicy(p TwoFactorPolicy) *UserService {
+	s.twoFactorPolicy = p
+	return s
+}
+
+// WithAuditLog записывает изменения политики 2FA в журнал действий администраторов
+func (s *UserService) WithAuditLog(audit AuditRecorder) *UserService {
+	s.audit = audit
+	return s
+}
+
+// RequireAdmin проверяет, что запрос делает администратор, выполнивший требования 2FA
+func (s *UserService) RequireAdmin(ctx context.Context, requesterID int) error {
+	requester, err := s.GetProfile(ctx, requesterID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return domain.ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+	if requester.Role != domain.RoleAdmin {
+		return domain.ErrForbidden
+	}
+
+	if s.twoFactorPolicy != nil && !requester.TwoFactorEnabled {
+		required, err := s.twoFactorPolicy.RequiresTwoFactor(ctx, requester.Role)
+		if err != nil {
+			return err
+		}
+		if required {
+			return domain.ErrTwoFactorRequired
+		}
+	}
+	return nil
+}
+
+// GetUser возвращает профиль любого пользователя; доступно только администраторам
+func (s *UserService) GetUser(ctx context.Context, requesterID, id int) (domain.User, error) {
+	if err := s.RequireAdmin(ctx, requesterID); err != nil {
+		return domain.User{}, err
+	}
+	return s.GetProfile(ctx, id)
+}
+
+// SetTwoFactorPolicy задаёт, обязана ли роль использовать 2FA
+func (s *UserService) SetTwoFactorPolicy(ctx context.Context, requesterID int, role string, required bool) error {
+	if s.twoFactorPolicy == nil {
+		return errors.New("two-factor authentication is not configured")
+	}
+	if err := s.RequireAdmin(ctx, requesterID); err != nil {
+		return err
+	}
+	if role == "" {
+		return fmt.Errorf("%w: role can't be empty", domain.ErrInvalidProfile)
+	}
+	// Администратор не может снять требование со своей роли и тем самым обойти его
+	if role == domain.RoleAdmin && !required {
+		return domain.ErrForbidden
+	}
+	if err := s.twoFactorPolicy.SetTwoFactorPolicy(ctx, role, required); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, requesterID, domain.AuditTwoFactorPolicy, nil, map[string]any{"role": role, "required": required})
+	return nil
+}
+
+// UpdateProfile применяет частичное изменение профиля. Новый email требует
+// повторного подтверждения, поэтому признак подтверждения сбрасывается.
+func (s *UserService) UpdateProfile(ctx context.Context, id int, upd domain.ProfileUpdate) (domain.User, error) {
+	user, err := s.GetProfile(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if upd.Name != nil {
+		name := strings.TrimSpace(*upd.Name)
+		if name == "" {
+			return domain.User{}, fmt.Errorf("%w: name can't be empty", domain.ErrInvalidProfile)
+		}
+		user.Name = name
+	}
+	emailChanged := false
+	if upd.Email != nil {
+		addr, err := mail.ParseAddress(strings.TrimSpace(*upd.Email))
+		if err != nil || addr.Name != "" {
+			return domain.User{}, fmt.Errorf("%w: invalid email", domain.ErrInvalidProfile)
+		}
+		if !strings.EqualFold(addr.Address, user.Email) {
+			user.Email = addr.Address
+			user.EmailVerified = false
+			emailChanged = true
+		}
+	}
+	if upd.Phone != nil {
+		phone := phoneSeparators.Replace(strings.TrimSpace(*upd.Phone))
+		if phone != "" && !phonePattern.MatchString(phone) {
+			return domain.User{}, fmt.Errorf("%w: invalid phone", domain.ErrInvalidProfile)
+		}
+		user.Phone = phone
+	}
+	if upd.Locale != nil {
+		if !localePattern.MatchString(*upd.Locale) {
+			return domain.User{}, fmt.Errorf("%w: invalid locale", domain.ErrInvalidProfile)
+		}
+		user.Locale = *upd.Locale
+	}
+
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.User{}, domain.ErrUserNotFound
+		}
+		return domain.User{}, err
+	}
+
+	if emailChanged && s.verifier != nil {
+		if err := s.verifier.SendVerification(ctx, user); err != nil {
+			log.Printf("Не удалось отправить подтверждение email пользователю %d: %v", user.ID, err)
+		}
+	}
+	return s.GetProfile(ctx, id)
+}
@@ -0,0 +1,92 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/domain"
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/service"
+)
+
+func strPtr(s string) *string { return &s }
+
+func newProfileRepo() *mockUserRepo {
+	return &mockUserRepo{users: map[string]domain.User{
+		"alex@email.com":  {ID: 1, Name: "Alex", Email: "alex@email.com", EmailVerified: true, Locale: "ru", Role: domain.RoleUser},
+		"maria@email.com": {ID: 2, Name: "Maria", Email: "maria@email.com", Locale: "ru", Role: domain.RoleUser},
+		"admin@email.com": {ID: 3, Name: "Admin", Email: "admin@email.com", Locale: "ru", Role: domain.RoleAdmin},
+	}}
+}
+
+func TestUpdateProfile_ChangesOnlyGivenFields(t *testing.T) {
+	s := service.NewUserService(newProfileRepo())
+
+	user, err := s.UpdateProfile(context.Background(), 1, domain.ProfileUpdate{
+		Phone:  strPtr("+7 (900) 123-45-67"),
+		Locale: strPtr("en"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Name != "Alex" || user.Email != "alex@email.com" || !user.EmailVerified {
+		t.Fatalf("untouched fields changed: %+v", user)
+	}
+	if user.Phone != "+79001234567" || user.Locale != "en" {
+		t.Fatalf("expected normalized phone and locale, got %q %q", user.Phone, user.Locale)
+	}
+}
+
+func TestUpdateProfile_NewEmailRequiresVerification(t *testing.T) {
+	s := service.NewUserService(newProfileRepo())
+
+	user, err := s.UpdateProfile(context.Background(), 1, domain.ProfileUpdate{Email: strPtr("alex@new.com")})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Email != "alex@new.com" || user.EmailVerified {
+		t.Fatalf("expected unverified new email, got %+v", user)
+	}
+}
+
+func TestUpdateProfile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		upd  domain.ProfileUpdate
+		want error
+	}{
+		{"empty name", domain.ProfileUpdate{Name: strPtr("  ")}, domain.ErrInvalidProfile},
+		{"invalid email", domain.ProfileUpdate{Email: strPtr("not-an-email")}, domain.ErrInvalidProfile},
+		{"invalid phone", domain.ProfileUpdate{Phone: strPtr("12ab")}, domain.ErrInvalidProfile},
+		{"invalid locale", domain.ProfileUpdate{Locale: strPtr("russian")}, domain.ErrInvalidProfile},
+		{"email taken", domain.ProfileUpdate{Email: strPtr("maria@email.com")}, domain.ErrEmailTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := service.NewUserService(newProfileRepo())
+			if _, err := s.UpdateProfile(context.Background(), 1, tt.upd); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGetUser_AdminOnly(t *testing.T) {
+	s := service.NewUserService(newProfileRepo())
+
+	if _, err := s.GetUser(context.Background(), 1, 2); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for regular user, got %v", err)
+	}
+
+	user, err := s.GetUser(context.Background(), 3, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Email != "maria@email.com" {
+		t.Fatalf("expected maria, got %+v", user)
+	}
+
+	if _, err := s.GetUser(context.Background(), 3, 42); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OvsyannikovAlexandr/marketplace/user-service/internal/totp"
+)
+
+// Контрольные значения из RFC 6238 (SHA1, секрет "12345678901234567890"), последние 6 цифр
+func TestCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := totp.Code(secret, totp.Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("at %d: expected %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestValidate_AcceptsAdjacentStep(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	now := time.Now()
+	prev := totp.Step(now) - 1
+	code, _ := totp.Code(secret, prev)
+
+	step, ok := totp.Validate(secret, code, now)
+	if !ok || step != prev {
+		t.Fatalf("expected code of previous step to be accepted, got %d %v", step, ok)
+	}
+
+	old, _ := totp.Code(secret, prev-2)
+	if _, ok := totp.Validate(secret, old, now); ok {
+		t.Fatal("expected code outside the skew window to be rejected")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("Marketplace", "alex@email.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/Marketplace:alex@email.com?") || !strings.Contains(uri, "secret=ABC") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_service.users;
\ No newline at end of file
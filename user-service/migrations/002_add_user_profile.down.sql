@@ -0,0 +1,5 @@
+ALTER TABLE user_service.users
+    DROP COLUMN IF EXISTS email_verified,
+    DROP COLUMN IF EXISTS role,
+    DROP COLUMN IF EXISTS locale,
+    DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,2 @@
+ALTER TABLE user_service.users DROP COLUMN IF EXISTS password_changed_at;
+DROP TABLE IF EXISTS user_service.password_reset_tokens;
@@ -0,0 +1,16 @@
+-- Одноразовые токены сброса пароля. Хранится только SHA-256 токена,
+-- использованный или просроченный токен повторно не принимается.
+CREATE TABLE IF NOT EXISTS user_service.password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user_service.users (id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx
+    ON user_service.password_reset_tokens (user_id);
+
+-- Момент последней смены пароля; сессии, выданные раньше, считаются отозванными
+ALTER TABLE user_service.users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP;
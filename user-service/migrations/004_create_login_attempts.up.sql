@@ -0,0 +1,8 @@
+-- Счётчики неудачных входов по email (account:...) и по IP (ip:...).
+-- Счётчик сбрасывается после успешного входа или когда окно подсчёта истекло.
+CREATE TABLE IF NOT EXISTS user_service.login_attempts (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_service.role_policies;
+DROP TABLE IF EXISTS user_service.recovery_codes;
+ALTER TABLE user_service.users
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS two_factor_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
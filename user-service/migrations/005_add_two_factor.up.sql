@@ -0,0 +1,27 @@
+-- TOTP: секрет задаётся при подключении и действует после подтверждения кодом.
+-- totp_last_step — последний принятый шаг, чтобы один код нельзя было использовать дважды.
+ALTER TABLE user_service.users
+    ADD COLUMN IF NOT EXISTS totp_secret TEXT,
+    ADD COLUMN IF NOT EXISTS two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Резервные коды хранятся хешами и гасятся при использовании
+CREATE TABLE IF NOT EXISTS user_service.recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user_service.users (id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
+
+-- Роли, которым администратор предписал 2FA
+CREATE TABLE IF NOT EXISTS user_service.role_policies (
+    role TEXT PRIMARY KEY,
+    require_two_factor BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO user_service.role_policies (role, require_two_factor)
+VALUES ('admin', TRUE), ('seller', TRUE)
+ON CONFLICT (role) DO NOTHING;
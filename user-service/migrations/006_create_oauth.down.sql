@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_service.oauth_consents;
+DROP TABLE IF EXISTS user_service.oauth_authorization_codes;
+DROP TABLE IF EXISTS user_service.oauth_clients;
@@ -0,0 +1,33 @@
+-- Приложения партнёров, которые входят через OIDC. Секрет хранится как SHA-256,
+-- у публичных клиентов его нет.
+CREATE TABLE IF NOT EXISTS user_service.oauth_clients (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    secret_hash TEXT,
+    redirect_uris TEXT[] NOT NULL,
+    created_by INTEGER REFERENCES user_service.users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Одноразовые коды авторизации; code_challenge — PKCE (S256)
+CREATE TABLE IF NOT EXISTS user_service.oauth_authorization_codes (
+    code_hash TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL REFERENCES user_service.oauth_clients (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES user_service.users (id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    nonce TEXT NOT NULL DEFAULT '',
+    code_challenge TEXT NOT NULL,
+    auth_time TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+-- Согласия пользователей: при повторном входе в то же приложение не спрашиваем снова
+CREATE TABLE IF NOT EXISTS user_service.oauth_consents (
+    user_id INTEGER NOT NULL REFERENCES user_service.users (id) ON DELETE CASCADE,
+    client_id TEXT NOT NULL REFERENCES user_service.oauth_clients (id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, client_id)
+);
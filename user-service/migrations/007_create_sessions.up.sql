@@ -0,0 +1,14 @@
+-- Сессии пользователей: каждый вход создаёт запись, ID которой попадает в токен (sid).
+-- last_seen_at обновляет api-gateway при проверке токена.
+CREATE TABLE IF NOT EXISTS user_service.sessions (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user_service.users (id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON user_service.sessions (user_id);
@@ -0,0 +1,21 @@
+-- Адресная книга: адреса доставки и плательщика. Частичный уникальный индекс
+-- гарантирует не больше одного адреса по умолчанию каждого типа.
+CREATE TABLE IF NOT EXISTS user_service.addresses (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user_service.users (id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('shipping', 'billing')),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    recipient TEXT NOT NULL,
+    phone TEXT NOT NULL DEFAULT '',
+    country CHAR(2) NOT NULL,
+    region TEXT NOT NULL DEFAULT '',
+    city TEXT NOT NULL,
+    line1 TEXT NOT NULL,
+    line2 TEXT NOT NULL DEFAULT '',
+    postal_code TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS addresses_user_id_idx ON user_service.addresses (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS addresses_default_idx ON user_service.addresses (user_id, type) WHERE is_default;
@@ -0,0 +1,3 @@
+-- Удалённый аккаунт: персональные данные обезличены, строка остаётся, чтобы ID
+-- пользователя в заказах и журналах не достался новому аккаунту
+ALTER TABLE user_service.users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_service.admin_audit_log;
+ALTER TABLE user_service.users
+    DROP COLUMN IF EXISTS ban_reason,
+    DROP COLUMN IF EXISTS banned_at;
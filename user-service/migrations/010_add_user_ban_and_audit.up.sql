@@ -0,0 +1,19 @@
+-- Блокировка пользователя администратором: при бане отзываются все сессии,
+-- вход запрещён до разблокировки
+ALTER TABLE user_service.users
+    ADD COLUMN IF NOT EXISTS banned_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS ban_reason TEXT NOT NULL DEFAULT '';
+
+-- Журнал действий администраторов. Ссылок на users нет: записи должны
+-- пережить любые изменения аккаунтов.
+CREATE TABLE IF NOT EXISTS user_service.admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    target_user_id INTEGER,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS admin_audit_log_target_idx ON user_service.admin_audit_log (target_user_id, created_at);
+CREATE INDEX IF NOT EXISTS admin_audit_log_created_idx ON user_service.admin_audit_log (created_at);
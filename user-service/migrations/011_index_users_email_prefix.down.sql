@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS user_service.users_email_prefix_idx;
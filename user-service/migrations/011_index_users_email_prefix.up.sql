@@ -0,0 +1,2 @@
+-- Поиск пользователей по началу email без учёта регистра
+CREATE INDEX CONCURRENTLY IF NOT EXISTS users_email_prefix_idx ON user_service.users (lower(email) text_pattern_ops);
@@ -0,0 +1,14 @@
+-- Профили продавцов. Профиль создаёт сам пользователь, товары выставлять можно
+-- только после одобрения администратором (status = 'active').
+CREATE TABLE IF NOT EXISTS user_service.sellers (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES user_service.users (id) ON DELETE CASCADE,
+    display_name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'suspended')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS sellers_display_name_idx ON user_service.sellers (lower(display_name));
+CREATE INDEX IF NOT EXISTS sellers_status_idx ON user_service.sellers (status, created_at);
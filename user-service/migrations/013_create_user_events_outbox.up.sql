@@ -0,0 +1,13 @@
+-- Исходящие события пользователей (transactional outbox). Строка пишется в той же
+-- транзакции, что и изменение данных, и публикуется в Kafka фоновой задачей.
+CREATE TABLE IF NOT EXISTS user_service.user_events_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS user_events_outbox_pending_idx ON user_service.user_events_outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,10 @@
+// Package migrations содержит SQL-миграции схемы user_service, которой владеет user-service.
+package migrations
+
+import "embed"
+
+// Schema — схема PostgreSQL сервиса. Версия миграций хранится в user_service.schema_migrations.
+const Schema = "user_service"
+
+//go:embed *.sql
+var FS embed.FS
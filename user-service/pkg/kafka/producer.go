@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type UserProducer struct {
+	writer *kafka.Writer
+}
+
+type Producer interface {
+	SendUserLockedOut(ctx context.Context, event UserLockedOutEvent) error
+}
+
+// UserLockedOutEvent — вход временно заблокирован после серии неудачных попыток.
+// Его читает мониторинг безопасности. UserID пуст, если email не зарегистрирован.
+type UserLockedOutEvent struct {
+	Type string `json:"type"`
+	// Scope — что заблокировано: account (email) или ip
+	Scope       string    `json:"scope"`
+	UserID      int64     `json:"user_id,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	IP          string    `json:"ip,omitempty"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+// UserDeletedEvent — аккаунт удалён по запросу пользователя. По нему cart-service
+// и order-service удаляют или обезличивают данные пользователя.
+type UserDeletedEvent struct {
+	Type      string    `json:"type"`
+	UserID    int64     `json:"user_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// UserBannedEvent — администратор заблокировал пользователя. По нему api-gateway
+// сразу забывает подтверждённые сессии пользователя.
+type UserBannedEvent struct {
+	Type     string    `json:"type"`
+	UserID   int64     `json:"user_id"`
+	BannedAt time.Time `json:"banned_at"`
+}
+
+const (
+	UserLockedOutType = "UserLockedOut"
+	UserDeletedType   = "UserDeleted"
+	UserBannedType    = "UserBanned"
+)
+
+func NewUserProducer(brokerAddress, topic string) *UserProducer {
+	return &UserProducer{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokerAddress),
+			Topic:    topic,
+			Balancer: &kafka.LeastBytes{},
+		},
+	}
+}
+
+func (p *UserProducer) SendUserLockedOut(ctx context.Context, event UserLockedOutEvent) error {
+	event.Type = UserLockedOutType
+	return p.send(ctx, event.UserID, event)
+}
+
+func (p *UserProducer) SendUserDeleted(ctx context.Context, event UserDeletedEvent) error {
+	event.Type = UserDeletedType
+	return p.send(ctx, event.UserID, event)
+}
+
+func (p *UserProducer) SendUserBanned(ctx context.Context, event UserBannedEvent) error {
+	event.Type = UserBannedType
+	return p.send(ctx, event.UserID, event)
+}
+
+// send публикует событие с ключом user_id, чтобы события одного пользователя шли по порядку
+func (p *UserProducer) send(ctx context.Context, userID int64, event any) error {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(strconv.FormatInt(userID, 10)),
+		Value: msg,
+		Time:  time.Now(),
+	})
+}
+
+func (p *UserProducer) Close() error {
+	return p.writer.Close()
+}